@@ -41,6 +41,7 @@ func main() {
 
 	log := logger.NewLogger(cfg.Env)
 	defer log.Sync()
+	logger.SetDefault(log)
 
 	server := app.NewServer(cfg, log)
 
@@ -4,7 +4,9 @@ import (
 	controller "avito-tech-merch/internal/controller/http"
 	"avito-tech-merch/internal/controller/http/middleware"
 	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/service"
+	"avito-tech-merch/internal/tracing"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -13,7 +15,9 @@ import (
 func SetupRoutes(router *gin.Engine, controller controller.Controller, authService service.Service) {
 	authMiddleware := middleware.JWTAuthMiddleware(authService)
 
-	router.Use(metrics.GinPrometheusMiddleware())
+	// Контекст gin отдаёт значения из контекста запроса, в том числе поля корреляции для логов
+	router.ContextWithFallback = true
+	router.Use(middleware.RequestID(), tracing.GinMiddleware(), metrics.GinPrometheusMiddleware())
 
 	api := router.Group("/api")
 	{
@@ -21,14 +25,43 @@ func SetupRoutes(router *gin.Engine, controller controller.Controller, authServi
 
 		api.POST("/auth/login", controller.Login)
 
+		api.POST("/auth/refresh", controller.Refresh)
+
 		protected := api.Group("/")
 		protected.Use(authMiddleware)
 		{
+			protected.POST("/auth/logout", controller.Logout)
+			protected.POST("/auth/logout-all", controller.LogoutAll)
 			protected.GET("/info", controller.GetInfo)
+			protected.GET("/history/transactions", controller.GetTransactionHistory)
+			protected.GET("/history/purchases", controller.GetPurchaseHistory)
 			protected.POST("/send-coin", controller.SendCoin)
 			protected.GET("/merch", controller.ListMerch)
 			protected.POST("/merch/buy/:item", controller.BuyMerch)
+			protected.POST("/purchases/:id/refund", controller.RefundPurchase)
+			protected.GET("/cart", controller.GetCart)
+			protected.POST("/cart/items", controller.AddToCart)
+			protected.DELETE("/cart/items/:item", controller.RemoveFromCart)
+			protected.POST("/cart/checkout", controller.Checkout)
+		}
+
+		admin := api.Group("/admin")
+		admin.Use(authMiddleware, middleware.RequireRole(models.RoleAdmin))
+		{
+			admin.PUT("/users/:id/role", controller.SetUserRole)
+			admin.GET("/merch", controller.ListAllMerch)
+			admin.POST("/merch", controller.CreateMerch)
+			admin.PUT("/merch/:id", controller.UpdateMerch)
+			admin.POST("/merch/:id/archive", controller.ArchiveMerch)
+			admin.POST("/merch/:id/restock", controller.RestockMerch)
+			admin.PUT("/merch/:id/stock", controller.SetMerchStock)
+			admin.GET("/merch/:id/prices", controller.GetMerchPriceHistory)
+			admin.PUT("/purchases/:id/status", controller.UpdatePurchaseStatus)
+			admin.GET("/purchases/:id/history", controller.GetPurchaseStatusHistory)
+			admin.GET("/ledger/verify", controller.VerifyLedger)
 		}
 	}
+	router.GET("/.well-known/jwks.json", controller.JWKS)
+
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 }
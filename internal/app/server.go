@@ -5,6 +5,7 @@ import (
 	controller "avito-tech-merch/internal/controller/http"
 	"avito-tech-merch/internal/metrics"
 	"avito-tech-merch/internal/service"
+	"avito-tech-merch/internal/storage/cache"
 	"avito-tech-merch/internal/storage/db"
 	"avito-tech-merch/internal/storage/db/postgres"
 	"avito-tech-merch/pkg/jwt"
@@ -14,6 +15,8 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"net/http"
 	"time"
 )
@@ -22,6 +25,9 @@ type Server struct {
 	closer       *Closer
 	router       *gin.Engine
 	pgPool       *pgxpool.Pool
+	primary      *postgres.PrimaryMonitor
+	replicas     *postgres.ReplicaSet
+	repo         db.Repository
 	config       *config.Config
 	httpServer   *http.Server
 	metricServer *http.Server
@@ -31,7 +37,7 @@ type Server struct {
 func NewServer(cfg *config.Config, log logger.Logger) *Server {
 	c := NewCloser()
 
-	pgPool, err := cfg.Storage.ConnectionToPostgres(log)
+	pgPool, replicaPools, err := cfg.Storage.ConnectionToPostgres(log)
 	if err != nil {
 		log.Fatalw("Failed to connect to postgres",
 			"error", err)
@@ -41,33 +47,85 @@ func NewServer(cfg *config.Config, log logger.Logger) *Server {
 		pgPool.Close()
 		return nil
 	})
+	replicas := postgres.NewReplicaSet(replicaPools, time.Duration(cfg.Storage.Postgres.Replication.MaxLag)*time.Second, log)
+	if len(replicaPools) > 0 {
+		c.Add(func(ctx context.Context) error {
+			log.Infow("Closing PostgreSQL replica pools")
+			replicas.Close()
+			return nil
+		})
+	}
+
+	tracerProvider, err := cfg.Tracing.TracerProvider(context.Background(), cfg.Application.App)
+	if err != nil {
+		log.Fatalw("Failed to set up tracing",
+			"error", err)
+	}
+	if tracerProvider != nil {
+		otel.SetTracerProvider(tracerProvider)
+		c.Add(func(ctx context.Context) error {
+			log.Infow("Flushing traces")
+			return tracerProvider.Shutdown(ctx)
+		})
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
-	txManager := postgres.NewTxManager(pgPool, log)
+	txManager := postgres.NewReplicatedTxManager(pgPool, replicas, log)
 
 	userRepo := postgres.NewUserRepository(txManager, log)
 	merchRepo := postgres.NewMerchRepository(txManager, log)
+	if cfg.Storage.Redis.Enabled() && cfg.Storage.Redis.MerchCacheTTL > 0 {
+		redisClient := cfg.Storage.ConnectionToRedis(log)
+		c.Add(func(ctx context.Context) error {
+			log.Infow("Closing Redis client")
+			return redisClient.Close()
+		})
+		merchRepo = cache.NewMerchCache(merchRepo, txManager, redisClient, time.Duration(cfg.Storage.Redis.MerchCacheTTL)*time.Second, log)
+	}
+	if cfg.Storage.MemoryCache.Enabled() {
+		merchRepo = cache.NewMerchMemoryCache(merchRepo, txManager, cfg.Storage.MemoryCache.MerchSize, time.Duration(cfg.Storage.MemoryCache.MerchTTL)*time.Second)
+	}
 	purchaseRepo := postgres.NewPurchaseRepository(txManager, log)
+	cartRepo := postgres.NewCartRepository(txManager, log)
 	transactionRepo := postgres.NewTransactionRepository(txManager, log)
+	refreshTokenRepo := postgres.NewRefreshTokenRepository(txManager, log)
+	tokenRevocationRepo := cache.NewRevocationCache(
+		postgres.NewTokenRevocationRepository(txManager, log),
+		time.Duration(cfg.JWT.RevocationCacheTTL)*time.Second,
+	)
+
+	idempotencyRepo := postgres.NewIdempotencyRepository(txManager, log)
+	ledgerRepo := postgres.NewLedgerRepository(txManager, log)
 
-	repo := db.NewRepository(userRepo, merchRepo, purchaseRepo, transactionRepo)
+	repo := db.NewRepository(userRepo, merchRepo, purchaseRepo, cartRepo, transactionRepo, refreshTokenRepo, tokenRevocationRepo, idempotencyRepo, ledgerRepo)
+
+	keyRing, err := cfg.JWT.KeyRing()
+	if err != nil {
+		log.Fatalw("Failed to load JWT signing keys",
+			"error", err)
+	}
 
-	tokenService := jwt.NewTokenService()
+	tokenService := jwt.NewTokenService(keyRing)
 
 	authService := service.NewAuthService(repo, log, cfg.JWT, tokenService, txManager)
 	userService := service.NewUserService(repo, log, txManager)
-	merchService := service.NewMerchService(repo, log)
-	purchaseService := service.NewPurchaseService(repo, log, txManager)
+	merchService := service.NewMerchService(repo, log, txManager)
+	purchaseService := service.NewPurchaseService(repo, log, cfg.Purchase, txManager)
+	cartService := service.NewCartService(repo, log)
 	transactionService := service.NewTransactionService(repo, log, txManager)
+	adminService := service.NewAdminService(repo, log, txManager)
 
-	serv := service.NewService(authService, userService, merchService, purchaseService, transactionService)
+	serv := service.NewService(authService, userService, merchService, purchaseService, cartService, transactionService, adminService)
 
 	authController := controller.NewAuthController(serv)
 	userController := controller.NewUserController(serv)
 	merchController := controller.NewMerchController(serv)
 	purchaseController := controller.NewPurchaseController(serv)
+	cartController := controller.NewCartController(serv)
 	transactionController := controller.NewTransactionController(serv)
+	adminController := controller.NewAdminController(serv)
 
-	contr := controller.NewController(authController, userController, merchController, purchaseController, transactionController)
+	contr := controller.NewController(authController, userController, merchController, purchaseController, cartController, transactionController, adminController)
 
 	router := gin.Default()
 	SetupRoutes(router, contr, serv)
@@ -77,6 +135,10 @@ func NewServer(cfg *config.Config, log logger.Logger) *Server {
 		Handler: router,
 	}
 
+	if cfg.Metrics.LowStockThreshold > 0 {
+		metrics.SetLowStockThreshold(cfg.Metrics.LowStockThreshold)
+	}
+
 	metricMux := http.NewServeMux()
 	metricMux.Handle("/metrics", metrics.MetricsHandler())
 	metricServer := &http.Server{
@@ -88,6 +150,9 @@ func NewServer(cfg *config.Config, log logger.Logger) *Server {
 		closer:       c,
 		router:       router,
 		pgPool:       pgPool,
+		primary:      postgres.NewPrimaryMonitor(pgPool, log),
+		replicas:     replicas,
+		repo:         repo,
 		config:       cfg,
 		httpServer:   httpServer,
 		metricServer: metricServer,
@@ -105,6 +170,8 @@ func (s *Server) Run(ctx context.Context) error {
 			case <-ticker.C:
 				stats := s.pgPool.Stat()
 				metrics.RecordDBActiveConnections(int(stats.TotalConns()))
+				metrics.RecordDBPoolConnections(postgres.PrimaryPoolName, int(stats.TotalConns()), int(stats.IdleConns()), int(stats.AcquiredConns()))
+				s.replicas.RecordPoolConnections()
 			case <-ctx.Done():
 				s.logger.Infow("Stopping DB metrics collection goroutine")
 				return
@@ -112,6 +179,51 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}()
 
+	// Проверка основной базы: недоступность и failover видны в логах и метриках
+	go s.primary.Run(ctx, time.Duration(s.config.Storage.Postgres.FailoverCheckPeriod)*time.Second)
+
+	// Проверка реплик: отстающие и недоступные исключаются из чтений
+	go s.replicas.Run(ctx, time.Duration(s.config.Storage.Postgres.Replication.CheckPeriod)*time.Second)
+
+	// Сбор метрик остатков: покупки обновляют gauge сразу, а периодический опрос
+	// восстанавливает значения после рестарта и учитывает изменения из других экземпляров
+	go func() {
+		s.recordMerchStock(ctx)
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.recordMerchStock(ctx)
+			case <-ctx.Done():
+				s.logger.Infow("Stopping merch stock metrics collection goroutine")
+				return
+			}
+		}
+	}()
+
+	// Очистка записей об отзыве уже истёкших токенов
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				deleted, err := s.repo.DeleteExpiredRevokedTokens(ctx)
+				if err != nil {
+					s.logger.Errorw("Failed to delete expired revoked tokens",
+						"error", err)
+					continue
+				}
+				s.logger.Debugw("Expired revoked tokens deleted",
+					"count", deleted)
+			case <-ctx.Done():
+				s.logger.Infow("Stopping revoked tokens cleanup goroutine")
+				return
+			}
+		}
+	}()
+
 	s.closer.Add(func(ctx context.Context) error {
 		s.logger.Infow("Shutting down HTTP server")
 		return s.httpServer.Shutdown(ctx)
@@ -142,6 +254,23 @@ func (s *Server) Run(ctx context.Context) error {
 	return nil
 }
 
+func (s *Server) recordMerchStock(ctx context.Context) {
+	merchList, err := s.repo.GetAllMerch(ctx, false)
+	if err != nil {
+		s.logger.Errorw("Failed to collect merch stock metrics",
+			"error", err)
+		return
+	}
+
+	for _, merch := range merchList {
+		if merch.HasStockLimit() {
+			metrics.RecordMerchStock(merch.Name, *merch.Stock)
+		} else {
+			metrics.ClearMerchStock(merch.Name)
+		}
+	}
+}
+
 func (s *Server) Shutdown(ctx context.Context) error {
 	return s.closer.Close(ctx)
 }
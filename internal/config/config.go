@@ -13,6 +13,8 @@ type Config struct {
 	Storage      StorageConfig      `mapstructure:"storage"`
 	JWT          JWTConfig          `mapstructure:"jwt"`
 	Metrics      MetricsConfig      `mapstructure:"metrics"`
+	Purchase     PurchaseConfig     `mapstructure:"purchase"`
+	Tracing      TracingConfig      `mapstructure:"tracing"`
 }
 
 func LoadConfig(configPath, envPath string) (*Config, error) {
@@ -37,6 +39,8 @@ func LoadConfig(configPath, envPath string) (*Config, error) {
 	viper.BindEnv("storage.redis.host", "REDIS_HOST")
 	viper.BindEnv("storage.redis.password", "REDIS_PASSWORD")
 	viper.BindEnv("jwt.secret_key", "JWT_SECRET_KEY")
+	viper.BindEnv("tracing.exporter", "TRACING_EXPORTER")
+	viper.BindEnv("tracing.endpoint", "TRACING_ENDPOINT")
 
 	var config Config
 	err = viper.Unmarshal(&config)
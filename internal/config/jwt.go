@@ -1,6 +1,103 @@
 package config
 
+import (
+	"avito-tech-merch/pkg/jwt"
+	"fmt"
+	"os"
+	"time"
+)
+
 type JWTConfig struct {
-	SecretKey   string `mapstructure:"secret_key"`
-	TokenExpiry int    `mapstructure:"token_expiry"`
+	SecretKey            string             `mapstructure:"secret_key"`
+	SecretKeyVerifyUntil string             `mapstructure:"secret_key_verify_until"`
+	ActiveKeyID          string             `mapstructure:"active_key_id"`
+	SigningKeys          []SigningKeyConfig `mapstructure:"signing_keys"`
+	TokenExpiry          int                `mapstructure:"token_expiry"`
+	RefreshTokenExpiry   int                `mapstructure:"refresh_token_expiry"`
+	RevocationCacheTTL   int                `mapstructure:"revocation_cache_ttl"`
+}
+
+// SigningKeyConfig описывает ключ подписи токенов.
+// Для HS256 задаётся secret, для RS256 и EdDSA - путь к приватному ключу в PEM.
+// verify_until (RFC3339) выводит ключ из ротации: до этого момента он только проверяет токены
+type SigningKeyConfig struct {
+	ID             string `mapstructure:"id"`
+	Algorithm      string `mapstructure:"algorithm"`
+	Secret         string `mapstructure:"secret"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	VerifyUntil    string `mapstructure:"verify_until"`
+}
+
+// KeyRing собирает набор ключей подписи. secret_key остаётся ключом HS256 с kid "default":
+// им проверяются токены без kid, и он активен, если active_key_id не задан
+func (c *JWTConfig) KeyRing() (*jwt.KeyRing, error) {
+	keys := make([]*jwt.SigningKey, 0, len(c.SigningKeys)+1)
+
+	if c.SecretKey != "" {
+		key, err := jwt.NewHMACKey(jwt.LegacyKeyID, c.SecretKey)
+		if err != nil {
+			return nil, err
+		}
+		if key.VerifyUntil, err = parseVerifyUntil(c.SecretKeyVerifyUntil); err != nil {
+			return nil, fmt.Errorf("secret_key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	for _, keyConfig := range c.SigningKeys {
+		key, err := keyConfig.signingKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	activeKeyID := c.ActiveKeyID
+	if activeKeyID == "" {
+		activeKeyID = jwt.LegacyKeyID
+	}
+
+	return jwt.NewKeyRing(activeKeyID, keys...)
+}
+
+func (k SigningKeyConfig) signingKey() (*jwt.SigningKey, error) {
+	var (
+		key *jwt.SigningKey
+		err error
+	)
+
+	switch k.Algorithm {
+	case jwt.AlgorithmHS256:
+		key, err = jwt.NewHMACKey(k.ID, k.Secret)
+	case jwt.AlgorithmRS256, jwt.AlgorithmEdDSA:
+		pemData, readErr := os.ReadFile(k.PrivateKeyFile)
+		if readErr != nil {
+			return nil, fmt.Errorf("signing key %q: failed to read private key: %w", k.ID, readErr)
+		}
+		key, err = jwt.ParsePrivateKeyPEM(k.ID, k.Algorithm, pemData)
+	default:
+		return nil, fmt.Errorf("signing key %q: unsupported algorithm %q", k.ID, k.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key.VerifyUntil, err = parseVerifyUntil(k.VerifyUntil); err != nil {
+		return nil, fmt.Errorf("signing key %q: %w", k.ID, err)
+	}
+
+	return key, nil
+}
+
+func parseVerifyUntil(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	verifyUntil, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid verify_until: %w", err)
+	}
+
+	return verifyUntil, nil
 }
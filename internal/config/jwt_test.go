@@ -0,0 +1,87 @@
+package config
+
+import (
+	"avito-tech-merch/pkg/jwt"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeEd25519Key(t *testing.T) string {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	return path
+}
+
+func TestJWTConfig_KeyRing_LegacySecretOnly(t *testing.T) {
+	cfg := JWTConfig{SecretKey: "secret"}
+
+	ring, err := cfg.KeyRing()
+	assert.NoError(t, err)
+	assert.Equal(t, jwt.LegacyKeyID, ring.Active().ID)
+	assert.Equal(t, jwt.AlgorithmHS256, ring.Active().Algorithm)
+	assert.Empty(t, ring.JWKS().Keys)
+}
+
+func TestJWTConfig_KeyRing_RotatedToEdDSA(t *testing.T) {
+	cfg := JWTConfig{
+		SecretKey:            "secret",
+		SecretKeyVerifyUntil: "2999-01-01T00:00:00Z",
+		ActiveKeyID:          "2025-03",
+		SigningKeys: []SigningKeyConfig{
+			{ID: "2025-03", Algorithm: jwt.AlgorithmEdDSA, PrivateKeyFile: writeEd25519Key(t)},
+		},
+	}
+
+	ring, err := cfg.KeyRing()
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-03", ring.Active().ID)
+
+	legacy, err := ring.Lookup(jwt.LegacyKeyID)
+	assert.NoError(t, err)
+	assert.False(t, legacy.VerifyUntil.IsZero())
+
+	jwks := ring.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "2025-03", jwks.Keys[0].KeyID)
+}
+
+func TestJWTConfig_KeyRing_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  JWTConfig
+	}{
+		{name: "no keys", cfg: JWTConfig{}},
+		{name: "invalid verify_until", cfg: JWTConfig{SecretKey: "secret", SecretKeyVerifyUntil: "tomorrow"}},
+		{name: "missing private key file", cfg: JWTConfig{
+			ActiveKeyID: "rsa",
+			SigningKeys: []SigningKeyConfig{{ID: "rsa", Algorithm: jwt.AlgorithmRS256, PrivateKeyFile: "/nonexistent.pem"}},
+		}},
+		{name: "unsupported algorithm", cfg: JWTConfig{
+			ActiveKeyID: "es",
+			SigningKeys: []SigningKeyConfig{{ID: "es", Algorithm: "ES256"}},
+		}},
+		{name: "active key not configured", cfg: JWTConfig{SecretKey: "secret", ActiveKeyID: "missing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := tt.cfg.KeyRing()
+			assert.Error(t, err)
+			assert.Nil(t, ring)
+		})
+	}
+}
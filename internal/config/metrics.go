@@ -1,6 +1,7 @@
 package config
 
 type MetricsConfig struct {
-	Endpoint string `mapstructure:"endpoint"`
-	Port     int    `mapstructure:"port"`
+	Endpoint          string `mapstructure:"endpoint"`
+	Port              int    `mapstructure:"port"`
+	LowStockThreshold int    `mapstructure:"low_stock_threshold"`
 }
@@ -0,0 +1,6 @@
+package config
+
+type PurchaseConfig struct {
+	// RefundWindow - сколько секунд после покупки пользователь может сам оформить возврат; 0 отключает самостоятельный возврат
+	RefundWindow int `mapstructure:"refund_window"`
+}
@@ -1,28 +1,49 @@
 package config
 
 import (
+	"avito-tech-merch/internal/tracing"
 	"avito-tech-merch/pkg/logger"
 	"context"
 	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
 	_ "github.com/sourcegraph/conc/pool"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type StorageConfig struct {
-	Postgres PostgresConfig `mapstructure:"postgres"`
-	Redis    RedisConfig    `mapstructure:"redis"`
+	Postgres    PostgresConfig    `mapstructure:"postgres"`
+	Redis       RedisConfig       `mapstructure:"redis"`
+	MemoryCache MemoryCacheConfig `mapstructure:"memory_cache"`
 }
 
 type PostgresConfig struct {
-	Hosts              []string   `mapstructure:"hosts"`
-	Port               int        `mapstructure:"port"`
-	Database           string     `mapstructure:"database"`
-	Username           string     `mapstructure:"username"`
-	Password           string     `mapstructure:"password"`
-	SSLMode            string     `mapstructure:"ssl_mode"`
-	ConnectionAttempts int        `mapstructure:"connection_attempts"`
-	Pool               PoolConfig `mapstructure:"pool"`
+	// Hosts - адреса серверов кластера: host или host:port, без порта используется Port
+	Hosts              []string          `mapstructure:"hosts"`
+	Port               int               `mapstructure:"port"`
+	Database           string            `mapstructure:"database"`
+	Username           string            `mapstructure:"username"`
+	Password           string            `mapstructure:"password"`
+	SSLMode            string            `mapstructure:"ssl_mode"`
+	ConnectTimeout     int               `mapstructure:"connect_timeout"`
+	ConnectionAttempts int               `mapstructure:"connection_attempts"`
+	ConnectionBackoff  BackoffConfig     `mapstructure:"connection_backoff"`
+	Pool               PoolConfig        `mapstructure:"pool"`
+	Replication        ReplicationConfig `mapstructure:"replication"`
+	// FailoverCheckPeriod - как часто в секундах проверяется соединение с основной базой
+	FailoverCheckPeriod int `mapstructure:"failover_check_period"`
+}
+
+// BackoffConfig - экспоненциальная задержка между попытками подключения
+type BackoffConfig struct {
+	// InitialDelay - задержка после первой неудачной попытки в секундах; дальше она удваивается
+	InitialDelay int `mapstructure:"initial_delay"`
+	// MaxDelay - предел задержки в секундах
+	MaxDelay int `mapstructure:"max_delay"`
 }
 
 type PoolConfig struct {
@@ -33,16 +54,103 @@ type PoolConfig struct {
 	HealthCheckPeriod int `mapstructure:"health_check_period"`
 }
 
+// ReplicationConfig - чтение с реплик. Если в hosts несколько адресов, чтения получают те из них,
+// которые сейчас работают репликами
+type ReplicationConfig struct {
+	// MaxLag - допустимое отставание реплики в секундах; отстающая реплика не получает чтения
+	MaxLag int `mapstructure:"max_lag"`
+	// CheckPeriod - как часто в секундах проверяются доступность и отставание реплик
+	CheckPeriod int `mapstructure:"check_period"`
+}
+
 type RedisConfig struct {
 	Host     []string `mapstructure:"host"`
 	Port     int      `mapstructure:"port"`
 	Password string   `mapstructure:"password"`
 	DB       int      `mapstructure:"db"`
+	// MerchCacheTTL - сколько секунд каталог товаров хранится в Redis; 0 выключает кеш
+	MerchCacheTTL int `mapstructure:"merch_cache_ttl"`
+}
+
+// Enabled сообщает, настроен ли Redis: без адреса сервис работает только с PostgreSQL
+func (r *RedisConfig) Enabled() bool {
+	return len(r.Host) > 0 && r.Host[0] != ""
+}
+
+// MemoryCacheConfig - in-process кеш товаров по названию
+type MemoryCacheConfig struct {
+	// MerchSize - сколько товаров держится в кеше
+	MerchSize int `mapstructure:"merch_size"`
+	// MerchTTL - сколько секунд товар хранится в кеше; 0 выключает кеш
+	MerchTTL int `mapstructure:"merch_ttl"`
 }
 
-func (s *StorageConfig) ConnectionToPostgres(log logger.Logger) (*pgxpool.Pool, error) {
+// Enabled сообщает, включён ли in-process кеш товаров
+func (m *MemoryCacheConfig) Enabled() bool {
+	return m.MerchSize > 0 && m.MerchTTL > 0
+}
+
+// ConnectionToPostgres создаёт пул основной базы и пулы реплик.
+// Пул основной базы подключается к первому из hosts, который принимает запись (target_session_attrs=read-write),
+// поэтому недоступный первый адрес не мешает запуску, а после failover новые соединения идут на новую основную базу.
+// Если адресов несколько, для каждого создаётся пул реплики (target_session_attrs=standby): текущая основная база
+// в нём не проходит проверку и не получает чтения, а бывшая основная база, вернувшись репликой, начинает их получать
+func (s *StorageConfig) ConnectionToPostgres(log logger.Logger) (*pgxpool.Pool, []*pgxpool.Pool, error) {
+	cfg := s.Postgres
+
+	pool, err := s.newPostgresPool(s.GetDSN(), int32(cfg.Pool.MinConnections))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = pool.Ping(context.Background())
+		if err == nil {
+			break
+		}
+		if attempt >= cfg.ConnectionAttempts {
+			pool.Close()
+			return nil, nil, fmt.Errorf("failed to connect to PostgreSQL after %d attempts: %w", attempt, err)
+		}
+
+		delay := cfg.ConnectionBackoff.delay(attempt)
+		log.Warnw("Attempt to connect to PostgreSQL",
+			"attempt", attempt,
+			"max_attempts", cfg.ConnectionAttempts,
+			"retry_in", delay.String(),
+			"error", err,
+		)
+		time.Sleep(delay)
+	}
+
+	if len(cfg.Hosts) < 2 {
+		return pool, nil, nil
+	}
+
+	replicas := make([]*pgxpool.Pool, 0, len(cfg.Hosts))
+	for _, host := range cfg.Hosts {
+		replica, err := s.newReplicaPool(host)
+		if err != nil {
+			pool.Close()
+			for _, replica := range replicas {
+				replica.Close()
+			}
+			return nil, nil, err
+		}
+		replicas = append(replicas, replica)
+	}
+
+	return pool, replicas, nil
+}
+
+// newReplicaPool создаёт пул реплики по одному адресу. Соединения открываются только при чтениях,
+// поэтому пул на адресе, который сейчас работает основной базой, не занимает её соединения
+func (s *StorageConfig) newReplicaPool(host string) (*pgxpool.Pool, error) {
+	return s.newPostgresPool(s.dsn([]string{host}, "standby"), 0)
+}
+
+func (s *StorageConfig) newPostgresPool(dsn string, minConns int32) (*pgxpool.Pool, error) {
 	cfg := s.Postgres
-	dsn := s.GetDSN()
 
 	poolConfig, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
@@ -50,40 +158,83 @@ func (s *StorageConfig) ConnectionToPostgres(log logger.Logger) (*pgxpool.Pool,
 	}
 
 	poolConfig.MaxConns = int32(cfg.Pool.MaxConnections)
-	poolConfig.MinConns = int32(cfg.Pool.MinConnections)
+	poolConfig.MinConns = minConns
 	poolConfig.MaxConnLifetime = time.Duration(cfg.Pool.MaxLifeTime) * time.Second
 	poolConfig.MaxConnIdleTime = time.Duration(cfg.Pool.MaxIdleTime) * time.Second
 	poolConfig.HealthCheckPeriod = time.Duration(cfg.Pool.HealthCheckPeriod) * time.Second
+	poolConfig.ConnConfig.Tracer = tracing.NewPgxTracer(cfg.Database)
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
 
-	var attempt int
-	for attempt < cfg.ConnectionAttempts {
-		if err := pool.Ping(context.Background()); err == nil {
-			break
-		}
-		attempt++
-		time.Sleep(time.Second * 2)
-		log.Warnw("Attempt to connect to PostgreSQL",
-			"attempt", attempt,
-			"max_attempts", cfg.ConnectionAttempts,
+	return pool, nil
+}
+
+// ConnectionToRedis создаёт клиент Redis. Соединение устанавливается лениво, поэтому
+// недоступный при старте Redis не мешает запуску: кеш начнёт работать, когда Redis поднимется
+func (s *StorageConfig) ConnectionToRedis(log logger.Logger) *redis.Client {
+	cfg := s.Redis
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", cfg.Host[0], cfg.Port),
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		log.Warnw("Redis is unavailable, cache falls back to PostgreSQL",
+			"address", client.Options().Addr,
 			"error", err,
 		)
 	}
 
-	if attempt == cfg.ConnectionAttempts {
-		return nil, fmt.Errorf("failed to connect to PostgreSQL after %d attempts", cfg.ConnectionAttempts)
-	}
-
-	return pool, nil
+	return client
 }
 
+// GetDSN возвращает DSN основной базы: все адреса кластера, из которых выбирается сервер, принимающий запись
 func (s *StorageConfig) GetDSN() string {
-	return fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		s.Postgres.Hosts[0], s.Postgres.Port, s.Postgres.Username, s.Postgres.Password, s.Postgres.Database, s.Postgres.SSLMode,
+	return s.dsn(s.Postgres.Hosts, "read-write")
+}
+
+func (s *StorageConfig) dsn(addresses []string, targetSessionAttrs string) string {
+	cfg := s.Postgres
+
+	hosts := make([]string, 0, len(addresses))
+	ports := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		address = strings.TrimSpace(address)
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			host, port = address, strconv.Itoa(cfg.Port)
+		}
+		hosts = append(hosts, host)
+		ports = append(ports, port)
+	}
+
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s target_session_attrs=%s",
+		strings.Join(hosts, ","), strings.Join(ports, ","), cfg.Username, cfg.Password, cfg.Database, cfg.SSLMode, targetSessionAttrs,
 	)
+	if cfg.ConnectTimeout > 0 {
+		dsn += fmt.Sprintf(" connect_timeout=%d", cfg.ConnectTimeout)
+	}
+	return dsn
+}
+
+// delay возвращает задержку перед следующей попыткой: InitialDelay, удвоенную за каждую прошлую
+// неудачу, но не больше MaxDelay. Случайная половина задержки разводит по времени переподключения
+// экземпляров сервиса, которые потеряли базу одновременно
+func (b BackoffConfig) delay(attempt int) time.Duration {
+	delay := time.Duration(max(b.InitialDelay, 1)) * time.Second
+	maxDelay := max(time.Duration(b.MaxDelay)*time.Second, delay)
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
 }
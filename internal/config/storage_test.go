@@ -0,0 +1,89 @@
+package config
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func testStorageConfig(hosts ...string) *StorageConfig {
+	return &StorageConfig{Postgres: PostgresConfig{
+		Hosts:          hosts,
+		Port:           5432,
+		Database:       "merch-store",
+		Username:       "champ001",
+		Password:       "secret",
+		SSLMode:        "disable",
+		ConnectTimeout: 3,
+	}}
+}
+
+func TestStorageConfig_GetDSN_MultiHost(t *testing.T) {
+	cfg := testStorageConfig("db1", " db2:5433 ")
+
+	assert.Equal(t,
+		"host=db1,db2 port=5432,5433 user=champ001 password=secret dbname=merch-store sslmode=disable target_session_attrs=read-write connect_timeout=3",
+		cfg.GetDSN())
+
+	// pgx перебирает адреса по порядку и оставляет первый сервер, который принимает запись
+	poolConfig, err := pgxpool.ParseConfig(cfg.GetDSN())
+	require.NoError(t, err)
+	assert.Equal(t, "db1", poolConfig.ConnConfig.Host)
+	require.Len(t, poolConfig.ConnConfig.Fallbacks, 1)
+	assert.Equal(t, "db2", poolConfig.ConnConfig.Fallbacks[0].Host)
+	assert.Equal(t, uint16(5433), poolConfig.ConnConfig.Fallbacks[0].Port)
+	assert.Equal(t, 3*time.Second, poolConfig.ConnConfig.ConnectTimeout)
+}
+
+func TestStorageConfig_ReplicaDSN(t *testing.T) {
+	cfg := testStorageConfig("db1", "db2")
+	cfg.Postgres.ConnectTimeout = 0
+
+	assert.Equal(t,
+		"host=db2 port=5432 user=champ001 password=secret dbname=merch-store sslmode=disable target_session_attrs=standby",
+		cfg.dsn([]string{"db2"}, "standby"))
+}
+
+func TestStorageConfig_NewReplicaPool(t *testing.T) {
+	cfg := testStorageConfig("db1", "db2")
+	cfg.Postgres.Pool = PoolConfig{MaxConnections: 50, MinConnections: 10, HealthCheckPeriod: 30}
+
+	// Пул реплики не открывает соединения заранее: они появляются только при чтениях
+	replica, err := cfg.newReplicaPool("db2")
+	require.NoError(t, err)
+	defer replica.Close()
+	assert.Equal(t, "db2", replica.Config().ConnConfig.Host)
+	assert.Equal(t, int32(0), replica.Config().MinConns)
+	assert.Equal(t, int32(50), replica.Config().MaxConns)
+}
+
+func TestBackoffConfig_Delay(t *testing.T) {
+	backoff := BackoffConfig{InitialDelay: 1, MaxDelay: 5}
+
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{attempt: 1, base: time.Second},
+		{attempt: 2, base: 2 * time.Second},
+		{attempt: 3, base: 4 * time.Second},
+		{attempt: 4, base: 5 * time.Second},
+		{attempt: 100, base: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			delay := backoff.delay(tt.attempt)
+			assert.GreaterOrEqual(t, delay, tt.base/2)
+			assert.LessOrEqual(t, delay, tt.base)
+		}
+	}
+}
+
+func TestBackoffConfig_DelayDefaults(t *testing.T) {
+	delay := BackoffConfig{}.delay(3)
+
+	assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+	assert.LessOrEqual(t, delay, time.Second)
+}
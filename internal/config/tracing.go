@@ -0,0 +1,67 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Экспортёры трейсов
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+type TracingConfig struct {
+	// Exporter - куда отправлять спаны: none (трейсинг выключен), stdout или otlp
+	Exporter string `mapstructure:"exporter"`
+	// Endpoint - адрес OTLP/HTTP коллектора (host:port), используется только для otlp
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure - отправлять спаны в коллектор по HTTP без TLS
+	Insecure bool `mapstructure:"insecure"`
+	// SampleRatio - доля трассируемых запросов, (0, 1]; 0 означает все запросы. Запросы с входящим traceparent следуют решению родителя
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// TracerProvider создаёт провайдер трейсов с выбранным экспортёром.
+// Для none возвращается nil: спаны остаются no-op и ничего не стоят
+func (t *TracingConfig) TracerProvider(ctx context.Context, serviceName string) (*sdktrace.TracerProvider, error) {
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+
+	switch t.Exporter {
+	case "", TracingExporterNone:
+		return nil, nil
+	case TracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case TracingExporterOTLP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(t.Endpoint)}
+		if t.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", t.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", t.Exporter, err)
+	}
+
+	ratio := t.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	), nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTracingConfig_TracerProvider_Disabled(t *testing.T) {
+	for _, exporter := range []string{"", TracingExporterNone} {
+		cfg := TracingConfig{Exporter: exporter}
+
+		provider, err := cfg.TracerProvider(context.Background(), "merch-store")
+		assert.NoError(t, err)
+		assert.Nil(t, provider)
+	}
+}
+
+func TestTracingConfig_TracerProvider_Exporters(t *testing.T) {
+	for _, cfg := range []TracingConfig{
+		{Exporter: TracingExporterStdout},
+		{Exporter: TracingExporterOTLP, Endpoint: "localhost:4318", Insecure: true, SampleRatio: 0.5},
+	} {
+		provider, err := cfg.TracerProvider(context.Background(), "merch-store")
+		require.NoError(t, err)
+		require.NotNil(t, provider)
+		assert.NoError(t, provider.Shutdown(context.Background()))
+	}
+}
+
+func TestTracingConfig_TracerProvider_UnknownExporter(t *testing.T) {
+	cfg := TracingConfig{Exporter: "jaeger"}
+
+	provider, err := cfg.TracerProvider(context.Background(), "merch-store")
+	assert.EqualError(t, err, `unknown tracing exporter "jaeger"`)
+	assert.Nil(t, provider)
+}
@@ -0,0 +1,423 @@
+package http
+
+import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/models/dto"
+	"avito-tech-merch/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type adminController struct {
+	service service.Service
+}
+
+func NewAdminController(service service.Service) AdminController {
+	return &adminController{service: service}
+}
+
+// SetUserRole godoc
+// @Summary Change a user's role
+// @Security BearerAuth
+// @Description Assigns the employee or admin role to a user. Access tokens already issued to the user are revoked, so the new role applies after the next token refresh. Admins cannot change their own role
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param request body dto.SetUserRoleRequest true "New role"
+// @Success 200 {object} dto.UserRoleResponse "Role changed"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 403 {object} dto.Problem "Forbidden"
+// @Failure 404 {object} dto.Problem "User not found (user_not_found)"
+// @Failure 422 {object} dto.Problem "Admin cannot change own role (own_role_change)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /admin/users/{id}/role [put]
+func (c *adminController) SetUserRole(ctx *gin.Context) {
+	adminID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || userID <= 0 {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid user id")
+		return
+	}
+
+	var request dto.SetUserRoleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		problem.Binding(ctx, err)
+		return
+	}
+
+	user, err := c.service.SetUserRole(ctx, adminID.(int), userID, request.Role)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapUserToRoleDTO(user))
+}
+
+// ListAllMerch godoc
+// @Summary List the whole catalog
+// @Security BearerAuth
+// @Description Returns all merch items including archived ones
+// @Tags admin
+// @Produce  json
+// @Success 200 {array} dto.MerchDTO "Catalog"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 403 {object} dto.Problem "Forbidden"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /admin/merch [get]
+func (c *adminController) ListAllMerch(ctx *gin.Context) {
+	merchList, err := c.service.ListAllMerch(ctx)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	merchListDTO := make([]*dto.MerchDTO, len(merchList))
+	for i, merch := range merchList {
+		merchListDTO[i] = dto.MapMerchToDTO(merch)
+	}
+
+	ctx.JSON(http.StatusOK, merchListDTO)
+}
+
+// CreateMerch godoc
+// @Summary Add a merch item
+// @Security BearerAuth
+// @Description Adds a new item to the catalog. Item names are unique. Without stock the item is sold in unlimited quantity
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param request body dto.CreateMerchRequest true "Merch item"
+// @Success 201 {object} dto.MerchDTO "Created item"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 403 {object} dto.Problem "Forbidden"
+// @Failure 409 {object} dto.Problem "Merch with this name already exists (merch_already_exists)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /admin/merch [post]
+func (c *adminController) CreateMerch(ctx *gin.Context) {
+	adminID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	var request dto.CreateMerchRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		problem.Binding(ctx, err)
+		return
+	}
+
+	merch, err := c.service.CreateMerch(ctx, adminID.(int), request.Name, request.Price, request.Stock)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dto.MapMerchToDTO(merch))
+}
+
+// UpdateMerch godoc
+// @Summary Update a merch item
+// @Security BearerAuth
+// @Description Changes the name and price of a catalog item. A price change is recorded in the item price history. Archived items cannot be updated
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Merch ID"
+// @Param request body dto.MerchRequest true "Merch item"
+// @Success 200 {object} dto.MerchDTO "Updated item"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 403 {object} dto.Problem "Forbidden"
+// @Failure 404 {object} dto.Problem "Merch not found (merch_not_found)"
+// @Failure 409 {object} dto.Problem "Merch with this name already exists (merch_already_exists)"
+// @Failure 422 {object} dto.Problem "Merch is archived (merch_archived)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /admin/merch/{id} [put]
+func (c *adminController) UpdateMerch(ctx *gin.Context) {
+	adminID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	merchID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || merchID <= 0 {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid merch id")
+		return
+	}
+
+	var request dto.MerchRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		problem.Binding(ctx, err)
+		return
+	}
+
+	merch, err := c.service.UpdateMerch(ctx, adminID.(int), merchID, request.Name, request.Price)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapMerchToDTO(merch))
+}
+
+// ArchiveMerch godoc
+// @Summary Archive a merch item
+// @Security BearerAuth
+// @Description Withdraws an item from sale. The item disappears from /merch and cannot be bought, but stays resolvable for past purchases
+// @Tags admin
+// @Produce  json
+// @Param id path int true "Merch ID"
+// @Success 200 {object} dto.MerchArchivedResponse "Item archived"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 403 {object} dto.Problem "Forbidden"
+// @Failure 404 {object} dto.Problem "Merch not found (merch_not_found)"
+// @Failure 409 {object} dto.Problem "Merch is already archived (merch_already_archived)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /admin/merch/{id}/archive [post]
+func (c *adminController) ArchiveMerch(ctx *gin.Context) {
+	merchID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || merchID <= 0 {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid merch id")
+		return
+	}
+
+	if err := c.service.ArchiveMerch(ctx, merchID); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MerchArchivedResponse{Message: "merch archived"})
+}
+
+// RestockMerch godoc
+// @Summary Restock a merch item
+// @Security BearerAuth
+// @Description Adds units to the item stock. Items without a quantity limit cannot be restocked: set their stock explicitly instead
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Merch ID"
+// @Param request body dto.RestockRequest true "Units to add"
+// @Success 200 {object} dto.MerchDTO "Restocked item"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 403 {object} dto.Problem "Forbidden"
+// @Failure 404 {object} dto.Problem "Merch not found (merch_not_found)"
+// @Failure 422 {object} dto.Problem "Merch is archived or its stock is not tracked (merch_archived, stock_not_tracked)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /admin/merch/{id}/restock [post]
+func (c *adminController) RestockMerch(ctx *gin.Context) {
+	merchID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || merchID <= 0 {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid merch id")
+		return
+	}
+
+	var request dto.RestockRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		problem.Binding(ctx, err)
+		return
+	}
+
+	merch, err := c.service.RestockMerch(ctx, merchID, request.Quantity)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapMerchToDTO(merch))
+}
+
+// SetMerchStock godoc
+// @Summary Set merch stock
+// @Security BearerAuth
+// @Description Overrides the item stock with an exact value (e.g. after an inventory count) or removes the quantity limit
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Merch ID"
+// @Param request body dto.SetStockRequest true "New stock"
+// @Success 200 {object} dto.MerchDTO "Updated item"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 403 {object} dto.Problem "Forbidden"
+// @Failure 404 {object} dto.Problem "Merch not found (merch_not_found)"
+// @Failure 422 {object} dto.Problem "Merch is archived (merch_archived)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /admin/merch/{id}/stock [put]
+func (c *adminController) SetMerchStock(ctx *gin.Context) {
+	merchID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || merchID <= 0 {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid merch id")
+		return
+	}
+
+	var request dto.SetStockRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		problem.Binding(ctx, err)
+		return
+	}
+
+	// Ровно одно из полей: конкретный остаток либо снятие ограничения
+	if request.Unlimited == (request.Stock != nil) {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "either stock or unlimited must be set")
+		return
+	}
+
+	merch, err := c.service.SetMerchStock(ctx, merchID, request.Stock)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapMerchToDTO(merch))
+}
+
+// GetMerchPriceHistory godoc
+// @Summary Get merch price history
+// @Security BearerAuth
+// @Description Returns every price the item has had, oldest first, with the admin who set it
+// @Tags admin
+// @Produce  json
+// @Param id path int true "Merch ID"
+// @Success 200 {array} dto.MerchPriceDTO "Price history"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 403 {object} dto.Problem "Forbidden"
+// @Failure 404 {object} dto.Problem "Merch not found (merch_not_found)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /admin/merch/{id}/prices [get]
+func (c *adminController) GetMerchPriceHistory(ctx *gin.Context) {
+	merchID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || merchID <= 0 {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid merch id")
+		return
+	}
+
+	history, err := c.service.GetMerchPriceHistory(ctx, merchID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	historyDTO := make([]*dto.MerchPriceDTO, len(history))
+	for i, price := range history {
+		historyDTO[i] = dto.MapMerchPriceToDTO(price)
+	}
+
+	ctx.JSON(http.StatusOK, historyDTO)
+}
+
+// UpdatePurchaseStatus godoc
+// @Summary Advance purchase fulfillment
+// @Security BearerAuth
+// @Description Moves a purchase to the next fulfillment status: placed -> ready_for_pickup -> delivered. Cancelling a purchase that has not been delivered refunds its coins and stock
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Purchase ID"
+// @Param request body dto.UpdatePurchaseStatusRequest true "New status"
+// @Success 200 {object} dto.PurchaseDTO "Updated purchase"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 403 {object} dto.Problem "Forbidden"
+// @Failure 404 {object} dto.Problem "Purchase not found (purchase_not_found)"
+// @Failure 409 {object} dto.Problem "Purchase is already refunded (already_refunded)"
+// @Failure 422 {object} dto.Problem "Status transition is not allowed (invalid_status_transition, refund_not_allowed)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /admin/purchases/{id}/status [put]
+func (c *adminController) UpdatePurchaseStatus(ctx *gin.Context) {
+	adminID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	purchaseID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || purchaseID <= 0 {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid purchase id")
+		return
+	}
+
+	var request dto.UpdatePurchaseStatusRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		problem.Binding(ctx, err)
+		return
+	}
+
+	purchase, err := c.service.UpdatePurchaseStatus(ctx, adminID.(int), purchaseID, request.Status)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapPurchaseToDTO(purchase))
+}
+
+// GetPurchaseStatusHistory godoc
+// @Summary Get purchase status history
+// @Security BearerAuth
+// @Description Returns every fulfillment status of the purchase, oldest first, with the user who set it
+// @Tags admin
+// @Produce  json
+// @Param id path int true "Purchase ID"
+// @Success 200 {array} dto.PurchaseStatusChangeDTO "Status history"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 403 {object} dto.Problem "Forbidden"
+// @Failure 404 {object} dto.Problem "Purchase not found (purchase_not_found)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /admin/purchases/{id}/history [get]
+func (c *adminController) GetPurchaseStatusHistory(ctx *gin.Context) {
+	purchaseID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || purchaseID <= 0 {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid purchase id")
+		return
+	}
+
+	history, err := c.service.GetPurchaseStatusHistory(ctx, purchaseID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	historyDTO := make([]*dto.PurchaseStatusChangeDTO, len(history))
+	for i, change := range history {
+		historyDTO[i] = dto.MapPurchaseStatusChangeToDTO(change)
+	}
+
+	ctx.JSON(http.StatusOK, historyDTO)
+}
+
+// VerifyLedger godoc
+// @Summary Verify the coin ledger
+// @Security BearerAuth
+// @Description Reconciles the double-entry ledger with user balances: checks that every entry is balanced and that each user's balance equals the sum of their postings. Issued is the number of coins held by users and the merch store
+// @Tags admin
+// @Produce  json
+// @Success 200 {object} dto.LedgerReportResponse "Verification result"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 403 {object} dto.Problem "Forbidden"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /admin/ledger/verify [get]
+func (c *adminController) VerifyLedger(ctx *gin.Context) {
+	report, err := c.service.VerifyLedger(ctx)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapLedgerReportToDTO(report))
+}
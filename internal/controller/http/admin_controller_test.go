@@ -0,0 +1,411 @@
+package http
+
+import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/models/dto"
+	"avito-tech-merch/internal/service"
+	mockServ "avito-tech-merch/internal/service/mock"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newAdminRouter(controller AdminController) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.PUT("/admin/users/:id/role", controller.SetUserRole)
+	router.POST("/admin/merch", controller.CreateMerch)
+	router.PUT("/admin/merch/:id", controller.UpdateMerch)
+	router.GET("/admin/merch/:id/prices", controller.GetMerchPriceHistory)
+	router.PUT("/admin/purchases/:id/status", controller.UpdatePurchaseStatus)
+	router.GET("/admin/purchases/:id/history", controller.GetPurchaseStatusHistory)
+	router.GET("/admin/ledger/verify", controller.VerifyLedger)
+	return router
+}
+
+func TestAdminController_SetUserRole_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	router := newAdminRouter(NewAdminController(mockService))
+
+	user := &models.User{ID: 2, Username: "hr_manager", Role: models.RoleAdmin}
+	mockService.On("SetUserRole", mock.Anything, 1, 2, models.RoleAdmin).Return(user, nil).Once()
+
+	reqBody, _ := json.Marshal(dto.SetUserRoleRequest{Role: models.RoleAdmin})
+	req, _ := http.NewRequest("PUT", "/admin/users/2/role", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.UserRoleResponse
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, dto.UserRoleResponse{UserID: 2, Username: "hr_manager", Role: models.RoleAdmin}, resp)
+}
+
+func TestAdminController_SetUserRole_InvalidRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{name: "invalid user id", path: "/admin/users/abc/role", body: `{"role": "admin"}`},
+		{name: "unknown role", path: "/admin/users/2/role", body: `{"role": "superuser"}`},
+		{name: "missing role", path: "/admin/users/2/role", body: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mockServ.NewService(t)
+			router := newAdminRouter(NewAdminController(mockService))
+
+			req, _ := http.NewRequest("PUT", tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			mockService.AssertNotCalled(t, "SetUserRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAdminController_SetUserRole_ServiceError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	router := newAdminRouter(NewAdminController(mockService))
+
+	mockService.On("SetUserRole", mock.Anything, 1, 2, models.RoleEmployee).Return(nil, fmt.Errorf("%w: id 2", service.ErrUserNotFound)).Once()
+
+	req, _ := http.NewRequest("PUT", "/admin/users/2/role", bytes.NewBufferString(`{"role": "employee"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	var resp dto.Problem
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, problem.TypePrefix+ErrorCodeUserNotFound, resp.Type)
+	assert.Equal(t, "user not found: id 2", resp.Detail)
+}
+
+func TestAdminController_ListAllMerch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	router := gin.New()
+	router.GET("/admin/merch", NewAdminController(mockService).ListAllMerch)
+
+	archivedAt := time.Date(2025, 3, 25, 12, 0, 0, 0, time.UTC)
+	mockService.On("ListAllMerch", mock.Anything).Return([]*models.Merch{
+		{ID: 1, Name: "cup", Price: 20},
+		{ID: 2, Name: "old-cup", Price: 15, ArchivedAt: &archivedAt},
+	}, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/admin/merch", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp []dto.MerchDTO
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Len(t, resp, 2)
+	assert.Nil(t, resp[0].ArchivedAt)
+	assert.True(t, archivedAt.Equal(*resp[1].ArchivedAt))
+}
+
+func TestAdminController_CreateMerch_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	router := newAdminRouter(NewAdminController(mockService))
+
+	stock := 100
+	mockService.On("CreateMerch", mock.Anything, 1, "sticker", 5, &stock).Return(&models.Merch{ID: 11, Name: "sticker", Price: 5, Stock: &stock}, nil).Once()
+
+	req, _ := http.NewRequest("POST", "/admin/merch", bytes.NewBufferString(`{"name": "sticker", "price": 5, "stock": 100}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var resp dto.MerchDTO
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, dto.MerchDTO{ID: 11, Name: "sticker", Price: 5, Stock: &stock}, resp)
+}
+
+func TestAdminController_CreateMerch_InvalidRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	router := newAdminRouter(NewAdminController(mockService))
+
+	req, _ := http.NewRequest("POST", "/admin/merch", bytes.NewBufferString(`{"name": "sticker", "price": -5}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNotCalled(t, "CreateMerch", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminController_UpdateMerch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	router := newAdminRouter(NewAdminController(mockService))
+
+	mockService.On("UpdateMerch", mock.Anything, 1, 2, "mug", 25).Return(&models.Merch{ID: 2, Name: "mug", Price: 25}, nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/admin/merch/2", bytes.NewBufferString(`{"name": "mug", "price": 25}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.MerchDTO
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "mug", resp.Name)
+	assert.Equal(t, 25, resp.Price)
+}
+
+func TestAdminController_ArchiveMerch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		serviceErr     error
+		callsService   bool
+		expectedStatus int
+	}{
+		{name: "Success", path: "/admin/merch/2/archive", callsService: true, expectedStatus: http.StatusOK},
+		{name: "Invalid id", path: "/admin/merch/abc/archive", expectedStatus: http.StatusBadRequest},
+		{name: "Service error", path: "/admin/merch/2/archive", serviceErr: errors.New("merch is already archived"), callsService: true, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mockServ.NewService(t)
+			router := gin.New()
+			router.POST("/admin/merch/:id/archive", NewAdminController(mockService).ArchiveMerch)
+
+			if tt.callsService {
+				mockService.On("ArchiveMerch", mock.Anything, 2).Return(tt.serviceErr).Once()
+			}
+
+			req, _ := http.NewRequest("POST", tt.path, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestAdminController_RestockMerch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		callsService   bool
+		expectedStatus int
+	}{
+		{name: "Success", path: "/admin/merch/2/restock", body: `{"quantity": 10}`, callsService: true, expectedStatus: http.StatusOK},
+		{name: "Invalid id", path: "/admin/merch/abc/restock", body: `{"quantity": 10}`, expectedStatus: http.StatusBadRequest},
+		{name: "Non-positive quantity", path: "/admin/merch/2/restock", body: `{"quantity": 0}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mockServ.NewService(t)
+			router := gin.New()
+			router.POST("/admin/merch/:id/restock", NewAdminController(mockService).RestockMerch)
+
+			stock := 13
+			if tt.callsService {
+				mockService.On("RestockMerch", mock.Anything, 2, 10).Return(&models.Merch{ID: 2, Name: "cup", Price: 20, Stock: &stock}, nil).Once()
+			}
+
+			req, _ := http.NewRequest("POST", tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var resp dto.MerchDTO
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				assert.NoError(t, err)
+				assert.Equal(t, &stock, resp.Stock)
+			}
+		})
+	}
+}
+
+func TestAdminController_SetMerchStock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	stock := 7
+	tests := []struct {
+		name           string
+		body           string
+		callsService   bool
+		serviceStock   *int
+		expectedStatus int
+	}{
+		{name: "Exact value", body: `{"stock": 7}`, callsService: true, serviceStock: &stock, expectedStatus: http.StatusOK},
+		{name: "Unlimited", body: `{"unlimited": true}`, callsService: true, expectedStatus: http.StatusOK},
+		{name: "Empty body", body: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "Both fields", body: `{"stock": 7, "unlimited": true}`, expectedStatus: http.StatusBadRequest},
+		{name: "Negative stock", body: `{"stock": -1}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mockServ.NewService(t)
+			router := gin.New()
+			router.PUT("/admin/merch/:id/stock", NewAdminController(mockService).SetMerchStock)
+
+			if tt.callsService {
+				mockService.On("SetMerchStock", mock.Anything, 2, tt.serviceStock).Return(&models.Merch{ID: 2, Name: "cup", Price: 20, Stock: tt.serviceStock}, nil).Once()
+			}
+
+			req, _ := http.NewRequest("PUT", "/admin/merch/2/stock", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if !tt.callsService {
+				mockService.AssertNotCalled(t, "SetMerchStock", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestAdminController_GetMerchPriceHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	router := newAdminRouter(NewAdminController(mockService))
+
+	adminID := 1
+	validFrom := time.Date(2025, 3, 28, 12, 0, 0, 0, time.UTC)
+	mockService.On("GetMerchPriceHistory", mock.Anything, 2).Return([]*models.MerchPrice{
+		{ID: 1, MerchID: 2, Price: 20, ValidFrom: validFrom.Add(-time.Hour)},
+		{ID: 7, MerchID: 2, Price: 25, ChangedBy: &adminID, ValidFrom: validFrom},
+	}, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/admin/merch/2/prices", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp []dto.MerchPriceDTO
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Len(t, resp, 2)
+	assert.Nil(t, resp[0].ChangedBy)
+	assert.Equal(t, 25, resp[1].Price)
+	assert.Equal(t, &adminID, resp[1].ChangedBy)
+}
+
+func TestAdminController_UpdatePurchaseStatus_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	router := newAdminRouter(NewAdminController(mockService))
+
+	purchase := &models.Purchase{ID: 7, UserID: 2, MerchID: 3, Quantity: 1, UnitPrice: 20, Status: models.PurchaseStatusReadyForPickup}
+	mockService.On("UpdatePurchaseStatus", mock.Anything, 1, 7, models.PurchaseStatusReadyForPickup).Return(purchase, nil).Once()
+
+	reqBody, _ := json.Marshal(dto.UpdatePurchaseStatusRequest{Status: models.PurchaseStatusReadyForPickup})
+	req, _ := http.NewRequest("PUT", "/admin/purchases/7/status", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.PurchaseDTO
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, models.PurchaseStatusReadyForPickup, resp.Status)
+}
+
+func TestAdminController_UpdatePurchaseStatus_InvalidRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	router := newAdminRouter(NewAdminController(mockService))
+
+	// placed - начальный статус, вернуть покупку в него нельзя
+	reqBody, _ := json.Marshal(dto.UpdatePurchaseStatusRequest{Status: models.PurchaseStatusPlaced})
+	req, _ := http.NewRequest("PUT", "/admin/purchases/7/status", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNotCalled(t, "UpdatePurchaseStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminController_GetPurchaseStatusHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	router := newAdminRouter(NewAdminController(mockService))
+
+	adminID := 1
+	changedAt := time.Date(2025, 3, 30, 12, 0, 0, 0, time.UTC)
+	mockService.On("GetPurchaseStatusHistory", mock.Anything, 7).Return([]*models.PurchaseStatusChange{
+		{ID: 1, PurchaseID: 7, Status: models.PurchaseStatusPlaced, ChangedAt: changedAt.Add(-time.Hour)},
+		{ID: 2, PurchaseID: 7, Status: models.PurchaseStatusReadyForPickup, ChangedBy: &adminID, ChangedAt: changedAt},
+	}, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/admin/purchases/7/history", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp []dto.PurchaseStatusChangeDTO
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp, 2)
+	assert.Equal(t, models.PurchaseStatusReadyForPickup, resp[1].Status)
+	assert.Equal(t, &adminID, resp[1].ChangedBy)
+	assert.Equal(t, changedAt, resp[1].ChangedAt)
+}
+
+func TestAdminController_VerifyLedger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	router := newAdminRouter(NewAdminController(mockService))
+
+	mockService.On("VerifyLedger", mock.Anything).Return(&models.LedgerReport{
+		Issued:     2000,
+		Mismatches: []*models.BalanceMismatch{},
+	}, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/admin/ledger/verify", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.LedgerReportResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Consistent)
+	assert.Equal(t, 2000, resp.Issued)
+	assert.Empty(t, resp.Mismatches)
+}
@@ -1,8 +1,10 @@
 package http
 
 import (
+	"avito-tech-merch/internal/controller/http/problem"
 	"avito-tech-merch/internal/models/dto"
 	"avito-tech-merch/internal/service"
+	myjwt "avito-tech-merch/pkg/jwt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
@@ -17,14 +19,15 @@ func NewAuthController(service service.Service) AuthController {
 
 // Register godoc
 // @Summary Register a new user
-// @Description Register a new user with username and password, returns a JWT token
+// @Description Register a new user with username and password, returns a JWT access token and a refresh token
 // @Tags auth
 // @Accept  json
 // @Produce  json
 // @Param request body dto.RegisterRequest true "User registration data"
-// @Success 200 {object} dto.AuthResponse "JWT token"
-// @Failure 400 {object} dto.ErrorResponse400 "Invalid request"
-// @Failure 500 {object} dto.ErrorResponse500 "Internal server error"
+// @Success 200 {object} dto.AuthResponse "JWT token pair"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 409 {object} dto.Problem "Username is taken (user_already_exists)"
+// @Failure 500 {object} dto.Problem "Internal server error"
 // @Router /auth/register [post]
 func (c *authController) Register(ctx *gin.Context) {
 	var request struct {
@@ -33,29 +36,30 @@ func (c *authController) Register(ctx *gin.Context) {
 	}
 
 	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, dto.ErrorResponse400{Code: 400, Message: "invalid request"})
+		problem.Binding(ctx, err)
 		return
 	}
 
-	token, err := c.service.Register(ctx, request.Username, request.Password)
+	tokens, err := c.service.Register(ctx, request.Username, request.Password)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, dto.ErrorResponse500{Code: 500, Message: err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dto.AuthResponse{Token: token})
+	ctx.JSON(http.StatusOK, dto.MapTokenPairToDTO(tokens))
 }
 
 // Login godoc
 // @Summary Login a user
-// @Description Login a user with username and password, returns a JWT token
+// @Description Login a user with username and password, returns a JWT access token and a refresh token
 // @Tags auth
 // @Accept  json
 // @Produce  json
 // @Param request body dto.LoginRequest true "User login data"
-// @Success 200 {object} dto.AuthResponse "JWT token"
-// @Failure 400 {object} dto.ErrorResponse400 "Invalid request"
-// @Failure 401 {object} dto.ErrorResponseInvalidCredentials401 "Invalid credentials"
+// @Success 200 {object} dto.AuthResponse "JWT token pair"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Invalid credentials (invalid_credentials)"
+// @Failure 500 {object} dto.Problem "Internal server error"
 // @Router /auth/login [post]
 func (c *authController) Login(ctx *gin.Context) {
 	var request struct {
@@ -64,15 +68,119 @@ func (c *authController) Login(ctx *gin.Context) {
 	}
 
 	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, dto.ErrorResponse400{Code: 400, Message: "invalid request"})
+		problem.Binding(ctx, err)
 		return
 	}
 
-	token, err := c.service.Login(ctx, request.Username, request.Password)
+	tokens, err := c.service.Login(ctx, request.Username, request.Password)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, dto.ErrorResponseInvalidCredentials401{Code: 401, Message: "invalid credentials"})
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dto.AuthResponse{Token: token})
+	ctx.JSON(http.StatusOK, dto.MapTokenPairToDTO(tokens))
+}
+
+// Refresh godoc
+// @Summary Refresh a token pair
+// @Description Exchanges a single-use refresh token for a new access and refresh token pair. Presenting an already used refresh token revokes its whole token family
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.RefreshRequest true "Refresh token"
+// @Success 200 {object} dto.AuthResponse "New token pair"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Invalid refresh token"
+// @Router /auth/refresh [post]
+func (c *authController) Refresh(ctx *gin.Context) {
+	var request dto.RefreshRequest
+
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		problem.Binding(ctx, err)
+		return
+	}
+
+	tokens, err := c.service.Refresh(ctx, request.RefreshToken)
+	if err != nil {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeInvalidToken, "invalid refresh token")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapTokenPairToDTO(tokens))
+}
+
+// Logout godoc
+// @Summary Logout from the current session
+// @Security BearerAuth
+// @Description Revokes the access token used for this request. If a refresh token is passed, its whole token family is revoked as well
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.LogoutRequest false "Refresh token of the session"
+// @Success 200 {object} dto.LogoutSuccessResponse "Logged out"
+// @Failure 400 {object} dto.Problem "Invalid request"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /auth/logout [post]
+func (c *authController) Logout(ctx *gin.Context) {
+	claims, exists := ctx.Get("claims")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	// Тело запроса необязательно
+	var request dto.LogoutRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			problem.Binding(ctx, err)
+			return
+		}
+	}
+
+	if err := c.service.Logout(ctx, claims.(*myjwt.Claims), request.RefreshToken); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.LogoutSuccessResponse{Message: "logged out"})
+}
+
+// LogoutAll godoc
+// @Summary Logout from all sessions
+// @Security BearerAuth
+// @Description Revokes every access and refresh token issued to the current user before this request
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.LogoutSuccessResponse "Logged out from all sessions"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /auth/logout-all [post]
+func (c *authController) LogoutAll(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := c.service.LogoutAll(ctx, userID.(int)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.LogoutSuccessResponse{Message: "logged out from all sessions"})
+}
+
+// JWKS godoc
+// @Summary Public signing keys
+// @Description Returns the JSON Web Key Set with public keys for verifying access tokens. Served at /.well-known/jwks.json, outside of the /api base path. Symmetric HS256 keys are never published
+// @Tags auth
+// @Produce  json
+// @Success 200 {object} dto.JWKSResponse "JSON Web Key Set"
+// @Router /.well-known/jwks.json [get]
+func (c *authController) JWKS(ctx *gin.Context) {
+	// Ключи меняются только при перезапуске, поэтому ответ можно кешировать
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, dto.MapJWKSToDTO(c.service.GetJWKS()))
 }
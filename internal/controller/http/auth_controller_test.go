@@ -1,8 +1,12 @@
 package http
 
 import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/models/dto"
+	"avito-tech-merch/internal/service"
 	mockServ "avito-tech-merch/internal/service/mock"
+	myjwt "avito-tech-merch/pkg/jwt"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -31,11 +35,12 @@ func TestAuthController_Register_InvalidRequest(t *testing.T) {
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
-	var resp dto.ErrorResponse400
+	var resp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, 400, resp.Code)
-	assert.Equal(t, "invalid request", resp.Message)
+	assert.Equal(t, 400, resp.Status)
+	assert.Equal(t, problem.TypePrefix+problem.CodeValidationFailed, resp.Type)
+	assert.Equal(t, []dto.FieldError{{Field: "password", Message: "is required"}}, resp.Errors)
 }
 
 func TestAuthController_Register_ServiceError(t *testing.T) {
@@ -55,17 +60,17 @@ func TestAuthController_Register_ServiceError(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	serviceErr := errors.New("registration failed")
-	mockService.On("Register", mock.Anything, "epchamp001", "strongpassword123").Return("", serviceErr).Once()
+	mockService.On("Register", mock.Anything, "epchamp001", "strongpassword123").Return(nil, serviceErr).Once()
 
 	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
-	var resp dto.ErrorResponse500
+	var resp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, 500, resp.Code)
-	assert.Equal(t, serviceErr.Error(), resp.Message)
+	assert.Equal(t, 500, resp.Status)
+	assert.Equal(t, "internal server error", resp.Detail)
 
 	mockService.AssertCalled(t, "Register", mock.Anything, "epchamp001", "strongpassword123")
 }
@@ -86,8 +91,8 @@ func TestAuthController_Register_Success(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/auth/register", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	expectedToken := "jwt-token"
-	mockService.On("Register", mock.Anything, "epchamp001", "strongpassword123").Return(expectedToken, nil).Once()
+	expectedTokens := &models.TokenPair{AccessToken: "jwt-token", RefreshToken: "refresh-token", ExpiresIn: 900}
+	mockService.On("Register", mock.Anything, "epchamp001", "strongpassword123").Return(expectedTokens, nil).Once()
 
 	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
@@ -96,7 +101,9 @@ func TestAuthController_Register_Success(t *testing.T) {
 	var resp dto.AuthResponse
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, expectedToken, resp.Token)
+	assert.Equal(t, expectedTokens.AccessToken, resp.Token)
+	assert.Equal(t, expectedTokens.RefreshToken, resp.RefreshToken)
+	assert.Equal(t, expectedTokens.ExpiresIn, resp.ExpiresIn)
 
 	mockService.AssertCalled(t, "Register", mock.Anything, "epchamp001", "strongpassword123")
 }
@@ -118,11 +125,11 @@ func TestAuthController_Login_InvalidRequest(t *testing.T) {
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
-	var resp dto.ErrorResponse400
+	var resp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, 400, resp.Code)
-	assert.Equal(t, "invalid request", resp.Message)
+	assert.Equal(t, 400, resp.Status)
+	assert.Equal(t, []dto.FieldError{{Field: "password", Message: "is required"}}, resp.Errors)
 }
 
 func TestAuthController_Login_InvalidCredentials(t *testing.T) {
@@ -141,21 +148,46 @@ func TestAuthController_Login_InvalidCredentials(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	mockService.On("Login", mock.Anything, "epchamp001", "wrongpassword").Return("", errors.New("invalid credentials")).Once()
+	mockService.On("Login", mock.Anything, "epchamp001", "wrongpassword").Return(nil, service.ErrInvalidCredentials).Once()
 
 	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusUnauthorized, rec.Code)
-	var resp dto.ErrorResponseInvalidCredentials401
+	var resp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, 401, resp.Code)
-	assert.Equal(t, "invalid credentials", resp.Message)
+	assert.Equal(t, 401, resp.Status)
+	assert.Equal(t, "invalid credentials", resp.Detail)
+	assert.Equal(t, problem.TypePrefix+problem.CodeInvalidCredentials, resp.Type)
 
 	mockService.AssertCalled(t, "Login", mock.Anything, "epchamp001", "wrongpassword")
 }
 
+func TestAuthController_Login_InternalError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+
+	controller := NewAuthController(mockService)
+	router := gin.New()
+	router.POST("/auth/login", controller.Login)
+
+	reqBody, _ := json.Marshal(dto.LoginRequest{Username: "epchamp001", Password: "strongpassword123"})
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	// Сбой базы не должен выглядеть для клиента как неверный пароль
+	mockService.On("Login", mock.Anything, "epchamp001", "strongpassword123").Return(nil, errors.New("connection refused")).Once()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	var resp dto.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, problem.TypePrefix+problem.CodeInternal, resp.Type)
+}
+
 func TestAuthController_Login_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := mockServ.NewService(t)
@@ -172,8 +204,8 @@ func TestAuthController_Login_Success(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	expectedToken := "jwt-token"
-	mockService.On("Login", mock.Anything, "epchamp001", "strongpassword123").Return(expectedToken, nil).Once()
+	expectedTokens := &models.TokenPair{AccessToken: "jwt-token", RefreshToken: "refresh-token", ExpiresIn: 900}
+	mockService.On("Login", mock.Anything, "epchamp001", "strongpassword123").Return(expectedTokens, nil).Once()
 
 	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
@@ -182,7 +214,242 @@ func TestAuthController_Login_Success(t *testing.T) {
 	var resp dto.AuthResponse
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, expectedToken, resp.Token)
+	assert.Equal(t, expectedTokens.AccessToken, resp.Token)
+	assert.Equal(t, expectedTokens.RefreshToken, resp.RefreshToken)
+	assert.Equal(t, expectedTokens.ExpiresIn, resp.ExpiresIn)
 
 	mockService.AssertCalled(t, "Login", mock.Anything, "epchamp001", "strongpassword123")
 }
+
+func TestAuthController_Refresh_InvalidRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+
+	controller := NewAuthController(mockService)
+	router := gin.New()
+	router.POST("/auth/refresh", controller.Refresh)
+
+	// Отправляем JSON без refresh_token
+	req, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer([]byte(`{}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var resp dto.Problem
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, resp.Status)
+	assert.Equal(t, []dto.FieldError{{Field: "refresh_token", Message: "is required"}}, resp.Errors)
+}
+
+func TestAuthController_Refresh_InvalidToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+
+	controller := NewAuthController(mockService)
+	router := gin.New()
+	router.POST("/auth/refresh", controller.Refresh)
+
+	reqBody, _ := json.Marshal(dto.RefreshRequest{RefreshToken: "stale-token"})
+	req, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	mockService.On("Refresh", mock.Anything, "stale-token").Return(nil, errors.New("refresh token reuse detected")).Once()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	var resp dto.Problem
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 401, resp.Status)
+	assert.Equal(t, "invalid refresh token", resp.Detail)
+
+	mockService.AssertCalled(t, "Refresh", mock.Anything, "stale-token")
+}
+
+func TestAuthController_Refresh_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+
+	controller := NewAuthController(mockService)
+	router := gin.New()
+	router.POST("/auth/refresh", controller.Refresh)
+
+	reqBody, _ := json.Marshal(dto.RefreshRequest{RefreshToken: "refresh-token"})
+	req, _ := http.NewRequest("POST", "/auth/refresh", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	expectedTokens := &models.TokenPair{AccessToken: "new-jwt-token", RefreshToken: "new-refresh-token", ExpiresIn: 900}
+	mockService.On("Refresh", mock.Anything, "refresh-token").Return(expectedTokens, nil).Once()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.AuthResponse
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTokens.AccessToken, resp.Token)
+	assert.Equal(t, expectedTokens.RefreshToken, resp.RefreshToken)
+
+	mockService.AssertCalled(t, "Refresh", mock.Anything, "refresh-token")
+}
+
+func TestAuthController_Logout_Unauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+
+	controller := NewAuthController(mockService)
+	router := gin.New()
+	router.POST("/auth/logout", controller.Logout)
+
+	// claims не установлены в контексте
+	req, _ := http.NewRequest("POST", "/auth/logout", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	var resp dto.Problem
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "unauthorized", resp.Detail)
+}
+
+func TestAuthController_Logout_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+
+	controller := NewAuthController(mockService)
+	router := gin.New()
+
+	claims := &myjwt.Claims{UserID: 1, TokenID: "jti"}
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", claims.UserID)
+		c.Set("claims", claims)
+		c.Next()
+	})
+	router.POST("/auth/logout", controller.Logout)
+
+	reqBody, _ := json.Marshal(dto.LogoutRequest{RefreshToken: "refresh-token"})
+	req, _ := http.NewRequest("POST", "/auth/logout", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	mockService.On("Logout", mock.Anything, claims, "refresh-token").Return(nil).Once()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.LogoutSuccessResponse
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "logged out", resp.Message)
+
+	mockService.AssertCalled(t, "Logout", mock.Anything, claims, "refresh-token")
+}
+
+func TestAuthController_Logout_EmptyBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+
+	controller := NewAuthController(mockService)
+	router := gin.New()
+
+	claims := &myjwt.Claims{UserID: 1, TokenID: "jti"}
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", claims)
+		c.Next()
+	})
+	router.POST("/auth/logout", controller.Logout)
+
+	mockService.On("Logout", mock.Anything, claims, "").Return(nil).Once()
+
+	req, _ := http.NewRequest("POST", "/auth/logout", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertCalled(t, "Logout", mock.Anything, claims, "")
+}
+
+func TestAuthController_LogoutAll_ServiceError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+
+	controller := NewAuthController(mockService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.POST("/auth/logout-all", controller.LogoutAll)
+
+	serviceErr := errors.New("logout failed")
+	mockService.On("LogoutAll", mock.Anything, 1).Return(serviceErr).Once()
+
+	req, _ := http.NewRequest("POST", "/auth/logout-all", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	var resp dto.Problem
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, resp.Status)
+}
+
+func TestAuthController_LogoutAll_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+
+	controller := NewAuthController(mockService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.POST("/auth/logout-all", controller.LogoutAll)
+
+	mockService.On("LogoutAll", mock.Anything, 1).Return(nil).Once()
+
+	req, _ := http.NewRequest("POST", "/auth/logout-all", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.LogoutSuccessResponse
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "logged out from all sessions", resp.Message)
+}
+
+func TestAuthController_JWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+
+	controller := NewAuthController(mockService)
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", controller.JWKS)
+
+	jwks := myjwt.JWKS{Keys: []myjwt.JSONWebKey{
+		{KeyType: "OKP", KeyID: "2025-03", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: "x-value"},
+	}}
+	mockService.On("GetJWKS").Return(jwks).Once()
+
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Cache-Control"))
+	var resp dto.JWKSResponse
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Keys, 1)
+	assert.Equal(t, "2025-03", resp.Keys[0].KeyID)
+	assert.Equal(t, "Ed25519", resp.Keys[0].Curve)
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/models/dto"
+	"avito-tech-merch/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type cartController struct {
+	service service.Service
+}
+
+func NewCartController(service service.Service) CartController {
+	return &cartController{service: service}
+}
+
+// GetCart godoc
+// @Summary Get the cart
+// @Security BearerAuth
+// @Description Returns the cart contents priced at current catalog prices
+// @Tags cart
+// @Produce  json
+// @Success 200 {object} dto.CartResponse "Cart contents"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /cart [get]
+func (c *cartController) GetCart(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	cart, err := c.service.GetCart(ctx, userID.(int))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapCartToDTO(cart))
+}
+
+// AddToCart godoc
+// @Summary Add merch to the cart
+// @Security BearerAuth
+// @Description Adds units of a merch item to the cart. Adding an item that is already in the cart increases its quantity. Stock is not reserved until checkout
+// @Tags cart
+// @Accept  json
+// @Produce  json
+// @Param request body dto.CartItemRequest true "Item and quantity"
+// @Success 200 {object} dto.CartResponse "Updated cart"
+// @Failure 400 {object} dto.Problem "Invalid request or the cart line would exceed 100 items (invalid_amount)"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 404 {object} dto.Problem "Unknown item (merch_not_found)"
+// @Failure 422 {object} dto.Problem "Item is archived (merch_unavailable)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /cart/items [post]
+func (c *cartController) AddToCart(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	var request dto.CartItemRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		problem.Binding(ctx, err)
+		return
+	}
+
+	cart, err := c.service.AddToCart(ctx, userID.(int), request.Item, request.Quantity)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapCartToDTO(cart))
+}
+
+// RemoveFromCart godoc
+// @Summary Remove merch from the cart
+// @Security BearerAuth
+// @Description Removes all units of a merch item from the cart
+// @Tags cart
+// @Produce  json
+// @Param item path string true "Item name" example:"pen"
+// @Success 200 {object} dto.CartResponse "Updated cart"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 404 {object} dto.Problem "Unknown item or item is not in the cart (merch_not_found, merch_not_in_cart)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /cart/items/{item} [delete]
+func (c *cartController) RemoveFromCart(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	cart, err := c.service.RemoveFromCart(ctx, userID.(int), ctx.Param("item"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapCartToDTO(cart))
+}
+
+// Checkout godoc
+// @Summary Check out the cart
+// @Security BearerAuth
+// @Description Buys everything in the cart in a single transaction. Either every item is purchased or none is; on success the cart is emptied
+// @Tags cart
+// @Produce  json
+// @Success 200 {object} dto.CheckoutResponse "Created purchases"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 404 {object} dto.Problem "User no longer exists (user_not_found)"
+// @Failure 409 {object} dto.Problem "Item price changed since the cart was read (price_changed)"
+// @Failure 422 {object} dto.Problem "Cart is empty, an item is archived or out of stock, or not enough coins (cart_empty, merch_unavailable, out_of_stock, insufficient_funds)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /cart/checkout [post]
+func (c *cartController) Checkout(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	purchases, err := c.service.Checkout(ctx, userID.(int))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapCheckoutToDTO(purchases))
+}
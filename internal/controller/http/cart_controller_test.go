@@ -0,0 +1,124 @@
+package http
+
+import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/models/dto"
+	"avito-tech-merch/internal/service"
+	mockServ "avito-tech-merch/internal/service/mock"
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newCartRouter(mockService *mockServ.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	controller := NewCartController(mockService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.GET("/cart", controller.GetCart)
+	router.POST("/cart/items", controller.AddToCart)
+	router.DELETE("/cart/items/:item", controller.RemoveFromCart)
+	router.POST("/cart/checkout", controller.Checkout)
+	return router
+}
+
+func TestCartController_AddToCart_Success(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newCartRouter(mockService)
+
+	mockService.On("AddToCart", mock.Anything, 1, "pen", 5).Return(&models.Cart{
+		UserID: 1,
+		Items: []*models.CartItem{
+			{UserID: 1, MerchID: 3, Quantity: 5, Merch: &models.Merch{ID: 3, Name: "pen", Price: 10}},
+		},
+	}, nil).Once()
+
+	req, _ := http.NewRequest("POST", "/cart/items", bytes.NewBufferString(`{"item": "pen", "quantity": 5}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.CartResponse
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, resp.Total)
+	assert.Len(t, resp.Items, 1)
+}
+
+func TestCartController_AddToCart_InvalidRequest(t *testing.T) {
+	for _, body := range []string{`{"item": "pen"}`, `{"item": "pen", "quantity": 101}`, `{"quantity": 1}`} {
+		mockService := mockServ.NewService(t)
+		router := newCartRouter(mockService)
+
+		req, _ := http.NewRequest("POST", "/cart/items", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		mockService.AssertNotCalled(t, "AddToCart", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestCartController_RemoveFromCart(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newCartRouter(mockService)
+
+	mockService.On("RemoveFromCart", mock.Anything, 1, "pen").Return(&models.Cart{UserID: 1}, nil).Once()
+
+	req, _ := http.NewRequest("DELETE", "/cart/items/pen", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"items": [], "total": 0}`, rec.Body.String())
+}
+
+func TestCartController_Checkout_Success(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newCartRouter(mockService)
+
+	mockService.On("Checkout", mock.Anything, 1).Return([]*models.Purchase{
+		{ID: 1, UserID: 1, MerchID: 3, Quantity: 5, UnitPrice: 10},
+		{ID: 2, UserID: 1, MerchID: 4, Quantity: 1, UnitPrice: 20},
+	}, nil).Once()
+
+	req, _ := http.NewRequest("POST", "/cart/checkout", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.CheckoutResponse
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 70, resp.Total)
+	assert.Len(t, resp.Purchases, 2)
+}
+
+func TestCartController_Checkout_ServiceError(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newCartRouter(mockService)
+
+	mockService.On("Checkout", mock.Anything, 1).Return(nil, service.ErrInsufficientFunds).Once()
+
+	req, _ := http.NewRequest("POST", "/cart/checkout", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	var resp dto.Problem
+	err := json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, problem.TypePrefix+ErrorCodeInsufficientFunds, resp.Type)
+	assert.Equal(t, "insufficient funds", resp.Detail)
+}
@@ -9,16 +9,24 @@ type Controller interface {
 	UserController
 	MerchController
 	PurchaseController
+	CartController
 	TransactionController
+	AdminController
 }
 
 type AuthController interface {
 	Register(ctx *gin.Context)
 	Login(ctx *gin.Context)
+	Refresh(ctx *gin.Context)
+	Logout(ctx *gin.Context)
+	LogoutAll(ctx *gin.Context)
+	JWKS(ctx *gin.Context)
 }
 
 type UserController interface {
 	GetInfo(ctx *gin.Context)
+	GetTransactionHistory(ctx *gin.Context)
+	GetPurchaseHistory(ctx *gin.Context)
 }
 
 type MerchController interface {
@@ -27,18 +35,42 @@ type MerchController interface {
 
 type PurchaseController interface {
 	BuyMerch(ctx *gin.Context)
+	RefundPurchase(ctx *gin.Context)
+}
+
+type CartController interface {
+	GetCart(ctx *gin.Context)
+	AddToCart(ctx *gin.Context)
+	RemoveFromCart(ctx *gin.Context)
+	Checkout(ctx *gin.Context)
 }
 
 type TransactionController interface {
 	SendCoin(ctx *gin.Context)
 }
 
+type AdminController interface {
+	SetUserRole(ctx *gin.Context)
+	ListAllMerch(ctx *gin.Context)
+	CreateMerch(ctx *gin.Context)
+	UpdateMerch(ctx *gin.Context)
+	ArchiveMerch(ctx *gin.Context)
+	RestockMerch(ctx *gin.Context)
+	SetMerchStock(ctx *gin.Context)
+	GetMerchPriceHistory(ctx *gin.Context)
+	UpdatePurchaseStatus(ctx *gin.Context)
+	GetPurchaseStatusHistory(ctx *gin.Context)
+	VerifyLedger(ctx *gin.Context)
+}
+
 type controller struct {
 	AuthController
 	UserController
 	MerchController
 	PurchaseController
+	CartController
 	TransactionController
+	AdminController
 }
 
 func NewController(
@@ -46,13 +78,17 @@ func NewController(
 	user UserController,
 	merch MerchController,
 	purchase PurchaseController,
+	cart CartController,
 	transaction TransactionController,
+	admin AdminController,
 ) Controller {
 	return &controller{
 		AuthController:        auth,
 		UserController:        user,
 		MerchController:       merch,
 		PurchaseController:    purchase,
+		CartController:        cart,
 		TransactionController: transaction,
+		AdminController:       admin,
 	}
 }
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/service"
+	"avito-tech-merch/pkg/logger"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Коды доменных ошибок: из них строится type в ответе, по нему клиент различает ошибки, а detail может меняться
+const (
+	ErrorCodeInvalidAmount       = "invalid_amount"
+	ErrorCodeInvalidTransferMemo = "invalid_transfer_memo"
+	ErrorCodeReceiverNotFound    = "receiver_not_found"
+	ErrorCodeSelfTransfer        = "self_transfer"
+	ErrorCodeInsufficientFunds   = "insufficient_funds"
+	ErrorCodeUserNotFound        = "user_not_found"
+	ErrorCodeMerchNotFound       = "merch_not_found"
+	ErrorCodeMerchUnavailable    = "merch_unavailable"
+	ErrorCodeOutOfStock          = "out_of_stock"
+	ErrorCodePriceChanged        = "price_changed"
+	ErrorCodeMerchNotInCart      = "merch_not_in_cart"
+	ErrorCodeCartEmpty           = "cart_empty"
+	ErrorCodePurchaseNotFound    = "purchase_not_found"
+	ErrorCodeAlreadyRefunded     = "already_refunded"
+	ErrorCodeRefundNotAllowed    = "refund_not_allowed"
+	ErrorCodeInvalidStatus       = "invalid_purchase_status"
+	ErrorCodeStatusTransition    = "invalid_status_transition"
+	ErrorCodeUserAlreadyExists   = "user_already_exists"
+	ErrorCodeInvalidRole         = "invalid_role"
+	ErrorCodeOwnRoleChange       = "own_role_change"
+	ErrorCodeInvalidMerch        = "invalid_merch"
+	ErrorCodeMerchAlreadyExists  = "merch_already_exists"
+	ErrorCodeMerchArchived       = "merch_archived"
+	ErrorCodeAlreadyArchived     = "merch_already_archived"
+	ErrorCodeStockNotTracked     = "stock_not_tracked"
+	ErrorCodeIdempotencyConflict = "idempotency_conflict"
+	ErrorCodeRequestInProgress   = "request_in_progress"
+)
+
+// domainError описывает, с каким статусом и кодом доменная ошибка сервиса отдаётся клиенту
+type domainError struct {
+	err    error
+	status int
+	code   string
+}
+
+var domainErrors = []domainError{
+	{service.ErrInvalidAmount, http.StatusBadRequest, ErrorCodeInvalidAmount},
+	{service.ErrInvalidTransferMemo, http.StatusBadRequest, ErrorCodeInvalidTransferMemo},
+	// Получатель задаётся в теле запроса, поэтому его отсутствие - ошибка запроса, а не 404
+	{service.ErrReceiverNotFound, http.StatusBadRequest, ErrorCodeReceiverNotFound},
+	{service.ErrInvalidPurchaseStatus, http.StatusBadRequest, ErrorCodeInvalidStatus},
+	{service.ErrInvalidRole, http.StatusBadRequest, ErrorCodeInvalidRole},
+	{service.ErrInvalidMerch, http.StatusBadRequest, ErrorCodeInvalidMerch},
+	{service.ErrInvalidCredentials, http.StatusUnauthorized, problem.CodeInvalidCredentials},
+	{service.ErrUserNotFound, http.StatusNotFound, ErrorCodeUserNotFound},
+	{service.ErrMerchNotFound, http.StatusNotFound, ErrorCodeMerchNotFound},
+	{service.ErrMerchNotInCart, http.StatusNotFound, ErrorCodeMerchNotInCart},
+	{service.ErrPurchaseNotFound, http.StatusNotFound, ErrorCodePurchaseNotFound},
+	{service.ErrPriceChanged, http.StatusConflict, ErrorCodePriceChanged},
+	{service.ErrAlreadyRefunded, http.StatusConflict, ErrorCodeAlreadyRefunded},
+	{service.ErrIdempotencyKeyReused, http.StatusConflict, ErrorCodeIdempotencyConflict},
+	{service.ErrRequestInProgress, http.StatusConflict, ErrorCodeRequestInProgress},
+	{service.ErrUserAlreadyExists, http.StatusConflict, ErrorCodeUserAlreadyExists},
+	{service.ErrMerchAlreadyExists, http.StatusConflict, ErrorCodeMerchAlreadyExists},
+	{service.ErrMerchAlreadyArchived, http.StatusConflict, ErrorCodeAlreadyArchived},
+	{service.ErrSelfTransfer, http.StatusUnprocessableEntity, ErrorCodeSelfTransfer},
+	{service.ErrInsufficientFunds, http.StatusUnprocessableEntity, ErrorCodeInsufficientFunds},
+	{service.ErrMerchUnavailable, http.StatusUnprocessableEntity, ErrorCodeMerchUnavailable},
+	{service.ErrOutOfStock, http.StatusUnprocessableEntity, ErrorCodeOutOfStock},
+	{service.ErrCartEmpty, http.StatusUnprocessableEntity, ErrorCodeCartEmpty},
+	{service.ErrRefundNotAllowed, http.StatusUnprocessableEntity, ErrorCodeRefundNotAllowed},
+	{service.ErrInvalidStatusTransition, http.StatusUnprocessableEntity, ErrorCodeStatusTransition},
+	{service.ErrOwnRoleChange, http.StatusUnprocessableEntity, ErrorCodeOwnRoleChange},
+	{service.ErrMerchArchived, http.StatusUnprocessableEntity, ErrorCodeMerchArchived},
+	{service.ErrStockNotTracked, http.StatusUnprocessableEntity, ErrorCodeStockNotTracked},
+}
+
+// respondError отдаёт ошибку сервиса клиенту в формате problem+json. Доменные ошибки получают свой статус и код,
+// а их текст формируется сервисом для клиента; остальные ошибки отдаются как 500 без подробностей,
+// чтобы сообщения хранилища не попадали в ответ (они уже записаны в лог сервисом)
+func respondError(ctx *gin.Context, err error) {
+	for _, known := range domainErrors {
+		if errors.Is(err, known.err) {
+			problem.Write(ctx, known.status, known.code, err.Error())
+			return
+		}
+	}
+
+	// Текст ошибки клиенту не отдаётся, поэтому он пишется в лог с тем же request_id, что получит клиент
+	logger.FromContext(ctx.Request.Context()).Errorw("Unhandled error",
+		"error", err,
+	)
+	problem.Internal(ctx)
+}
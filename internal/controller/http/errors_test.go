@@ -0,0 +1,71 @@
+package http
+
+import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/models/dto"
+	"avito-tech-merch/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRespondError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedCode    string
+		expectedMessage string
+	}{
+		{name: "Invalid amount", err: fmt.Errorf("%w: amount must be positive", service.ErrInvalidAmount), expectedStatus: http.StatusBadRequest, expectedCode: ErrorCodeInvalidAmount, expectedMessage: "invalid amount: amount must be positive"},
+		{name: "Receiver not found", err: fmt.Errorf("%w: bob", service.ErrReceiverNotFound), expectedStatus: http.StatusBadRequest, expectedCode: ErrorCodeReceiverNotFound, expectedMessage: "receiver not found: bob"},
+		{name: "User not found", err: fmt.Errorf("%w: id 1", service.ErrUserNotFound), expectedStatus: http.StatusNotFound, expectedCode: ErrorCodeUserNotFound, expectedMessage: "user not found: id 1"},
+		{name: "Merch not found", err: fmt.Errorf("%w: hat", service.ErrMerchNotFound), expectedStatus: http.StatusNotFound, expectedCode: ErrorCodeMerchNotFound, expectedMessage: "merch not found: hat"},
+		{name: "Idempotency conflict", err: service.ErrIdempotencyKeyReused, expectedStatus: http.StatusConflict, expectedCode: ErrorCodeIdempotencyConflict, expectedMessage: service.ErrIdempotencyKeyReused.Error()},
+		{name: "Self transfer", err: service.ErrSelfTransfer, expectedStatus: http.StatusUnprocessableEntity, expectedCode: ErrorCodeSelfTransfer, expectedMessage: "cannot transfer to yourself"},
+		{name: "Insufficient funds", err: service.ErrInsufficientFunds, expectedStatus: http.StatusUnprocessableEntity, expectedCode: ErrorCodeInsufficientFunds, expectedMessage: "insufficient funds"},
+		{name: "Out of stock", err: fmt.Errorf("%w: cup", service.ErrOutOfStock), expectedStatus: http.StatusUnprocessableEntity, expectedCode: ErrorCodeOutOfStock, expectedMessage: "out of stock: cup"},
+		{name: "Stock not tracked", err: service.ErrStockNotTracked, expectedStatus: http.StatusUnprocessableEntity, expectedCode: ErrorCodeStockNotTracked, expectedMessage: "merch stock is not tracked"},
+		{name: "Price changed", err: fmt.Errorf("%w: cup now costs 30", service.ErrPriceChanged), expectedStatus: http.StatusConflict, expectedCode: ErrorCodePriceChanged, expectedMessage: "merch price has changed: cup now costs 30"},
+		{name: "Internal error", err: errors.New("failed to lock balances: ERROR: canceling statement due to lock timeout (SQLSTATE 55P03)"), expectedStatus: http.StatusInternalServerError, expectedCode: problem.CodeInternal, expectedMessage: "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(ctx *gin.Context) {
+				respondError(ctx, tt.err)
+			})
+
+			req, _ := http.NewRequest("GET", "/", nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			var resp dto.Problem
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedStatus, resp.Status)
+			assert.Equal(t, problem.TypePrefix+tt.expectedCode, resp.Type)
+			assert.Equal(t, tt.expectedMessage, resp.Detail)
+		})
+	}
+}
+
+// TestDomainErrors_Unique проверяет, что каждая доменная ошибка сопоставлена ровно один раз
+func TestDomainErrors_Unique(t *testing.T) {
+	seen := make(map[error]bool)
+	codes := make(map[string]bool)
+	for _, known := range domainErrors {
+		assert.False(t, seen[known.err], "duplicate mapping for %v", known.err)
+		assert.False(t, codes[known.code], "duplicate error code %s", known.code)
+		seen[known.err] = true
+		codes[known.code] = true
+	}
+}
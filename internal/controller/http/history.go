@@ -0,0 +1,60 @@
+package http
+
+import (
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/models/dto"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"time"
+)
+
+// historyQuery читает общие параметры истории: limit, cursor и период from/to в формате RFC 3339
+func historyQuery(ctx *gin.Context) (models.HistoryPage, *time.Time, *time.Time, error) {
+	var page models.HistoryPage
+
+	if value := ctx.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > models.MaxHistoryPageSize {
+			return page, nil, nil, errors.New("invalid limit")
+		}
+		page.Limit = limit
+	}
+
+	if value := ctx.Query("cursor"); value != "" {
+		cursor, err := dto.DecodeHistoryCursor(value)
+		if err != nil {
+			return page, nil, nil, err
+		}
+		page.After = cursor
+	}
+
+	from, err := queryTime(ctx, "from")
+	if err != nil {
+		return page, nil, nil, err
+	}
+	to, err := queryTime(ctx, "to")
+	if err != nil {
+		return page, nil, nil, err
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return page, nil, nil, errors.New("invalid date range")
+	}
+
+	return page, from, to, nil
+}
+
+func queryTime(ctx *gin.Context, name string) (*time.Time, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("invalid " + name + " date")
+	}
+	// created_at хранится без часового пояса в локальном времени сервера, в нём же сравниваем границы
+	t = t.Local()
+	return &t, nil
+}
@@ -0,0 +1,22 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+const (
+	// IdempotencyKeyHeader - заголовок, которым клиент помечает повторы одного и того же запроса
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotencyKey читает ключ идемпотентности из заголовка; пустой ключ означает, что запрос выполняется без дедупликации
+func idempotencyKey(ctx *gin.Context) (string, bool) {
+	key := strings.TrimSpace(ctx.GetHeader(IdempotencyKeyHeader))
+	if len(key) > maxIdempotencyKeyLength {
+		return "", false
+	}
+	return key, true
+}
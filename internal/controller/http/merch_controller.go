@@ -23,12 +23,12 @@ func NewMerchController(service service.Service) MerchController {
 // @Accept  json
 // @Produce  json
 // @Success 200 {array} dto.MerchDTO "List of merchandise items"
-// @Failure 500 {object} dto.ErrorResponse500 "Internal server error"
+// @Failure 500 {object} dto.Problem "Internal server error"
 // @Router /merch [get]
 func (c *merchController) ListMerch(ctx *gin.Context) {
 	merchList, err := c.service.ListMerch(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, dto.ErrorResponse500{Code: 500, Message: err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
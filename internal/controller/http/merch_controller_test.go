@@ -30,11 +30,11 @@ func TestMerchController_ListMerch_ServiceError(t *testing.T) {
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
-	var errResp dto.ErrorResponse500
+	var errResp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &errResp)
 	assert.NoError(t, err)
-	assert.Equal(t, 500, errResp.Code)
-	assert.Equal(t, expectedErr.Error(), errResp.Message)
+	assert.Equal(t, 500, errResp.Status)
+	assert.Equal(t, "internal server error", errResp.Detail)
 
 	mockService.AssertCalled(t, "ListMerch", mock.Anything)
 }
@@ -1,8 +1,10 @@
 package middleware
 
 import (
-	"avito-tech-merch/internal/models/dto"
+	"avito-tech-merch/internal/controller/http/problem"
 	"avito-tech-merch/internal/service"
+	"avito-tech-merch/pkg/logger"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strings"
@@ -12,27 +14,28 @@ func JWTAuthMiddleware(authService service.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.GetHeader("Authorization")
 		if token == "" {
-			c.JSON(http.StatusUnauthorized, dto.ErrorJWTMissingToken{
-				Code:    401,
-				Message: "missing token",
-			})
-			c.Abort()
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeMissingToken, "missing token")
 			return
 		}
 
 		token = strings.TrimPrefix(token, "Bearer ")
 
-		userID, err := authService.ValidateToken(token)
+		claims, err := authService.ValidateToken(c, token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, dto.ErrorJWTInvalidToken{
-				Code:    401,
-				Message: "invalid token",
-			})
+			// 401 заставляет клиента выбросить сессию, поэтому он отдаётся только для самого токена;
+			// сбой проверки отзыва - внутренняя ошибка, и сессия остаётся действительной
+			if errors.Is(err, service.ErrInvalidToken) {
+				problem.Abort(c, http.StatusUnauthorized, problem.CodeInvalidToken, "invalid token")
+				return
+			}
+			problem.Internal(c)
 			c.Abort()
 			return
 		}
 
-		c.Set("userID", userID)
+		c.Set("userID", claims.UserID)
+		c.Set("claims", claims)
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), logger.UserIDKey, claims.UserID))
 		c.Next()
 	}
 }
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/service"
+	mockServ "avito-tech-merch/internal/service/mock"
+	myjwt "avito-tech-merch/pkg/jwt"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func performWithToken(authService service.AuthService, token string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/info", JWTAuthMiddleware(authService), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/api/info", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestJWTAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		token          string
+		claims         *myjwt.Claims
+		validateErr    error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "Valid token", token: "valid", claims: &myjwt.Claims{UserID: 1}, expectedStatus: http.StatusOK},
+		{name: "Missing token", expectedStatus: http.StatusUnauthorized, expectedCode: problem.CodeMissingToken},
+		{name: "Revoked token", token: "revoked", validateErr: fmt.Errorf("%w: revoked", service.ErrInvalidToken), expectedStatus: http.StatusUnauthorized, expectedCode: problem.CodeInvalidToken},
+		// Сбой хранилища не должен заставлять клиента выбрасывать действующую сессию
+		{name: "Revocation check failed", token: "valid", validateErr: fmt.Errorf("failed to check token revocation: %w", errors.New("connection refused")), expectedStatus: http.StatusInternalServerError, expectedCode: problem.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servMock := mockServ.NewService(t)
+			if tt.token != "" {
+				servMock.On("ValidateToken", mock.Anything, tt.token).Return(tt.claims, tt.validateErr).Once()
+			}
+
+			rec := performWithToken(servMock, tt.token)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, rec.Body.String(), problem.TypePrefix+tt.expectedCode)
+			}
+		})
+	}
+}
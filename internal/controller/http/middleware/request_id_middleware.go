@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const maxRequestIDLength = 128
+
+// RequestID берёт идентификатор запроса из заголовка X-Request-ID или генерирует новый,
+// возвращает его в ответе и кладёт в контекст запроса вместе с маршрутом, чтобы он попадал
+// во все записи логов сервисов и репозиториев. Должен стоять перед остальными middleware
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(problem.RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Header(problem.RequestIDHeader, id)
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		ctx := logger.NewContext(c.Request.Context(), logger.RequestIDKey, id, logger.RouteKey, c.Request.Method+" "+route)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Идентификатор от клиента попадает в логи и заголовки как есть, поэтому допускаются
+// только короткие строки из букв, цифр и разделителей
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func performWithRequestID(requestID string) (*httptest.ResponseRecorder, []interface{}) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.ContextWithFallback = true
+
+	var fields []interface{}
+	router.GET("/items/:id", RequestID(), func(c *gin.Context) {
+		fields = logger.Fields(c)
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/items/1", nil)
+	if requestID != "" {
+		req.Header.Set(problem.RequestIDHeader, requestID)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec, fields
+}
+
+func TestRequestID_UsesClientID(t *testing.T) {
+	rec, fields := performWithRequestID("req-42")
+
+	assert.Equal(t, "req-42", rec.Header().Get(problem.RequestIDHeader))
+	assert.Equal(t, []interface{}{logger.RequestIDKey, "req-42", logger.RouteKey, "GET /items/:id"}, fields)
+}
+
+func TestRequestID_GeneratesID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+	}{
+		{name: "Missing header", requestID: ""},
+		{name: "Invalid characters", requestID: "req 42\" injected=1"},
+		{name: "Too long", requestID: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, fields := performWithRequestID(tt.requestID)
+
+			id := rec.Header().Get(problem.RequestIDHeader)
+			_, err := uuid.Parse(id)
+			assert.NoError(t, err)
+			assert.Equal(t, []interface{}{logger.RequestIDKey, id, logger.RouteKey, "GET /items/:id"}, fields)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"avito-tech-merch/internal/controller/http/problem"
+	myjwt "avito-tech-merch/pkg/jwt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RequireRole пропускает запрос, только если роль из токена входит в список разрешённых.
+// Должен стоять после JWTAuthMiddleware
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]struct{}, len(roles))
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		value, exists := c.Get("claims")
+		claims, ok := value.(*myjwt.Claims)
+		if !exists || !ok {
+			problem.Abort(c, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+			return
+		}
+
+		if _, ok := allowed[claims.Role]; !ok {
+			problem.Abort(c, http.StatusForbidden, problem.CodeForbidden, "forbidden")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"avito-tech-merch/internal/models"
+	myjwt "avito-tech-merch/pkg/jwt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func performWithClaims(claims *myjwt.Claims, roles ...string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if claims != nil {
+			c.Set("claims", claims)
+		}
+		c.Next()
+	})
+	router.GET("/admin", RequireRole(roles...), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/admin", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequireRole_Allowed(t *testing.T) {
+	rec := performWithClaims(&myjwt.Claims{UserID: 1, Role: models.RoleAdmin}, models.RoleAdmin)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRequireRole_Forbidden(t *testing.T) {
+	rec := performWithClaims(&myjwt.Claims{UserID: 1, Role: models.RoleEmployee}, models.RoleAdmin)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestRequireRole_MultipleRoles(t *testing.T) {
+	rec := performWithClaims(&myjwt.Claims{UserID: 1, Role: models.RoleEmployee}, models.RoleAdmin, models.RoleEmployee)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRequireRole_MissingClaims(t *testing.T) {
+	rec := performWithClaims(nil, models.RoleAdmin)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,152 @@
+package problem
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	// ContentType - тип содержимого ответов с ошибкой (RFC 7807)
+	ContentType = "application/problem+json"
+
+	// RequestIDHeader - заголовок с идентификатором запроса, который попадает в ответ с ошибкой
+	RequestIDHeader = "X-Request-ID"
+
+	// TypePrefix - префикс URI типа ошибки; к нему добавляется код ошибки
+	TypePrefix = "urn:merch-store:problem:"
+)
+
+// Коды общих ошибок, не связанных с доменными ошибками сервисов
+const (
+	CodeInvalidRequest     = "invalid_request"
+	CodeValidationFailed   = "validation_failed"
+	CodeUnauthorized       = "unauthorized"
+	CodeMissingToken       = "missing_token"
+	CodeInvalidToken       = "invalid_token"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeForbidden          = "forbidden"
+	CodeInternal           = "internal_error"
+)
+
+// Поля в ошибках валидации называются так же, как в JSON запроса, а не как в Go-структуре
+func init() {
+	if validate, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		validate.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+// Write отдаёт ошибку в формате application/problem+json. code определяет type ошибки,
+// detail описывает конкретный случай и показывается клиенту как есть
+func Write(ctx *gin.Context, status int, code, detail string) {
+	write(ctx, status, code, detail, nil)
+}
+
+// Abort отдаёт ошибку и прерывает цепочку обработчиков; используется в middleware
+func Abort(ctx *gin.Context, status int, code, detail string) {
+	Write(ctx, status, code, detail)
+	ctx.Abort()
+}
+
+// Internal отдаёт 500 без подробностей: текст исходной ошибки не должен попадать клиенту
+func Internal(ctx *gin.Context) {
+	Write(ctx, http.StatusInternalServerError, CodeInternal, "internal server error")
+}
+
+// Binding отдаёт ошибку разбора тела запроса. Для ошибок валидации и несовпадения типов
+// в ответ попадает список полей с причиной
+func Binding(ctx *gin.Context, err error) {
+	var (
+		validationErrs validator.ValidationErrors
+		typeErr        *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make([]dto.FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields = append(fields, dto.FieldError{Field: fieldErr.Field(), Message: fieldMessage(fieldErr)})
+		}
+		write(ctx, http.StatusBadRequest, CodeValidationFailed, "request validation failed", fields)
+	case errors.As(err, &typeErr):
+		fields := []dto.FieldError{{Field: typeErr.Field, Message: "must be " + typeErr.Type.Kind().String()}}
+		write(ctx, http.StatusBadRequest, CodeValidationFailed, "request validation failed", fields)
+	default:
+		Write(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+	}
+}
+
+func write(ctx *gin.Context, status int, code, detail string, fields []dto.FieldError) {
+	// Content-Type выставляется до ctx.JSON, иначе gin подставит application/json
+	ctx.Header("Content-Type", ContentType)
+	ctx.JSON(status, dto.Problem{
+		Type:      TypePrefix + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  ctx.Request.URL.Path,
+		RequestID: requestID(ctx),
+		Errors:    fields,
+	})
+}
+
+// requestID возвращает идентификатор запроса: уже выставленный в ответе, присланный клиентом или новый.
+// Идентификатор дублируется в заголовке ответа, чтобы по нему можно было найти запрос в логах
+func requestID(ctx *gin.Context) string {
+	if id := ctx.Writer.Header().Get(RequestIDHeader); id != "" {
+		return id
+	}
+
+	id := ctx.GetHeader(RequestIDHeader)
+	if id == "" {
+		id = uuid.NewString()
+	}
+	ctx.Header(RequestIDHeader, id)
+	return id
+}
+
+func fieldMessage(fieldErr validator.FieldError) string {
+	unit := ""
+	if fieldErr.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "gt":
+		return "must be greater than " + fieldErr.Param()
+	case "gte":
+		return "must be at least " + fieldErr.Param()
+	case "lt":
+		return "must be less than " + fieldErr.Param()
+	case "lte":
+		return "must be at most " + fieldErr.Param()
+	case "min":
+		return "must be at least " + fieldErr.Param() + unit
+	case "max":
+		return "must be at most " + fieldErr.Param() + unit
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	default:
+		return "is invalid"
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,139 @@
+package problem
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type bindingRequest struct {
+	Item     string `json:"item" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,gt=0,lte=100"`
+	Role     string `json:"role" binding:"omitempty,oneof=employee admin"`
+	Comment  string `json:"comment" binding:"omitempty,max=5"`
+}
+
+func serve(handler gin.HandlerFunc, body string, header http.Header) (*httptest.ResponseRecorder, dto.Problem) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/things", handler)
+
+	req, _ := http.NewRequest("POST", "/things", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name := range header {
+		req.Header.Set(name, header.Get(name))
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp dto.Problem
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp
+}
+
+func TestWrite(t *testing.T) {
+	rec, resp := serve(func(ctx *gin.Context) {
+		Write(ctx, http.StatusConflict, "merch_already_exists", "merch already exists")
+	}, "", nil)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, dto.Problem{
+		Type:      TypePrefix + "merch_already_exists",
+		Title:     "Conflict",
+		Status:    http.StatusConflict,
+		Detail:    "merch already exists",
+		Instance:  "/things",
+		RequestID: rec.Header().Get(RequestIDHeader),
+	}, resp)
+	assert.NotEmpty(t, resp.RequestID)
+}
+
+func TestWrite_EchoesRequestID(t *testing.T) {
+	rec, resp := serve(func(ctx *gin.Context) {
+		Internal(ctx)
+	}, "", http.Header{http.CanonicalHeaderKey(RequestIDHeader): {"req-42"}})
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "req-42", resp.RequestID)
+	assert.Equal(t, "req-42", rec.Header().Get(RequestIDHeader))
+	assert.Equal(t, TypePrefix+CodeInternal, resp.Type)
+	assert.Equal(t, "internal server error", resp.Detail)
+}
+
+func TestAbort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	called := false
+	router.GET("/", func(ctx *gin.Context) {
+		Abort(ctx, http.StatusForbidden, CodeForbidden, "forbidden")
+	}, func(ctx *gin.Context) {
+		called = true
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.False(t, called)
+}
+
+func TestBinding(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedType   string
+		expectedDetail string
+		expectedFields []dto.FieldError
+	}{
+		{
+			name:           "Missing and out of range fields",
+			body:           `{"quantity": 101, "role": "owner", "comment": "too long"}`,
+			expectedType:   TypePrefix + CodeValidationFailed,
+			expectedDetail: "request validation failed",
+			expectedFields: []dto.FieldError{
+				{Field: "item", Message: "is required"},
+				{Field: "quantity", Message: "must be at most 100"},
+				{Field: "role", Message: "must be one of: employee, admin"},
+				{Field: "comment", Message: "must be at most 5 characters"},
+			},
+		},
+		{
+			name:           "Wrong field type",
+			body:           `{"item": "pen", "quantity": "five"}`,
+			expectedType:   TypePrefix + CodeValidationFailed,
+			expectedDetail: "request validation failed",
+			expectedFields: []dto.FieldError{{Field: "quantity", Message: "must be int"}},
+		},
+		{
+			name:           "Malformed JSON",
+			body:           `{"item": `,
+			expectedType:   TypePrefix + CodeInvalidRequest,
+			expectedDetail: "invalid request body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, resp := serve(func(ctx *gin.Context) {
+				var request bindingRequest
+				if err := ctx.ShouldBindJSON(&request); err != nil {
+					Binding(ctx, err)
+					return
+				}
+				ctx.Status(http.StatusOK)
+			}, tt.body, nil)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, tt.expectedType, resp.Type)
+			assert.Equal(t, tt.expectedDetail, resp.Detail)
+			assert.Equal(t, tt.expectedFields, resp.Errors)
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package http
 
 import (
+	"avito-tech-merch/internal/controller/http/problem"
 	"avito-tech-merch/internal/models/dto"
 	"avito-tech-merch/internal/service"
+	myjwt "avito-tech-merch/pkg/jwt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
 )
 
 type purchaseController struct {
@@ -23,29 +26,90 @@ func NewPurchaseController(service service.Service) PurchaseController {
 // @Accept  json
 // @Produce  json
 // @Param item path string true "Item ID to purchase" example:"cup"
+// @Param quantity query int false "Number of units to purchase (1-100)" default(1)
+// @Param Idempotency-Key header string false "Unique key of the request; a retry with the same key returns the original purchase instead of buying again"
 // @Success 200 {object} dto.PurchaseSuccessResponse "Purchase successful"
-// @Failure 400 {object} dto.ErrorResponse400 "Bad request (item is required)"
-// @Failure 401 {object} dto.ErrorResponseUnauthorized401 "Unauthorized"
-// @Failure 500 {object} dto.ErrorResponse500 "Internal server error"
+// @Failure 400 {object} dto.Problem "Bad request (item is required, invalid quantity or idempotency key)"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 404 {object} dto.Problem "Unknown item or user (merch_not_found, user_not_found)"
+// @Failure 409 {object} dto.Problem "Item price changed since it was read, idempotency key was used for a different request or the original request is still in progress (price_changed, idempotency_conflict, request_in_progress)"
+// @Failure 422 {object} dto.Problem "Item is archived or out of stock, or not enough coins (merch_unavailable, out_of_stock, insufficient_funds)"
+// @Failure 500 {object} dto.Problem "Internal server error"
 // @Router /merch/buy/{item} [post]
 func (c *purchaseController) BuyMerch(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, dto.ErrorResponseUnauthorized401{Code: 401, Message: "unauthorized"})
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
 		return
 	}
 
 	item := ctx.Param("item")
 	if item == "" {
-		ctx.JSON(http.StatusBadRequest, dto.ErrorResponse400{Code: 400, Message: "item is required"})
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "item is required")
 		return
 	}
 
-	err := c.service.PurchaseMerch(ctx, userID.(int), item)
+	quantity, err := strconv.Atoi(ctx.DefaultQuery("quantity", "1"))
+	if err != nil || quantity <= 0 || quantity > service.MaxPurchaseQuantity {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid quantity")
+		return
+	}
+
+	key, ok := idempotencyKey(ctx)
+	if !ok {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid idempotency key")
+		return
+	}
+
+	purchase, err := c.service.PurchaseMerch(ctx, userID.(int), item, quantity, key)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.PurchaseSuccessResponse{Message: "purchase successful", PurchaseID: purchase.ID})
+}
+
+// RefundPurchase godoc
+// @Summary Refund a purchase
+// @Security BearerAuth
+// @Description Returns the coins and stock of a purchase and marks it refunded. Employees can refund their own purchases within the configured refund window, admins can refund any purchase at any time
+// @Tags purchase
+// @Produce  json
+// @Param id path int true "Purchase ID"
+// @Success 200 {object} dto.PurchaseDTO "Refunded purchase"
+// @Failure 400 {object} dto.Problem "Invalid purchase id"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 404 {object} dto.Problem "Purchase not found (purchase_not_found)"
+// @Failure 409 {object} dto.Problem "Purchase is already refunded (already_refunded)"
+// @Failure 422 {object} dto.Problem "Purchase cannot be refunded in its status or the refund window has expired (refund_not_allowed)"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /purchases/{id}/refund [post]
+func (c *purchaseController) RefundPurchase(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	value, _ := ctx.Get("claims")
+	claims, ok := value.(*myjwt.Claims)
+	if !ok {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	purchaseID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || purchaseID <= 0 {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid purchase id")
+		return
+	}
+
+	purchase, err := c.service.RefundPurchase(ctx, userID.(int), claims.Role, purchaseID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, dto.ErrorResponse500{Code: 500, Message: err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dto.PurchaseSuccessResponse{Message: "purchase successful"})
+	ctx.JSON(http.StatusOK, dto.MapPurchaseToDTO(purchase))
 }
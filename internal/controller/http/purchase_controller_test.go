@@ -1,8 +1,12 @@
 package http
 
 import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/models/dto"
+	"avito-tech-merch/internal/service"
 	mockServ "avito-tech-merch/internal/service/mock"
+	myjwt "avito-tech-merch/pkg/jwt"
 	"encoding/json"
 	"errors"
 	"github.com/gin-gonic/gin"
@@ -11,6 +15,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestPurchaseController_BuyMerch_Unauthorized(t *testing.T) {
@@ -26,11 +31,11 @@ func TestPurchaseController_BuyMerch_Unauthorized(t *testing.T) {
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusUnauthorized, rec.Code)
-	var resp dto.ErrorResponseUnauthorized401
+	var resp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, 401, resp.Code)
-	assert.Equal(t, "unauthorized", resp.Message)
+	assert.Equal(t, 401, resp.Status)
+	assert.Equal(t, "unauthorized", resp.Detail)
 }
 
 func TestPurchaseController_BuyMerch_ServiceError(t *testing.T) {
@@ -47,21 +52,23 @@ func TestPurchaseController_BuyMerch_ServiceError(t *testing.T) {
 	router.POST("/merch/buy/:item", controller.BuyMerch)
 
 	// Ожидаем, что сервис вернет ошибку при покупке
-	serviceErr := errors.New("purchase failed")
-	mockService.On("PurchaseMerch", mock.Anything, 1, "cup").Return(serviceErr).Once()
+	serviceErr := errors.New("failed to create purchase: connection reset by peer")
+	mockService.On("PurchaseMerch", mock.Anything, 1, "cup", 1, "").Return(nil, serviceErr).Once()
 
 	req, _ := http.NewRequest("POST", "/merch/buy/cup", nil)
 	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
-	var resp dto.ErrorResponse500
+	var resp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, 500, resp.Code)
-	assert.Equal(t, serviceErr.Error(), resp.Message)
+	assert.Equal(t, 500, resp.Status)
+	assert.Equal(t, problem.TypePrefix+problem.CodeInternal, resp.Type)
+	// Текст внутренней ошибки не должен попадать в ответ
+	assert.Equal(t, "internal server error", resp.Detail)
 
-	mockService.AssertCalled(t, "PurchaseMerch", mock.Anything, 1, "cup")
+	mockService.AssertCalled(t, "PurchaseMerch", mock.Anything, 1, "cup", 1, "")
 }
 
 func TestPurchaseController_BuyMerch_Success(t *testing.T) {
@@ -77,7 +84,7 @@ func TestPurchaseController_BuyMerch_Success(t *testing.T) {
 	})
 	router.POST("/merch/buy/:item", controller.BuyMerch)
 
-	mockService.On("PurchaseMerch", mock.Anything, 1, "cup").Return(nil).Once()
+	mockService.On("PurchaseMerch", mock.Anything, 1, "cup", 1, "").Return(&models.Purchase{ID: 7}, nil).Once()
 
 	req, _ := http.NewRequest("POST", "/merch/buy/cup", nil)
 	rec := httptest.NewRecorder()
@@ -88,6 +95,131 @@ func TestPurchaseController_BuyMerch_Success(t *testing.T) {
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
 	assert.Equal(t, "purchase successful", resp.Message)
+	assert.Equal(t, 7, resp.PurchaseID)
 
-	mockService.AssertCalled(t, "PurchaseMerch", mock.Anything, 1, "cup")
+	mockService.AssertCalled(t, "PurchaseMerch", mock.Anything, 1, "cup", 1, "")
+}
+
+func TestPurchaseController_BuyMerch_Quantity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		quantity       int
+		expectedStatus int
+	}{
+		{name: "Explicit quantity", query: "?quantity=5", quantity: 5, expectedStatus: http.StatusOK},
+		{name: "Zero quantity", query: "?quantity=0", expectedStatus: http.StatusBadRequest},
+		{name: "Too many", query: "?quantity=101", expectedStatus: http.StatusBadRequest},
+		{name: "Not a number", query: "?quantity=five", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mockServ.NewService(t)
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("userID", 1)
+				c.Next()
+			})
+			router.POST("/merch/buy/:item", NewPurchaseController(mockService).BuyMerch)
+
+			if tt.expectedStatus == http.StatusOK {
+				mockService.On("PurchaseMerch", mock.Anything, 1, "pen", tt.quantity, "").Return(&models.Purchase{ID: 7}, nil).Once()
+			}
+
+			req, _ := http.NewRequest("POST", "/merch/buy/pen"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func newRefundRouter(controller PurchaseController, role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Set("claims", &myjwt.Claims{UserID: 1, Role: role})
+		c.Next()
+	})
+	router.POST("/purchases/:id/refund", controller.RefundPurchase)
+	return router
+}
+
+func TestPurchaseController_RefundPurchase_Success(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newRefundRouter(NewPurchaseController(mockService), models.RoleEmployee)
+
+	refundedAt := time.Now().UTC()
+	refundedBy := 1
+	purchase := &models.Purchase{ID: 7, UserID: 1, MerchID: 3, Quantity: 2, UnitPrice: 20, RefundedAt: &refundedAt, RefundedBy: &refundedBy}
+	mockService.On("RefundPurchase", mock.Anything, 1, models.RoleEmployee, 7).Return(purchase, nil).Once()
+
+	req, _ := http.NewRequest("POST", "/purchases/7/refund", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.PurchaseDTO
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 7, resp.ID)
+	assert.Equal(t, 40, resp.TotalPrice)
+	assert.NotNil(t, resp.RefundedAt)
+}
+
+func TestPurchaseController_RefundPurchase_InvalidID(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newRefundRouter(NewPurchaseController(mockService), models.RoleEmployee)
+
+	req, _ := http.NewRequest("POST", "/purchases/abc/refund", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNotCalled(t, "RefundPurchase", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPurchaseController_RefundPurchase_ServiceError(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newRefundRouter(NewPurchaseController(mockService), models.RoleAdmin)
+
+	mockService.On("RefundPurchase", mock.Anything, 1, models.RoleAdmin, 7).Return(nil, service.ErrAlreadyRefunded).Once()
+
+	req, _ := http.NewRequest("POST", "/purchases/7/refund", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	var resp dto.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, problem.TypePrefix+ErrorCodeAlreadyRefunded, resp.Type)
+	assert.Equal(t, "purchase is already refunded", resp.Detail)
+}
+
+func TestPurchaseController_BuyMerch_IdempotencyKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	controller := NewPurchaseController(mockService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.POST("/merch/buy/:item", controller.BuyMerch)
+
+	mockService.On("PurchaseMerch", mock.Anything, 1, "cup", 1, "buy-1").Return(&models.Purchase{ID: 7}, nil).Once()
+
+	req, _ := http.NewRequest("POST", "/merch/buy/cup", nil)
+	req.Header.Set(IdempotencyKeyHeader, "buy-1")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.PurchaseSuccessResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 7, resp.PurchaseID)
 }
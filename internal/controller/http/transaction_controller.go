@@ -1,6 +1,8 @@
 package http
 
 import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/models/dto"
 	"avito-tech-merch/internal/service"
 	"github.com/gin-gonic/gin"
@@ -18,38 +20,52 @@ func NewTransactionController(service service.Service) TransactionController {
 // SendCoin godoc
 // @Summary Transfer coins between users
 // @Security BearerAuth
-// @Description Allows a user to send coins to another user by specifying the receiver ID and the amount
+// @Description Allows a user to send coins to another user by specifying the receiver username (to_user) or ID (receiver_id) and the amount, optionally with a message (up to 280 characters) and a category
 // @Tags transaction
 // @Accept  json
 // @Produce  json
 // @Param request body dto.TransferRequest true "Transfer request data"
+// @Param Idempotency-Key header string false "Unique key of the request; a retry with the same key returns the original transfer instead of sending coins again"
 // @Success 200 {object} dto.TransferSuccessResponse "Coins transferred successfully"
-// @Failure 400 {object} dto.ErrorResponse400 "Invalid request (missing or invalid data)"
-// @Failure 401 {object} dto.ErrorResponseUnauthorized401 "Unauthorized"
-// @Failure 500 {object} dto.ErrorResponse500 "Internal server error"
+// @Failure 400 {object} dto.Problem "Invalid request (missing or invalid data, unknown receiver, invalid message or category, or invalid idempotency key)"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 404 {object} dto.Problem "Sender no longer exists (user_not_found)"
+// @Failure 409 {object} dto.Problem "Idempotency key was used for a different request or the original request is still in progress (idempotency_conflict, request_in_progress)"
+// @Failure 422 {object} dto.Problem "Transfer to yourself or not enough coins (self_transfer, insufficient_funds)"
+// @Failure 500 {object} dto.Problem "Internal server error"
 // @Router /send-coin [post]
 func (c *transactionController) SendCoin(ctx *gin.Context) {
 	senderID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, dto.ErrorResponseUnauthorized401{Code: 401, Message: "unauthorized"})
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
 		return
 	}
 
-	var request struct {
-		ReceiverID int `json:"receiver_id" binding:"required"`
-		Amount     int `json:"amount" binding:"required"`
+	var request dto.TransferRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		problem.Binding(ctx, err)
+		return
 	}
 
-	if err := ctx.ShouldBindJSON(&request); err != nil {
-		ctx.JSON(http.StatusBadRequest, dto.ErrorResponse400{Code: 400, Message: "invalid request"})
+	// Ровно одно из полей: логин получателя либо его ID для старых клиентов
+	if (request.ToUser == "") == (request.ReceiverID == 0) {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "either to_user or receiver_id must be set")
+		return
+	}
+
+	key, ok := idempotencyKey(ctx)
+	if !ok {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "invalid idempotency key")
 		return
 	}
 
-	err := c.service.TransferCoins(ctx, senderID.(int), request.ReceiverID, request.Amount)
+	recipient := models.TransferRecipient{ID: request.ReceiverID, Username: request.ToUser}
+	memo := models.TransferMemo{Message: request.Message, Category: request.Category}
+	transaction, err := c.service.TransferCoins(ctx, senderID.(int), recipient, request.Amount, memo, key)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, dto.ErrorResponse500{Code: 500, Message: err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, dto.TransferSuccessResponse{Message: "coins transferred successfully"})
+	ctx.JSON(http.StatusOK, dto.TransferSuccessResponse{Message: "coins transferred successfully", TransactionID: transaction.ID})
 }
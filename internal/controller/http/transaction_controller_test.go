@@ -1,16 +1,21 @@
 package http
 
 import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/models/dto"
+	"avito-tech-merch/internal/service"
 	mockServ "avito-tech-merch/internal/service/mock"
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -33,11 +38,11 @@ func TestTransactionController_SendCoin_Unauthorized(t *testing.T) {
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusUnauthorized, rec.Code)
-	var resp dto.ErrorResponseUnauthorized401
+	var resp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, 401, resp.Code)
-	assert.Equal(t, "unauthorized", resp.Message)
+	assert.Equal(t, 401, resp.Status)
+	assert.Equal(t, "unauthorized", resp.Detail)
 }
 
 func TestTransactionController_SendCoin_InvalidRequest(t *testing.T) {
@@ -62,11 +67,12 @@ func TestTransactionController_SendCoin_InvalidRequest(t *testing.T) {
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
-	var resp dto.ErrorResponse400
+	var resp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, 400, resp.Code)
-	assert.Equal(t, "invalid request", resp.Message)
+	assert.Equal(t, 400, resp.Status)
+	assert.Equal(t, "request validation failed", resp.Detail)
+	assert.Equal(t, []dto.FieldError{{Field: "amount", Message: "is required"}}, resp.Errors)
 }
 
 func TestTransactionController_SendCoin_ServiceError(t *testing.T) {
@@ -91,21 +97,22 @@ func TestTransactionController_SendCoin_ServiceError(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/send-coin", bytes.NewBuffer(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	serviceErr := errors.New("transfer failed")
+	serviceErr := errors.New("failed to create transaction: ERROR: deadlock detected (SQLSTATE 40P01)")
 	// Ожидаем, что сервис вернет ошибку при вызове TransferCoins
-	mockService.On("TransferCoins", mock.Anything, 1, 2, 100).Return(serviceErr).Once()
+	mockService.On("TransferCoins", mock.Anything, 1, models.TransferRecipient{ID: 2}, 100, models.TransferMemo{}, "").Return(nil, serviceErr).Once()
 
 	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
-	var resp dto.ErrorResponse500
+	var resp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, 500, resp.Code)
-	assert.Equal(t, serviceErr.Error(), resp.Message)
+	assert.Equal(t, 500, resp.Status)
+	assert.Equal(t, problem.TypePrefix+problem.CodeInternal, resp.Type)
+	assert.NotContains(t, rec.Body.String(), "SQLSTATE")
 
-	mockService.AssertCalled(t, "TransferCoins", mock.Anything, 1, 2, 100)
+	mockService.AssertCalled(t, "TransferCoins", mock.Anything, 1, models.TransferRecipient{ID: 2}, 100, models.TransferMemo{}, "")
 }
 
 func TestTransactionController_SendCoin_Success(t *testing.T) {
@@ -131,7 +138,7 @@ func TestTransactionController_SendCoin_Success(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Ожидаем успешный вызов сервиса
-	mockService.On("TransferCoins", mock.Anything, 1, 2, 100).Return(nil).Once()
+	mockService.On("TransferCoins", mock.Anything, 1, models.TransferRecipient{ID: 2}, 100, models.TransferMemo{}, "").Return(&models.Transaction{ID: 42}, nil).Once()
 
 	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
@@ -141,6 +148,165 @@ func TestTransactionController_SendCoin_Success(t *testing.T) {
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
 	assert.Equal(t, "coins transferred successfully", resp.Message)
+	assert.Equal(t, 42, resp.TransactionID)
 
-	mockService.AssertCalled(t, "TransferCoins", mock.Anything, 1, 2, 100)
+	mockService.AssertCalled(t, "TransferCoins", mock.Anything, 1, models.TransferRecipient{ID: 2}, 100, models.TransferMemo{}, "")
+}
+
+func TestTransactionController_SendCoin_IdempotencyKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	controller := NewTransactionController(mockService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.POST("/send-coin", controller.SendCoin)
+
+	mockService.On("TransferCoins", mock.Anything, 1, models.TransferRecipient{ID: 2}, 100, models.TransferMemo{}, "transfer-1").Return(&models.Transaction{ID: 42}, nil).Once()
+
+	reqBody, _ := json.Marshal(dto.TransferRequest{ReceiverID: 2, Amount: 100})
+	req, _ := http.NewRequest("POST", "/send-coin", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, "transfer-1")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestTransactionController_SendCoin_IdempotencyKeyTooLong(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	controller := NewTransactionController(mockService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.POST("/send-coin", controller.SendCoin)
+
+	reqBody, _ := json.Marshal(dto.TransferRequest{ReceiverID: 2, Amount: 100})
+	req, _ := http.NewRequest("POST", "/send-coin", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, strings.Repeat("k", 256))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var resp dto.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "invalid idempotency key", resp.Detail)
+	mockService.AssertNotCalled(t, "TransferCoins", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func newSendCoinRouter(controller TransactionController) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.POST("/send-coin", controller.SendCoin)
+	return router
+}
+
+func TestTransactionController_SendCoin_ToUser(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newSendCoinRouter(NewTransactionController(mockService))
+
+	mockService.On("TransferCoins", mock.Anything, 1, models.TransferRecipient{Username: "colleague"}, 100, models.TransferMemo{}, "").
+		Return(&models.Transaction{ID: 42}, nil).Once()
+
+	reqBody, _ := json.Marshal(dto.TransferRequest{ToUser: "colleague", Amount: 100})
+	req, _ := http.NewRequest("POST", "/send-coin", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestTransactionController_SendCoin_RecipientRequired(t *testing.T) {
+	tests := []struct {
+		name    string
+		reqBody string
+	}{
+		{name: "neither", reqBody: `{"amount": 100}`},
+		{name: "both", reqBody: `{"to_user": "colleague", "receiver_id": 2, "amount": 100}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mockServ.NewService(t)
+			router := newSendCoinRouter(NewTransactionController(mockService))
+
+			req, _ := http.NewRequest("POST", "/send-coin", bytes.NewBufferString(tt.reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var resp dto.Problem
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, "either to_user or receiver_id must be set", resp.Detail)
+		})
+	}
+}
+
+func TestTransactionController_SendCoin_UnknownReceiver(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newSendCoinRouter(NewTransactionController(mockService))
+
+	mockService.On("TransferCoins", mock.Anything, 1, models.TransferRecipient{Username: "ghost"}, 100, models.TransferMemo{}, "").
+		Return(nil, fmt.Errorf("%w: ghost", service.ErrReceiverNotFound)).Once()
+
+	reqBody, _ := json.Marshal(dto.TransferRequest{ToUser: "ghost", Amount: 100})
+	req, _ := http.NewRequest("POST", "/send-coin", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var resp dto.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "receiver not found: ghost", resp.Detail)
+}
+
+func TestTransactionController_SendCoin_WithMemo(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newSendCoinRouter(NewTransactionController(mockService))
+
+	memo := models.TransferMemo{Message: "Thanks for the review", Category: models.TransferCategoryHelpedMe}
+	mockService.On("TransferCoins", mock.Anything, 1, models.TransferRecipient{Username: "colleague"}, 100, memo, "").
+		Return(&models.Transaction{ID: 42}, nil).Once()
+
+	reqBody, _ := json.Marshal(dto.TransferRequest{ToUser: "colleague", Amount: 100, Message: memo.Message, Category: memo.Category})
+	req, _ := http.NewRequest("POST", "/send-coin", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestTransactionController_SendCoin_InvalidMemo(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newSendCoinRouter(NewTransactionController(mockService))
+
+	memo := models.TransferMemo{Category: "bribe"}
+	mockService.On("TransferCoins", mock.Anything, 1, models.TransferRecipient{ID: 2}, 100, memo, "").
+		Return(nil, fmt.Errorf("%w: unknown category %q", service.ErrInvalidTransferMemo, "bribe")).Once()
+
+	reqBody, _ := json.Marshal(dto.TransferRequest{ReceiverID: 2, Amount: 100, Category: "bribe"})
+	req, _ := http.NewRequest("POST", "/send-coin", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var resp dto.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, `invalid transfer memo: unknown category "bribe"`, resp.Detail)
 }
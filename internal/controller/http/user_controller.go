@@ -1,6 +1,8 @@
 package http
 
 import (
+	"avito-tech-merch/internal/controller/http/problem"
+	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/models/dto"
 	"avito-tech-merch/internal/service"
 	"github.com/gin-gonic/gin"
@@ -18,24 +20,33 @@ func NewUserController(service service.Service) UserController {
 // GetInfo godoc
 // @Summary Get user information
 // @Security BearerAuth
-// @Description Fetches user information based on the userID from the context
+// @Description Fetches user information based on the userID from the context: balance, owned merch, the 100 latest purchases with their status and the 100 latest received and sent transfers. The coin history can be filtered by transfer category
 // @Tags user
 // @Accept  json
 // @Produce  json
+// @Param category query string false "Only transfers with this category" Enums(helped_me, great_talk, teamwork, mentoring, thank_you)
 // @Success 200 {object} dto.UserInfoResponse "User information"
-// @Failure 401 {object} dto.ErrorResponseUnauthorized401 "Unauthorized"
-// @Failure 500 {object} dto.ErrorResponse500 "Internal server error"
+// @Failure 400 {object} dto.Problem "Unknown transfer category"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 404 {object} dto.Problem "User no longer exists (user_not_found)"
+// @Failure 500 {object} dto.Problem "Internal server error"
 // @Router /info [get]
 func (c *userController) GetInfo(ctx *gin.Context) {
 	userID, exists := ctx.Get("userID")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, dto.ErrorResponseUnauthorized401{Code: 401, Message: "unauthorized"})
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
 		return
 	}
 
-	info, err := c.service.GetInfo(ctx, userID.(int))
+	filter := models.TransactionFilter{Category: ctx.Query("category")}
+	if filter.Category != "" && !models.IsValidTransferCategory(filter.Category) {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "unknown transfer category")
+		return
+	}
+
+	info, err := c.service.GetInfo(ctx, userID.(int), filter)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, dto.ErrorResponse500{Code: 500, Message: err.Error()})
+		respondError(ctx, err)
 		return
 	}
 
@@ -43,3 +54,96 @@ func (c *userController) GetInfo(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, infoDTO)
 }
+
+// GetTransactionHistory godoc
+// @Summary Get coin transfer history
+// @Security BearerAuth
+// @Description Returns the user's coin transfers from newest to oldest, one page at a time. Pass next_cursor from the response as cursor to get the next page
+// @Tags user
+// @Produce  json
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor from next_cursor of the previous page"
+// @Param from query string false "Only transfers made at or after this time (RFC 3339)"
+// @Param to query string false "Only transfers made before this time (RFC 3339)"
+// @Param direction query string false "Only sent or only received transfers" Enums(sent, received)
+// @Param counterparty query string false "Only transfers with this user"
+// @Param category query string false "Only transfers with this category" Enums(helped_me, great_talk, teamwork, mentoring, thank_you)
+// @Success 200 {object} dto.TransactionHistoryResponse "Page of coin transfers"
+// @Failure 400 {object} dto.Problem "Invalid filter, limit or cursor"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /history/transactions [get]
+func (c *userController) GetTransactionHistory(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	page, from, to, err := historyQuery(ctx)
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	filter := models.TransactionFilter{
+		Category:     ctx.Query("category"),
+		Direction:    ctx.Query("direction"),
+		Counterparty: ctx.Query("counterparty"),
+		From:         from,
+		To:           to,
+	}
+	if filter.Direction != "" && !models.IsValidDirection(filter.Direction) {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "unknown direction")
+		return
+	}
+	if filter.Category != "" && !models.IsValidTransferCategory(filter.Category) {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, "unknown transfer category")
+		return
+	}
+
+	history, err := c.service.GetTransactionHistory(ctx, userID.(int), filter, page)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapTransferPageToDTO(history))
+}
+
+// GetPurchaseHistory godoc
+// @Summary Get purchase history
+// @Security BearerAuth
+// @Description Returns the user's purchases from newest to oldest, one page at a time. Pass next_cursor from the response as cursor to get the next page
+// @Tags user
+// @Produce  json
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor from next_cursor of the previous page"
+// @Param from query string false "Only purchases made at or after this time (RFC 3339)"
+// @Param to query string false "Only purchases made before this time (RFC 3339)"
+// @Success 200 {object} dto.PurchaseHistoryResponse "Page of purchases"
+// @Failure 400 {object} dto.Problem "Invalid date range, limit or cursor"
+// @Failure 401 {object} dto.Problem "Unauthorized"
+// @Failure 500 {object} dto.Problem "Internal server error"
+// @Router /history/purchases [get]
+func (c *userController) GetPurchaseHistory(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		problem.Write(ctx, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+		return
+	}
+
+	page, from, to, err := historyQuery(ctx)
+	if err != nil {
+		problem.Write(ctx, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	history, err := c.service.GetPurchaseHistory(ctx, userID.(int), models.PurchaseFilter{From: from, To: to}, page)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.MapPurchasePageToDTO(history))
+}
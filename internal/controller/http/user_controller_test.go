@@ -1,17 +1,21 @@
 package http
 
 import (
+	"avito-tech-merch/internal/controller/http/problem"
 	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/models/dto"
+	"avito-tech-merch/internal/service"
 	mockServ "avito-tech-merch/internal/service/mock"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestUserController_GetInfo_Unauthorized(t *testing.T) {
@@ -27,11 +31,11 @@ func TestUserController_GetInfo_Unauthorized(t *testing.T) {
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusUnauthorized, rec.Code)
-	var resp dto.ErrorResponseUnauthorized401
+	var resp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, 401, resp.Code)
-	assert.Equal(t, "unauthorized", resp.Message)
+	assert.Equal(t, 401, resp.Status)
+	assert.Equal(t, "unauthorized", resp.Detail)
 }
 
 func TestUserController_GetInfo_ServiceError(t *testing.T) {
@@ -48,20 +52,44 @@ func TestUserController_GetInfo_ServiceError(t *testing.T) {
 	router.GET("/info", controller.GetInfo)
 
 	expectedErr := errors.New("service error")
-	mockService.On("GetInfo", mock.Anything, 1).Return(nil, expectedErr).Once()
+	mockService.On("GetInfo", mock.Anything, 1, models.TransactionFilter{}).Return(nil, expectedErr).Once()
 
 	req, _ := http.NewRequest("GET", "/info", nil)
 	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
-	var resp dto.ErrorResponse500
+	var resp dto.Problem
 	err := json.Unmarshal(rec.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, 500, resp.Code)
-	assert.Equal(t, expectedErr.Error(), resp.Message)
+	assert.Equal(t, 500, resp.Status)
+	assert.Equal(t, problem.TypePrefix+problem.CodeInternal, resp.Type)
+	assert.Equal(t, "internal server error", resp.Detail)
 
-	mockService.AssertCalled(t, "GetInfo", mock.Anything, 1)
+	mockService.AssertCalled(t, "GetInfo", mock.Anything, 1, models.TransactionFilter{})
+}
+
+func TestUserController_GetInfo_UserNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	controller := NewUserController(mockService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.GET("/info", controller.GetInfo)
+
+	mockService.On("GetInfo", mock.Anything, 1, models.TransactionFilter{}).Return(nil, fmt.Errorf("%w: id 1", service.ErrUserNotFound)).Once()
+
+	req, _ := http.NewRequest("GET", "/info", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	var resp dto.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, problem.TypePrefix+ErrorCodeUserNotFound, resp.Type)
 }
 
 func TestUserController_GetInfo_Success(t *testing.T) {
@@ -79,15 +107,18 @@ func TestUserController_GetInfo_Success(t *testing.T) {
 
 	// Создаем тестовый объект userInfo
 	userInfo := &models.UserInfo{
-		UserID:       1,
-		Username:     "testuser",
-		Balance:      1000,
-		Purchases:    []*models.Purchase{},
-		Transactions: []*models.Transaction{},
+		UserID:    1,
+		Username:  "testuser",
+		Balance:   1000,
+		Inventory: []*models.InventoryItem{{MerchName: "cup", Quantity: 2}},
+		CoinHistory: &models.CoinHistory{
+			Received: []*models.CoinTransfer{{TransactionID: 1, Counterparty: "alice", Amount: 100}},
+			Sent:     []*models.CoinTransfer{},
+		},
 	}
 	expectedDTO := dto.MapUserInfoResponseToDTO(userInfo)
 
-	mockService.On("GetInfo", mock.Anything, 1).Return(userInfo, nil).Once()
+	mockService.On("GetInfo", mock.Anything, 1, models.TransactionFilter{}).Return(userInfo, nil).Once()
 
 	req, _ := http.NewRequest("GET", "/info", nil)
 	rec := httptest.NewRecorder()
@@ -99,5 +130,159 @@ func TestUserController_GetInfo_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedDTO, &resp)
 
-	mockService.AssertCalled(t, "GetInfo", mock.Anything, 1)
+	mockService.AssertCalled(t, "GetInfo", mock.Anything, 1, models.TransactionFilter{})
+}
+
+func TestUserController_GetInfo_CategoryFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	controller := NewUserController(mockService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.GET("/info", controller.GetInfo)
+
+	filter := models.TransactionFilter{Category: models.TransferCategoryGreatTalk}
+	mockService.On("GetInfo", mock.Anything, 1, filter).Return(&models.UserInfo{UserID: 1}, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/info?category=great_talk", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestUserController_GetInfo_UnknownCategory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := mockServ.NewService(t)
+	controller := NewUserController(mockService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.GET("/info", controller.GetInfo)
+
+	req, _ := http.NewRequest("GET", "/info?category=bribe", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var resp dto.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "unknown transfer category", resp.Detail)
+	mockService.AssertNotCalled(t, "GetInfo", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func newHistoryRouter(controller UserController) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", 1)
+		c.Next()
+	})
+	router.GET("/history/transactions", controller.GetTransactionHistory)
+	router.GET("/history/purchases", controller.GetPurchaseHistory)
+	return router
+}
+
+func TestUserController_GetTransactionHistory_Success(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newHistoryRouter(NewUserController(mockService))
+
+	cursor := &models.HistoryCursor{CreatedAt: time.Date(2025, 2, 16, 12, 0, 0, 0, time.UTC), ID: 12}
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC).Local()
+	filter := models.TransactionFilter{
+		Category:     models.TransferCategoryHelpedMe,
+		Direction:    models.DirectionReceived,
+		Counterparty: "bob",
+		From:         &from,
+	}
+	nextCursor := &models.HistoryCursor{CreatedAt: time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC), ID: 5}
+	mockService.On("GetTransactionHistory", mock.Anything, 1, filter, models.HistoryPage{Limit: 2, After: cursor}).
+		Return(&models.TransferPage{
+			Transfers:  []*models.CoinTransfer{{TransactionID: 5, Direction: models.DirectionReceived, Counterparty: "bob", Amount: 10}},
+			NextCursor: nextCursor,
+		}, nil).Once()
+
+	url := "/history/transactions?limit=2&direction=received&counterparty=bob&category=helped_me" +
+		"&from=2025-02-01T00:00:00Z&cursor=" + dto.EncodeHistoryCursor(cursor)
+	req, _ := http.NewRequest("GET", url, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.TransactionHistoryResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Transactions, 1)
+	assert.Equal(t, "bob", resp.Transactions[0].Counterparty)
+	assert.Equal(t, dto.EncodeHistoryCursor(nextCursor), resp.NextCursor)
+}
+
+func TestUserController_GetTransactionHistory_InvalidQuery(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		expectedMessage string
+	}{
+		{name: "limit is not a number", query: "limit=ten", expectedMessage: "invalid limit"},
+		{name: "limit too large", query: "limit=101", expectedMessage: "invalid limit"},
+		{name: "broken cursor", query: "cursor=not-a-cursor!", expectedMessage: "invalid cursor"},
+		{name: "bad date", query: "from=yesterday", expectedMessage: "invalid from date"},
+		{name: "empty range", query: "from=2025-02-02T00:00:00Z&to=2025-02-01T00:00:00Z", expectedMessage: "invalid date range"},
+		{name: "unknown direction", query: "direction=sideways", expectedMessage: "unknown direction"},
+		{name: "unknown category", query: "category=bribe", expectedMessage: "unknown transfer category"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mockServ.NewService(t)
+			router := newHistoryRouter(NewUserController(mockService))
+
+			req, _ := http.NewRequest("GET", "/history/transactions?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var resp dto.Problem
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedMessage, resp.Detail)
+		})
+	}
+}
+
+func TestUserController_GetPurchaseHistory_Success(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newHistoryRouter(NewUserController(mockService))
+
+	to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC).Local()
+	mockService.On("GetPurchaseHistory", mock.Anything, 1, models.PurchaseFilter{To: &to}, models.HistoryPage{}).
+		Return(&models.PurchasePage{Purchases: []*models.Purchase{{ID: 7, Quantity: 1, UnitPrice: 20}}}, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/history/purchases?to=2025-03-01T00:00:00Z", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.PurchaseHistoryResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Purchases, 1)
+	assert.Equal(t, 7, resp.Purchases[0].ID)
+	assert.Empty(t, resp.NextCursor)
+}
+
+func TestUserController_GetPurchaseHistory_ServiceError(t *testing.T) {
+	mockService := mockServ.NewService(t)
+	router := newHistoryRouter(NewUserController(mockService))
+
+	mockService.On("GetPurchaseHistory", mock.Anything, 1, models.PurchaseFilter{}, models.HistoryPage{}).
+		Return(nil, errors.New("db is down")).Once()
+
+	req, _ := http.NewRequest("GET", "/history/purchases", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 }
@@ -1,6 +1,9 @@
 package metrics
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sync/atomic"
+)
 
 var (
 	// RegistrationsTotal - счетчик количества регистраций
@@ -34,12 +37,36 @@ var (
 			Help: "Total number of merch purchases.",
 		},
 	)
+
+	// MerchStockLevel - gauge остатка товаров с ограниченным количеством
+	MerchStockLevel = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "merch_stock_level",
+			Help: "Remaining stock of merch items with limited quantity.",
+		},
+		[]string{"merch"},
+	)
+
+	// MerchLowStock - gauge-флаг (1/0) того, что остаток товара не выше порога
+	MerchLowStock = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "merch_low_stock",
+			Help: "Whether the merch item stock is at or below the low-stock threshold (1) or not (0).",
+		},
+		[]string{"merch"},
+	)
+
+	lowStockThreshold atomic.Int64
 )
 
 func init() {
-	prometheus.MustRegister(RegistrationsTotal, LoginsTotal, CoinTransfersTotal, MerchPurchasesTotal)
+	prometheus.MustRegister(RegistrationsTotal, LoginsTotal, CoinTransfersTotal, MerchPurchasesTotal,
+		MerchStockLevel, MerchLowStock)
+	lowStockThreshold.Store(defaultLowStockThreshold)
 }
 
+const defaultLowStockThreshold = 5
+
 func RecordRegistration() {
 	RegistrationsTotal.Inc()
 }
@@ -55,3 +82,24 @@ func RecordCoinTransfer() {
 func RecordMerchPurchase() {
 	MerchPurchasesTotal.Inc()
 }
+
+// SetLowStockThreshold задаёт порог, при котором остаток товара считается низким
+func SetLowStockThreshold(threshold int) {
+	lowStockThreshold.Store(int64(threshold))
+}
+
+func RecordMerchStock(merchName string, stock int) {
+	MerchStockLevel.WithLabelValues(merchName).Set(float64(stock))
+
+	lowStock := 0.0
+	if int64(stock) <= lowStockThreshold.Load() {
+		lowStock = 1
+	}
+	MerchLowStock.WithLabelValues(merchName).Set(lowStock)
+}
+
+// ClearMerchStock убирает серии товара, для которого остаток больше не ведётся
+func ClearMerchStock(merchName string) {
+	MerchStockLevel.DeleteLabelValues(merchName)
+	MerchLowStock.DeleteLabelValues(merchName)
+}
@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRecordMerchStock(t *testing.T) {
+	SetLowStockThreshold(3)
+	defer SetLowStockThreshold(defaultLowStockThreshold)
+
+	RecordMerchStock("test-hoody", 10)
+	assert.Equal(t, 10.0, testutil.ToFloat64(MerchStockLevel.WithLabelValues("test-hoody")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(MerchLowStock.WithLabelValues("test-hoody")))
+
+	RecordMerchStock("test-hoody", 3)
+	assert.Equal(t, 3.0, testutil.ToFloat64(MerchStockLevel.WithLabelValues("test-hoody")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(MerchLowStock.WithLabelValues("test-hoody")))
+
+	ClearMerchStock("test-hoody")
+	assert.Equal(t, 0, testutil.CollectAndCount(MerchStockLevel, "merch_stock_level"))
+	assert.Equal(t, 0, testutil.CollectAndCount(MerchLowStock, "merch_low_stock"))
+}
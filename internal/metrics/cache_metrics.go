@@ -0,0 +1,48 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// CacheHitsTotal - счетчик ответов, отданных из кеша
+	CacheHitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_hits_total",
+			Help: "Total number of cache hits.",
+		},
+		[]string{"cache"},
+	)
+
+	// CacheMissesTotal - счетчик обращений, которые ушли в базу данных, потому что в кеше ничего не было
+	CacheMissesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_misses_total",
+			Help: "Total number of cache misses.",
+		},
+		[]string{"cache"},
+	)
+
+	// CacheErrorsTotal - счетчик ошибок кеша; при ошибке чтения запрос обслуживается из базы данных
+	CacheErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_errors_total",
+			Help: "Total number of cache errors.",
+		},
+		[]string{"cache", "operation"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(CacheHitsTotal, CacheMissesTotal, CacheErrorsTotal)
+}
+
+func RecordCacheHit(cache string) {
+	CacheHitsTotal.WithLabelValues(cache).Inc()
+}
+
+func RecordCacheMiss(cache string) {
+	CacheMissesTotal.WithLabelValues(cache).Inc()
+}
+
+func RecordCacheError(cache, operation string) {
+	CacheErrorsTotal.WithLabelValues(cache, operation).Inc()
+}
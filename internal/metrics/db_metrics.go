@@ -29,10 +29,63 @@ var (
 		},
 		[]string{"query"},
 	)
+
+	// DBPoolConnections - gauge соединений каждого пула (основная база и реплики) по состоянию
+	DBPoolConnections = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "db_pool_connections",
+			Help: "Number of database connections per pool and state.",
+		},
+		[]string{"pool", "state"},
+	)
+
+	// DBReadsTotal - счетчик чтений, направленных в пул: показывает, какая доля чтений ушла на реплики
+	DBReadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_reads_total",
+			Help: "Total number of routed reads per pool.",
+		},
+		[]string{"pool"},
+	)
+
+	// DBReplicaLag - отставание реплики по последней проверке
+	DBReplicaLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "db_replica_lag_seconds",
+			Help: "Replication lag of a read replica.",
+		},
+		[]string{"pool"},
+	)
+
+	// DBReplicaHealthy - 1, если реплика получает чтения, 0 - если исключена из-за ошибки или отставания
+	DBReplicaHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "db_replica_healthy",
+			Help: "Whether a read replica receives reads.",
+		},
+		[]string{"pool"},
+	)
+
+	// DBPrimaryUp - 1, если последняя проверка основной базы прошла успешно
+	DBPrimaryUp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "db_primary_up",
+			Help: "Whether the PostgreSQL primary is reachable and accepts writes.",
+		},
+	)
+
+	// DBPrimaryFailoversTotal - счетчик смен сервера, к которому подключён пул основной базы
+	DBPrimaryFailoversTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "db_primary_failovers_total",
+			Help: "Total number of detected PostgreSQL primary changes.",
+		},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(DBQueryDuration, DBActiveConnections, DBErrorsTotal)
+	prometheus.MustRegister(DBQueryDuration, DBActiveConnections, DBErrorsTotal, DBPoolConnections, DBReadsTotal, DBReplicaLag, DBReplicaHealthy,
+		DBPrimaryUp, DBPrimaryFailoversTotal)
 }
 
 func RecordDBQueryDuration(query string, duration float64) {
@@ -46,3 +99,37 @@ func RecordDBActiveConnections(count int) {
 func RecordDBError(query string) {
 	DBErrorsTotal.WithLabelValues(query).Inc()
 }
+
+func RecordDBPoolConnections(pool string, total, idle, acquired int) {
+	DBPoolConnections.WithLabelValues(pool, "total").Set(float64(total))
+	DBPoolConnections.WithLabelValues(pool, "idle").Set(float64(idle))
+	DBPoolConnections.WithLabelValues(pool, "acquired").Set(float64(acquired))
+}
+
+func RecordDBRead(pool string) {
+	DBReadsTotal.WithLabelValues(pool).Inc()
+}
+
+func RecordDBReplicaLag(pool string, lag float64) {
+	DBReplicaLag.WithLabelValues(pool).Set(lag)
+}
+
+func RecordDBReplicaHealthy(pool string, healthy bool) {
+	if healthy {
+		DBReplicaHealthy.WithLabelValues(pool).Set(1)
+	} else {
+		DBReplicaHealthy.WithLabelValues(pool).Set(0)
+	}
+}
+
+func RecordDBPrimaryUp(up bool) {
+	if up {
+		DBPrimaryUp.Set(1)
+	} else {
+		DBPrimaryUp.Set(0)
+	}
+}
+
+func RecordDBPrimaryFailover() {
+	DBPrimaryFailoversTotal.Inc()
+}
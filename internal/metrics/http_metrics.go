@@ -1,6 +1,9 @@
 package metrics
 
 import (
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -42,8 +45,13 @@ func init() {
 	prometheus.MustRegister(HTTPRequestsTotal, HTTPRequestDuration, HTTPErrorsTotal)
 }
 
+// MetricsHandler отдаёт метрики; в формате OpenMetrics вместе с exemplar'ами, по которым
+// можно перейти от выброса латентности к запросу в логах
 func MetricsHandler() http.Handler {
-	return promhttp.Handler()
+	return promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)
 }
 
 func GinPrometheusMiddleware() gin.HandlerFunc {
@@ -59,9 +67,20 @@ func GinPrometheusMiddleware() gin.HandlerFunc {
 		}
 
 		HTTPRequestsTotal.WithLabelValues(c.Request.Method, endpoint, http.StatusText(status)).Inc()
-		HTTPRequestDuration.WithLabelValues(c.Request.Method, endpoint).Observe(duration)
+		observeWithRequestID(c.Request.Context(), HTTPRequestDuration.WithLabelValues(c.Request.Method, endpoint), duration)
 		if status >= 400 {
 			HTTPErrorsTotal.WithLabelValues(c.Request.Method, endpoint, http.StatusText(status)).Inc()
 		}
 	}
 }
+
+// observeWithRequestID записывает значение с exemplar'ом request_id, если идентификатор запроса есть в контексте
+func observeWithRequestID(ctx context.Context, observer prometheus.Observer, value float64) {
+	id, ok := logger.Value(ctx, logger.RequestIDKey)
+	exemplarObserver, canExemplar := observer.(prometheus.ExemplarObserver)
+	if !ok || !canExemplar {
+		observer.Observe(value)
+		return
+	}
+	exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{logger.RequestIDKey: fmt.Sprint(id)})
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// CartItem - позиция корзины. Merch заполняется актуальными данными каталога при чтении корзины
+type CartItem struct {
+	UserID   int       `json:"user_id"`
+	MerchID  int       `json:"merch_id"`
+	Quantity int       `json:"quantity"`
+	AddedAt  time.Time `json:"added_at"`
+	Merch    *Merch    `json:"merch,omitempty"`
+}
+
+type Cart struct {
+	UserID int         `json:"user_id"`
+	Items  []*CartItem `json:"items"`
+}
+
+// Total возвращает стоимость корзины по текущим ценам каталога
+func (c *Cart) Total() int {
+	total := 0
+	for _, item := range c.Items {
+		if item.Merch != nil {
+			total += item.Merch.Price * item.Quantity
+		}
+	}
+	return total
+}
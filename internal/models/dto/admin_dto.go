@@ -0,0 +1,64 @@
+package dto
+
+import "avito-tech-merch/internal/models"
+
+// SetUserRoleRequest Role change request
+// @Description New role of the user: employee or admin
+type SetUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=employee admin" example:"admin"`
+}
+
+// UserRoleResponse User role
+// @Description User with the role assigned to them
+type UserRoleResponse struct {
+	UserID   int    `json:"user_id" example:"1"`
+	Username string `json:"username" example:"epchamp001"`
+	Role     string `json:"role" example:"admin"`
+}
+
+// MapUserToRoleDTO Maps User model to UserRoleResponse
+func MapUserToRoleDTO(user *models.User) *UserRoleResponse {
+	return &UserRoleResponse{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+	}
+}
+
+// LedgerReportResponse Ledger verification result
+// @Description Reconciliation of the double-entry ledger with user balances. The ledger is consistent when total is zero and there are no unbalanced entries or mismatches
+type LedgerReportResponse struct {
+	Consistent        bool                 `json:"consistent" example:"true"`
+	Total             int                  `json:"total" example:"0"`
+	Issued            int                  `json:"issued" example:"3000"`
+	UnbalancedEntries int                  `json:"unbalanced_entries" example:"0"`
+	Mismatches        []BalanceMismatchDTO `json:"mismatches"`
+}
+
+// BalanceMismatchDTO Balance mismatch
+// @Description User whose stored balance differs from the sum of their ledger postings
+type BalanceMismatchDTO struct {
+	UserID        int `json:"user_id" example:"1"`
+	Balance       int `json:"balance" example:"900"`
+	LedgerBalance int `json:"ledger_balance" example:"1000"`
+}
+
+// MapLedgerReportToDTO Maps LedgerReport model to LedgerReportResponse
+func MapLedgerReportToDTO(report *models.LedgerReport) *LedgerReportResponse {
+	mismatches := make([]BalanceMismatchDTO, len(report.Mismatches))
+	for i, mismatch := range report.Mismatches {
+		mismatches[i] = BalanceMismatchDTO{
+			UserID:        mismatch.UserID,
+			Balance:       mismatch.Balance,
+			LedgerBalance: mismatch.LedgerBalance,
+		}
+	}
+
+	return &LedgerReportResponse{
+		Consistent:        report.IsConsistent(),
+		Total:             report.Total,
+		Issued:            report.Issued,
+		UnbalancedEntries: report.UnbalancedEntries,
+		Mismatches:        mismatches,
+	}
+}
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"avito-tech-merch/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMapUserToRoleDTO(t *testing.T) {
+	user := &models.User{
+		ID:       3,
+		Username: "hr_manager",
+		Balance:  1000,
+		Role:     models.RoleAdmin,
+	}
+
+	dto := MapUserToRoleDTO(user)
+
+	assert.Equal(t, user.ID, dto.UserID)
+	assert.Equal(t, user.Username, dto.Username)
+	assert.Equal(t, user.Role, dto.Role)
+}
+
+func TestMapLedgerReportToDTO(t *testing.T) {
+	consistent := MapLedgerReportToDTO(&models.LedgerReport{Issued: 2000, Mismatches: []*models.BalanceMismatch{}})
+	assert.True(t, consistent.Consistent)
+	assert.Equal(t, 2000, consistent.Issued)
+	assert.NotNil(t, consistent.Mismatches)
+	assert.Empty(t, consistent.Mismatches)
+
+	broken := MapLedgerReportToDTO(&models.LedgerReport{
+		Issued:     2000,
+		Mismatches: []*models.BalanceMismatch{{UserID: 4, Balance: 900, LedgerBalance: 1000}},
+	})
+	assert.False(t, broken.Consistent)
+	assert.Equal(t, []BalanceMismatchDTO{{UserID: 4, Balance: 900, LedgerBalance: 1000}}, broken.Mismatches)
+}
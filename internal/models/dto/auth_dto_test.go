@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"avito-tech-merch/internal/models"
+	myjwt "avito-tech-merch/pkg/jwt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMapTokenPairToDTO(t *testing.T) {
+	tokens := &models.TokenPair{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresIn:    900,
+	}
+
+	dto := MapTokenPairToDTO(tokens)
+
+	assert.Equal(t, tokens.AccessToken, dto.Token)
+	assert.Equal(t, tokens.RefreshToken, dto.RefreshToken)
+	assert.Equal(t, tokens.ExpiresIn, dto.ExpiresIn)
+}
+
+func TestMapJWKSToDTO(t *testing.T) {
+	jwks := myjwt.JWKS{Keys: []myjwt.JSONWebKey{
+		{KeyType: "OKP", KeyID: "ed", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: "x-value"},
+		{KeyType: "RSA", KeyID: "rsa", Use: "sig", Algorithm: "RS256", N: "n-value", E: "AQAB"},
+	}}
+
+	dto := MapJWKSToDTO(jwks)
+
+	assert.Len(t, dto.Keys, 2)
+	assert.Equal(t, JSONWebKey{KeyType: "OKP", KeyID: "ed", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: "x-value"}, dto.Keys[0])
+	assert.Equal(t, JSONWebKey{KeyType: "RSA", KeyID: "rsa", Use: "sig", Algorithm: "RS256", N: "n-value", E: "AQAB"}, dto.Keys[1])
+}
+
+func TestMapJWKSToDTO_Empty(t *testing.T) {
+	dto := MapJWKSToDTO(myjwt.JWKS{})
+
+	assert.NotNil(t, dto.Keys)
+	assert.Empty(t, dto.Keys)
+}
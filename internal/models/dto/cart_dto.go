@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"avito-tech-merch/internal/models"
+)
+
+// CartItemRequest DTO for adding merch to the cart
+// @Description Merch item name and the number of units to add
+type CartItemRequest struct {
+	Item     string `json:"item" binding:"required" example:"pen"`
+	Quantity int    `json:"quantity" binding:"required,gt=0,lte=100" example:"5"`
+}
+
+// CartItemDTO DTO for a cart line
+// @Description Cart line priced at the current catalog price
+type CartItemDTO struct {
+	MerchID   int    `json:"merch_id" example:"3"`
+	Name      string `json:"name" example:"pen"`
+	UnitPrice int    `json:"unit_price" example:"10"`
+	Quantity  int    `json:"quantity" example:"5"`
+	Subtotal  int    `json:"subtotal" example:"50"`
+}
+
+// CartResponse DTO for the cart contents
+// @Description Cart contents and total at current catalog prices
+type CartResponse struct {
+	Items []*CartItemDTO `json:"items"`
+	Total int            `json:"total" example:"50"`
+}
+
+// CheckoutResponse DTO for a successful checkout
+// @Description Purchases created by the checkout and the amount charged
+type CheckoutResponse struct {
+	Purchases []*PurchaseDTO `json:"purchases"`
+	Total     int            `json:"total" example:"50"`
+}
+
+// MapCartToDTO Maps Cart model to CartResponse
+func MapCartToDTO(cart *models.Cart) *CartResponse {
+	items := make([]*CartItemDTO, len(cart.Items))
+	for i, item := range cart.Items {
+		items[i] = &CartItemDTO{
+			MerchID:   item.MerchID,
+			Name:      item.Merch.Name,
+			UnitPrice: item.Merch.Price,
+			Quantity:  item.Quantity,
+			Subtotal:  item.Merch.Price * item.Quantity,
+		}
+	}
+
+	return &CartResponse{
+		Items: items,
+		Total: cart.Total(),
+	}
+}
+
+// MapCheckoutToDTO Maps purchases created by a checkout to CheckoutResponse
+func MapCheckoutToDTO(purchases []*models.Purchase) *CheckoutResponse {
+	purchasesDTO := make([]*PurchaseDTO, len(purchases))
+	total := 0
+	for i, purchase := range purchases {
+		purchasesDTO[i] = MapPurchaseToDTO(purchase)
+		total += purchase.TotalPrice()
+	}
+
+	return &CheckoutResponse{
+		Purchases: purchasesDTO,
+		Total:     total,
+	}
+}
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"avito-tech-merch/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMapCartToDTO(t *testing.T) {
+	cart := &models.Cart{
+		UserID: 1,
+		Items: []*models.CartItem{
+			{UserID: 1, MerchID: 3, Quantity: 5, Merch: &models.Merch{ID: 3, Name: "pen", Price: 10}},
+			{UserID: 1, MerchID: 4, Quantity: 1, Merch: &models.Merch{ID: 4, Name: "cup", Price: 20}},
+		},
+	}
+
+	dto := MapCartToDTO(cart)
+
+	assert.Equal(t, 70, dto.Total)
+	assert.Len(t, dto.Items, 2)
+	assert.Equal(t, &CartItemDTO{MerchID: 3, Name: "pen", UnitPrice: 10, Quantity: 5, Subtotal: 50}, dto.Items[0])
+}
+
+func TestMapCartToDTO_Empty(t *testing.T) {
+	dto := MapCartToDTO(&models.Cart{UserID: 1})
+
+	assert.NotNil(t, dto.Items)
+	assert.Empty(t, dto.Items)
+	assert.Equal(t, 0, dto.Total)
+}
+
+func TestMapCheckoutToDTO(t *testing.T) {
+	purchases := []*models.Purchase{
+		{ID: 1, UserID: 1, MerchID: 3, Quantity: 5, UnitPrice: 10},
+		{ID: 2, UserID: 1, MerchID: 4, Quantity: 1, UnitPrice: 20},
+	}
+
+	dto := MapCheckoutToDTO(purchases)
+
+	assert.Equal(t, 70, dto.Total)
+	assert.Len(t, dto.Purchases, 2)
+	assert.Equal(t, 5, dto.Purchases[0].Quantity)
+}
@@ -1,43 +1,20 @@
 package dto
 
-// ErrorResponse400 Response with an error
-// @Description The standard API error format for 400 Bad Request
-type ErrorResponse400 struct {
-	Code    int    `json:"code" example:"400"`
-	Message string `json:"message" example:"invalid request"`
+// Problem Response with an error
+// @Description The API error format, application/problem+json (RFC 7807). Clients should branch on type, not on detail
+type Problem struct {
+	Type      string       `json:"type" example:"urn:merch-store:problem:insufficient_funds"`
+	Title     string       `json:"title" example:"Unprocessable Entity"`
+	Status    int          `json:"status" example:"422"`
+	Detail    string       `json:"detail,omitempty" example:"insufficient funds"`
+	Instance  string       `json:"instance,omitempty" example:"/api/send-coin"`
+	RequestID string       `json:"request_id,omitempty" example:"3f1c9a52-7a0e-4c1b-9d1e-5b2f0e6c8a41"`
+	Errors    []FieldError `json:"errors,omitempty"`
 }
 
-// ErrorResponse500 Response with an error
-// @Description The standard API error format for 500 Internal Server Error
-type ErrorResponse500 struct {
-	Code    int    `json:"code" example:"500"`
-	Message string `json:"message" example:"server error"`
-}
-
-// ErrorResponseInvalidCredentials401 Response with an error
-// @Description The standard API error format for 401 Unauthorized (invalid credentials)
-type ErrorResponseInvalidCredentials401 struct {
-	Code    int    `json:"code" example:"401"`
-	Message string `json:"message" example:"invalid credentials"`
-}
-
-// ErrorResponseUnauthorized401 Response with an error
-// @Description The standard API error format for 401 Unauthorized (general unauthorized error)
-type ErrorResponseUnauthorized401 struct {
-	Code    int    `json:"code" example:"401"`
-	Message string `json:"message" example:"unauthorized"`
-}
-
-// ErrorJWTMissingToken represents an error response when the JWT token is missing
-// @Description The standard API error format for 401 Unauthorized (missing token)
-type ErrorJWTMissingToken struct {
-	Code    int    `json:"code" example:"401"`
-	Message string `json:"message" example:"missing token"`
-}
-
-// ErrorJWTInvalidToken represents an error response when the JWT token is invalid
-// @Description The standard API error format for 401 Unauthorized (invalid token)
-type ErrorJWTInvalidToken struct {
-	Code    int    `json:"code" example:"401"`
-	Message string `json:"message" example:"invalid token"`
+// FieldError Validation error of a request field
+// @Description A request field that failed validation and the reason
+type FieldError struct {
+	Field   string `json:"field" example:"amount"`
+	Message string `json:"message" example:"is required"`
 }
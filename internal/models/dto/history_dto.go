@@ -0,0 +1,104 @@
+package dto
+
+import (
+	"avito-tech-merch/internal/models"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TransferHistoryItemDTO Coin transfer in the user history
+// @Description Coin transfer as seen by the user: direction and the username of the other side
+type TransferHistoryItemDTO struct {
+	TransactionID int       `json:"transaction_id" example:"12"`
+	Direction     string    `json:"direction" enums:"sent,received" example:"received"`
+	Counterparty  string    `json:"counterparty" example:"colleague"`
+	Amount        int       `json:"amount" example:"100"`
+	Message       string    `json:"message,omitempty" example:"Thanks for the code review!"`
+	Category      string    `json:"category,omitempty" example:"helped_me"`
+	CreatedAt     time.Time `json:"created_at" example:"2025-02-16T14:30:00Z"`
+}
+
+// TransactionHistoryResponse Page of the coin transfer history
+// @Description Coin transfers from newest to oldest; pass next_cursor as cursor to get the next page
+type TransactionHistoryResponse struct {
+	Transactions []*TransferHistoryItemDTO `json:"transactions"`
+	NextCursor   string                    `json:"next_cursor,omitempty" example:"MTc0MDA2NTQwMDAwMDAwMDoxMg"`
+}
+
+// PurchaseHistoryResponse Page of the purchase history
+// @Description Purchases from newest to oldest; pass next_cursor as cursor to get the next page
+type PurchaseHistoryResponse struct {
+	Purchases  []*PurchaseDTO `json:"purchases"`
+	NextCursor string         `json:"next_cursor,omitempty" example:"MTc0MDA2NTQwMDAwMDAwMDo3"`
+}
+
+// MapTransferPageToDTO Maps TransferPage model to TransactionHistoryResponse DTO
+func MapTransferPageToDTO(page *models.TransferPage) *TransactionHistoryResponse {
+	transfers := make([]*TransferHistoryItemDTO, len(page.Transfers))
+	for i, transfer := range page.Transfers {
+		transfers[i] = &TransferHistoryItemDTO{
+			TransactionID: transfer.TransactionID,
+			Direction:     transfer.Direction,
+			Counterparty:  transfer.Counterparty,
+			Amount:        transfer.Amount,
+			Message:       transfer.Message,
+			Category:      transfer.Category,
+			CreatedAt:     transfer.CreatedAt,
+		}
+	}
+
+	return &TransactionHistoryResponse{
+		Transactions: transfers,
+		NextCursor:   EncodeHistoryCursor(page.NextCursor),
+	}
+}
+
+// MapPurchasePageToDTO Maps PurchasePage model to PurchaseHistoryResponse DTO
+func MapPurchasePageToDTO(page *models.PurchasePage) *PurchaseHistoryResponse {
+	purchases := make([]*PurchaseDTO, len(page.Purchases))
+	for i, purchase := range page.Purchases {
+		purchases[i] = MapPurchaseToDTO(purchase)
+	}
+
+	return &PurchaseHistoryResponse{
+		Purchases:  purchases,
+		NextCursor: EncodeHistoryCursor(page.NextCursor),
+	}
+}
+
+// EncodeHistoryCursor кодирует курсор в непрозрачную для клиента строку; nil - пустая строка
+func EncodeHistoryCursor(cursor *models.HistoryCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	raw := fmt.Sprintf("%d:%d", cursor.CreatedAt.UnixMicro(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeHistoryCursor разбирает курсор, выданный EncodeHistoryCursor
+func DecodeHistoryCursor(value string) (*models.HistoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, errors.New("invalid cursor")
+	}
+	micros, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	cursorID, err := strconv.Atoi(id)
+	if err != nil || cursorID <= 0 {
+		return nil, errors.New("invalid cursor")
+	}
+
+	// Время в БД хранится с точностью до микросекунд, поэтому курсор указывает на запись точно
+	return &models.HistoryCursor{CreatedAt: time.UnixMicro(micros).UTC(), ID: cursorID}, nil
+}
@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"avito-tech-merch/internal/models"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHistoryCursor_RoundTrip(t *testing.T) {
+	cursor := &models.HistoryCursor{CreatedAt: time.Date(2025, 2, 16, 14, 30, 0, 123456000, time.UTC), ID: 42}
+
+	decoded, err := DecodeHistoryCursor(EncodeHistoryCursor(cursor))
+
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+}
+
+func TestEncodeHistoryCursor_Nil(t *testing.T) {
+	assert.Empty(t, EncodeHistoryCursor(nil))
+}
+
+func TestDecodeHistoryCursor_Invalid(t *testing.T) {
+	tests := []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("1739716200000000")),
+		base64.RawURLEncoding.EncodeToString([]byte("yesterday:42")),
+		base64.RawURLEncoding.EncodeToString([]byte("1739716200000000:0")),
+		base64.RawURLEncoding.EncodeToString([]byte("1739716200000000:x")),
+	}
+
+	for _, value := range tests {
+		_, err := DecodeHistoryCursor(value)
+		assert.EqualError(t, err, "invalid cursor", value)
+	}
+}
+
+func TestMapTransferPageToDTO(t *testing.T) {
+	now := time.Now()
+	page := &models.TransferPage{
+		Transfers: []*models.CoinTransfer{
+			{TransactionID: 3, Direction: models.DirectionSent, Counterparty: "bob", Amount: 10, Category: models.TransferCategoryTeamwork, CreatedAt: now},
+		},
+		NextCursor: &models.HistoryCursor{CreatedAt: now, ID: 3},
+	}
+
+	dto := MapTransferPageToDTO(page)
+
+	assert.Equal(t, []*TransferHistoryItemDTO{
+		{TransactionID: 3, Direction: models.DirectionSent, Counterparty: "bob", Amount: 10, Category: models.TransferCategoryTeamwork, CreatedAt: now},
+	}, dto.Transactions)
+	assert.Equal(t, EncodeHistoryCursor(page.NextCursor), dto.NextCursor)
+}
+
+func TestMapPurchasePageToDTO_LastPage(t *testing.T) {
+	page := &models.PurchasePage{
+		Purchases: []*models.Purchase{{ID: 7, UserID: 1, MerchID: 2, Quantity: 2, UnitPrice: 10}},
+	}
+
+	dto := MapPurchasePageToDTO(page)
+
+	assert.Len(t, dto.Purchases, 1)
+	assert.Equal(t, 20, dto.Purchases[0].TotalPrice)
+	assert.Empty(t, dto.NextCursor)
+}
@@ -1,29 +1,89 @@
 package dto
 
-import "avito-tech-merch/internal/models"
+import (
+	"avito-tech-merch/internal/models"
+	"time"
+)
 
 // MerchDTO DTO for Merch data in API response
 // @Description DTO representing merch information
 type MerchDTO struct {
-	ID    int    `json:"id" example:"2"`
-	Name  string `json:"name" example:"cup"`
-	Price int    `json:"price" example:"20"`
+	ID         int        `json:"id" example:"2"`
+	Name       string     `json:"name" example:"cup"`
+	Price      int        `json:"price" example:"20"`
+	Stock      *int       `json:"stock,omitempty" example:"15"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty" example:"2025-03-25T12:00:00Z"`
+}
+
+// CreateMerchRequest New catalog item
+// @Description Data for creating a merch item. Omit stock to sell the item without a quantity limit
+type CreateMerchRequest struct {
+	Name  string `json:"name" binding:"required" example:"sticker"`
+	Price int    `json:"price" binding:"required,gt=0" example:"5"`
+	Stock *int   `json:"stock" binding:"omitempty,gte=0" example:"100"`
+}
+
+// MerchRequest Catalog item data
+// @Description Data for updating a merch item
+type MerchRequest struct {
+	Name  string `json:"name" binding:"required" example:"sticker"`
+	Price int    `json:"price" binding:"required,gt=0" example:"5"`
+}
+
+// RestockRequest Restock request
+// @Description Number of units to add to the item stock
+type RestockRequest struct {
+	Quantity int `json:"quantity" binding:"required,gt=0" example:"50"`
+}
+
+// SetStockRequest Stock override request
+// @Description Sets the stock to an exact value, or removes the quantity limit when unlimited is true
+type SetStockRequest struct {
+	Stock     *int `json:"stock" binding:"omitempty,gte=0" example:"20"`
+	Unlimited bool `json:"unlimited" example:"false"`
+}
+
+// MerchArchivedResponse DTO for successful archive response
+// @Description Response indicating that the merch item was withdrawn from sale
+type MerchArchivedResponse struct {
+	Message string `json:"message" example:"merch archived"`
 }
 
 // MapMerchToDTO Maps Merch model to MerchDTO
 func MapMerchToDTO(merch *models.Merch) *MerchDTO {
 	return &MerchDTO{
-		ID:    merch.ID,
-		Name:  merch.Name,
-		Price: merch.Price,
+		ID:         merch.ID,
+		Name:       merch.Name,
+		Price:      merch.Price,
+		Stock:      merch.Stock,
+		ArchivedAt: merch.ArchivedAt,
 	}
 }
 
 // MapMerchDTOToMerch Maps MerchDTO to Merch model
 func MapMerchDTOToMerch(merchDTO *MerchDTO) *models.Merch {
 	return &models.Merch{
-		ID:    merchDTO.ID,
-		Name:  merchDTO.Name,
-		Price: merchDTO.Price,
+		ID:         merchDTO.ID,
+		Name:       merchDTO.Name,
+		Price:      merchDTO.Price,
+		Stock:      merchDTO.Stock,
+		ArchivedAt: merchDTO.ArchivedAt,
+	}
+}
+
+// MerchPriceDTO DTO for a merch price history entry
+// @Description Price of a merch item and the moment it took effect
+type MerchPriceDTO struct {
+	Price     int       `json:"price" example:"25"`
+	ChangedBy *int      `json:"changed_by,omitempty" example:"1"`
+	ValidFrom time.Time `json:"valid_from" example:"2025-03-28T12:00:00Z"`
+}
+
+// MapMerchPriceToDTO Maps MerchPrice model to MerchPriceDTO
+func MapMerchPriceToDTO(price *models.MerchPrice) *MerchPriceDTO {
+	return &MerchPriceDTO{
+		Price:     price.Price,
+		ChangedBy: price.ChangedBy,
+		ValidFrom: price.ValidFrom,
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"avito-tech-merch/internal/models"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestMapMerchToDTO(t *testing.T) {
@@ -33,3 +34,21 @@ func TestMapMerchDTOToMerch(t *testing.T) {
 	assert.Equal(t, dto.Name, merch.Name)
 	assert.Equal(t, dto.Price, merch.Price)
 }
+
+func TestMapMerchToDTO_Archived(t *testing.T) {
+	archivedAt := time.Date(2025, 3, 25, 12, 0, 0, 0, time.UTC)
+	stock := 0
+	merch := &models.Merch{
+		ID:         1,
+		Name:       "Old Merch",
+		Price:      100,
+		Stock:      &stock,
+		ArchivedAt: &archivedAt,
+	}
+
+	dto := MapMerchToDTO(merch)
+	assert.Equal(t, &archivedAt, dto.ArchivedAt)
+	assert.Equal(t, &stock, dto.Stock)
+
+	assert.Equal(t, merch, MapMerchDTOToMerch(dto))
+}
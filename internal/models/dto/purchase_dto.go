@@ -8,25 +8,37 @@ import (
 // PurchaseDTO DTO for Purchase data in API response
 // @Description DTO representing purchase information
 type PurchaseDTO struct {
-	ID        int       `json:"id" example:"1"`
-	UserID    int       `json:"user_id" example:"1"`
-	MerchID   int       `json:"merch_id" example:"3"`
-	CreatedAt time.Time `json:"created_at" example:"2025-02-15T10:00:00"`
+	ID         int       `json:"id" example:"1"`
+	UserID     int       `json:"user_id" example:"1"`
+	MerchID    int       `json:"merch_id" example:"3"`
+	Quantity   int       `json:"quantity" example:"2"`
+	UnitPrice  int       `json:"unit_price" example:"20"`
+	TotalPrice int       `json:"total_price" example:"40"`
+	CreatedAt  time.Time `json:"created_at" example:"2025-02-15T10:00:00"`
+	Status     string    `json:"status" example:"placed"`
+	// RefundedAt заполнен, если по покупке оформлен возврат
+	RefundedAt *time.Time `json:"refunded_at,omitempty" example:"2025-02-15T10:05:00"`
 }
 
 // PurchaseSuccessResponse DTO for successful purchase response
 // @Description Response indicating that the purchase was successful
 type PurchaseSuccessResponse struct {
-	Message string `json:"message" example:"purchase successful"`
+	Message    string `json:"message" example:"purchase successful"`
+	PurchaseID int    `json:"purchase_id" example:"7"`
 }
 
 // MapPurchaseToDTO Maps Purchase model to PurchaseDTO
 func MapPurchaseToDTO(purchase *models.Purchase) *PurchaseDTO {
 	return &PurchaseDTO{
-		ID:        purchase.ID,
-		UserID:    purchase.UserID,
-		MerchID:   purchase.MerchID,
-		CreatedAt: purchase.CreatedAt,
+		ID:         purchase.ID,
+		UserID:     purchase.UserID,
+		MerchID:    purchase.MerchID,
+		Quantity:   purchase.Quantity,
+		UnitPrice:  purchase.UnitPrice,
+		TotalPrice: purchase.TotalPrice(),
+		CreatedAt:  purchase.CreatedAt,
+		Status:     purchase.Status,
+		RefundedAt: purchase.RefundedAt,
 	}
 }
 
@@ -36,6 +48,32 @@ func MapPurchaseDTOToPurchase(purchaseDTO *PurchaseDTO) *models.Purchase {
 		ID:        purchaseDTO.ID,
 		UserID:    purchaseDTO.UserID,
 		MerchID:   purchaseDTO.MerchID,
+		Quantity:  purchaseDTO.Quantity,
+		UnitPrice: purchaseDTO.UnitPrice,
 		CreatedAt: purchaseDTO.CreatedAt,
+		Status:    purchaseDTO.Status,
+	}
+}
+
+// UpdatePurchaseStatusRequest Purchase status change request
+// @Description Next fulfillment status: placed -> ready_for_pickup -> delivered, or cancelled before delivery
+type UpdatePurchaseStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=ready_for_pickup delivered cancelled" example:"ready_for_pickup"`
+}
+
+// PurchaseStatusChangeDTO DTO for a purchase status history entry
+// @Description Fulfillment status of a purchase and the moment it was set
+type PurchaseStatusChangeDTO struct {
+	Status    string    `json:"status" example:"ready_for_pickup"`
+	ChangedBy *int      `json:"changed_by,omitempty" example:"1"`
+	ChangedAt time.Time `json:"changed_at" example:"2025-03-30T12:00:00Z"`
+}
+
+// MapPurchaseStatusChangeToDTO Maps PurchaseStatusChange model to PurchaseStatusChangeDTO
+func MapPurchaseStatusChangeToDTO(change *models.PurchaseStatusChange) *PurchaseStatusChangeDTO {
+	return &PurchaseStatusChangeDTO{
+		Status:    change.Status,
+		ChangedBy: change.ChangedBy,
+		ChangedAt: change.ChangedAt,
 	}
 }
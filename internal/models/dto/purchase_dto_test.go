@@ -13,7 +13,10 @@ func TestMapPurchaseToDTO(t *testing.T) {
 		ID:        1,
 		UserID:    2,
 		MerchID:   3,
+		Quantity:  2,
+		UnitPrice: 20,
 		CreatedAt: now,
+		Status:    models.PurchaseStatusPlaced,
 	}
 
 	dto := MapPurchaseToDTO(purchase)
@@ -21,7 +24,21 @@ func TestMapPurchaseToDTO(t *testing.T) {
 	assert.Equal(t, purchase.ID, dto.ID)
 	assert.Equal(t, purchase.UserID, dto.UserID)
 	assert.Equal(t, purchase.MerchID, dto.MerchID)
+	assert.Equal(t, purchase.Quantity, dto.Quantity)
+	assert.Equal(t, purchase.UnitPrice, dto.UnitPrice)
+	assert.Equal(t, 40, dto.TotalPrice)
 	assert.Equal(t, purchase.CreatedAt, dto.CreatedAt)
+	assert.Equal(t, purchase.Status, dto.Status)
+	assert.Nil(t, dto.RefundedAt)
+}
+
+func TestMapPurchaseToDTO_Refunded(t *testing.T) {
+	refundedAt := time.Now()
+	purchase := &models.Purchase{ID: 1, Quantity: 1, UnitPrice: 20, RefundedAt: &refundedAt}
+
+	dto := MapPurchaseToDTO(purchase)
+
+	assert.Equal(t, &refundedAt, dto.RefundedAt)
 }
 
 func TestMapPurchaseDTOToPurchase(t *testing.T) {
@@ -40,3 +57,14 @@ func TestMapPurchaseDTOToPurchase(t *testing.T) {
 	assert.Equal(t, dto.MerchID, purchase.MerchID)
 	assert.Equal(t, dto.CreatedAt, purchase.CreatedAt)
 }
+
+func TestMapPurchaseStatusChangeToDTO(t *testing.T) {
+	changedBy := 1
+	change := &models.PurchaseStatusChange{ID: 2, PurchaseID: 7, Status: models.PurchaseStatusDelivered, ChangedBy: &changedBy, ChangedAt: time.Now()}
+
+	dto := MapPurchaseStatusChangeToDTO(change)
+
+	assert.Equal(t, change.Status, dto.Status)
+	assert.Equal(t, change.ChangedBy, dto.ChangedBy)
+	assert.Equal(t, change.ChangedAt, dto.ChangedAt)
+}
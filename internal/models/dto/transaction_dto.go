@@ -12,20 +12,26 @@ type TransactionDTO struct {
 	SenderID   int       `json:"sender_id" example:"1"`
 	ReceiverID int       `json:"receiver_id" example:"2"`
 	Amount     int       `json:"amount" example:"200"`
+	Message    string    `json:"message,omitempty" example:"Thanks for the code review!"`
+	Category   string    `json:"category,omitempty" example:"helped_me"`
 	CreatedAt  time.Time `json:"created_at" example:"2025-02-16T14:30:00"`
 }
 
 // TransferRequest DTO for transferring coins
-// @Description Data for transferring coins between users
+// @Description Data for transferring coins between users. The receiver is set either by username (to_user) or by numeric ID (receiver_id); message and category are optional
 type TransferRequest struct {
-	ReceiverID int `json:"receiver_id" binding:"required" example:"2"`
-	Amount     int `json:"amount" binding:"required" example:"100"`
+	ToUser     string `json:"to_user,omitempty" binding:"omitempty,max=255" example:"epchamp001"`
+	ReceiverID int    `json:"receiver_id,omitempty" example:"2"`
+	Amount     int    `json:"amount" binding:"required" example:"100"`
+	Message    string `json:"message,omitempty" example:"Thanks for the code review!"`
+	Category   string `json:"category,omitempty" enums:"helped_me,great_talk,teamwork,mentoring,thank_you" example:"helped_me"`
 }
 
 // TransferSuccessResponse DTO for successful coin transfer response
 // @Description Response indicating that the coin transfer was successful
 type TransferSuccessResponse struct {
-	Message string `json:"message" example:"coins transferred successfully"`
+	Message       string `json:"message" example:"coins transferred successfully"`
+	TransactionID int    `json:"transaction_id" example:"42"`
 }
 
 // MapTransactionToDTO Maps Transaction model to TransactionDTO
@@ -35,6 +41,8 @@ func MapTransactionToDTO(transaction *models.Transaction) *TransactionDTO {
 		SenderID:   transaction.SenderID,
 		ReceiverID: transaction.ReceiverID,
 		Amount:     transaction.Amount,
+		Message:    transaction.Message,
+		Category:   transaction.Category,
 		CreatedAt:  transaction.CreatedAt,
 	}
 }
@@ -46,6 +54,8 @@ func MapTransactionDTOToTransaction(transactionDTO *TransactionDTO) *models.Tran
 		SenderID:   transactionDTO.SenderID,
 		ReceiverID: transactionDTO.ReceiverID,
 		Amount:     transactionDTO.Amount,
+		Message:    transactionDTO.Message,
+		Category:   transactionDTO.Category,
 		CreatedAt:  transactionDTO.CreatedAt,
 	}
 }
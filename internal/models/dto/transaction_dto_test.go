@@ -14,6 +14,8 @@ func TestMapTransactionToDTO(t *testing.T) {
 		SenderID:   10,
 		ReceiverID: 20,
 		Amount:     150,
+		Message:    "thanks",
+		Category:   models.TransferCategoryHelpedMe,
 		CreatedAt:  now,
 	}
 
@@ -23,6 +25,8 @@ func TestMapTransactionToDTO(t *testing.T) {
 	assert.Equal(t, transaction.SenderID, dto.SenderID)
 	assert.Equal(t, transaction.ReceiverID, dto.ReceiverID)
 	assert.Equal(t, transaction.Amount, dto.Amount)
+	assert.Equal(t, transaction.Message, dto.Message)
+	assert.Equal(t, transaction.Category, dto.Category)
 	assert.Equal(t, transaction.CreatedAt, dto.CreatedAt)
 }
 
@@ -33,6 +37,8 @@ func TestMapTransactionDTOToTransaction(t *testing.T) {
 		SenderID:   10,
 		ReceiverID: 20,
 		Amount:     150,
+		Message:    "thanks",
+		Category:   models.TransferCategoryHelpedMe,
 		CreatedAt:  now,
 	}
 
@@ -42,5 +48,7 @@ func TestMapTransactionDTOToTransaction(t *testing.T) {
 	assert.Equal(t, dto.SenderID, transaction.SenderID)
 	assert.Equal(t, dto.ReceiverID, transaction.ReceiverID)
 	assert.Equal(t, dto.Amount, transaction.Amount)
+	assert.Equal(t, dto.Message, transaction.Message)
+	assert.Equal(t, dto.Category, transaction.Category)
 	assert.Equal(t, dto.CreatedAt, transaction.CreatedAt)
 }
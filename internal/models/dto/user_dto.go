@@ -2,55 +2,153 @@ package dto
 
 import (
 	"avito-tech-merch/internal/models"
+	"time"
 )
 
 // UserInfoResponse User information response
-// @Description Response containing user information
+// @Description Response containing user balance, owned merch, recent purchases with their status and recent coin history
 type UserInfoResponse struct {
-	UserID       int               `json:"user_id" example:"1"`
-	Username     string            `json:"username" example:"epchamp001"`
-	Balance      int               `json:"balance" example:"1500"`
-	Purchases    []*PurchaseDTO    `json:"purchases"`
-	Transactions []*TransactionDTO `json:"transactions"`
+	UserID    int                 `json:"user_id" example:"1"`
+	Username  string              `json:"username" example:"epchamp001"`
+	Balance   int                 `json:"balance" example:"1500"`
+	Spent     int                 `json:"spent" example:"120"`
+	Inventory []*InventoryItemDTO `json:"inventory"`
+	// Purchases - до 100 последних покупок от новых к старым, вся история - в /api/history/purchases
+	Purchases   []*PurchaseDTO       `json:"purchases"`
+	CoinHistory *CoinHistoryResponse `json:"coin_history"`
+}
+
+// InventoryItemDTO Merch owned by the user
+// @Description Total quantity of a merch item across all purchases that were not refunded
+type InventoryItemDTO struct {
+	Type     string `json:"type" example:"t-shirt"`
+	Quantity int    `json:"quantity" example:"2"`
+}
+
+// CoinHistoryResponse Coin history of the user
+// @Description Up to 100 latest received and 100 latest sent coin transfers; the full history is available in /api/history/transactions
+type CoinHistoryResponse struct {
+	Received []*ReceivedCoinDTO `json:"received"`
+	Sent     []*SentCoinDTO     `json:"sent"`
+}
+
+// ReceivedCoinDTO Incoming coin transfer
+// @Description Coins received from another user
+type ReceivedCoinDTO struct {
+	TransactionID int       `json:"transaction_id" example:"12"`
+	FromUser      string    `json:"from_user" example:"colleague"`
+	Amount        int       `json:"amount" example:"100"`
+	Message       string    `json:"message,omitempty" example:"Thanks for the code review!"`
+	Category      string    `json:"category,omitempty" example:"helped_me"`
+	CreatedAt     time.Time `json:"created_at" example:"2025-02-16T14:30:00"`
+}
+
+// SentCoinDTO Outgoing coin transfer
+// @Description Coins sent to another user
+type SentCoinDTO struct {
+	TransactionID int       `json:"transaction_id" example:"13"`
+	ToUser        string    `json:"to_user" example:"colleague"`
+	Amount        int       `json:"amount" example:"50"`
+	Message       string    `json:"message,omitempty" example:"Great talk!"`
+	Category      string    `json:"category,omitempty" example:"great_talk"`
+	CreatedAt     time.Time `json:"created_at" example:"2025-02-16T14:30:00"`
 }
 
 // MapUserInfoResponseToDTO Maps internal UserInfo model to UserInfoResponse DTO
 func MapUserInfoResponseToDTO(userInfo *models.UserInfo) *UserInfoResponse {
+	inventoryDTO := make([]*InventoryItemDTO, len(userInfo.Inventory))
+	for i, item := range userInfo.Inventory {
+		inventoryDTO[i] = &InventoryItemDTO{Type: item.MerchName, Quantity: item.Quantity}
+	}
+
 	purchasesDTO := make([]*PurchaseDTO, len(userInfo.Purchases))
 	for i, purchase := range userInfo.Purchases {
 		purchasesDTO[i] = MapPurchaseToDTO(purchase)
 	}
 
-	transactionsDTO := make([]*TransactionDTO, len(userInfo.Transactions))
-	for i, transaction := range userInfo.Transactions {
-		transactionsDTO[i] = MapTransactionToDTO(transaction)
+	coinHistoryDTO := &CoinHistoryResponse{
+		Received: []*ReceivedCoinDTO{},
+		Sent:     []*SentCoinDTO{},
+	}
+	if userInfo.CoinHistory != nil {
+		for _, transfer := range userInfo.CoinHistory.Received {
+			coinHistoryDTO.Received = append(coinHistoryDTO.Received, &ReceivedCoinDTO{
+				TransactionID: transfer.TransactionID,
+				FromUser:      transfer.Counterparty,
+				Amount:        transfer.Amount,
+				Message:       transfer.Message,
+				Category:      transfer.Category,
+				CreatedAt:     transfer.CreatedAt,
+			})
+		}
+		for _, transfer := range userInfo.CoinHistory.Sent {
+			coinHistoryDTO.Sent = append(coinHistoryDTO.Sent, &SentCoinDTO{
+				TransactionID: transfer.TransactionID,
+				ToUser:        transfer.Counterparty,
+				Amount:        transfer.Amount,
+				Message:       transfer.Message,
+				Category:      transfer.Category,
+				CreatedAt:     transfer.CreatedAt,
+			})
+		}
 	}
 
 	return &UserInfoResponse{
-		UserID:       userInfo.UserID,
-		Username:     userInfo.Username,
-		Balance:      userInfo.Balance,
-		Purchases:    purchasesDTO,
-		Transactions: transactionsDTO,
+		UserID:      userInfo.UserID,
+		Username:    userInfo.Username,
+		Balance:     userInfo.Balance,
+		Spent:       userInfo.Spent,
+		Inventory:   inventoryDTO,
+		Purchases:   purchasesDTO,
+		CoinHistory: coinHistoryDTO,
 	}
 }
 
 func MapDTOToUserInfoResponse(userInfoDTO *UserInfoResponse) *models.UserInfo {
+	inventory := make([]*models.InventoryItem, len(userInfoDTO.Inventory))
+	for i, item := range userInfoDTO.Inventory {
+		inventory[i] = &models.InventoryItem{MerchName: item.Type, Quantity: item.Quantity}
+	}
+
 	purchases := make([]*models.Purchase, len(userInfoDTO.Purchases))
-	for i, purchaseDTO := range userInfoDTO.Purchases {
-		purchases[i] = MapPurchaseDTOToPurchase(purchaseDTO)
+	for i, purchase := range userInfoDTO.Purchases {
+		purchases[i] = MapPurchaseDTOToPurchase(purchase)
 	}
 
-	transactions := make([]*models.Transaction, len(userInfoDTO.Transactions))
-	for i, transactionDTO := range userInfoDTO.Transactions {
-		transactions[i] = MapTransactionDTOToTransaction(transactionDTO)
+	coinHistory := &models.CoinHistory{
+		Received: []*models.CoinTransfer{},
+		Sent:     []*models.CoinTransfer{},
+	}
+	if userInfoDTO.CoinHistory != nil {
+		for _, transfer := range userInfoDTO.CoinHistory.Received {
+			coinHistory.Received = append(coinHistory.Received, &models.CoinTransfer{
+				TransactionID: transfer.TransactionID,
+				Counterparty:  transfer.FromUser,
+				Amount:        transfer.Amount,
+				Message:       transfer.Message,
+				Category:      transfer.Category,
+				CreatedAt:     transfer.CreatedAt,
+			})
+		}
+		for _, transfer := range userInfoDTO.CoinHistory.Sent {
+			coinHistory.Sent = append(coinHistory.Sent, &models.CoinTransfer{
+				TransactionID: transfer.TransactionID,
+				Counterparty:  transfer.ToUser,
+				Amount:        transfer.Amount,
+				Message:       transfer.Message,
+				Category:      transfer.Category,
+				CreatedAt:     transfer.CreatedAt,
+			})
+		}
 	}
 
 	return &models.UserInfo{
-		UserID:       userInfoDTO.UserID,
-		Username:     userInfoDTO.Username,
-		Balance:      userInfoDTO.Balance,
-		Purchases:    purchases,
-		Transactions: transactions,
+		UserID:      userInfoDTO.UserID,
+		Username:    userInfoDTO.Username,
+		Balance:     userInfoDTO.Balance,
+		Spent:       userInfoDTO.Spent,
+		Inventory:   inventory,
+		Purchases:   purchases,
+		CoinHistory: coinHistory,
 	}
 }
@@ -10,17 +10,26 @@ import (
 func TestMapUserInfoResponseToDTO(t *testing.T) {
 	now := time.Now()
 
-	purchase1 := &models.Purchase{ID: 1, UserID: 1, MerchID: 101, CreatedAt: now}
-	purchase2 := &models.Purchase{ID: 2, UserID: 1, MerchID: 102, CreatedAt: now.Add(time.Minute)}
-	transaction1 := &models.Transaction{ID: 10, SenderID: 1, ReceiverID: 2, Amount: 50, CreatedAt: now}
-	transaction2 := &models.Transaction{ID: 11, SenderID: 1, ReceiverID: 3, Amount: 75, CreatedAt: now.Add(2 * time.Minute)}
-
 	userInfo := &models.UserInfo{
-		UserID:       1,
-		Username:     "testuser",
-		Balance:      1000,
-		Purchases:    []*models.Purchase{purchase1, purchase2},
-		Transactions: []*models.Transaction{transaction1, transaction2},
+		UserID:   1,
+		Username: "testuser",
+		Balance:  1000,
+		Spent:    120,
+		Inventory: []*models.InventoryItem{
+			{MerchName: "cup", Quantity: 1},
+			{MerchName: "t-shirt", Quantity: 2},
+		},
+		Purchases: []*models.Purchase{
+			{ID: 7, UserID: 1, MerchID: 3, Quantity: 1, UnitPrice: 20, CreatedAt: now, Status: models.PurchaseStatusCancelled, RefundedAt: &now},
+		},
+		CoinHistory: &models.CoinHistory{
+			Received: []*models.CoinTransfer{
+				{TransactionID: 10, Counterparty: "alice", Amount: 50, Message: "thanks", Category: models.TransferCategoryHelpedMe, CreatedAt: now},
+			},
+			Sent: []*models.CoinTransfer{
+				{TransactionID: 11, Counterparty: "bob", Amount: 75, CreatedAt: now.Add(time.Minute)},
+			},
+		},
 	}
 
 	dto := MapUserInfoResponseToDTO(userInfo)
@@ -28,35 +37,50 @@ func TestMapUserInfoResponseToDTO(t *testing.T) {
 	assert.Equal(t, userInfo.UserID, dto.UserID)
 	assert.Equal(t, userInfo.Username, dto.Username)
 	assert.Equal(t, userInfo.Balance, dto.Balance)
+	assert.Equal(t, userInfo.Spent, dto.Spent)
+
+	assert.Equal(t, []*InventoryItemDTO{
+		{Type: "cup", Quantity: 1},
+		{Type: "t-shirt", Quantity: 2},
+	}, dto.Inventory)
+
+	assert.Equal(t, []*PurchaseDTO{
+		{ID: 7, UserID: 1, MerchID: 3, Quantity: 1, UnitPrice: 20, TotalPrice: 20, CreatedAt: now, Status: models.PurchaseStatusCancelled, RefundedAt: &now},
+	}, dto.Purchases)
+
+	assert.Equal(t, []*ReceivedCoinDTO{
+		{TransactionID: 10, FromUser: "alice", Amount: 50, Message: "thanks", Category: models.TransferCategoryHelpedMe, CreatedAt: now},
+	}, dto.CoinHistory.Received)
+	assert.Equal(t, []*SentCoinDTO{
+		{TransactionID: 11, ToUser: "bob", Amount: 75, CreatedAt: now.Add(time.Minute)},
+	}, dto.CoinHistory.Sent)
+}
 
-	assert.Len(t, dto.Purchases, len(userInfo.Purchases))
-	assert.Len(t, dto.Transactions, len(userInfo.Transactions))
-
-	for i, purchase := range userInfo.Purchases {
-		expectedDTO := MapPurchaseToDTO(purchase)
-		assert.Equal(t, expectedDTO, dto.Purchases[i])
-	}
+func TestMapUserInfoResponseToDTO_EmptyHistory(t *testing.T) {
+	dto := MapUserInfoResponseToDTO(&models.UserInfo{UserID: 1})
 
-	for i, transaction := range userInfo.Transactions {
-		expectedDTO := MapTransactionToDTO(transaction)
-		assert.Equal(t, expectedDTO, dto.Transactions[i])
-	}
+	// Пустые списки сериализуются как [], а не null
+	assert.NotNil(t, dto.Inventory)
+	assert.NotNil(t, dto.Purchases)
+	assert.NotNil(t, dto.CoinHistory.Received)
+	assert.NotNil(t, dto.CoinHistory.Sent)
 }
 
 func TestMapDTOToUserInfoResponse(t *testing.T) {
 	now := time.Now()
 
-	purchaseDTO1 := &PurchaseDTO{ID: 1, UserID: 1, MerchID: 101, CreatedAt: now}
-	purchaseDTO2 := &PurchaseDTO{ID: 2, UserID: 1, MerchID: 102, CreatedAt: now.Add(time.Minute)}
-	transactionDTO1 := &TransactionDTO{ID: 10, SenderID: 1, ReceiverID: 2, Amount: 50, CreatedAt: now}
-	transactionDTO2 := &TransactionDTO{ID: 11, SenderID: 1, ReceiverID: 3, Amount: 75, CreatedAt: now.Add(2 * time.Minute)}
-
 	userInfoDTO := &UserInfoResponse{
-		UserID:       1,
-		Username:     "testuser",
-		Balance:      1000,
-		Purchases:    []*PurchaseDTO{purchaseDTO1, purchaseDTO2},
-		Transactions: []*TransactionDTO{transactionDTO1, transactionDTO2},
+		UserID:   1,
+		Username: "testuser",
+		Balance:  1000,
+		Spent:    120,
+		Inventory: []*InventoryItemDTO{
+			{Type: "cup", Quantity: 1},
+		},
+		CoinHistory: &CoinHistoryResponse{
+			Received: []*ReceivedCoinDTO{{TransactionID: 10, FromUser: "alice", Amount: 50, CreatedAt: now}},
+			Sent:     []*SentCoinDTO{{TransactionID: 11, ToUser: "bob", Amount: 75, Category: models.TransferCategoryTeamwork, CreatedAt: now}},
+		},
 	}
 
 	userInfo := MapDTOToUserInfoResponse(userInfoDTO)
@@ -64,17 +88,13 @@ func TestMapDTOToUserInfoResponse(t *testing.T) {
 	assert.Equal(t, userInfoDTO.UserID, userInfo.UserID)
 	assert.Equal(t, userInfoDTO.Username, userInfo.Username)
 	assert.Equal(t, userInfoDTO.Balance, userInfo.Balance)
-
-	assert.Len(t, userInfo.Purchases, len(userInfoDTO.Purchases))
-	assert.Len(t, userInfo.Transactions, len(userInfoDTO.Transactions))
-
-	for i, purchaseDTO := range userInfoDTO.Purchases {
-		expectedPurchase := MapPurchaseDTOToPurchase(purchaseDTO)
-		assert.Equal(t, expectedPurchase, userInfo.Purchases[i])
-	}
-
-	for i, transactionDTO := range userInfoDTO.Transactions {
-		expectedTransaction := MapTransactionDTOToTransaction(transactionDTO)
-		assert.Equal(t, expectedTransaction, userInfo.Transactions[i])
-	}
+	assert.Equal(t, userInfoDTO.Spent, userInfo.Spent)
+
+	assert.Equal(t, []*models.InventoryItem{{MerchName: "cup", Quantity: 1}}, userInfo.Inventory)
+	assert.Equal(t, []*models.CoinTransfer{
+		{TransactionID: 10, Counterparty: "alice", Amount: 50, CreatedAt: now},
+	}, userInfo.CoinHistory.Received)
+	assert.Equal(t, []*models.CoinTransfer{
+		{TransactionID: 11, Counterparty: "bob", Amount: 75, Category: models.TransferCategoryTeamwork, CreatedAt: now},
+	}, userInfo.CoinHistory.Sent)
 }
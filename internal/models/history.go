@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Направления перевода относительно пользователя, чья история запрошена
+const (
+	DirectionSent     = "sent"
+	DirectionReceived = "received"
+)
+
+// Размер страницы истории по умолчанию и максимальный
+const (
+	DefaultHistoryPageSize = 20
+	MaxHistoryPageSize     = 100
+)
+
+// InfoHistoryLimit - сколько последних покупок и переводов в каждую сторону показывает /api/info;
+// вся история доступна постранично в /api/history
+const InfoHistoryLimit = 100
+
+// HistoryCursor указывает на последнюю выданную запись: история отсортирована по (CreatedAt, ID)
+// от новых к старым, следующая страница начинается строго после курсора
+type HistoryCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// HistoryPage задаёт размер страницы и курсор, после которого её выдавать; без курсора - с самых новых записей
+type HistoryPage struct {
+	Limit int
+	After *HistoryCursor
+}
+
+// PurchaseFilter ограничивает историю покупок периодом [From, To); nil-границы не фильтруют
+type PurchaseFilter struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// TransferPage - страница истории переводов; NextCursor nil, если записей больше нет
+type TransferPage struct {
+	Transfers  []*CoinTransfer
+	NextCursor *HistoryCursor
+}
+
+// PurchasePage - страница истории покупок; NextCursor nil, если записей больше нет
+type PurchasePage struct {
+	Purchases  []*Purchase
+	NextCursor *HistoryCursor
+}
+
+// IsValidDirection проверяет, что направление перевода входит в список известных
+func IsValidDirection(direction string) bool {
+	return direction == DirectionSent || direction == DirectionReceived
+}
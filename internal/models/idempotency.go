@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// IdempotencyKey - результат запроса, выполненного с заголовком Idempotency-Key.
+// Ключ уникален в пределах пользователя; RequestHash защищает от повторного использования ключа с другими параметрами
+type IdempotencyKey struct {
+	UserID      int
+	Key         string
+	Operation   string
+	RequestHash string
+	// Response - JSON с результатом исходного запроса, который возвращается при повторе
+	Response  []byte
+	CreatedAt time.Time
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// Причины проводок в журнале
+const (
+	LedgerReasonGrant          = "grant"
+	LedgerReasonTransfer       = "transfer"
+	LedgerReasonPurchase       = "purchase"
+	LedgerReasonRefund         = "refund"
+	LedgerReasonOpeningBalance = "opening_balance"
+)
+
+// Системные счета. Все монеты выпускаются со счёта эмиссии, поэтому его баланс отрицательный
+// и по модулю равен количеству монет в обращении; счёт магазина накапливает монеты, потраченные на мерч
+const (
+	AccountIssuance   = "system:issuance"
+	AccountMerchStore = "system:merch_store"
+)
+
+// LedgerEntry - проводка журнала: набор движений, сумма которых равна нулю.
+// ReferenceID указывает на перевод, покупку или пользователя в зависимости от Reason
+type LedgerEntry struct {
+	ID          int              `json:"id"`
+	Reason      string           `json:"reason"`
+	ReferenceID int              `json:"reference_id"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Postings    []*LedgerPosting `json:"postings"`
+}
+
+// LedgerPosting - изменение баланса одного счёта. UserID заполнен для счетов пользователей
+type LedgerPosting struct {
+	ID      int    `json:"id"`
+	EntryID int    `json:"entry_id"`
+	Account string `json:"account"`
+	UserID  *int   `json:"user_id,omitempty"`
+	Delta   int    `json:"delta"`
+}
+
+// LedgerReport - результат сверки журнала с балансами пользователей
+type LedgerReport struct {
+	// Total - сумма всех движений, в согласованном журнале равна нулю
+	Total int `json:"total"`
+	// Issued - количество выпущенных монет, находящихся у пользователей и магазина
+	Issued            int                `json:"issued"`
+	UnbalancedEntries int                `json:"unbalanced_entries"`
+	Mismatches        []*BalanceMismatch `json:"mismatches"`
+}
+
+// BalanceMismatch - пользователь, чей users.balance расходится с суммой его движений в журнале
+type BalanceMismatch struct {
+	UserID        int `json:"user_id"`
+	Balance       int `json:"balance"`
+	LedgerBalance int `json:"ledger_balance"`
+}
+
+// UserAccount возвращает счёт пользователя
+func UserAccount(userID int) string {
+	return "user:" + strconv.Itoa(userID)
+}
+
+// UserPosting - движение по счёту пользователя
+func UserPosting(userID int, delta int) *LedgerPosting {
+	return &LedgerPosting{Account: UserAccount(userID), UserID: &userID, Delta: delta}
+}
+
+// SystemPosting - движение по системному счёту
+func SystemPosting(account string, delta int) *LedgerPosting {
+	return &LedgerPosting{Account: account, Delta: delta}
+}
+
+// NewLedgerEntry создаёт проводку из движений; сбалансированность проверяет IsBalanced
+func NewLedgerEntry(reason string, referenceID int, postings ...*LedgerPosting) *LedgerEntry {
+	return &LedgerEntry{
+		Reason:      reason,
+		ReferenceID: referenceID,
+		CreatedAt:   time.Now(),
+		Postings:    postings,
+	}
+}
+
+// IsBalanced сообщает, что проводка затрагивает хотя бы два счёта и не создаёт и не уничтожает монеты
+func (e *LedgerEntry) IsBalanced() bool {
+	if len(e.Postings) < 2 {
+		return false
+	}
+	total := 0
+	for _, posting := range e.Postings {
+		if posting.Delta == 0 {
+			return false
+		}
+		total += posting.Delta
+	}
+	return total == 0
+}
+
+// IsConsistent сообщает, что журнал сбалансирован и все балансы пользователей с ним совпадают
+func (r *LedgerReport) IsConsistent() bool {
+	return r.Total == 0 && r.UnbalancedEntries == 0 && len(r.Mismatches) == 0
+}
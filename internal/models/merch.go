@@ -1,7 +1,22 @@
 package models
 
+import "time"
+
 type Merch struct {
 	ID    int    `json:"id"`
 	Name  string `json:"name"`
 	Price int    `json:"price"`
+	// Stock - остаток на складе; nil означает, что количество не ограничено
+	Stock      *int       `json:"stock,omitempty"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// IsArchived сообщает, снят ли товар с продажи
+func (m *Merch) IsArchived() bool {
+	return m.ArchivedAt != nil
+}
+
+// HasStockLimit сообщает, ведётся ли учёт остатка товара
+func (m *Merch) HasStockLimit() bool {
+	return m.Stock != nil
 }
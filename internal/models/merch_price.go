@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// MerchPrice - запись истории цен товара: цена действует с ValidFrom до следующей записи
+type MerchPrice struct {
+	ID      int `json:"id"`
+	MerchID int `json:"merch_id"`
+	Price   int `json:"price"`
+	// ChangedBy - администратор, установивший цену; nil для цен, заведённых до появления истории
+	ChangedBy *int      `json:"changed_by,omitempty"`
+	ValidFrom time.Time `json:"valid_from"`
+}
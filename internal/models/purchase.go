@@ -2,9 +2,69 @@ package models
 
 import "time"
 
+// Статусы выдачи покупки: placed -> ready_for_pickup -> delivered, либо cancelled до выдачи
+const (
+	PurchaseStatusPlaced         = "placed"
+	PurchaseStatusReadyForPickup = "ready_for_pickup"
+	PurchaseStatusDelivered      = "delivered"
+	PurchaseStatusCancelled      = "cancelled"
+)
+
+// purchaseTransitions перечисляет допустимые переходы между статусами; delivered и cancelled конечные
+var purchaseTransitions = map[string][]string{
+	PurchaseStatusPlaced:         {PurchaseStatusReadyForPickup, PurchaseStatusCancelled},
+	PurchaseStatusReadyForPickup: {PurchaseStatusDelivered, PurchaseStatusCancelled},
+}
+
 type Purchase struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	MerchID   int       `json:"merch_id"`
+	ID      int `json:"id"`
+	UserID  int `json:"user_id"`
+	MerchID int `json:"merch_id"`
+	// Quantity и UnitPrice фиксируются в момент покупки и не зависят от последующих изменений каталога
+	Quantity  int       `json:"quantity"`
+	UnitPrice int       `json:"unit_price"`
 	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status"`
+	// RefundedAt и RefundedBy заполняются при возврате; возвращённая покупка не удаляется
+	RefundedAt *time.Time `json:"refunded_at,omitempty"`
+	RefundedBy *int       `json:"refunded_by,omitempty"`
+}
+
+// IsRefunded сообщает, оформлен ли по покупке возврат
+func (p *Purchase) IsRefunded() bool {
+	return p.RefundedAt != nil
+}
+
+// TotalPrice возвращает сумму, списанную за покупку
+func (p *Purchase) TotalPrice() int {
+	return p.UnitPrice * p.Quantity
+}
+
+// IsValidPurchaseStatus проверяет, что статус входит в список известных
+func IsValidPurchaseStatus(status string) bool {
+	switch status {
+	case PurchaseStatusPlaced, PurchaseStatusReadyForPickup, PurchaseStatusDelivered, PurchaseStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// CanTransitionPurchaseStatus сообщает, можно ли перевести покупку из статуса from в статус to
+func CanTransitionPurchaseStatus(from, to string) bool {
+	for _, next := range purchaseTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PurchaseStatusChange - запись истории статусов покупки
+type PurchaseStatusChange struct {
+	ID         int    `json:"id"`
+	PurchaseID int    `json:"purchase_id"`
+	Status     string `json:"status"`
+	// ChangedBy - пользователь, сменивший статус; nil, если пользователь удалён
+	ChangedBy *int      `json:"changed_by,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
 }
@@ -2,10 +2,58 @@ package models
 
 import "time"
 
+// MaxTransferMessageLength - максимальная длина сообщения к переводу в символах
+const MaxTransferMessageLength = 280
+
+// Категории благодарности, с которыми можно отправить перевод
+const (
+	TransferCategoryHelpedMe  = "helped_me"
+	TransferCategoryGreatTalk = "great_talk"
+	TransferCategoryTeamwork  = "teamwork"
+	TransferCategoryMentoring = "mentoring"
+	TransferCategoryThankYou  = "thank_you"
+)
+
 type Transaction struct {
-	ID         int       `json:"id"`
-	SenderID   int       `json:"sender_id"`
-	ReceiverID int       `json:"receiver_id"`
-	Amount     int       `json:"amount"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID         int    `json:"id"`
+	SenderID   int    `json:"sender_id"`
+	ReceiverID int    `json:"receiver_id"`
+	Amount     int    `json:"amount"`
+	Message    string `json:"message"`
+	// Category пустая, если отправитель не указал категорию
+	Category  string    `json:"category"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TransferRecipient - получатель перевода: по логину или, для старых клиентов, по ID.
+// Если задан Username, ID не используется
+type TransferRecipient struct {
+	ID       int
+	Username string
+}
+
+// TransferMemo - необязательные сообщение и категория, которые отправитель прикладывает к переводу
+type TransferMemo struct {
+	Message  string
+	Category string
+}
+
+// TransactionFilter ограничивает историю переводов; пустые поля не фильтруют.
+// Counterparty - логин второй стороны перевода, период задаётся как [From, To)
+type TransactionFilter struct {
+	Category     string
+	Direction    string
+	Counterparty string
+	From         *time.Time
+	To           *time.Time
+}
+
+// IsValidTransferCategory проверяет, что категория входит в список известных
+func IsValidTransferCategory(category string) bool {
+	switch category {
+	case TransferCategoryHelpedMe, TransferCategoryGreatTalk, TransferCategoryTeamwork,
+		TransferCategoryMentoring, TransferCategoryThankYou:
+		return true
+	}
+	return false
 }
@@ -2,10 +2,21 @@ package models
 
 import "time"
 
+const (
+	RoleEmployee = "employee"
+	RoleAdmin    = "admin"
+)
+
 type User struct {
 	ID           int       `json:"id"`
 	Username     string    `json:"username"`
 	PasswordHash string    `json:"-"`
 	Balance      int       `json:"balance"`
+	Role         string    `json:"role"`
 	CreatedAt    time.Time `json:"created_at"`
 }
+
+// IsValidRole проверяет, что роль входит в список известных
+func IsValidRole(role string) bool {
+	return role == RoleEmployee || role == RoleAdmin
+}
@@ -1,9 +1,40 @@
 package models
 
+import "time"
+
 type UserInfo struct {
-	UserID       int            `json:"user_id"`
-	Username     string         `json:"username"`
-	Balance      int            `json:"balance"`
-	Purchases    []*Purchase    `json:"purchases"`
-	Transactions []*Transaction `json:"transactions"`
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Balance  int    `json:"balance"`
+	// Spent - сумма, фактически списанная за все покупки, по ценам на момент покупки
+	Spent     int              `json:"spent"`
+	Inventory []*InventoryItem `json:"inventory"`
+	// Purchases - последние покупки от новых к старым со статусом выдачи и временем возврата
+	Purchases   []*Purchase  `json:"purchases"`
+	CoinHistory *CoinHistory `json:"coin_history"`
+}
+
+// InventoryItem - сколько единиц товара есть у пользователя с учётом всех невозвращённых покупок
+type InventoryItem struct {
+	MerchName string `json:"merch_name"`
+	Quantity  int    `json:"quantity"`
+}
+
+// CoinHistory - последние переводы пользователя, разделённые на входящие и исходящие
+type CoinHistory struct {
+	Received []*CoinTransfer `json:"received"`
+	Sent     []*CoinTransfer `json:"sent"`
+}
+
+// CoinTransfer - перевод с точки зрения пользователя. Counterparty - отправитель для входящих
+// и получатель для исходящих; пустой, если пользователь удалён
+type CoinTransfer struct {
+	TransactionID int `json:"transaction_id"`
+	// Direction - DirectionSent или DirectionReceived относительно пользователя
+	Direction    string    `json:"direction"`
+	Counterparty string    `json:"counterparty"`
+	Amount       int       `json:"amount"`
+	Message      string    `json:"message"`
+	Category     string    `json:"category"`
+	CreatedAt    time.Time `json:"created_at"`
 }
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/storage/db/postgres"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type adminService struct {
+	repo      db.Repository
+	logger    logger.Logger
+	txManager db.TxManager
+}
+
+func NewAdminService(repo db.Repository, log logger.Logger, txManager db.TxManager) AdminService {
+	return &adminService{repo: repo, logger: log, txManager: txManager}
+}
+
+// SetUserRole меняет роль пользователя. Уже выданные access-токены пользователя отзываются,
+// чтобы новая роль вступила в силу при ближайшем обмене refresh-токена, а не через token_expiry
+func (s *adminService) SetUserRole(ctx context.Context, adminID int, userID int, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	if adminID == userID {
+		logger.WithContext(ctx, s.logger).Warnw("Admin attempted to change own role",
+			"adminID", adminID,
+		)
+		return nil, ErrOwnRoleChange
+	}
+
+	var user *models.User
+
+	err := s.txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
+		var err error
+		user, err = s.repo.GetUserByID(txCtx, userID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				logger.WithContext(ctx, s.logger).Infow("User not found",
+					"userID", userID,
+				)
+				return fmt.Errorf("%w: id %d", ErrUserNotFound, userID)
+			}
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get user",
+				"error", err,
+				"userID", userID,
+			)
+			return err
+		}
+
+		if user.Role == role {
+			return nil
+		}
+
+		if err := s.repo.UpdateUserRole(txCtx, userID, role); err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to update user role",
+				"error", err,
+				"userID", userID,
+			)
+			return err
+		}
+
+		if err := s.repo.SetTokensValidAfter(txCtx, userID, time.Now()); err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to revoke user tokens after role change",
+				"error", err,
+				"userID", userID,
+			)
+			return err
+		}
+
+		logger.WithContext(ctx, s.logger).Infow("User role changed",
+			"adminID", adminID,
+			"userID", userID,
+			"oldRole", user.Role,
+			"newRole", role,
+		)
+		user.Role = role
+		return nil
+	})
+
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Error during SetUserRole operation",
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// VerifyLedger сверяет журнал с балансами пользователей. Запросы выполняются в одном снимке,
+// чтобы параллельные переводы не давали ложных расхождений
+func (s *adminService) VerifyLedger(ctx context.Context) (*models.LedgerReport, error) {
+	var report *models.LedgerReport
+
+	err := s.txManager.WithTx(ctx, postgres.IsolationLevelRepeatableRead, postgres.AccessModeReadOnly, func(txCtx context.Context) error {
+		var err error
+		report, err = s.repo.VerifyLedger(txCtx)
+		if err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to verify ledger",
+				"error", err,
+			)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !report.IsConsistent() {
+		logger.WithContext(ctx, s.logger).Warnw("Ledger is inconsistent",
+			"total", report.Total,
+			"unbalancedEntries", report.UnbalancedEntries,
+			"mismatches", len(report.Mismatches),
+		)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"avito-tech-merch/internal/models"
+	mockRepo "avito-tech-merch/internal/storage/db/mock"
+	"avito-tech-merch/internal/storage/db/postgres"
+	mockLog "avito-tech-merch/pkg/logger/mock"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func setupReadCommittedTx(txManagerMock *mockRepo.TxManager) {
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Return(func(ctx context.Context, _ pgx.TxIsoLevel, _ pgx.TxAccessMode, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+}
+
+func TestAdminService_SetUserRole_Success(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewAdminService(repoMock, loggerMock, txManagerMock)
+
+	setupReadCommittedTx(txManagerMock)
+
+	user := &models.User{ID: 2, Username: "hr_manager", Role: models.RoleEmployee}
+	repoMock.On("GetUserByID", mock.Anything, 2).Return(user, nil).Once()
+	repoMock.On("UpdateUserRole", mock.Anything, 2, models.RoleAdmin).Return(nil).Once()
+	repoMock.On("SetTokensValidAfter", mock.Anything, 2, mock.AnythingOfType("time.Time")).Return(nil).Once()
+	loggerMock.On("Infow", "User role changed",
+		"adminID", 1, "userID", 2, "oldRole", models.RoleEmployee, "newRole", models.RoleAdmin).Return().Once()
+
+	updated, err := service.SetUserRole(context.Background(), 1, 2, models.RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, updated.Role)
+	assert.Equal(t, "hr_manager", updated.Username)
+}
+
+func TestAdminService_SetUserRole_Unchanged(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewAdminService(repoMock, loggerMock, txManagerMock)
+
+	setupReadCommittedTx(txManagerMock)
+
+	user := &models.User{ID: 2, Role: models.RoleAdmin}
+	repoMock.On("GetUserByID", mock.Anything, 2).Return(user, nil).Once()
+
+	updated, err := service.SetUserRole(context.Background(), 1, 2, models.RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, updated.Role)
+	repoMock.AssertNotCalled(t, "UpdateUserRole", mock.Anything, mock.Anything, mock.Anything)
+	repoMock.AssertNotCalled(t, "SetTokensValidAfter", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminService_SetUserRole_InvalidRole(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	service := NewAdminService(repoMock, loggerMock, nil)
+
+	updated, err := service.SetUserRole(context.Background(), 1, 2, "superuser")
+	assert.EqualError(t, err, "invalid role")
+	assert.Nil(t, updated)
+}
+
+func TestAdminService_SetUserRole_Self(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	service := NewAdminService(repoMock, loggerMock, nil)
+
+	loggerMock.On("Warnw", "Admin attempted to change own role", "adminID", 1).Return().Once()
+
+	updated, err := service.SetUserRole(context.Background(), 1, 1, models.RoleEmployee)
+	assert.EqualError(t, err, "cannot change own role")
+	assert.Nil(t, updated)
+}
+
+func TestAdminService_SetUserRole_UserNotFound(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewAdminService(repoMock, loggerMock, txManagerMock)
+
+	setupReadCommittedTx(txManagerMock)
+
+	repoMock.On("GetUserByID", mock.Anything, 2).Return(nil, fmt.Errorf("failed to get a user by ID: %w", pgx.ErrNoRows)).Once()
+	loggerMock.On("Infow", "User not found", "userID", 2).Return().Once()
+	loggerMock.On("Errorw", "Error during SetUserRole operation", "error", mock.Anything).Return().Once()
+
+	updated, err := service.SetUserRole(context.Background(), 1, 2, models.RoleAdmin)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.Nil(t, updated)
+}
+
+func TestAdminService_SetUserRole_UpdateError(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewAdminService(repoMock, loggerMock, txManagerMock)
+
+	setupReadCommittedTx(txManagerMock)
+
+	dbErr := errors.New("db error")
+	repoMock.On("GetUserByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleEmployee}, nil).Once()
+	repoMock.On("UpdateUserRole", mock.Anything, 2, models.RoleAdmin).Return(dbErr).Once()
+	loggerMock.On("Errorw", "Failed to update user role", "error", dbErr, "userID", 2).Return().Once()
+	loggerMock.On("Errorw", "Error during SetUserRole operation", "error", dbErr).Return().Once()
+
+	updated, err := service.SetUserRole(context.Background(), 1, 2, models.RoleAdmin)
+	assert.ErrorIs(t, err, dbErr)
+	assert.Nil(t, updated)
+	repoMock.AssertNotCalled(t, "SetTokensValidAfter", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminService_VerifyLedger(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewAdminService(repoMock, loggerMock, txManagerMock)
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelRepeatableRead, postgres.AccessModeReadOnly,
+			mock.AnythingOfType("func(context.Context) error")).
+		Return(func(ctx context.Context, _ pgx.TxIsoLevel, _ pgx.TxAccessMode, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+
+	report := &models.LedgerReport{
+		Issued:     3000,
+		Mismatches: []*models.BalanceMismatch{{UserID: 2, Balance: 900, LedgerBalance: 1000}},
+	}
+	repoMock.On("VerifyLedger", mock.Anything).Return(report, nil).Once()
+	loggerMock.On("Warnw", "Ledger is inconsistent",
+		"total", 0, "unbalancedEntries", 0, "mismatches", 1).Return().Once()
+
+	result, err := service.VerifyLedger(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, result.IsConsistent())
+	assert.Equal(t, 3000, result.Issued)
+}
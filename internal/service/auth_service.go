@@ -35,15 +35,15 @@ func NewAuthService(repo db.Repository, log logger.Logger, jwtConfig config.JWTC
 	}
 }
 
-func (s *authService) Register(ctx context.Context, username string, password string) (string, error) {
+func (s *authService) Register(ctx context.Context, username string, password string) (*models.TokenPair, error) {
 	metrics.RecordRegistration()
 
-	var token string
+	var tokens *models.TokenPair
 
 	err := s.txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
 		existingUser, err := s.repo.GetUserByUsername(txCtx, username)
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Errorw("Failed to get user by username",
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get user by username",
 				"error", err,
 				"username", username,
 			)
@@ -51,15 +51,15 @@ func (s *authService) Register(ctx context.Context, username string, password st
 		}
 
 		if existingUser != nil {
-			s.logger.Infow("User already exists",
+			logger.WithContext(ctx, s.logger).Infow("User already exists",
 				"username", username,
 			)
-			return fmt.Errorf("user already exists")
+			return ErrUserAlreadyExists
 		}
 
 		hashedPassword, err := pass.HashPassword(password)
 		if err != nil {
-			s.logger.Errorw("Failed to hash password",
+			logger.WithContext(ctx, s.logger).Errorw("Failed to hash password",
 				"error", err,
 				"username", username,
 			)
@@ -69,92 +69,328 @@ func (s *authService) Register(ctx context.Context, username string, password st
 		user := &models.User{
 			Username:     username,
 			PasswordHash: hashedPassword,
-			Balance:      1000,
+			Role:         models.RoleEmployee,
 			CreatedAt:    time.Now(),
 		}
 
 		userID, err := s.repo.CreateUser(txCtx, user)
 		if err != nil {
-			s.logger.Errorw("Failed to create user",
+			logger.WithContext(ctx, s.logger).Errorw("Failed to create user",
 				"error", err,
 				"username", username,
 			)
 			return err
 		}
 
-		token, err = s.tokenService.GenerateToken(userID, s.JWTConfig.SecretKey, s.JWTConfig.TokenExpiry)
-		if err != nil {
-			s.logger.Errorw("Failed to generate token",
-				"error", err,
-				"userID", userID,
-			)
-			return fmt.Errorf("failed to generate token: %w", err)
+		// Стартовые монеты выпускаются со счёта эмиссии, чтобы баланс нового сотрудника был отражён в журнале
+		grant := models.NewLedgerEntry(models.LedgerReasonGrant, userID,
+			models.SystemPosting(models.AccountIssuance, -welcomeGrant),
+			models.UserPosting(userID, welcomeGrant),
+		)
+		if err = postLedgerEntry(txCtx, s.repo, s.logger, grant); err != nil {
+			return err
 		}
 
-		return nil
+		tokens, err = s.issueTokens(txCtx, userID, user.Role, "")
+		return err
 	})
 
 	if err != nil {
-		s.logger.Errorw("Error during Register operation",
+		logger.WithContext(ctx, s.logger).Errorw("Error during Register operation",
 			"error", err,
 		)
-		return "", err
+		return nil, err
 	}
 
-	return token, nil
+	return tokens, nil
 }
 
-func (s *authService) Login(ctx context.Context, username string, password string) (string, error) {
+func (s *authService) Login(ctx context.Context, username string, password string) (*models.TokenPair, error) {
 	metrics.RecordLogin()
 
 	user, err := s.repo.GetUserByUsername(ctx, username)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Infow("User not found",
+			logger.WithContext(ctx, s.logger).Infow("User not found",
 				"username", username,
 			)
-			return "", errors.New("user not found")
+			return nil, ErrInvalidCredentials
 		}
-		s.logger.Errorw("Failed to get user by username",
+		logger.WithContext(ctx, s.logger).Errorw("Failed to get user by username",
 			"error", err,
 			"username", username,
 		)
-		return "", err
+		return nil, err
 	}
 
 	if !pass.CheckPassword(user.PasswordHash, password) {
-		s.logger.Infow("Invalid password",
+		logger.WithContext(ctx, s.logger).Infow("Invalid password",
 			"username", username,
 		)
-		return "", fmt.Errorf("invalid password")
+		return nil, ErrInvalidCredentials
 	}
 
-	token, err := s.tokenService.GenerateToken(user.ID, s.JWTConfig.SecretKey, s.JWTConfig.TokenExpiry)
+	return s.issueTokens(ctx, user.ID, user.Role, "")
+}
+
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	var (
+		tokens        *models.TokenPair
+		reuseDetected bool
+	)
+
+	err := s.txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
+		stored, err := s.repo.GetRefreshTokenByHash(txCtx, s.tokenService.HashRefreshToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				logger.WithContext(ctx, s.logger).Infow("Refresh token not found")
+				return fmt.Errorf("invalid refresh token")
+			}
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get refresh token",
+				"error", err,
+			)
+			return err
+		}
+
+		// Повторное предъявление уже обменянного токена означает, что он утёк:
+		// отзываем всю цепочку, но транзакцию фиксируем, чтобы отзыв сохранился
+		if stored.UsedAt != nil || stored.RevokedAt != nil {
+			logger.WithContext(ctx, s.logger).Warnw("Refresh token reuse detected",
+				"userID", stored.UserID,
+				"familyID", stored.FamilyID,
+			)
+			if err := s.repo.RevokeRefreshTokenFamily(txCtx, stored.FamilyID); err != nil {
+				logger.WithContext(ctx, s.logger).Errorw("Failed to revoke refresh token family",
+					"error", err,
+					"familyID", stored.FamilyID,
+				)
+				return err
+			}
+			reuseDetected = true
+			return nil
+		}
+
+		if time.Now().After(stored.ExpiresAt) {
+			logger.WithContext(ctx, s.logger).Infow("Refresh token expired",
+				"userID", stored.UserID,
+			)
+			return fmt.Errorf("refresh token expired")
+		}
+
+		if err := s.repo.MarkRefreshTokenUsed(txCtx, stored.ID); err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to mark refresh token as used",
+				"error", err,
+				"tokenID", stored.ID,
+			)
+			return err
+		}
+
+		// Роль берётся из базы, чтобы её изменение вступало в силу при следующем обмене
+		user, err := s.repo.GetUserByID(txCtx, stored.UserID)
+		if err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get user for refresh",
+				"error", err,
+				"userID", stored.UserID,
+			)
+			return err
+		}
+
+		tokens, err = s.issueTokens(txCtx, user.ID, user.Role, stored.FamilyID)
+		return err
+	})
+
 	if err != nil {
-		s.logger.Errorw("Failed to generate token",
+		logger.WithContext(ctx, s.logger).Errorw("Error during Refresh operation",
 			"error", err,
-			"userID", user.ID,
 		)
-		return "", fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
+	}
+
+	if reuseDetected {
+		return nil, fmt.Errorf("refresh token reuse detected")
 	}
 
-	return token, nil
+	return tokens, nil
 }
 
-func (s *authService) ValidateToken(token string) (int, error) {
-	userID, err := s.tokenService.ParseJWTToken(token, s.JWTConfig.SecretKey)
+func (s *authService) Logout(ctx context.Context, claims *myjwt.Claims, refreshToken string) error {
+	err := s.txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
+		if err := s.repo.RevokeToken(txCtx, claims.TokenID, claims.UserID, claims.ExpiresAt); err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to revoke access token",
+				"error", err,
+				"userID", claims.UserID,
+			)
+			return err
+		}
+
+		if refreshToken == "" {
+			return nil
+		}
+
+		stored, err := s.repo.GetRefreshTokenByHash(txCtx, s.tokenService.HashRefreshToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				// Неизвестный refresh-токен не мешает выходу: access-токен уже отозван
+				return nil
+			}
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get refresh token",
+				"error", err,
+			)
+			return err
+		}
+
+		if stored.UserID != claims.UserID {
+			logger.WithContext(ctx, s.logger).Warnw("Refresh token belongs to another user",
+				"userID", claims.UserID,
+				"ownerID", stored.UserID,
+			)
+			return nil
+		}
+
+		if err := s.repo.RevokeRefreshTokenFamily(txCtx, stored.FamilyID); err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to revoke refresh token family",
+				"error", err,
+				"familyID", stored.FamilyID,
+			)
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Error during Logout operation",
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (s *authService) LogoutAll(ctx context.Context, userID int) error {
+	err := s.txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
+		if err := s.repo.SetTokensValidAfter(txCtx, userID, time.Now()); err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to update tokens watermark",
+				"error", err,
+				"userID", userID,
+			)
+			return err
+		}
+
+		if err := s.repo.RevokeUserRefreshTokens(txCtx, userID); err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to revoke user refresh tokens",
+				"error", err,
+				"userID", userID,
+			)
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Error during LogoutAll operation",
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (s *authService) ValidateToken(ctx context.Context, token string) (*myjwt.Claims, error) {
+	claims, err := s.tokenService.ParseJWTToken(token)
 	if err != nil {
 		if errors.Is(err, jwt.ErrSignatureInvalid) {
-			s.logger.Errorw("Invalid token signature",
+			logger.WithContext(ctx, s.logger).Errorw("Invalid token signature",
 				"error", err,
 			)
-			return 0, fmt.Errorf("invalid token signature")
+			return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
 		}
-		s.logger.Errorw("Invalid token",
+		logger.WithContext(ctx, s.logger).Errorw("Invalid token",
+			"error", err,
+		)
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := s.repo.IsTokenRevoked(ctx, claims.TokenID)
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to check token revocation",
+			"error", err,
+			"userID", claims.UserID,
+		)
+		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	if revoked {
+		logger.WithContext(ctx, s.logger).Infow("Token revoked",
+			"userID", claims.UserID,
+		)
+		return nil, fmt.Errorf("%w: revoked", ErrInvalidToken)
+	}
+
+	validAfter, err := s.repo.GetTokensValidAfter(ctx, claims.UserID)
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to get tokens watermark",
+			"error", err,
+			"userID", claims.UserID,
+		)
+		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	if claims.IssuedAt.Before(validAfter) {
+		logger.WithContext(ctx, s.logger).Infow("Token issued before logout from all sessions",
+			"userID", claims.UserID,
+		)
+		return nil, fmt.Errorf("%w: revoked", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+func (s *authService) GetJWKS() myjwt.JWKS {
+	return s.tokenService.JWKS()
+}
+
+// issueTokens выпускает пару access/refresh токенов; пустой familyID открывает новую цепочку ротации
+func (s *authService) issueTokens(ctx context.Context, userID int, role string, familyID string) (*models.TokenPair, error) {
+	accessToken, err := s.tokenService.GenerateToken(userID, role, s.JWTConfig.TokenExpiry)
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to generate token",
+			"error", err,
+			"userID", userID,
+		)
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	refreshToken, err := s.tokenService.GenerateRefreshToken()
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to generate refresh token",
+			"error", err,
+			"userID", userID,
+		)
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	stored := &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: s.tokenService.HashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().UTC().Add(time.Duration(s.JWTConfig.RefreshTokenExpiry) * time.Second),
+	}
+
+	if _, err := s.repo.CreateRefreshToken(ctx, stored); err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to store refresh token",
 			"error", err,
+			"userID", userID,
 		)
-		return 0, fmt.Errorf("invalid token")
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
 	}
 
-	return userID, nil
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    s.JWTConfig.TokenExpiry,
+	}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"avito-tech-merch/internal/models"
 	mockRepo "avito-tech-merch/internal/storage/db/mock"
 	"avito-tech-merch/internal/storage/db/postgres"
+	myjwt "avito-tech-merch/pkg/jwt"
 	mockJWT "avito-tech-merch/pkg/jwt/mock"
 	mockLog "avito-tech-merch/pkg/logger/mock"
 	pass "avito-tech-merch/pkg/password"
@@ -50,20 +51,42 @@ func TestAuthService_Register_Success(t *testing.T) {
 		Return(nil, pgx.ErrNoRows)
 	repoMock.
 		On("CreateUser", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
-			return user.Username == username && user.PasswordHash != "" && user.Balance == 1000
+			return user.Username == username && user.PasswordHash != "" && user.Balance == 0
 		})).
 		Return(1, nil)
+	repoMock.
+		On("CreditBalance", mock.Anything, 1, welcomeGrant).
+		Return(welcomeGrant, nil)
+	repoMock.
+		On("CreateLedgerEntry", mock.Anything, matchLedgerEntry(models.LedgerReasonGrant, 1,
+			models.SystemPosting(models.AccountIssuance, -welcomeGrant),
+			models.UserPosting(1, welcomeGrant),
+		)).
+		Return(1, nil)
 	jwtMock.
-		On("GenerateToken", 1, jwtConfig.SecretKey, jwtConfig.TokenExpiry).
+		On("GenerateToken", 1, models.RoleEmployee, jwtConfig.TokenExpiry).
 		Return(expectedToken, nil)
+	jwtMock.
+		On("GenerateRefreshToken").
+		Return("refresh-token", nil)
+	jwtMock.
+		On("HashRefreshToken", "refresh-token").
+		Return("refresh-hash")
+	repoMock.
+		On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+			return token.UserID == 1 && token.TokenHash == "refresh-hash" && token.FamilyID == ""
+		})).
+		Return(1, nil)
 
-	token, err := authService.Register(ctx, username, password)
+	tokens, err := authService.Register(ctx, username, password)
 	assert.NoError(t, err)
-	assert.Equal(t, expectedToken, token)
+	assert.Equal(t, expectedToken, tokens.AccessToken)
+	assert.Equal(t, "refresh-token", tokens.RefreshToken)
+	assert.Equal(t, jwtConfig.TokenExpiry, tokens.ExpiresIn)
 
 	repoMock.AssertCalled(t, "GetUserByUsername", mock.Anything, username)
 	repoMock.AssertCalled(t, "CreateUser", mock.Anything, mock.Anything)
-	jwtMock.AssertCalled(t, "GenerateToken", 1, jwtConfig.SecretKey, jwtConfig.TokenExpiry)
+	jwtMock.AssertCalled(t, "GenerateToken", 1, models.RoleEmployee, jwtConfig.TokenExpiry)
 	txManagerMock.AssertExpectations(t)
 }
 
@@ -262,10 +285,16 @@ func TestAuthService_Register_GenerateTokenError(t *testing.T) {
 	repoMock.
 		On("CreateUser", mock.Anything, mock.AnythingOfType("*models.User")).
 		Return(1, nil)
+	repoMock.
+		On("CreditBalance", mock.Anything, 1, welcomeGrant).
+		Return(welcomeGrant, nil)
+	repoMock.
+		On("CreateLedgerEntry", mock.Anything, mock.AnythingOfType("*models.LedgerEntry")).
+		Return(1, nil)
 
 	expectedErr := errors.New("failed to generate token")
 	jwtMock.
-		On("GenerateToken", 1, jwtConfig.SecretKey, jwtConfig.TokenExpiry).
+		On("GenerateToken", 1, models.RoleEmployee, jwtConfig.TokenExpiry).
 		Return("", expectedErr)
 
 	loggerMock.
@@ -284,7 +313,7 @@ func TestAuthService_Register_GenerateTokenError(t *testing.T) {
 
 	repoMock.AssertCalled(t, "GetUserByUsername", mock.Anything, username)
 	repoMock.AssertCalled(t, "CreateUser", mock.Anything, mock.Anything)
-	jwtMock.AssertCalled(t, "GenerateToken", 1, jwtConfig.SecretKey, jwtConfig.TokenExpiry)
+	jwtMock.AssertCalled(t, "GenerateToken", 1, models.RoleEmployee, jwtConfig.TokenExpiry)
 	loggerMock.AssertCalled(t, "Errorw", "Failed to generate token",
 		"error", expectedErr,
 		"userID", 1,
@@ -350,7 +379,7 @@ func TestAuthService_Login_UserNotFound(t *testing.T) {
 
 	token, err := service.Login(ctx, username, password)
 	assert.Error(t, err)
-	assert.EqualError(t, err, "user not found")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
 	assert.Empty(t, token)
 
 	repoMock.AssertCalled(t, "GetUserByUsername", ctx, username)
@@ -416,7 +445,7 @@ func TestAuthService_Login_InvalidPassword(t *testing.T) {
 
 	token, err := service.Login(ctx, username, wrongPassword)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "invalid password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
 	assert.Empty(t, token)
 
 	repoMock.AssertCalled(t, "GetUserByUsername", ctx, username)
@@ -449,7 +478,7 @@ func TestAuthService_Login_TokenError(t *testing.T) {
 	repoMock.On("GetUserByUsername", ctx, username).Return(user, nil)
 
 	expectedErr := errors.New("token error")
-	jwtMock.On("GenerateToken", user.ID, jwtConfig.SecretKey, jwtConfig.TokenExpiry).Return("", expectedErr)
+	jwtMock.On("GenerateToken", user.ID, user.Role, jwtConfig.TokenExpiry).Return("", expectedErr)
 	loggerMock.On("Errorw", "Failed to generate token", "error", expectedErr, "userID", user.ID).Return()
 
 	token, err := service.Login(ctx, username, password)
@@ -458,7 +487,7 @@ func TestAuthService_Login_TokenError(t *testing.T) {
 	assert.Empty(t, token)
 
 	repoMock.AssertCalled(t, "GetUserByUsername", ctx, username)
-	jwtMock.AssertCalled(t, "GenerateToken", user.ID, jwtConfig.SecretKey, jwtConfig.TokenExpiry)
+	jwtMock.AssertCalled(t, "GenerateToken", user.ID, user.Role, jwtConfig.TokenExpiry)
 	loggerMock.AssertCalled(t, "Errorw", "Failed to generate token", "error", expectedErr, "userID", user.ID)
 }
 
@@ -484,17 +513,202 @@ func TestAuthService_Login_Success(t *testing.T) {
 		Username:     username,
 		PasswordHash: validHash,
 		Balance:      100,
+		Role:         models.RoleAdmin,
 	}
 	repoMock.On("GetUserByUsername", ctx, username).Return(user, nil)
 	expectedToken := "jwt-token"
-	jwtMock.On("GenerateToken", user.ID, jwtConfig.SecretKey, jwtConfig.TokenExpiry).Return(expectedToken, nil)
+	jwtMock.On("GenerateToken", user.ID, user.Role, jwtConfig.TokenExpiry).Return(expectedToken, nil)
+	jwtMock.On("GenerateRefreshToken").Return("refresh-token", nil)
+	jwtMock.On("HashRefreshToken", "refresh-token").Return("refresh-hash")
+	repoMock.On("CreateRefreshToken", ctx, mock.AnythingOfType("*models.RefreshToken")).Return(1, nil)
 
-	token, err := service.Login(ctx, username, password)
+	tokens, err := service.Login(ctx, username, password)
 	assert.NoError(t, err)
-	assert.Equal(t, expectedToken, token)
+	assert.Equal(t, expectedToken, tokens.AccessToken)
+	assert.Equal(t, "refresh-token", tokens.RefreshToken)
 
 	repoMock.AssertCalled(t, "GetUserByUsername", ctx, username)
-	jwtMock.AssertCalled(t, "GenerateToken", user.ID, jwtConfig.SecretKey, jwtConfig.TokenExpiry)
+	jwtMock.AssertCalled(t, "GenerateToken", user.ID, user.Role, jwtConfig.TokenExpiry)
+	repoMock.AssertCalled(t, "CreateRefreshToken", ctx, mock.AnythingOfType("*models.RefreshToken"))
+}
+
+func TestAuthService_Refresh_Success(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:          "secret",
+		TokenExpiry:        900,
+		RefreshTokenExpiry: 3600,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+	ctx := context.Background()
+
+	stored := &models.RefreshToken{
+		ID:        7,
+		UserID:    1,
+		FamilyID:  "family",
+		TokenHash: "old-hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			_ = fn(context.Background())
+		}).
+		Return(nil)
+
+	jwtMock.On("HashRefreshToken", "old-token").Return("old-hash")
+	repoMock.On("GetRefreshTokenByHash", mock.Anything, "old-hash").Return(stored, nil)
+	repoMock.On("MarkRefreshTokenUsed", mock.Anything, stored.ID).Return(nil)
+	// Роль в новом токене берётся из базы, а не из старой сессии
+	repoMock.On("GetUserByID", mock.Anything, stored.UserID).Return(&models.User{ID: stored.UserID, Role: models.RoleAdmin}, nil)
+	jwtMock.On("GenerateToken", stored.UserID, models.RoleAdmin, jwtConfig.TokenExpiry).Return("new-jwt-token", nil)
+	jwtMock.On("GenerateRefreshToken").Return("new-token", nil)
+	jwtMock.On("HashRefreshToken", "new-token").Return("new-hash")
+	repoMock.
+		On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(token *models.RefreshToken) bool {
+			return token.UserID == stored.UserID && token.FamilyID == stored.FamilyID && token.TokenHash == "new-hash"
+		})).
+		Return(8, nil)
+
+	tokens, err := service.Refresh(ctx, "old-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-jwt-token", tokens.AccessToken)
+	assert.Equal(t, "new-token", tokens.RefreshToken)
+
+	repoMock.AssertCalled(t, "MarkRefreshTokenUsed", mock.Anything, stored.ID)
+	repoMock.AssertNotCalled(t, "RevokeRefreshTokenFamily", mock.Anything, mock.Anything)
+	txManagerMock.AssertExpectations(t)
+}
+
+func TestAuthService_Refresh_NotFound(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:   "secret",
+		TokenExpiry: 900,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+	ctx := context.Background()
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			_ = fn(context.Background())
+		}).
+		Return(fmt.Errorf("invalid refresh token"))
+
+	jwtMock.On("HashRefreshToken", "unknown").Return("unknown-hash")
+	repoMock.On("GetRefreshTokenByHash", mock.Anything, "unknown-hash").Return(nil, pgx.ErrNoRows)
+	loggerMock.On("Infow", "Refresh token not found").Return()
+	loggerMock.On("Errorw", "Error during Refresh operation", "error", fmt.Errorf("invalid refresh token")).Return()
+
+	tokens, err := service.Refresh(ctx, "unknown")
+	assert.EqualError(t, err, "invalid refresh token")
+	assert.Nil(t, tokens)
+
+	jwtMock.AssertNotCalled(t, "GenerateToken", mock.Anything, mock.Anything, mock.Anything)
+	txManagerMock.AssertExpectations(t)
+}
+
+func TestAuthService_Refresh_Expired(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:   "secret",
+		TokenExpiry: 900,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+	ctx := context.Background()
+
+	stored := &models.RefreshToken{
+		ID:        7,
+		UserID:    1,
+		FamilyID:  "family",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			_ = fn(context.Background())
+		}).
+		Return(fmt.Errorf("refresh token expired"))
+
+	jwtMock.On("HashRefreshToken", "expired").Return("expired-hash")
+	repoMock.On("GetRefreshTokenByHash", mock.Anything, "expired-hash").Return(stored, nil)
+	loggerMock.On("Infow", "Refresh token expired", "userID", stored.UserID).Return()
+	loggerMock.On("Errorw", "Error during Refresh operation", "error", fmt.Errorf("refresh token expired")).Return()
+
+	tokens, err := service.Refresh(ctx, "expired")
+	assert.EqualError(t, err, "refresh token expired")
+	assert.Nil(t, tokens)
+
+	repoMock.AssertNotCalled(t, "MarkRefreshTokenUsed", mock.Anything, mock.Anything)
+	txManagerMock.AssertExpectations(t)
+}
+
+func TestAuthService_Refresh_ReuseRevokesFamily(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:   "secret",
+		TokenExpiry: 900,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+	ctx := context.Background()
+
+	usedAt := time.Now().Add(-time.Minute)
+	stored := &models.RefreshToken{
+		ID:        7,
+		UserID:    1,
+		FamilyID:  "family",
+		ExpiresAt: time.Now().Add(time.Hour),
+		UsedAt:    &usedAt,
+	}
+
+	// Отзыв цепочки должен быть зафиксирован, поэтому транзакция завершается без ошибки
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			assert.NoError(t, fn(context.Background()))
+		}).
+		Return(nil)
+
+	jwtMock.On("HashRefreshToken", "reused").Return("reused-hash")
+	repoMock.On("GetRefreshTokenByHash", mock.Anything, "reused-hash").Return(stored, nil)
+	repoMock.On("RevokeRefreshTokenFamily", mock.Anything, stored.FamilyID).Return(nil)
+	loggerMock.On("Warnw", "Refresh token reuse detected", "userID", stored.UserID, "familyID", stored.FamilyID).Return()
+
+	tokens, err := service.Refresh(ctx, "reused")
+	assert.EqualError(t, err, "refresh token reuse detected")
+	assert.Nil(t, tokens)
+
+	repoMock.AssertCalled(t, "RevokeRefreshTokenFamily", mock.Anything, stored.FamilyID)
+	repoMock.AssertNotCalled(t, "MarkRefreshTokenUsed", mock.Anything, mock.Anything)
+	jwtMock.AssertNotCalled(t, "GenerateToken", mock.Anything, mock.Anything, mock.Anything)
+	txManagerMock.AssertExpectations(t)
 }
 
 func TestAuthService_ValidateToken_Success(t *testing.T) {
@@ -508,18 +722,26 @@ func TestAuthService_ValidateToken_Success(t *testing.T) {
 		TokenExpiry: 3600,
 	}
 	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+	ctx := context.Background()
 
-	expectedUserID := 42
 	token := "valid-token"
+	expectedClaims := &myjwt.Claims{
+		UserID:    42,
+		TokenID:   "jti",
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
 
-	// Настраиваем токен-сервис: возвращаем корректный userID без ошибки
-	jwtMock.On("ParseJWTToken", token, jwtConfig.SecretKey).Return(expectedUserID, nil).Once()
+	// Настраиваем токен-сервис: возвращаем корректные claims без ошибки
+	jwtMock.On("ParseJWTToken", token).Return(expectedClaims, nil).Once()
+	repoMock.On("IsTokenRevoked", ctx, expectedClaims.TokenID).Return(false, nil).Once()
+	repoMock.On("GetTokensValidAfter", ctx, expectedClaims.UserID).Return(time.Time{}, nil).Once()
 
-	userID, err := service.ValidateToken(token)
+	claims, err := service.ValidateToken(ctx, token)
 	assert.NoError(t, err)
-	assert.Equal(t, expectedUserID, userID)
+	assert.Equal(t, expectedClaims, claims)
 
-	jwtMock.AssertCalled(t, "ParseJWTToken", token, jwtConfig.SecretKey)
+	jwtMock.AssertCalled(t, "ParseJWTToken", token)
 }
 
 func TestAuthService_ValidateToken_InvalidSignature(t *testing.T) {
@@ -538,16 +760,18 @@ func TestAuthService_ValidateToken_InvalidSignature(t *testing.T) {
 	signatureErr := jwt.ErrSignatureInvalid
 
 	// Настраиваем токен-сервис: возвращаем ошибку подписи.
-	jwtMock.On("ParseJWTToken", token, jwtConfig.SecretKey).Return(0, signatureErr).Once()
+	jwtMock.On("ParseJWTToken", token).Return(nil, signatureErr).Once()
 	loggerMock.On("Errorw", "Invalid token signature", "error", signatureErr).Once()
 
-	userID, err := service.ValidateToken(token)
+	claims, err := service.ValidateToken(context.Background(), token)
 	assert.Error(t, err)
-	assert.Equal(t, 0, userID)
-	assert.EqualError(t, err, "invalid token signature")
+	assert.Nil(t, claims)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.EqualError(t, err, "invalid token: bad signature")
 
-	jwtMock.AssertCalled(t, "ParseJWTToken", token, jwtConfig.SecretKey)
+	jwtMock.AssertCalled(t, "ParseJWTToken", token)
 	loggerMock.AssertCalled(t, "Errorw", "Invalid token signature", "error", signatureErr)
+	repoMock.AssertNotCalled(t, "IsTokenRevoked", mock.Anything, mock.Anything)
 }
 
 func TestAuthService_ValidateToken_InvalidToken(t *testing.T) {
@@ -566,14 +790,283 @@ func TestAuthService_ValidateToken_InvalidToken(t *testing.T) {
 	otherErr := errors.New("some token error")
 
 	// Настраиваем токен-сервис: возвращаем ошибку, отличную от ErrSignatureInvalid.
-	jwtMock.On("ParseJWTToken", token, jwtConfig.SecretKey).Return(0, otherErr).Once()
+	jwtMock.On("ParseJWTToken", token).Return(nil, otherErr).Once()
 	loggerMock.On("Errorw", "Invalid token", "error", otherErr).Once()
 
-	userID, err := service.ValidateToken(token)
+	claims, err := service.ValidateToken(context.Background(), token)
 	assert.Error(t, err)
-	assert.Equal(t, 0, userID)
-	assert.EqualError(t, err, "invalid token")
+	assert.Nil(t, claims)
+	assert.ErrorIs(t, err, ErrInvalidToken)
 
-	jwtMock.AssertCalled(t, "ParseJWTToken", token, jwtConfig.SecretKey)
+	jwtMock.AssertCalled(t, "ParseJWTToken", token)
 	loggerMock.AssertCalled(t, "Errorw", "Invalid token", "error", otherErr)
 }
+
+func TestAuthService_ValidateToken_Revoked(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:   "secret",
+		TokenExpiry: 3600,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+	ctx := context.Background()
+
+	token := "revoked-token"
+	claims := &myjwt.Claims{UserID: 42, TokenID: "jti", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+
+	jwtMock.On("ParseJWTToken", token).Return(claims, nil).Once()
+	repoMock.On("IsTokenRevoked", ctx, claims.TokenID).Return(true, nil).Once()
+	loggerMock.On("Infow", "Token revoked", "userID", claims.UserID).Once()
+
+	result, err := service.ValidateToken(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.EqualError(t, err, "invalid token: revoked")
+	assert.Nil(t, result)
+
+	repoMock.AssertNotCalled(t, "GetTokensValidAfter", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_ValidateToken_IssuedBeforeLogoutAll(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:   "secret",
+		TokenExpiry: 3600,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+	ctx := context.Background()
+
+	token := "old-token"
+	issuedAt := time.Now().Add(-time.Minute)
+	claims := &myjwt.Claims{UserID: 42, TokenID: "jti", IssuedAt: issuedAt, ExpiresAt: time.Now().Add(time.Hour)}
+
+	jwtMock.On("ParseJWTToken", token).Return(claims, nil).Once()
+	repoMock.On("IsTokenRevoked", ctx, claims.TokenID).Return(false, nil).Once()
+	repoMock.On("GetTokensValidAfter", ctx, claims.UserID).Return(issuedAt.Add(time.Second), nil).Once()
+	loggerMock.On("Infow", "Token issued before logout from all sessions", "userID", claims.UserID).Once()
+
+	result, err := service.ValidateToken(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.EqualError(t, err, "invalid token: revoked")
+	assert.Nil(t, result)
+}
+
+func TestAuthService_ValidateToken_RevocationCheckError(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:   "secret",
+		TokenExpiry: 3600,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+	ctx := context.Background()
+
+	token := "valid-token"
+	claims := &myjwt.Claims{UserID: 42, TokenID: "jti", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	dbErr := errors.New("db error")
+
+	jwtMock.On("ParseJWTToken", token).Return(claims, nil).Once()
+	repoMock.On("IsTokenRevoked", ctx, claims.TokenID).Return(false, dbErr).Once()
+	loggerMock.On("Errorw", "Failed to check token revocation", "error", dbErr, "userID", claims.UserID).Once()
+
+	result, err := service.ValidateToken(ctx, token)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, dbErr))
+	assert.NotErrorIs(t, err, ErrInvalidToken)
+	assert.Nil(t, result)
+}
+
+func TestAuthService_Logout_Success(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:   "secret",
+		TokenExpiry: 3600,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+
+	claims := &myjwt.Claims{UserID: 42, TokenID: "jti", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	stored := &models.RefreshToken{ID: 1, UserID: claims.UserID, FamilyID: "family"}
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			assert.NoError(t, fn(context.Background()))
+		}).
+		Return(nil)
+
+	repoMock.On("RevokeToken", mock.Anything, claims.TokenID, claims.UserID, claims.ExpiresAt).Return(nil).Once()
+	jwtMock.On("HashRefreshToken", "refresh-token").Return("refresh-hash").Once()
+	repoMock.On("GetRefreshTokenByHash", mock.Anything, "refresh-hash").Return(stored, nil).Once()
+	repoMock.On("RevokeRefreshTokenFamily", mock.Anything, stored.FamilyID).Return(nil).Once()
+
+	err := service.Logout(context.Background(), claims, "refresh-token")
+	assert.NoError(t, err)
+
+	repoMock.AssertCalled(t, "RevokeRefreshTokenFamily", mock.Anything, stored.FamilyID)
+	txManagerMock.AssertExpectations(t)
+}
+
+func TestAuthService_Logout_WithoutRefreshToken(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:   "secret",
+		TokenExpiry: 3600,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+
+	claims := &myjwt.Claims{UserID: 42, TokenID: "jti", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			assert.NoError(t, fn(context.Background()))
+		}).
+		Return(nil)
+
+	repoMock.On("RevokeToken", mock.Anything, claims.TokenID, claims.UserID, claims.ExpiresAt).Return(nil).Once()
+
+	err := service.Logout(context.Background(), claims, "")
+	assert.NoError(t, err)
+
+	repoMock.AssertNotCalled(t, "GetRefreshTokenByHash", mock.Anything, mock.Anything)
+	txManagerMock.AssertExpectations(t)
+}
+
+func TestAuthService_Logout_ForeignRefreshToken(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:   "secret",
+		TokenExpiry: 3600,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+
+	claims := &myjwt.Claims{UserID: 42, TokenID: "jti", IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	stored := &models.RefreshToken{ID: 1, UserID: 7, FamilyID: "family"}
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			assert.NoError(t, fn(context.Background()))
+		}).
+		Return(nil)
+
+	repoMock.On("RevokeToken", mock.Anything, claims.TokenID, claims.UserID, claims.ExpiresAt).Return(nil).Once()
+	jwtMock.On("HashRefreshToken", "foreign").Return("foreign-hash").Once()
+	repoMock.On("GetRefreshTokenByHash", mock.Anything, "foreign-hash").Return(stored, nil).Once()
+	loggerMock.On("Warnw", "Refresh token belongs to another user", "userID", claims.UserID, "ownerID", stored.UserID).Once()
+
+	err := service.Logout(context.Background(), claims, "foreign")
+	assert.NoError(t, err)
+
+	repoMock.AssertNotCalled(t, "RevokeRefreshTokenFamily", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_LogoutAll_Success(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:   "secret",
+		TokenExpiry: 3600,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+	userID := 42
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			assert.NoError(t, fn(context.Background()))
+		}).
+		Return(nil)
+
+	before := time.Now()
+	repoMock.
+		On("SetTokensValidAfter", mock.Anything, userID, mock.MatchedBy(func(validAfter time.Time) bool {
+			return !validAfter.Before(before)
+		})).
+		Return(nil).Once()
+	repoMock.On("RevokeUserRefreshTokens", mock.Anything, userID).Return(nil).Once()
+
+	err := service.LogoutAll(context.Background(), userID)
+	assert.NoError(t, err)
+
+	txManagerMock.AssertExpectations(t)
+}
+
+func TestAuthService_LogoutAll_Error(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	jwtConfig := config.JWTConfig{
+		SecretKey:   "secret",
+		TokenExpiry: 3600,
+	}
+	service := NewAuthService(repoMock, loggerMock, jwtConfig, jwtMock, txManagerMock)
+	userID := 42
+	dbErr := errors.New("db error")
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			_ = fn(context.Background())
+		}).
+		Return(dbErr)
+
+	repoMock.On("SetTokensValidAfter", mock.Anything, userID, mock.AnythingOfType("time.Time")).Return(dbErr).Once()
+	loggerMock.On("Errorw", "Failed to update tokens watermark", "error", dbErr, "userID", userID).Once()
+	loggerMock.On("Errorw", "Error during LogoutAll operation", "error", dbErr).Once()
+
+	err := service.LogoutAll(context.Background(), userID)
+	assert.Equal(t, dbErr, err)
+
+	repoMock.AssertNotCalled(t, "RevokeUserRefreshTokens", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_GetJWKS(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	jwtMock := mockJWT.NewTokenService(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	authService := NewAuthService(repoMock, loggerMock, config.JWTConfig{}, jwtMock, txManagerMock)
+
+	expected := myjwt.JWKS{Keys: []myjwt.JSONWebKey{{KeyType: "OKP", KeyID: "ed", Use: "sig", Algorithm: "EdDSA"}}}
+	jwtMock.On("JWKS").Return(expected).Once()
+
+	assert.Equal(t, expected, authService.GetJWKS())
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+)
+
+type cartService struct {
+	repo   db.Repository
+	logger logger.Logger
+}
+
+func NewCartService(repo db.Repository, log logger.Logger) CartService {
+	return &cartService{repo: repo, logger: log}
+}
+
+func (s *cartService) GetCart(ctx context.Context, userID int) (*models.Cart, error) {
+	items, err := s.repo.GetCartItems(ctx, userID)
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to get cart items",
+			"userID", userID,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return &models.Cart{UserID: userID, Items: items}, nil
+}
+
+// AddToCart добавляет товар в корзину. Количество в позиции не может превысить MaxPurchaseQuantity.
+// Остаток при этом не резервируется: он проверяется при оформлении заказа
+func (s *cartService) AddToCart(ctx context.Context, userID int, merchName string, quantity int) (*models.Cart, error) {
+	if err := validateQuantity(quantity); err != nil {
+		return nil, err
+	}
+
+	merch, err := s.findMerchByName(ctx, merchName)
+	if err != nil {
+		return nil, err
+	}
+
+	if merch.IsArchived() {
+		logger.WithContext(ctx, s.logger).Warnw("Attempt to add archived merch to cart",
+			"userID", userID,
+			"merchName", merchName,
+		)
+		return nil, ErrMerchUnavailable
+	}
+
+	total, err := s.repo.AddCartItem(ctx, userID, merch.ID, quantity, MaxPurchaseQuantity)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: cart line quantity must not exceed %d", ErrInvalidAmount, MaxPurchaseQuantity)
+		}
+		logger.WithContext(ctx, s.logger).Errorw("Failed to add cart item",
+			"userID", userID,
+			"merchName", merchName,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	logger.WithContext(ctx, s.logger).Infow("Merch added to cart",
+		"userID", userID,
+		"merchName", merchName,
+		"quantity", total,
+	)
+
+	return s.GetCart(ctx, userID)
+}
+
+func (s *cartService) RemoveFromCart(ctx context.Context, userID int, merchName string) (*models.Cart, error) {
+	merch, err := s.findMerchByName(ctx, merchName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RemoveCartItem(ctx, userID, merch.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMerchNotInCart
+		}
+		logger.WithContext(ctx, s.logger).Errorw("Failed to remove cart item",
+			"userID", userID,
+			"merchName", merchName,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return s.GetCart(ctx, userID)
+}
+
+func (s *cartService) findMerchByName(ctx context.Context, merchName string) (*models.Merch, error) {
+	merch, err := s.repo.GetMerchByName(ctx, merchName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrMerchNotFound, merchName)
+		}
+		logger.WithContext(ctx, s.logger).Errorw("Failed to get merch",
+			"merchName", merchName,
+			"error", err,
+		)
+		return nil, fmt.Errorf("failed to get merch: %w", err)
+	}
+
+	return merch, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"avito-tech-merch/internal/models"
+	mockRepo "avito-tech-merch/internal/storage/db/mock"
+	mockLog "avito-tech-merch/pkg/logger/mock"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestCartService_AddToCart_Success(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	service := NewCartService(repoMock, loggerMock)
+	ctx := context.Background()
+
+	merch := &models.Merch{ID: 3, Name: "pen", Price: 10}
+	repoMock.On("GetMerchByName", ctx, "pen").Return(merch, nil).Once()
+	repoMock.On("AddCartItem", ctx, 1, 3, 2, MaxPurchaseQuantity).Return(5, nil).Once()
+	repoMock.On("GetCartItems", ctx, 1).Return([]*models.CartItem{
+		{UserID: 1, MerchID: 3, Quantity: 5, Merch: merch},
+	}, nil).Once()
+	loggerMock.On("Infow", "Merch added to cart", "userID", 1, "merchName", "pen", "quantity", 5).Return().Once()
+
+	cart, err := service.AddToCart(ctx, 1, "pen", 2)
+	assert.NoError(t, err)
+	assert.Len(t, cart.Items, 1)
+	assert.Equal(t, 50, cart.Total())
+}
+
+func TestCartService_AddToCart_Errors(t *testing.T) {
+	archivedAt := time.Now()
+
+	tests := []struct {
+		name          string
+		quantity      int
+		merch         *models.Merch
+		merchErr      error
+		expectedError string
+	}{
+		{name: "Invalid quantity", quantity: 0, expectedError: "invalid amount: quantity must be between 1 and 100"},
+		{name: "Unknown merch", quantity: 1, merchErr: fmt.Errorf("failed to retrieve merchandise: %w", pgx.ErrNoRows), expectedError: "merch not found: pen"},
+		{name: "Archived merch", quantity: 1, merch: &models.Merch{ID: 3, Name: "pen", ArchivedAt: &archivedAt}, expectedError: "merch is not available"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoMock := mockRepo.NewRepository(t)
+			loggerMock := mockLog.NewLogger(t)
+			service := NewCartService(repoMock, loggerMock)
+			ctx := context.Background()
+
+			if tt.merch != nil || tt.merchErr != nil {
+				repoMock.On("GetMerchByName", ctx, "pen").Return(tt.merch, tt.merchErr).Once()
+			}
+			if tt.merch != nil {
+				loggerMock.On("Warnw", "Attempt to add archived merch to cart", "userID", 1, "merchName", "pen").Return().Once()
+			}
+
+			cart, err := service.AddToCart(ctx, 1, "pen", tt.quantity)
+			assert.EqualError(t, err, tt.expectedError)
+			assert.Nil(t, cart)
+			repoMock.AssertNotCalled(t, "AddCartItem", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCartService_AddToCart_LineLimitExceeded(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	service := NewCartService(repoMock, loggerMock)
+	ctx := context.Background()
+
+	// В позиции уже 90 штук: ещё 20 вывели бы её за MaxPurchaseQuantity
+	repoMock.On("GetMerchByName", ctx, "pen").Return(&models.Merch{ID: 3, Name: "pen", Price: 10}, nil).Once()
+	repoMock.On("AddCartItem", ctx, 1, 3, 20, MaxPurchaseQuantity).Return(0, fmt.Errorf("cart item quantity limit exceeded: %w", pgx.ErrNoRows)).Once()
+
+	cart, err := service.AddToCart(ctx, 1, "pen", 20)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+	assert.EqualError(t, err, "invalid amount: cart line quantity must not exceed 100")
+	assert.Nil(t, cart)
+	repoMock.AssertNotCalled(t, "GetCartItems", mock.Anything, mock.Anything)
+}
+
+func TestCartService_RemoveFromCart_NotInCart(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	service := NewCartService(repoMock, loggerMock)
+	ctx := context.Background()
+
+	repoMock.On("GetMerchByName", ctx, "pen").Return(&models.Merch{ID: 3, Name: "pen", Price: 10}, nil).Once()
+	repoMock.On("RemoveCartItem", ctx, 1, 3).Return(fmt.Errorf("cart item with merch ID 3 not found: %w", pgx.ErrNoRows)).Once()
+
+	cart, err := service.RemoveFromCart(ctx, 1, "pen")
+	assert.EqualError(t, err, "merch is not in cart")
+	assert.Nil(t, cart)
+}
+
+func TestCartService_GetCart(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	service := NewCartService(repoMock, loggerMock)
+	ctx := context.Background()
+
+	repoMock.On("GetCartItems", ctx, 1).Return(nil, nil).Once()
+
+	cart, err := service.GetCart(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Cart{UserID: 1}, cart)
+}
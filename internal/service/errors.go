@@ -0,0 +1,97 @@
+package service
+
+import "errors"
+
+// Доменные ошибки сервисов. Контроллеры различают их через errors.Is и отдают клиенту
+// с подходящим HTTP-статусом; всё остальное считается внутренней ошибкой
+var (
+	// ErrInvalidAmount возвращается, если сумма перевода или количество товара вне допустимого диапазона
+	ErrInvalidAmount = errors.New("invalid amount")
+
+	// ErrInvalidTransferMemo возвращается, если сообщение или категория перевода не прошли проверку
+	ErrInvalidTransferMemo = errors.New("invalid transfer memo")
+
+	// ErrReceiverNotFound возвращается, если получатель перевода не существует
+	ErrReceiverNotFound = errors.New("receiver not found")
+
+	// ErrSelfTransfer возвращается при попытке перевести монеты самому себе
+	ErrSelfTransfer = errors.New("cannot transfer to yourself")
+
+	// ErrInsufficientFunds возвращается, если на балансе не хватает монет для списания
+	ErrInsufficientFunds = errors.New("insufficient funds")
+
+	// ErrUserNotFound возвращается, если пользователь, от имени которого выполняется операция, не существует
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrMerchNotFound возвращается, если товара с таким названием нет в каталоге
+	ErrMerchNotFound = errors.New("merch not found")
+
+	// ErrMerchUnavailable возвращается, если товар снят с продажи
+	ErrMerchUnavailable = errors.New("merch is not available")
+
+	// ErrOutOfStock возвращается, если остатка товара не хватает на заказ
+	ErrOutOfStock = errors.New("out of stock")
+
+	// ErrPriceChanged возвращается, если цена товара изменилась между его чтением и оплатой;
+	// запрос можно повторить, чтобы купить по новой цене
+	ErrPriceChanged = errors.New("merch price has changed")
+
+	// ErrMerchNotInCart возвращается при удалении из корзины товара, которого в ней нет
+	ErrMerchNotInCart = errors.New("merch is not in cart")
+
+	// ErrCartEmpty возвращается при оформлении пустой корзины
+	ErrCartEmpty = errors.New("cart is empty")
+
+	// ErrPurchaseNotFound возвращается, если покупка не существует или недоступна запросившему
+	ErrPurchaseNotFound = errors.New("purchase not found")
+
+	// ErrAlreadyRefunded возвращается при повторном возврате покупки
+	ErrAlreadyRefunded = errors.New("purchase is already refunded")
+
+	// ErrRefundNotAllowed возвращается, если покупку нельзя вернуть из-за её статуса или истёкшего срока возврата
+	ErrRefundNotAllowed = errors.New("refund is not allowed")
+
+	// ErrInvalidPurchaseStatus возвращается, если статус покупки неизвестен
+	ErrInvalidPurchaseStatus = errors.New("invalid purchase status")
+
+	// ErrInvalidStatusTransition возвращается, если покупку нельзя перевести из текущего статуса в запрошенный
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+
+	// ErrInvalidCredentials возвращается при входе с неизвестным логином или неверным паролем;
+	// ответ не различает эти случаи, чтобы по нему нельзя было перебирать логины
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	// ErrInvalidToken возвращается, если access-токен повреждён, просрочен или отозван. Ошибки хранилища
+	// при проверке отзыва её не оборачивают: из-за них клиенту не нужно заново входить в систему
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrUserAlreadyExists возвращается при регистрации занятого логина
+	ErrUserAlreadyExists = errors.New("user already exists")
+
+	// ErrInvalidRole возвращается, если роль пользователя неизвестна
+	ErrInvalidRole = errors.New("invalid role")
+
+	// ErrOwnRoleChange возвращается при попытке администратора сменить собственную роль
+	ErrOwnRoleChange = errors.New("cannot change own role")
+
+	// ErrInvalidMerch возвращается, если название, цена или остаток товара не прошли проверку
+	ErrInvalidMerch = errors.New("invalid merch")
+
+	// ErrMerchAlreadyExists возвращается, если товар с таким названием уже есть в каталоге
+	ErrMerchAlreadyExists = errors.New("merch already exists")
+
+	// ErrMerchArchived возвращается при изменении товара, снятого с продажи
+	ErrMerchArchived = errors.New("merch is archived")
+
+	// ErrStockNotTracked возвращается при пополнении товара, остаток которого не ограничен
+	ErrStockNotTracked = errors.New("merch stock is not tracked")
+
+	// ErrMerchAlreadyArchived возвращается при повторном снятии товара с продажи
+	ErrMerchAlreadyArchived = errors.New("merch is already archived")
+
+	// ErrIdempotencyKeyReused возвращается, если ключ идемпотентности уже использован для другого запроса
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+	// ErrRequestInProgress возвращается, если параллельный запрос с тем же ключом идемпотентности ещё не завершён
+	ErrRequestInProgress = errors.New("request with this idempotency key is already in progress")
+)
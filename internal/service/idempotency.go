@@ -0,0 +1,127 @@
+package service
+
+import (
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+)
+
+// idempotencyRequest - запрос, переданный с заголовком Idempotency-Key
+type idempotencyRequest struct {
+	userID    int
+	key       string
+	operation string
+	hash      string
+}
+
+// newIdempotencyRequest возвращает nil, если ключ не передан: такой запрос выполняется без дедупликации.
+// Хеш считается по операции и её параметрам, поэтому ключ нельзя переиспользовать ни для другой суммы, ни для другой операции
+func newIdempotencyRequest(userID int, key string, operation string, params ...any) *idempotencyRequest {
+	if key == "" {
+		return nil
+	}
+
+	h := sha256.New()
+	h.Write([]byte(operation))
+	for _, param := range params {
+		fmt.Fprintf(h, "\x00%v", param)
+	}
+
+	return &idempotencyRequest{
+		userID:    userID,
+		key:       key,
+		operation: operation,
+		hash:      hex.EncodeToString(h.Sum(nil)),
+	}
+}
+
+// replayIdempotent ищет результат запроса с тем же ключом в текущей транзакции и, если он есть, записывает его в result.
+// Возвращает true, если запрос уже выполнялся и повторять его не нужно
+func replayIdempotent(ctx context.Context, repo db.IdempotencyRepository, log logger.Logger, request *idempotencyRequest, result any) (bool, error) {
+	if request == nil {
+		return false, nil
+	}
+
+	stored, err := repo.GetIdempotencyKey(ctx, request.userID, request.key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		logger.WithContext(ctx, log).Errorw("Failed to get idempotency key",
+			"userID", request.userID,
+			"operation", request.operation,
+			"error", err,
+		)
+		return false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if stored.RequestHash != request.hash {
+		logger.WithContext(ctx, log).Warnw("Idempotency key reused with a different request",
+			"userID", request.userID,
+			"operation", request.operation,
+			"storedOperation", stored.Operation,
+		)
+		return false, ErrIdempotencyKeyReused
+	}
+
+	if err := json.Unmarshal(stored.Response, result); err != nil {
+		logger.WithContext(ctx, log).Errorw("Failed to decode stored idempotent response",
+			"userID", request.userID,
+			"operation", request.operation,
+			"error", err,
+		)
+		return false, fmt.Errorf("failed to decode stored response: %w", err)
+	}
+
+	logger.WithContext(ctx, log).Infow("Replaying idempotent request",
+		"userID", request.userID,
+		"operation", request.operation,
+	)
+	return true, nil
+}
+
+// saveIdempotent сохраняет результат запроса в той же транзакции, что и изменение баланса:
+// при откате не остаётся ни списания, ни ключа
+func saveIdempotent(ctx context.Context, repo db.IdempotencyRepository, log logger.Logger, request *idempotencyRequest, result any) error {
+	if request == nil {
+		return nil
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response: %w", err)
+	}
+
+	err = repo.CreateIdempotencyKey(ctx, &models.IdempotencyKey{
+		UserID:      request.userID,
+		Key:         request.key,
+		Operation:   request.operation,
+		RequestHash: request.hash,
+		Response:    response,
+	})
+	if err != nil {
+		// Параллельный запрос с тем же ключом уже записал его: клиент получит исходный результат при следующем повторе
+		if IsUniqueViolation(err) {
+			logger.WithContext(ctx, log).Warnw("Concurrent request with the same idempotency key",
+				"userID", request.userID,
+				"operation", request.operation,
+			)
+			return ErrRequestInProgress
+		}
+		logger.WithContext(ctx, log).Errorw("Failed to save idempotency key",
+			"userID", request.userID,
+			"operation", request.operation,
+			"error", err,
+		)
+		return fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+
+	return nil
+}
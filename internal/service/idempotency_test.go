@@ -0,0 +1,169 @@
+package service
+
+import (
+	"avito-tech-merch/internal/models"
+	mockRepo "avito-tech-merch/internal/storage/db/mock"
+	mockLog "avito-tech-merch/pkg/logger/mock"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestNewIdempotencyRequest(t *testing.T) {
+	assert.Nil(t, newIdempotencyRequest(1, "", "TransferCoins", 2, 100))
+
+	request := newIdempotencyRequest(1, "key", "TransferCoins", 2, 100)
+	assert.Equal(t, request.hash, newIdempotencyRequest(1, "key", "TransferCoins", 2, 100).hash)
+	assert.NotEqual(t, request.hash, newIdempotencyRequest(1, "key", "TransferCoins", 2, 101).hash)
+	assert.NotEqual(t, request.hash, newIdempotencyRequest(1, "key", "PurchaseMerch", 2, 100).hash)
+	// Разделитель не даёт склеить соседние параметры в одинаковую строку
+	assert.NotEqual(t,
+		newIdempotencyRequest(1, "key", "PurchaseMerch", "pen1", 1).hash,
+		newIdempotencyRequest(1, "key", "PurchaseMerch", "pen", 11).hash,
+	)
+}
+
+func TestTransferCoins_IdempotencyKeySaved(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	repoMock.On("GetIdempotencyKey", mock.Anything, 1, "transfer-1").Return(nil, fmt.Errorf("failed to retrieve idempotency key: %w", pgx.ErrNoRows)).Once()
+	repoMock.On("LockBalances", mock.Anything, 1, 2).Return(map[int]int{1: 500, 2: 100}, nil).Once()
+	repoMock.On("DebitBalance", mock.Anything, 1, 100).Return(400, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 2, 100).Return(200, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, mock.AnythingOfType("*models.LedgerEntry")).Return(1, nil).Once()
+	repoMock.On("CreateTransaction", mock.Anything, mock.AnythingOfType("*models.Transaction")).Return(42, nil).Once()
+	repoMock.On("CreateIdempotencyKey", mock.Anything, mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+		var stored models.Transaction
+		return key.UserID == 1 && key.Key == "transfer-1" && key.Operation == "TransferCoins" &&
+			json.Unmarshal(key.Response, &stored) == nil && stored.ID == 42
+	})).Return(nil).Once()
+
+	transaction, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{ID: 2}, 100, models.TransferMemo{}, "transfer-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, transaction.ID)
+}
+
+func TestTransferCoins_IdempotentReplay(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	request := newIdempotencyRequest(1, "transfer-1", "TransferCoins", 2, "", 100, "", "")
+	response, _ := json.Marshal(&models.Transaction{ID: 42, SenderID: 1, ReceiverID: 2, Amount: 100})
+
+	repoMock.On("LockBalances", mock.Anything, 1, 2).Return(map[int]int{1: 500, 2: 100}, nil).Once()
+	repoMock.On("GetIdempotencyKey", mock.Anything, 1, "transfer-1").Return(&models.IdempotencyKey{
+		UserID: 1, Key: "transfer-1", Operation: "TransferCoins", RequestHash: request.hash, Response: response,
+	}, nil).Once()
+	loggerMock.On("Infow", "Replaying idempotent request", "userID", 1, "operation", "TransferCoins").Return().Once()
+
+	transaction, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{ID: 2}, 100, models.TransferMemo{}, "transfer-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, transaction.ID)
+	repoMock.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+	repoMock.AssertNotCalled(t, "CreateLedgerEntry", mock.Anything, mock.Anything)
+}
+
+func TestTransferCoins_IdempotencyKeyReusedWithDifferentPayload(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	original := newIdempotencyRequest(1, "transfer-1", "TransferCoins", 2, "", 100, "", "")
+
+	repoMock.On("LockBalances", mock.Anything, 1, 2).Return(map[int]int{1: 500, 2: 100}, nil).Once()
+	repoMock.On("GetIdempotencyKey", mock.Anything, 1, "transfer-1").Return(&models.IdempotencyKey{
+		UserID: 1, Key: "transfer-1", Operation: "TransferCoins", RequestHash: original.hash, Response: []byte(`{}`),
+	}, nil).Once()
+	loggerMock.On("Warnw", "Idempotency key reused with a different request",
+		"userID", 1, "operation", "TransferCoins", "storedOperation", "TransferCoins").Return().Once()
+	loggerMock.On("Errorw", "Error during TransferCoins operation",
+		"error", fmt.Errorf("idempotency key was already used for a different request")).Return().Once()
+
+	_, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{ID: 2}, 500, models.TransferMemo{}, "transfer-1")
+	assert.EqualError(t, err, "idempotency key was already used for a different request")
+	repoMock.AssertNotCalled(t, "CreateLedgerEntry", mock.Anything, mock.Anything)
+}
+
+func TestTransferCoins_ConcurrentIdempotencyKey(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	uniqueErr := fmt.Errorf("failed to create idempotency key: %w", &pgconn.PgError{Code: "23505"})
+
+	repoMock.On("GetIdempotencyKey", mock.Anything, 1, "transfer-1").Return(nil, fmt.Errorf("failed to retrieve idempotency key: %w", pgx.ErrNoRows)).Once()
+	repoMock.On("LockBalances", mock.Anything, 1, 2).Return(map[int]int{1: 500, 2: 100}, nil).Once()
+	repoMock.On("DebitBalance", mock.Anything, 1, 100).Return(400, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 2, 100).Return(200, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, mock.AnythingOfType("*models.LedgerEntry")).Return(1, nil).Once()
+	repoMock.On("CreateTransaction", mock.Anything, mock.AnythingOfType("*models.Transaction")).Return(42, nil).Once()
+	repoMock.On("CreateIdempotencyKey", mock.Anything, mock.AnythingOfType("*models.IdempotencyKey")).Return(uniqueErr).Once()
+	loggerMock.On("Warnw", "Concurrent request with the same idempotency key", "userID", 1, "operation", "TransferCoins").Return().Once()
+	loggerMock.On("Errorw", "Error during TransferCoins operation",
+		"error", fmt.Errorf("request with this idempotency key is already in progress")).Return().Once()
+
+	_, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{ID: 2}, 100, models.TransferMemo{}, "transfer-1")
+	assert.EqualError(t, err, "request with this idempotency key is already in progress")
+}
+
+func TestPurchaseMerch_IdempotentReplay(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+	repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 100}, nil).Once()
+
+	request := newIdempotencyRequest(1, "buy-1", "PurchaseMerch", "pen", 2)
+	response, _ := json.Marshal([]*models.Purchase{{ID: 7, UserID: 1, MerchID: 3, Quantity: 2, UnitPrice: 10}})
+
+	repoMock.On("GetIdempotencyKey", mock.Anything, 1, "buy-1").Return(&models.IdempotencyKey{
+		UserID: 1, Key: "buy-1", Operation: "PurchaseMerch", RequestHash: request.hash, Response: response,
+	}, nil).Once()
+	loggerMock.On("Infow", "Replaying idempotent request", "userID", 1, "operation", "PurchaseMerch").Return().Once()
+
+	purchase, err := service.PurchaseMerch(context.Background(), 1, "pen", 2, "buy-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, purchase.ID)
+	repoMock.AssertNotCalled(t, "GetMerchByName", mock.Anything, mock.Anything)
+	repoMock.AssertNotCalled(t, "CreateLedgerEntry", mock.Anything, mock.Anything)
+}
+
+func TestPurchaseMerch_IdempotencyKeySaved(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	repoMock.On("GetIdempotencyKey", mock.Anything, 1, "buy-1").Return(nil, fmt.Errorf("failed to retrieve idempotency key: %w", pgx.ErrNoRows)).Once()
+	repoMock.On("GetMerchByName", mock.Anything, "pen").Return(&models.Merch{ID: 3, Name: "pen", Price: 10}, nil).Once()
+	repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 100}, nil).Once()
+	repoMock.On("DecrementMerchStock", mock.Anything, 3, 10, 2).Return(nil, nil).Once()
+	repoMock.On("DebitBalance", mock.Anything, 1, 20).Return(80, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, mock.AnythingOfType("*models.LedgerEntry")).Return(1, nil).Once()
+	repoMock.On("CreatePurchase", mock.Anything, mock.AnythingOfType("*models.Purchase")).Return(7, nil).Once()
+	repoMock.On("CreatePurchaseStatusChange", mock.Anything, mock.AnythingOfType("*models.PurchaseStatusChange")).Return(1, nil).Once()
+	repoMock.On("CreateIdempotencyKey", mock.Anything, mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+		return key.UserID == 1 && key.Key == "buy-1" && key.Operation == "PurchaseMerch"
+	})).Return(nil).Once()
+
+	_, err := service.PurchaseMerch(context.Background(), 1, "pen", 2, "buy-1")
+	assert.NoError(t, err)
+}
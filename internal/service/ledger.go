@@ -0,0 +1,81 @@
+package service
+
+import (
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+)
+
+// welcomeGrant - количество монет, которое начисляется новому сотруднику при регистрации
+const welcomeGrant = 1000
+
+// postLedgerEntry записывает проводку в журнал и применяет её движения к балансам пользователей.
+// Балансы пользователей меняются только через журнал, поэтому несбалансированная проводка отклоняется до обращения к базе
+func postLedgerEntry(ctx context.Context, repo db.Repository, log logger.Logger, entry *models.LedgerEntry) error {
+	if !entry.IsBalanced() {
+		logger.WithContext(ctx, log).Errorw("Unbalanced ledger entry",
+			"reason", entry.Reason,
+			"referenceID", entry.ReferenceID,
+		)
+		return fmt.Errorf("ledger entry %s/%d is not balanced", entry.Reason, entry.ReferenceID)
+	}
+
+	for _, posting := range entry.Postings {
+		if posting.UserID == nil {
+			continue
+		}
+		if err := applyPosting(ctx, repo, log, posting); err != nil {
+			return err
+		}
+	}
+
+	if _, err := repo.CreateLedgerEntry(ctx, entry); err != nil {
+		logger.WithContext(ctx, log).Errorw("Failed to create ledger entry",
+			"reason", entry.Reason,
+			"referenceID", entry.ReferenceID,
+			"error", err,
+		)
+		return fmt.Errorf("failed to create ledger entry: %w", err)
+	}
+
+	return nil
+}
+
+// applyPosting меняет баланс пользователя одним условным UPDATE, без чтения и записи вычисленного значения,
+// поэтому параллельные списания не уводят баланс в минус даже при READ COMMITTED
+func applyPosting(ctx context.Context, repo db.UserRepository, log logger.Logger, posting *models.LedgerPosting) error {
+	userID := *posting.UserID
+
+	if posting.Delta < 0 {
+		if _, err := repo.DebitBalance(ctx, userID, -posting.Delta); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				logger.WithContext(ctx, log).Warnw("Insufficient funds",
+					"userID", userID,
+					"amount", -posting.Delta,
+				)
+				return ErrInsufficientFunds
+			}
+			logger.WithContext(ctx, log).Errorw("Failed to debit user balance",
+				"userID", userID,
+				"amount", -posting.Delta,
+				"error", err,
+			)
+			return fmt.Errorf("failed to debit user balance: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := repo.CreditBalance(ctx, userID, posting.Delta); err != nil {
+		logger.WithContext(ctx, log).Errorw("Failed to credit user balance",
+			"userID", userID,
+			"amount", posting.Delta,
+			"error", err,
+		)
+		return fmt.Errorf("failed to credit user balance: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"avito-tech-merch/internal/models"
+	mockRepo "avito-tech-merch/internal/storage/db/mock"
+	mockLog "avito-tech-merch/pkg/logger/mock"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+// matchLedgerEntry сопоставляет проводку по причине, ссылке и движениям (счёт и сумма, в порядке записи)
+func matchLedgerEntry(reason string, referenceID int, postings ...*models.LedgerPosting) any {
+	return mock.MatchedBy(func(entry *models.LedgerEntry) bool {
+		if entry.Reason != reason || entry.ReferenceID != referenceID || len(entry.Postings) != len(postings) {
+			return false
+		}
+		for i, posting := range postings {
+			if entry.Postings[i].Account != posting.Account || entry.Postings[i].Delta != posting.Delta {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestPostLedgerEntry_Success(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+
+	entry := models.NewLedgerEntry(models.LedgerReasonTransfer, 7, models.UserPosting(1, -50), models.UserPosting(2, 50))
+	repoMock.On("DebitBalance", mock.Anything, 1, 50).Return(950, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 2, 50).Return(1050, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, entry).Return(1, nil).Once()
+
+	err := postLedgerEntry(context.Background(), repoMock, loggerMock, entry)
+	assert.NoError(t, err)
+}
+
+func TestPostLedgerEntry_Unbalanced(t *testing.T) {
+	tests := []struct {
+		name     string
+		postings []*models.LedgerPosting
+	}{
+		{name: "coins created", postings: []*models.LedgerPosting{models.UserPosting(1, -50), models.UserPosting(2, 60)}},
+		{name: "single posting", postings: []*models.LedgerPosting{models.UserPosting(1, 0)}},
+		{name: "zero delta", postings: []*models.LedgerPosting{models.UserPosting(1, 0), models.UserPosting(2, 0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoMock := mockRepo.NewRepository(t)
+			loggerMock := mockLog.NewLogger(t)
+
+			entry := models.NewLedgerEntry(models.LedgerReasonTransfer, 7, tt.postings...)
+			loggerMock.On("Errorw", "Unbalanced ledger entry", "reason", models.LedgerReasonTransfer, "referenceID", 7).Return().Once()
+
+			err := postLedgerEntry(context.Background(), repoMock, loggerMock, entry)
+			assert.EqualError(t, err, "ledger entry transfer/7 is not balanced")
+			repoMock.AssertNotCalled(t, "CreateLedgerEntry", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestPostLedgerEntry_RepositoryError(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+
+	entry := models.NewLedgerEntry(models.LedgerReasonRefund, 3,
+		models.SystemPosting(models.AccountMerchStore, -20), models.UserPosting(1, 20))
+	dbErr := errors.New("ledger error")
+	repoMock.On("CreditBalance", mock.Anything, 1, 20).Return(20, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, entry).Return(0, dbErr).Once()
+	loggerMock.On("Errorw", "Failed to create ledger entry", "reason", models.LedgerReasonRefund, "referenceID", 3, "error", dbErr).Return().Once()
+
+	err := postLedgerEntry(context.Background(), repoMock, loggerMock, entry)
+	assert.ErrorIs(t, err, dbErr)
+	assert.Contains(t, err.Error(), "failed to create ledger entry")
+}
+
+func TestPostLedgerEntry_InsufficientFunds(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+
+	// Условное списание не нашло строку: параллельная операция уже потратила монеты
+	entry := models.NewLedgerEntry(models.LedgerReasonPurchase, 3,
+		models.UserPosting(1, -80), models.SystemPosting(models.AccountMerchStore, 80))
+	repoMock.On("DebitBalance", mock.Anything, 1, 80).Return(0, fmt.Errorf("failed to debit user balance: %w", pgx.ErrNoRows)).Once()
+	loggerMock.On("Warnw", "Insufficient funds", "userID", 1, "amount", 80).Return().Once()
+
+	err := postLedgerEntry(context.Background(), repoMock, loggerMock, entry)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	repoMock.AssertNotCalled(t, "CreateLedgerEntry", mock.Anything, mock.Anything)
+}
+
+func TestPostLedgerEntry_CreditError(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+
+	entry := models.NewLedgerEntry(models.LedgerReasonTransfer, 7, models.UserPosting(1, -50), models.UserPosting(2, 50))
+	dbErr := errors.New("connection reset")
+	repoMock.On("DebitBalance", mock.Anything, 1, 50).Return(950, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 2, 50).Return(0, dbErr).Once()
+	loggerMock.On("Errorw", "Failed to credit user balance", "userID", 2, "amount", 50, "error", dbErr).Return().Once()
+
+	err := postLedgerEntry(context.Background(), repoMock, loggerMock, entry)
+	assert.ErrorIs(t, err, dbErr)
+	assert.Contains(t, err.Error(), "failed to credit user balance")
+}
@@ -1,25 +1,32 @@
 package service
 
 import (
+	"avito-tech-merch/internal/metrics"
 	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/storage/db/postgres"
 	"avito-tech-merch/pkg/logger"
 	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strings"
 )
 
 type merchService struct {
-	repo   db.Repository
-	logger logger.Logger
+	repo      db.Repository
+	logger    logger.Logger
+	txManager db.TxManager
 }
 
-func NewMerchService(repo db.Repository, log logger.Logger) MerchService {
-	return &merchService{repo: repo, logger: log}
+func NewMerchService(repo db.Repository, log logger.Logger, txManager db.TxManager) MerchService {
+	return &merchService{repo: repo, logger: log, txManager: txManager}
 }
 
 func (s *merchService) ListMerch(ctx context.Context) ([]*models.Merch, error) {
-	merchList, err := s.repo.GetAllMerch(ctx)
+	merchList, err := s.repo.GetAllMerch(ctx, false)
 	if err != nil {
-		s.logger.Errorw("Failed to fetch merch list",
+		logger.WithContext(ctx, s.logger).Errorw("Failed to fetch merch list",
 			"error", err,
 		)
 		return nil, err
@@ -31,7 +38,7 @@ func (s *merchService) ListMerch(ctx context.Context) ([]*models.Merch, error) {
 func (s *merchService) GetMerch(ctx context.Context, merchID int) (*models.Merch, error) {
 	merch, err := s.repo.GetMerchByID(ctx, merchID)
 	if err != nil {
-		s.logger.Errorw("Failed to fetch merch",
+		logger.WithContext(ctx, s.logger).Errorw("Failed to fetch merch",
 			"merchID", merchID,
 			"error", err,
 		)
@@ -40,3 +47,327 @@ func (s *merchService) GetMerch(ctx context.Context, merchID int) (*models.Merch
 
 	return merch, nil
 }
+
+// ListAllMerch возвращает каталог вместе с архивными товарами
+func (s *merchService) ListAllMerch(ctx context.Context) ([]*models.Merch, error) {
+	merchList, err := s.repo.GetAllMerch(ctx, true)
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to fetch full merch list",
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return merchList, nil
+}
+
+// CreateMerch добавляет товар в каталог и открывает его историю цен
+func (s *merchService) CreateMerch(ctx context.Context, adminID int, name string, price int, stock *int) (*models.Merch, error) {
+	name = strings.TrimSpace(name)
+	if err := validateMerch(name, price); err != nil {
+		return nil, err
+	}
+	if stock != nil && *stock < 0 {
+		return nil, fmt.Errorf("%w: stock cannot be negative", ErrInvalidMerch)
+	}
+
+	merch := &models.Merch{Name: name, Price: price, Stock: stock}
+
+	err := s.txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
+		if _, err := s.repo.CreateMerch(txCtx, merch); err != nil {
+			if IsUniqueViolation(err) {
+				logger.WithContext(ctx, s.logger).Infow("Merch already exists",
+					"merchName", name,
+				)
+				return ErrMerchAlreadyExists
+			}
+			logger.WithContext(ctx, s.logger).Errorw("Failed to create merch",
+				"merchName", name,
+				"error", err,
+			)
+			return err
+		}
+
+		return s.recordPrice(txCtx, adminID, merch)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithContext(ctx, s.logger).Infow("Merch created",
+		"merchID", merch.ID,
+		"merchName", merch.Name,
+		"price", merch.Price,
+	)
+
+	if merch.HasStockLimit() {
+		metrics.RecordMerchStock(merch.Name, *merch.Stock)
+	}
+
+	return merch, nil
+}
+
+// UpdateMerch меняет название и цену товара; новая цена попадает в историю цен
+func (s *merchService) UpdateMerch(ctx context.Context, adminID int, merchID int, name string, price int) (*models.Merch, error) {
+	name = strings.TrimSpace(name)
+	if err := validateMerch(name, price); err != nil {
+		return nil, err
+	}
+
+	var (
+		merch   *models.Merch
+		oldName string
+	)
+
+	err := s.txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
+		var err error
+		merch, err = s.findMerch(txCtx, merchID)
+		if err != nil {
+			return err
+		}
+
+		if merch.IsArchived() {
+			logger.WithContext(ctx, s.logger).Warnw("Attempt to update archived merch",
+				"merchID", merchID,
+			)
+			return ErrMerchArchived
+		}
+
+		oldName = merch.Name
+		priceChanged := merch.Price != price
+		merch.Name = name
+		merch.Price = price
+
+		if err := s.repo.UpdateMerch(txCtx, merch); err != nil {
+			if IsUniqueViolation(err) {
+				logger.WithContext(ctx, s.logger).Infow("Merch already exists",
+					"merchName", name,
+				)
+				return ErrMerchAlreadyExists
+			}
+			logger.WithContext(ctx, s.logger).Errorw("Failed to update merch",
+				"merchID", merchID,
+				"error", err,
+			)
+			return err
+		}
+
+		if !priceChanged {
+			return nil
+		}
+
+		return s.recordPrice(txCtx, adminID, merch)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithContext(ctx, s.logger).Infow("Merch updated",
+		"merchID", merch.ID,
+		"merchName", merch.Name,
+		"price", merch.Price,
+	)
+
+	// Серии метрик остатка привязаны к имени товара
+	if merch.HasStockLimit() && oldName != merch.Name {
+		metrics.ClearMerchStock(oldName)
+		metrics.RecordMerchStock(merch.Name, *merch.Stock)
+	}
+
+	return merch, nil
+}
+
+// ArchiveMerch снимает товар с продажи; запись остаётся, чтобы по ней разрешались прошлые покупки
+func (s *merchService) ArchiveMerch(ctx context.Context, merchID int) error {
+	merch, err := s.findMerch(ctx, merchID)
+	if err != nil {
+		return err
+	}
+
+	if merch.IsArchived() {
+		return ErrMerchAlreadyArchived
+	}
+
+	if err := s.repo.ArchiveMerch(ctx, merchID); err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to archive merch",
+			"merchID", merchID,
+			"error", err,
+		)
+		return err
+	}
+
+	logger.WithContext(ctx, s.logger).Infow("Merch archived",
+		"merchID", merchID,
+		"merchName", merch.Name,
+	)
+
+	metrics.ClearMerchStock(merch.Name)
+
+	return nil
+}
+
+// RestockMerch пополняет остаток товара на quantity единиц. Товар без учёта остатка не пополняется:
+// иначе он молча стал бы ограниченным ровно этим количеством
+func (s *merchService) RestockMerch(ctx context.Context, merchID int, quantity int) (*models.Merch, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("%w: restock quantity must be positive", ErrInvalidAmount)
+	}
+
+	merch, err := s.findMerch(ctx, merchID)
+	if err != nil {
+		return nil, err
+	}
+
+	if merch.IsArchived() {
+		return nil, ErrMerchArchived
+	}
+	if !merch.HasStockLimit() {
+		return nil, ErrStockNotTracked
+	}
+
+	stock, err := s.repo.RestockMerch(ctx, merchID, quantity)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, s.stockChangeRejected(ctx, merchID, true)
+		}
+		logger.WithContext(ctx, s.logger).Errorw("Failed to restock merch",
+			"merchID", merchID,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	merch.Stock = &stock
+	metrics.RecordMerchStock(merch.Name, stock)
+
+	logger.WithContext(ctx, s.logger).Infow("Merch restocked",
+		"merchID", merchID,
+		"quantity", quantity,
+		"stock", stock,
+	)
+
+	return merch, nil
+}
+
+// SetMerchStock задаёт остаток товара напрямую (например, после инвентаризации); nil снимает ограничение
+func (s *merchService) SetMerchStock(ctx context.Context, merchID int, stock *int) (*models.Merch, error) {
+	if stock != nil && *stock < 0 {
+		return nil, fmt.Errorf("%w: stock cannot be negative", ErrInvalidMerch)
+	}
+
+	merch, err := s.findMerch(ctx, merchID)
+	if err != nil {
+		return nil, err
+	}
+
+	if merch.IsArchived() {
+		return nil, ErrMerchArchived
+	}
+
+	if err := s.repo.SetMerchStock(ctx, merchID, stock); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, s.stockChangeRejected(ctx, merchID, false)
+		}
+		logger.WithContext(ctx, s.logger).Errorw("Failed to set merch stock",
+			"merchID", merchID,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	merch.Stock = stock
+	if merch.HasStockLimit() {
+		metrics.RecordMerchStock(merch.Name, *stock)
+	} else {
+		metrics.ClearMerchStock(merch.Name)
+	}
+
+	logger.WithContext(ctx, s.logger).Infow("Merch stock set",
+		"merchID", merchID,
+		"stock", stock,
+	)
+
+	return merch, nil
+}
+
+// GetMerchPriceHistory возвращает историю цен товара от самой ранней к текущей
+func (s *merchService) GetMerchPriceHistory(ctx context.Context, merchID int) ([]*models.MerchPrice, error) {
+	if _, err := s.findMerch(ctx, merchID); err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.GetMerchPriceHistory(ctx, merchID)
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to fetch merch price history",
+			"merchID", merchID,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (s *merchService) recordPrice(ctx context.Context, adminID int, merch *models.Merch) error {
+	price := &models.MerchPrice{
+		MerchID:   merch.ID,
+		Price:     merch.Price,
+		ChangedBy: &adminID,
+	}
+
+	if _, err := s.repo.CreateMerchPrice(ctx, price); err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to record merch price",
+			"merchID", merch.ID,
+			"error", err,
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (s *merchService) findMerch(ctx context.Context, merchID int) (*models.Merch, error) {
+	merch, err := s.repo.GetMerchByID(ctx, merchID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.WithContext(ctx, s.logger).Infow("Merch not found",
+				"merchID", merchID,
+			)
+			return nil, ErrMerchNotFound
+		}
+		logger.WithContext(ctx, s.logger).Errorw("Failed to fetch merch",
+			"merchID", merchID,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return merch, nil
+}
+
+// stockChangeRejected объясняет, почему UPDATE остатка не затронул строку: после проверки товар удалили,
+// сняли с продажи или (для пополнения, requireLimit) перевели на неограниченный остаток
+func (s *merchService) stockChangeRejected(ctx context.Context, merchID int, requireLimit bool) error {
+	merch, err := s.findMerch(ctx, merchID)
+	if err != nil {
+		return err
+	}
+	if merch.IsArchived() {
+		return ErrMerchArchived
+	}
+	if requireLimit && !merch.HasStockLimit() {
+		return ErrStockNotTracked
+	}
+	return fmt.Errorf("merch with ID %d changed concurrently", merchID)
+}
+
+func validateMerch(name string, price int) error {
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidMerch)
+	}
+	if price <= 0 {
+		return fmt.Errorf("%w: price must be positive", ErrInvalidMerch)
+	}
+
+	return nil
+}
@@ -6,8 +6,12 @@ import (
 	mockLog "avito-tech-merch/pkg/logger/mock"
 	"context"
 	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"testing"
+	"time"
 )
 
 func TestListMerch(t *testing.T) {
@@ -21,9 +25,10 @@ func TestListMerch(t *testing.T) {
 
 	ctx := context.Background()
 
-	repoMock.On("GetAllMerch", ctx).Return(expectedMerch, nil)
+	repoMock.On("GetAllMerch", ctx, false).Return(expectedMerch, nil)
 
-	service := NewMerchService(repoMock, loggerMock)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
 
 	merchList, err := service.ListMerch(ctx)
 
@@ -39,14 +44,15 @@ func TestListMerch_Error(t *testing.T) {
 
 	expectedError := assert.AnError
 
-	repoMock.On("GetAllMerch", ctx).Return(nil, expectedError)
+	repoMock.On("GetAllMerch", ctx, false).Return(nil, expectedError)
 
 	loggerMock.On("Errorw",
 		"Failed to fetch merch list",
 		"error", expectedError,
 	).Return()
 
-	service := NewMerchService(repoMock, loggerMock)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
 
 	merchList, err := service.ListMerch(ctx)
 
@@ -55,7 +61,7 @@ func TestListMerch_Error(t *testing.T) {
 
 	assert.Nil(t, merchList)
 
-	repoMock.AssertCalled(t, "GetAllMerch", ctx)
+	repoMock.AssertCalled(t, "GetAllMerch", ctx, false)
 
 	loggerMock.AssertCalled(t, "Errorw",
 		"Failed to fetch merch list",
@@ -110,7 +116,8 @@ func TestGetMerch(t *testing.T) {
 				loggerMock.On("Errorw", "Failed to fetch merch", "merchID", tt.merchID, "error", tt.mockError).Return()
 			}
 
-			service := NewMerchService(repoMock, loggerMock)
+			txManagerMock := mockRepo.NewTxManager(t)
+			service := NewMerchService(repoMock, loggerMock, txManagerMock)
 
 			merch, err := service.GetMerch(ctx, tt.merchID)
 
@@ -130,3 +137,356 @@ func TestGetMerch(t *testing.T) {
 		})
 	}
 }
+
+func TestListAllMerch(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+
+	archivedAt := time.Now()
+	expectedMerch := []*models.Merch{
+		{ID: 1, Name: "cup", Price: 20},
+		{ID: 2, Name: "old-cup", Price: 15, ArchivedAt: &archivedAt},
+	}
+
+	ctx := context.Background()
+	repoMock.On("GetAllMerch", ctx, true).Return(expectedMerch, nil)
+
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+
+	merchList, err := service.ListAllMerch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedMerch, merchList)
+}
+
+func TestCreateMerch(t *testing.T) {
+	tests := []struct {
+		name          string
+		merchName     string
+		price         int
+		stock         *int
+		repoErr       error
+		expectedError string
+	}{
+		{name: "Success", merchName: " sticker ", price: 5},
+		{name: "Negative stock", merchName: "sticker", price: 5, stock: intPtr(-1), expectedError: "invalid merch: stock cannot be negative"},
+		{name: "Empty name", merchName: "  ", price: 5, expectedError: "invalid merch: name is required"},
+		{name: "Non-positive price", merchName: "sticker", price: 0, expectedError: "invalid merch: price must be positive"},
+		{
+			name:          "Duplicate name",
+			merchName:     "cup",
+			price:         20,
+			repoErr:       errors.New("failed to create merch: ERROR: duplicate key value (SQLSTATE 23505)"),
+			expectedError: "merch already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoMock := mockRepo.NewRepository(t)
+			loggerMock := mockLog.NewLogger(t)
+			txManagerMock := mockRepo.NewTxManager(t)
+			service := NewMerchService(repoMock, loggerMock, txManagerMock)
+			ctx := context.Background()
+
+			if tt.expectedError == "" || tt.repoErr != nil {
+				setupReadCommittedTx(txManagerMock)
+				repoMock.On("CreateMerch", ctx, mock.AnythingOfType("*models.Merch")).
+					Run(func(args mock.Arguments) {
+						args.Get(1).(*models.Merch).ID = 11
+					}).
+					Return(11, tt.repoErr).Once()
+			}
+			if tt.repoErr != nil {
+				loggerMock.On("Infow", "Merch already exists", "merchName", tt.merchName).Return().Once()
+			} else if tt.expectedError == "" {
+				repoMock.On("CreateMerchPrice", ctx, &models.MerchPrice{MerchID: 11, Price: tt.price, ChangedBy: intPtr(1)}).Return(1, nil).Once()
+				loggerMock.On("Infow", "Merch created", "merchID", 11, "merchName", "sticker", "price", tt.price).Return().Once()
+			}
+
+			merch, err := service.CreateMerch(ctx, 1, tt.merchName, tt.price, tt.stock)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Nil(t, merch)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, &models.Merch{ID: 11, Name: "sticker", Price: 5}, merch)
+		})
+	}
+}
+
+func TestUpdateMerch_Success(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+	setupReadCommittedTx(txManagerMock)
+
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", Price: 20}, nil).Once()
+	repoMock.On("UpdateMerch", ctx, &models.Merch{ID: 2, Name: "mug", Price: 25}).Return(nil).Once()
+	repoMock.On("CreateMerchPrice", ctx, &models.MerchPrice{MerchID: 2, Price: 25, ChangedBy: intPtr(1)}).Return(7, nil).Once()
+	loggerMock.On("Infow", "Merch updated", "merchID", 2, "merchName", "mug", "price", 25).Return().Once()
+
+	merch, err := service.UpdateMerch(ctx, 1, 2, "mug", 25)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Merch{ID: 2, Name: "mug", Price: 25}, merch)
+}
+
+func TestUpdateMerch_NotFound(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+	setupReadCommittedTx(txManagerMock)
+
+	repoMock.On("GetMerchByID", ctx, 99).Return(nil, fmt.Errorf("failed to retrieve merch: %w", pgx.ErrNoRows)).Once()
+	loggerMock.On("Infow", "Merch not found", "merchID", 99).Return().Once()
+
+	merch, err := service.UpdateMerch(ctx, 1, 99, "mug", 25)
+	assert.EqualError(t, err, "merch not found")
+	assert.Nil(t, merch)
+	repoMock.AssertNotCalled(t, "UpdateMerch", mock.Anything, mock.Anything)
+}
+
+func TestUpdateMerch_Archived(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+	setupReadCommittedTx(txManagerMock)
+
+	archivedAt := time.Now()
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", Price: 20, ArchivedAt: &archivedAt}, nil).Once()
+	loggerMock.On("Warnw", "Attempt to update archived merch", "merchID", 2).Return().Once()
+
+	merch, err := service.UpdateMerch(ctx, 1, 2, "mug", 25)
+	assert.EqualError(t, err, "merch is archived")
+	assert.Nil(t, merch)
+	repoMock.AssertNotCalled(t, "UpdateMerch", mock.Anything, mock.Anything)
+}
+
+func TestArchiveMerch_Success(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", Price: 20}, nil).Once()
+	repoMock.On("ArchiveMerch", ctx, 2).Return(nil).Once()
+	loggerMock.On("Infow", "Merch archived", "merchID", 2, "merchName", "cup").Return().Once()
+
+	err := service.ArchiveMerch(ctx, 2)
+	assert.NoError(t, err)
+}
+
+func TestArchiveMerch_AlreadyArchived(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+
+	archivedAt := time.Now()
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", ArchivedAt: &archivedAt}, nil).Once()
+
+	err := service.ArchiveMerch(ctx, 2)
+	assert.EqualError(t, err, "merch is already archived")
+	repoMock.AssertNotCalled(t, "ArchiveMerch", mock.Anything, mock.Anything)
+}
+
+func TestRestockMerch_Success(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", Price: 20, Stock: intPtr(3)}, nil).Once()
+	repoMock.On("RestockMerch", ctx, 2, 10).Return(13, nil).Once()
+	loggerMock.On("Infow", "Merch restocked", "merchID", 2, "quantity", 10, "stock", 13).Return().Once()
+
+	merch, err := service.RestockMerch(ctx, 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, intPtr(13), merch.Stock)
+}
+
+func TestRestockMerch_InvalidQuantity(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+
+	merch, err := service.RestockMerch(context.Background(), 2, 0)
+	assert.EqualError(t, err, "invalid amount: restock quantity must be positive")
+	assert.Nil(t, merch)
+	repoMock.AssertNotCalled(t, "RestockMerch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRestockMerch_Archived(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+
+	archivedAt := time.Now()
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", ArchivedAt: &archivedAt}, nil).Once()
+
+	merch, err := service.RestockMerch(ctx, 2, 5)
+	assert.EqualError(t, err, "merch is archived")
+	assert.Nil(t, merch)
+	repoMock.AssertNotCalled(t, "RestockMerch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRestockMerch_Unlimited(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+
+	// Пополнение не должно превращать неограниченный товар в товар с остатком ровно в quantity
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", Price: 20}, nil).Once()
+
+	merch, err := service.RestockMerch(ctx, 2, 5)
+	assert.ErrorIs(t, err, ErrStockNotTracked)
+	assert.Nil(t, merch)
+	repoMock.AssertNotCalled(t, "RestockMerch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRestockMerch_ArchivedConcurrently(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+
+	// Товар сняли с продажи между проверкой и UPDATE: условие в UPDATE не даёт изменить остаток
+	archivedAt := time.Now()
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", Price: 20, Stock: intPtr(3)}, nil).Once()
+	repoMock.On("RestockMerch", ctx, 2, 5).Return(0, fmt.Errorf("merch with ID 2 not found, archived or without stock limit: %w", pgx.ErrNoRows)).Once()
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", Price: 20, Stock: intPtr(3), ArchivedAt: &archivedAt}, nil).Once()
+
+	merch, err := service.RestockMerch(ctx, 2, 5)
+	assert.ErrorIs(t, err, ErrMerchArchived)
+	assert.Nil(t, merch)
+}
+
+func TestSetMerchStock(t *testing.T) {
+	tests := []struct {
+		name  string
+		stock *int
+	}{
+		{name: "Exact value", stock: intPtr(7)},
+		{name: "Unlimited", stock: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoMock := mockRepo.NewRepository(t)
+			loggerMock := mockLog.NewLogger(t)
+			txManagerMock := mockRepo.NewTxManager(t)
+			service := NewMerchService(repoMock, loggerMock, txManagerMock)
+			ctx := context.Background()
+
+			repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", Price: 20, Stock: intPtr(1)}, nil).Once()
+			repoMock.On("SetMerchStock", ctx, 2, tt.stock).Return(nil).Once()
+			loggerMock.On("Infow", "Merch stock set", "merchID", 2, "stock", tt.stock).Return().Once()
+
+			merch, err := service.SetMerchStock(ctx, 2, tt.stock)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.stock, merch.Stock)
+		})
+	}
+}
+
+func TestSetMerchStock_Negative(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+
+	merch, err := service.SetMerchStock(context.Background(), 2, intPtr(-5))
+	assert.EqualError(t, err, "invalid merch: stock cannot be negative")
+	assert.Nil(t, merch)
+	repoMock.AssertNotCalled(t, "GetMerchByID", mock.Anything, mock.Anything)
+}
+
+func TestSetMerchStock_Archived(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+
+	archivedAt := time.Now()
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", ArchivedAt: &archivedAt}, nil).Once()
+
+	merch, err := service.SetMerchStock(ctx, 2, intPtr(5))
+	assert.EqualError(t, err, "merch is archived")
+	assert.Nil(t, merch)
+	repoMock.AssertNotCalled(t, "SetMerchStock", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetMerchStock_ArchivedConcurrently(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+
+	archivedAt := time.Now()
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", Price: 20}, nil).Once()
+	repoMock.On("SetMerchStock", ctx, 2, intPtr(5)).Return(fmt.Errorf("merch with ID 2 not found or archived: %w", pgx.ErrNoRows)).Once()
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", Price: 20, ArchivedAt: &archivedAt}, nil).Once()
+
+	merch, err := service.SetMerchStock(ctx, 2, intPtr(5))
+	assert.ErrorIs(t, err, ErrMerchArchived)
+	assert.Nil(t, merch)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestUpdateMerch_RenameKeepsPriceHistory(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+	setupReadCommittedTx(txManagerMock)
+
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "cup", Price: 20}, nil).Once()
+	repoMock.On("UpdateMerch", ctx, &models.Merch{ID: 2, Name: "mug", Price: 20}).Return(nil).Once()
+	loggerMock.On("Infow", "Merch updated", "merchID", 2, "merchName", "mug", "price", 20).Return().Once()
+
+	_, err := service.UpdateMerch(ctx, 1, 2, "mug", 20)
+	assert.NoError(t, err)
+	repoMock.AssertNotCalled(t, "CreateMerchPrice", mock.Anything, mock.Anything)
+}
+
+func TestGetMerchPriceHistory(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewMerchService(repoMock, loggerMock, txManagerMock)
+	ctx := context.Background()
+
+	history := []*models.MerchPrice{
+		{ID: 1, MerchID: 2, Price: 20},
+		{ID: 7, MerchID: 2, Price: 25, ChangedBy: intPtr(1)},
+	}
+	repoMock.On("GetMerchByID", ctx, 2).Return(&models.Merch{ID: 2, Name: "mug", Price: 25}, nil).Once()
+	repoMock.On("GetMerchPriceHistory", ctx, 2).Return(history, nil).Once()
+
+	result, err := service.GetMerchPriceHistory(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, history, result)
+}
@@ -3,10 +3,12 @@
 package mock
 
 import (
-	models "avito-tech-merch/internal/models"
+	jwt "avito-tech-merch/pkg/jwt"
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	models "avito-tech-merch/internal/models"
 )
 
 // Service is an autogenerated mock type for the Service type
@@ -14,24 +16,132 @@ type Service struct {
 	mock.Mock
 }
 
-// GetInfo provides a mock function with given fields: ctx, userID
-func (_m *Service) GetInfo(ctx context.Context, userID int) (*models.UserInfo, error) {
+// AddToCart provides a mock function with given fields: ctx, userID, merchName, quantity
+func (_m *Service) AddToCart(ctx context.Context, userID int, merchName string, quantity int) (*models.Cart, error) {
+	ret := _m.Called(ctx, userID, merchName, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddToCart")
+	}
+
+	var r0 *models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int) (*models.Cart, error)); ok {
+		return rf(ctx, userID, merchName, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int) *models.Cart); ok {
+		r0 = rf(ctx, userID, merchName, quantity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, int) error); ok {
+		r1 = rf(ctx, userID, merchName, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ArchiveMerch provides a mock function with given fields: ctx, merchID
+func (_m *Service) ArchiveMerch(ctx context.Context, merchID int) error {
+	ret := _m.Called(ctx, merchID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ArchiveMerch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, merchID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Checkout provides a mock function with given fields: ctx, userID
+func (_m *Service) Checkout(ctx context.Context, userID int) ([]*models.Purchase, error) {
 	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetInfo")
+		panic("no return value specified for Checkout")
 	}
 
-	var r0 *models.UserInfo
+	var r0 []*models.Purchase
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.UserInfo, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.Purchase, error)); ok {
 		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) *models.UserInfo); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.Purchase); ok {
 		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.UserInfo)
+			r0 = ret.Get(0).([]*models.Purchase)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMerch provides a mock function with given fields: ctx, adminID, name, price, stock
+func (_m *Service) CreateMerch(ctx context.Context, adminID int, name string, price int, stock *int) (*models.Merch, error) {
+	ret := _m.Called(ctx, adminID, name, price, stock)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMerch")
+	}
+
+	var r0 *models.Merch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int, *int) (*models.Merch, error)); ok {
+		return rf(ctx, adminID, name, price, stock)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int, *int) *models.Merch); ok {
+		r0 = rf(ctx, adminID, name, price, stock)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Merch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, int, *int) error); ok {
+		r1 = rf(ctx, adminID, name, price, stock)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCart provides a mock function with given fields: ctx, userID
+func (_m *Service) GetCart(ctx context.Context, userID int) (*models.Cart, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCart")
+	}
+
+	var r0 *models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.Cart, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.Cart); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Cart)
 		}
 	}
 
@@ -44,6 +154,54 @@ func (_m *Service) GetInfo(ctx context.Context, userID int) (*models.UserInfo, e
 	return r0, r1
 }
 
+// GetInfo provides a mock function with given fields: ctx, userID, filter
+func (_m *Service) GetInfo(ctx context.Context, userID int, filter models.TransactionFilter) (*models.UserInfo, error) {
+	ret := _m.Called(ctx, userID, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInfo")
+	}
+
+	var r0 *models.UserInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter) (*models.UserInfo, error)); ok {
+		return rf(ctx, userID, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter) *models.UserInfo); ok {
+		r0 = rf(ctx, userID, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.UserInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.TransactionFilter) error); ok {
+		r1 = rf(ctx, userID, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJWKS provides a mock function with no fields
+func (_m *Service) GetJWKS() jwt.JWKS {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetJWKS")
+	}
+
+	var r0 jwt.JWKS
+	if rf, ok := ret.Get(0).(func() jwt.JWKS); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(jwt.JWKS)
+	}
+
+	return r0
+}
+
 // GetMerch provides a mock function with given fields: ctx, merchID
 func (_m *Service) GetMerch(ctx context.Context, merchID int) (*models.Merch, error) {
 	ret := _m.Called(ctx, merchID)
@@ -74,6 +232,156 @@ func (_m *Service) GetMerch(ctx context.Context, merchID int) (*models.Merch, er
 	return r0, r1
 }
 
+// GetMerchPriceHistory provides a mock function with given fields: ctx, merchID
+func (_m *Service) GetMerchPriceHistory(ctx context.Context, merchID int) ([]*models.MerchPrice, error) {
+	ret := _m.Called(ctx, merchID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMerchPriceHistory")
+	}
+
+	var r0 []*models.MerchPrice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.MerchPrice, error)); ok {
+		return rf(ctx, merchID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.MerchPrice); ok {
+		r0 = rf(ctx, merchID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.MerchPrice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, merchID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPurchaseHistory provides a mock function with given fields: ctx, userID, filter, page
+func (_m *Service) GetPurchaseHistory(ctx context.Context, userID int, filter models.PurchaseFilter, page models.HistoryPage) (*models.PurchasePage, error) {
+	ret := _m.Called(ctx, userID, filter, page)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPurchaseHistory")
+	}
+
+	var r0 *models.PurchasePage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.PurchaseFilter, models.HistoryPage) (*models.PurchasePage, error)); ok {
+		return rf(ctx, userID, filter, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.PurchaseFilter, models.HistoryPage) *models.PurchasePage); ok {
+		r0 = rf(ctx, userID, filter, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PurchasePage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.PurchaseFilter, models.HistoryPage) error); ok {
+		r1 = rf(ctx, userID, filter, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPurchaseStatusHistory provides a mock function with given fields: ctx, purchaseID
+func (_m *Service) GetPurchaseStatusHistory(ctx context.Context, purchaseID int) ([]*models.PurchaseStatusChange, error) {
+	ret := _m.Called(ctx, purchaseID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPurchaseStatusHistory")
+	}
+
+	var r0 []*models.PurchaseStatusChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.PurchaseStatusChange, error)); ok {
+		return rf(ctx, purchaseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.PurchaseStatusChange); ok {
+		r0 = rf(ctx, purchaseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.PurchaseStatusChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, purchaseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTransactionHistory provides a mock function with given fields: ctx, userID, filter, page
+func (_m *Service) GetTransactionHistory(ctx context.Context, userID int, filter models.TransactionFilter, page models.HistoryPage) (*models.TransferPage, error) {
+	ret := _m.Called(ctx, userID, filter, page)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTransactionHistory")
+	}
+
+	var r0 *models.TransferPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter, models.HistoryPage) (*models.TransferPage, error)); ok {
+		return rf(ctx, userID, filter, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter, models.HistoryPage) *models.TransferPage); ok {
+		r0 = rf(ctx, userID, filter, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TransferPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.TransactionFilter, models.HistoryPage) error); ok {
+		r1 = rf(ctx, userID, filter, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAllMerch provides a mock function with given fields: ctx
+func (_m *Service) ListAllMerch(ctx context.Context) ([]*models.Merch, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAllMerch")
+	}
+
+	var r0 []*models.Merch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*models.Merch, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*models.Merch); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Merch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListMerch provides a mock function with given fields: ctx
 func (_m *Service) ListMerch(ctx context.Context) ([]*models.Merch, error) {
 	ret := _m.Called(ctx)
@@ -105,22 +413,24 @@ func (_m *Service) ListMerch(ctx context.Context) ([]*models.Merch, error) {
 }
 
 // Login provides a mock function with given fields: ctx, username, password
-func (_m *Service) Login(ctx context.Context, username string, password string) (string, error) {
+func (_m *Service) Login(ctx context.Context, username string, password string) (*models.TokenPair, error) {
 	ret := _m.Called(ctx, username, password)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Login")
 	}
 
-	var r0 string
+	var r0 *models.TokenPair
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*models.TokenPair, error)); ok {
 		return rf(ctx, username, password)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.TokenPair); ok {
 		r0 = rf(ctx, username, password)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TokenPair)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
@@ -132,17 +442,35 @@ func (_m *Service) Login(ctx context.Context, username string, password string)
 	return r0, r1
 }
 
-// PurchaseMerch provides a mock function with given fields: ctx, userID, merchName
-func (_m *Service) PurchaseMerch(ctx context.Context, userID int, merchName string) error {
-	ret := _m.Called(ctx, userID, merchName)
+// Logout provides a mock function with given fields: ctx, claims, refreshToken
+func (_m *Service) Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error {
+	ret := _m.Called(ctx, claims, refreshToken)
 
 	if len(ret) == 0 {
-		panic("no return value specified for PurchaseMerch")
+		panic("no return value specified for Logout")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
-		r0 = rf(ctx, userID, merchName)
+	if rf, ok := ret.Get(0).(func(context.Context, *jwt.Claims, string) error); ok {
+		r0 = rf(ctx, claims, refreshToken)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LogoutAll provides a mock function with given fields: ctx, userID
+func (_m *Service) LogoutAll(ctx context.Context, userID int) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LogoutAll")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -150,23 +478,115 @@ func (_m *Service) PurchaseMerch(ctx context.Context, userID int, merchName stri
 	return r0
 }
 
+// PurchaseMerch provides a mock function with given fields: ctx, userID, merchName, quantity, idempotencyKey
+func (_m *Service) PurchaseMerch(ctx context.Context, userID int, merchName string, quantity int, idempotencyKey string) (*models.Purchase, error) {
+	ret := _m.Called(ctx, userID, merchName, quantity, idempotencyKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurchaseMerch")
+	}
+
+	var r0 *models.Purchase
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int, string) (*models.Purchase, error)); ok {
+		return rf(ctx, userID, merchName, quantity, idempotencyKey)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int, string) *models.Purchase); ok {
+		r0 = rf(ctx, userID, merchName, quantity, idempotencyKey)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Purchase)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, int, string) error); ok {
+		r1 = rf(ctx, userID, merchName, quantity, idempotencyKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Refresh provides a mock function with given fields: ctx, refreshToken
+func (_m *Service) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	ret := _m.Called(ctx, refreshToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 *models.TokenPair
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.TokenPair, error)); ok {
+		return rf(ctx, refreshToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.TokenPair); ok {
+		r0 = rf(ctx, refreshToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TokenPair)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, refreshToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundPurchase provides a mock function with given fields: ctx, requesterID, requesterRole, purchaseID
+func (_m *Service) RefundPurchase(ctx context.Context, requesterID int, requesterRole string, purchaseID int) (*models.Purchase, error) {
+	ret := _m.Called(ctx, requesterID, requesterRole, purchaseID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefundPurchase")
+	}
+
+	var r0 *models.Purchase
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int) (*models.Purchase, error)); ok {
+		return rf(ctx, requesterID, requesterRole, purchaseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, int) *models.Purchase); ok {
+		r0 = rf(ctx, requesterID, requesterRole, purchaseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Purchase)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string, int) error); ok {
+		r1 = rf(ctx, requesterID, requesterRole, purchaseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Register provides a mock function with given fields: ctx, username, password
-func (_m *Service) Register(ctx context.Context, username string, password string) (string, error) {
+func (_m *Service) Register(ctx context.Context, username string, password string) (*models.TokenPair, error) {
 	ret := _m.Called(ctx, username, password)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Register")
 	}
 
-	var r0 string
+	var r0 *models.TokenPair
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*models.TokenPair, error)); ok {
 		return rf(ctx, username, password)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.TokenPair); ok {
 		r0 = rf(ctx, username, password)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TokenPair)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
@@ -178,45 +598,269 @@ func (_m *Service) Register(ctx context.Context, username string, password strin
 	return r0, r1
 }
 
-// TransferCoins provides a mock function with given fields: ctx, senderID, receiverID, amount
-func (_m *Service) TransferCoins(ctx context.Context, senderID int, receiverID int, amount int) error {
-	ret := _m.Called(ctx, senderID, receiverID, amount)
+// RemoveFromCart provides a mock function with given fields: ctx, userID, merchName
+func (_m *Service) RemoveFromCart(ctx context.Context, userID int, merchName string) (*models.Cart, error) {
+	ret := _m.Called(ctx, userID, merchName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveFromCart")
+	}
+
+	var r0 *models.Cart
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (*models.Cart, error)); ok {
+		return rf(ctx, userID, merchName)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) *models.Cart); ok {
+		r0 = rf(ctx, userID, merchName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Cart)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, userID, merchName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestockMerch provides a mock function with given fields: ctx, merchID, quantity
+func (_m *Service) RestockMerch(ctx context.Context, merchID int, quantity int) (*models.Merch, error) {
+	ret := _m.Called(ctx, merchID, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestockMerch")
+	}
+
+	var r0 *models.Merch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (*models.Merch, error)); ok {
+		return rf(ctx, merchID, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) *models.Merch); ok {
+		r0 = rf(ctx, merchID, quantity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Merch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, merchID, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetMerchStock provides a mock function with given fields: ctx, merchID, stock
+func (_m *Service) SetMerchStock(ctx context.Context, merchID int, stock *int) (*models.Merch, error) {
+	ret := _m.Called(ctx, merchID, stock)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMerchStock")
+	}
+
+	var r0 *models.Merch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, *int) (*models.Merch, error)); ok {
+		return rf(ctx, merchID, stock)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, *int) *models.Merch); ok {
+		r0 = rf(ctx, merchID, stock)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Merch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, *int) error); ok {
+		r1 = rf(ctx, merchID, stock)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserRole provides a mock function with given fields: ctx, adminID, userID, role
+func (_m *Service) SetUserRole(ctx context.Context, adminID int, userID int, role string) (*models.User, error) {
+	ret := _m.Called(ctx, adminID, userID, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserRole")
+	}
+
+	var r0 *models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, string) (*models.User, error)); ok {
+		return rf(ctx, adminID, userID, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, string) *models.User); ok {
+		r0 = rf(ctx, adminID, userID, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, string) error); ok {
+		r1 = rf(ctx, adminID, userID, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TransferCoins provides a mock function with given fields: ctx, senderID, recipient, amount, memo, idempotencyKey
+func (_m *Service) TransferCoins(ctx context.Context, senderID int, recipient models.TransferRecipient, amount int, memo models.TransferMemo, idempotencyKey string) (*models.Transaction, error) {
+	ret := _m.Called(ctx, senderID, recipient, amount, memo, idempotencyKey)
 
 	if len(ret) == 0 {
 		panic("no return value specified for TransferCoins")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) error); ok {
-		r0 = rf(ctx, senderID, receiverID, amount)
+	var r0 *models.Transaction
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransferRecipient, int, models.TransferMemo, string) (*models.Transaction, error)); ok {
+		return rf(ctx, senderID, recipient, amount, memo, idempotencyKey)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransferRecipient, int, models.TransferMemo, string) *models.Transaction); ok {
+		r0 = rf(ctx, senderID, recipient, amount, memo, idempotencyKey)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Transaction)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.TransferRecipient, int, models.TransferMemo, string) error); ok {
+		r1 = rf(ctx, senderID, recipient, amount, memo, idempotencyKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// ValidateToken provides a mock function with given fields: token
-func (_m *Service) ValidateToken(token string) (int, error) {
-	ret := _m.Called(token)
+// UpdateMerch provides a mock function with given fields: ctx, adminID, merchID, name, price
+func (_m *Service) UpdateMerch(ctx context.Context, adminID int, merchID int, name string, price int) (*models.Merch, error) {
+	ret := _m.Called(ctx, adminID, merchID, name, price)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMerch")
+	}
+
+	var r0 *models.Merch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, string, int) (*models.Merch, error)); ok {
+		return rf(ctx, adminID, merchID, name, price)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, string, int) *models.Merch); ok {
+		r0 = rf(ctx, adminID, merchID, name, price)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Merch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, string, int) error); ok {
+		r1 = rf(ctx, adminID, merchID, name, price)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdatePurchaseStatus provides a mock function with given fields: ctx, adminID, purchaseID, status
+func (_m *Service) UpdatePurchaseStatus(ctx context.Context, adminID int, purchaseID int, status string) (*models.Purchase, error) {
+	ret := _m.Called(ctx, adminID, purchaseID, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePurchaseStatus")
+	}
+
+	var r0 *models.Purchase
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, string) (*models.Purchase, error)); ok {
+		return rf(ctx, adminID, purchaseID, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, string) *models.Purchase); ok {
+		r0 = rf(ctx, adminID, purchaseID, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Purchase)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, string) error); ok {
+		r1 = rf(ctx, adminID, purchaseID, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ValidateToken provides a mock function with given fields: ctx, token
+func (_m *Service) ValidateToken(ctx context.Context, token string) (*jwt.Claims, error) {
+	ret := _m.Called(ctx, token)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ValidateToken")
 	}
 
-	var r0 int
+	var r0 *jwt.Claims
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (int, error)); ok {
-		return rf(token)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*jwt.Claims, error)); ok {
+		return rf(ctx, token)
 	}
-	if rf, ok := ret.Get(0).(func(string) int); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *jwt.Claims); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*jwt.Claims)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
 	} else {
-		r0 = ret.Get(0).(int)
+		r1 = ret.Error(1)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(token)
+	return r0, r1
+}
+
+// VerifyLedger provides a mock function with given fields: ctx
+func (_m *Service) VerifyLedger(ctx context.Context) (*models.LedgerReport, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyLedger")
+	}
+
+	var r0 *models.LedgerReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*models.LedgerReport, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *models.LedgerReport); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.LedgerReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
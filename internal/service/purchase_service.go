@@ -1,106 +1,573 @@
 package service
 
 import (
+	"avito-tech-merch/internal/config"
 	"avito-tech-merch/internal/metrics"
 	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/storage/db"
 	"avito-tech-merch/internal/storage/db/postgres"
+	"avito-tech-merch/internal/tracing"
 	"avito-tech-merch/pkg/logger"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"slices"
 	"time"
 )
 
+// MaxPurchaseQuantity ограничивает количество единиц одного товара в покупке или позиции корзины
+const MaxPurchaseQuantity = 100
+
 type purchaseService struct {
-	repo      db.Repository
-	logger    logger.Logger
-	txManager db.TxManager
+	repo         db.Repository
+	logger       logger.Logger
+	refundWindow time.Duration
+	txManager    db.TxManager
+}
+
+// orderLine - позиция заказа: товар с ценой каталога на момент покупки и количество
+type orderLine struct {
+	merch    *models.Merch
+	quantity int
 }
 
-func NewPurchaseService(repo db.Repository, log logger.Logger, txManager db.TxManager) *purchaseService {
-	return &purchaseService{repo: repo, logger: log, txManager: txManager}
+func NewPurchaseService(repo db.Repository, log logger.Logger, purchaseConfig config.PurchaseConfig, txManager db.TxManager) *purchaseService {
+	return &purchaseService{
+		repo:         repo,
+		logger:       log,
+		refundWindow: time.Duration(purchaseConfig.RefundWindow) * time.Second,
+		txManager:    txManager,
+	}
 }
 
-func (s *purchaseService) PurchaseMerch(ctx context.Context, userID int, merchName string) error {
+// PurchaseMerch покупает товар и возвращает созданную покупку. С непустым idempotencyKey повтор запроса
+// возвращает исходную покупку, не списывая монеты второй раз
+func (s *purchaseService) PurchaseMerch(ctx context.Context, userID int, merchName string, quantity int, idempotencyKey string) (*models.Purchase, error) {
 	metrics.RecordMerchPurchase()
-	var err error
 
-	merch, err := s.repo.GetMerchByName(ctx, merchName)
-	if err != nil {
-		s.logger.Errorw("Failed to get merch",
-			"merchName", merchName,
-			"error", err,
-		)
-		return fmt.Errorf("failed to get merch: %w", err)
+	if err := validateQuantity(quantity); err != nil {
+		return nil, err
 	}
 
-	const maxRetries = 3
+	idempotency := newIdempotencyRequest(userID, idempotencyKey, "PurchaseMerch", merchName, quantity)
 
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		err = s.txManager.WithTx(ctx, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
-			balance, err := s.repo.GetBalanceByID(txCtx, userID)
-			if err != nil {
-				s.logger.Errorw("Failed to get user balance",
+	// Товар читается через кеш и может отставать от базы; цену, архивацию и остаток окончательно
+	// проверяет списание в placeOrder
+	purchases, err := s.placeOrder(ctx, userID, "PurchaseMerch", idempotency, func(txCtx context.Context) ([]orderLine, error) {
+		merch, err := s.repo.GetMerchByName(txCtx, merchName)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				logger.WithContext(ctx, s.logger).Warnw("Merch not found",
 					"userID", userID,
-					"error", err,
+					"merchName", merchName,
 				)
-				return fmt.Errorf("failed to get user balance: %w", err)
+				return nil, fmt.Errorf("%w: %s", ErrMerchNotFound, merchName)
 			}
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get merch",
+				"merchName", merchName,
+				"error", err,
+			)
+			return nil, fmt.Errorf("failed to get merch: %w", err)
+		}
+
+		if merch.IsArchived() {
+			logger.WithContext(ctx, s.logger).Warnw("Attempt to purchase archived merch",
+				"userID", userID,
+				"merchName", merchName,
+			)
+			return nil, ErrMerchUnavailable
+		}
+
+		return []orderLine{{merch: merch, quantity: quantity}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return purchases[0], nil
+}
 
-			if balance < merch.Price {
-				s.logger.Warnw("Insufficient funds",
+// Checkout покупает всё содержимое корзины одной транзакцией: либо оплачиваются все позиции, либо ни одна
+func (s *purchaseService) Checkout(ctx context.Context, userID int) ([]*models.Purchase, error) {
+	metrics.RecordMerchPurchase()
+
+	return s.placeOrder(ctx, userID, "Checkout", nil, func(txCtx context.Context) ([]orderLine, error) {
+		items, err := s.repo.GetCartItems(txCtx, userID)
+		if err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get cart items",
+				"userID", userID,
+				"error", err,
+			)
+			return nil, fmt.Errorf("failed to get cart items: %w", err)
+		}
+
+		if len(items) == 0 {
+			return nil, ErrCartEmpty
+		}
+
+		lines := make([]orderLine, 0, len(items))
+		for _, item := range items {
+			// Количество в позиции проверяется так же, как в разовой покупке
+			if err := validateQuantity(item.Quantity); err != nil {
+				return nil, fmt.Errorf("%w (%s)", err, item.Merch.Name)
+			}
+			if item.Merch.IsArchived() {
+				logger.WithContext(ctx, s.logger).Warnw("Attempt to checkout archived merch",
 					"userID", userID,
-					"balance", balance,
-					"merchPrice", merch.Price,
+					"merchName", item.Merch.Name,
 				)
-				return fmt.Errorf("insufficient funds")
+				return nil, fmt.Errorf("%w: %s", ErrMerchUnavailable, item.Merch.Name)
 			}
+			lines = append(lines, orderLine{merch: item.Merch, quantity: item.Quantity})
+		}
 
-			newBalance := balance - merch.Price
-			if err = s.repo.UpdateBalance(txCtx, userID, newBalance); err != nil {
-				s.logger.Errorw("Failed to update user balance",
-					"userID", userID,
-					"newBalance", newBalance,
+		// Корзина очищается в той же транзакции: если заказ не пройдёт, она восстановится при откате
+		if err := s.repo.ClearCart(txCtx, userID); err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to clear cart",
+				"userID", userID,
+				"error", err,
+			)
+			return nil, fmt.Errorf("failed to clear cart: %w", err)
+		}
+
+		return lines, nil
+	})
+}
+
+// placeOrder списывает монеты и остатки за все позиции заказа и создаёт покупки в одной транзакции READ COMMITTED.
+// Строка покупателя блокируется до чтения позиций через takeLines, поэтому заказы одного сотрудника выполняются по очереди,
+// а монеты и остатки списываются условными UPDATE. Позиция оплачивается по цене, с которой её вернул takeLines, только
+// если товар всё ещё продаётся по этой цене. Если запрос с тем же ключом идемпотентности уже выполнялся,
+// возвращаются его покупки
+func (s *purchaseService) placeOrder(
+	ctx context.Context,
+	userID int,
+	operation string,
+	idempotency *idempotencyRequest,
+	takeLines func(txCtx context.Context) ([]orderLine, error),
+) ([]*models.Purchase, error) {
+	var (
+		purchases      []*models.Purchase
+		remainingStock map[string]int
+	)
+
+	err := s.txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
+		purchases = nil
+		remainingStock = make(map[string]int)
+
+		balances, err := s.repo.LockBalances(txCtx, userID)
+		if err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to lock user balance",
+				"userID", userID,
+				"error", err,
+			)
+			return fmt.Errorf("failed to lock user balance: %w", err)
+		}
+
+		balance, ok := balances[userID]
+		if !ok {
+			logger.WithContext(ctx, s.logger).Errorw("User not found",
+				"userID", userID,
+			)
+			return fmt.Errorf("%w: id %d", ErrUserNotFound, userID)
+		}
+
+		if ok, err := replayIdempotent(txCtx, s.repo, s.logger, idempotency, &purchases); err != nil || ok {
+			return err
+		}
+
+		lines, err := takeLines(txCtx)
+		if err != nil {
+			return err
+		}
+
+		total := 0
+		for _, line := range lines {
+			total += line.merch.Price * line.quantity
+		}
+
+		if balance < total {
+			logger.WithContext(ctx, s.logger).Warnw("Insufficient funds",
+				"userID", userID,
+				"balance", balance,
+				"orderTotal", total,
+			)
+			return ErrInsufficientFunds
+		}
+
+		// Остатки списываются в той же транзакции, что и монеты: при откате покупки они вернутся.
+		// Списание проходит по каждой позиции, в том числе без учёта остатка, потому что оно же
+		// проверяет цену и архивацию. Товары блокируются в порядке ID, чтобы встречные заказы
+		// из корзин не взаимоблокировались
+		for _, line := range sortedByMerchID(lines) {
+			stock, err := s.repo.DecrementMerchStock(txCtx, line.merch.ID, line.merch.Price, line.quantity)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return s.rejectLine(ctx, txCtx, userID, line)
+				}
+				logger.WithContext(ctx, s.logger).Errorw("Failed to decrement merch stock",
+					"merchName", line.merch.Name,
 					"error", err,
 				)
-				return fmt.Errorf("failed to update user balance: %w", err)
+				return fmt.Errorf("failed to decrement merch stock: %w", err)
+			}
+			if stock != nil {
+				remainingStock[line.merch.Name] = *stock
 			}
+		}
 
+		for _, line := range lines {
 			purchase := &models.Purchase{
 				UserID:    userID,
-				MerchID:   merch.ID,
+				MerchID:   line.merch.ID,
+				Quantity:  line.quantity,
+				UnitPrice: line.merch.Price,
 				CreatedAt: time.Now(),
+				Status:    models.PurchaseStatusPlaced,
 			}
 
 			if _, err = s.repo.CreatePurchase(txCtx, purchase); err != nil {
-				s.logger.Errorw("Failed to create purchase",
+				logger.WithContext(ctx, s.logger).Errorw("Failed to create purchase",
 					"userID", userID,
-					"merchName", merchName,
+					"merchName", line.merch.Name,
 					"error", err,
 				)
 				return fmt.Errorf("failed to create purchase: %w", err)
 			}
+			if err = s.recordStatus(txCtx, purchase.ID, purchase.Status, userID); err != nil {
+				return err
+			}
 
-			return nil
-		})
+			entry := models.NewLedgerEntry(models.LedgerReasonPurchase, purchase.ID,
+				models.UserPosting(userID, -purchase.TotalPrice()),
+				models.SystemPosting(models.AccountMerchStore, purchase.TotalPrice()),
+			)
+			if err = postLedgerEntry(txCtx, s.repo, s.logger, entry); err != nil {
+				return err
+			}
+			purchases = append(purchases, purchase)
+		}
+
+		return saveIdempotent(txCtx, s.repo, s.logger, idempotency, purchases)
+	})
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Error during "+operation+" operation", "error", err)
+		return nil, err
+	}
+
+	for merchName, stock := range remainingStock {
+		metrics.RecordMerchStock(merchName, stock)
+	}
+	return purchases, nil
+}
+
+// rejectLine объясняет, почему списание позиции не прошло: перечитывает товар в транзакции
+// и возвращает ErrMerchUnavailable, ErrPriceChanged или ErrOutOfStock
+func (s *purchaseService) rejectLine(ctx, txCtx context.Context, userID int, line orderLine) error {
+	current, err := s.repo.GetMerchByID(txCtx, line.merch.ID)
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to get merch",
+			"merchID", line.merch.ID,
+			"error", err,
+		)
+		return fmt.Errorf("failed to get merch: %w", err)
+	}
+
+	switch {
+	case current.IsArchived():
+		logger.WithContext(ctx, s.logger).Warnw("Attempt to purchase archived merch",
+			"userID", userID,
+			"merchName", line.merch.Name,
+		)
+		return fmt.Errorf("%w: %s", ErrMerchUnavailable, line.merch.Name)
+	case current.Price != line.merch.Price:
+		logger.WithContext(ctx, s.logger).Warnw("Merch price changed during purchase",
+			"userID", userID,
+			"merchName", line.merch.Name,
+			"expectedPrice", line.merch.Price,
+			"price", current.Price,
+		)
+		return fmt.Errorf("%w: %s now costs %d", ErrPriceChanged, line.merch.Name, current.Price)
+	default:
+		logger.WithContext(ctx, s.logger).Warnw("Merch out of stock",
+			"userID", userID,
+			"merchName", line.merch.Name,
+			"quantity", line.quantity,
+		)
+		return fmt.Errorf("%w: %s", ErrOutOfStock, line.merch.Name)
+	}
+}
+
+// RefundPurchase отменяет покупку: возвращает монеты и остаток в одной serializable-транзакции и помечает покупку возвращённой.
+// Сотрудник может вернуть только свою покупку в пределах окна возврата, администратор - любую и без ограничения по времени
+func (s *purchaseService) RefundPurchase(ctx context.Context, requesterID int, requesterRole string, purchaseID int) (*models.Purchase, error) {
+	isAdmin := requesterRole == models.RoleAdmin
+
+	var (
+		refunded       *models.Purchase
+		merchName      string
+		remainingStock *int
+	)
+
+	err := s.withSerializableRetry(ctx, "RefundPurchase", func(txCtx context.Context) error {
+		refunded = nil
+		remainingStock = nil
+
+		purchase, err := s.findPurchase(txCtx, purchaseID)
+		if err != nil {
+			return err
+		}
+
+		// Чужая покупка для сотрудника неотличима от несуществующей
+		if !isAdmin && purchase.UserID != requesterID {
+			logger.WithContext(ctx, s.logger).Warnw("Attempt to refund another user's purchase",
+				"requesterID", requesterID,
+				"purchaseID", purchaseID,
+			)
+			return ErrPurchaseNotFound
+		}
+
+		if purchase.IsRefunded() {
+			return ErrAlreadyRefunded
+		}
+
+		// Выданную покупку вернуть нельзя: возврат переводит покупку в cancelled
+		if !models.CanTransitionPurchaseStatus(purchase.Status, models.PurchaseStatusCancelled) {
+			return fmt.Errorf("%w: purchase is %s", ErrRefundNotAllowed, purchase.Status)
+		}
+
+		if !isAdmin && (s.refundWindow <= 0 || time.Since(purchase.CreatedAt) > s.refundWindow) {
+			logger.WithContext(ctx, s.logger).Warnw("Refund window has expired",
+				"requesterID", requesterID,
+				"purchaseID", purchaseID,
+				"createdAt", purchase.CreatedAt,
+			)
+			return fmt.Errorf("%w: refund window has expired", ErrRefundNotAllowed)
+		}
+
+		entry := models.NewLedgerEntry(models.LedgerReasonRefund, purchase.ID,
+			models.SystemPosting(models.AccountMerchStore, -purchase.TotalPrice()),
+			models.UserPosting(purchase.UserID, purchase.TotalPrice()),
+		)
+		if err = postLedgerEntry(txCtx, s.repo, s.logger, entry); err != nil {
+			return err
+		}
+
+		merch, err := s.repo.GetMerchByID(txCtx, purchase.MerchID)
+		if err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get merch",
+				"merchID", purchase.MerchID,
+				"error", err,
+			)
+			return fmt.Errorf("failed to get merch: %w", err)
+		}
+		merchName = merch.Name
+
+		// Остаток возвращается только товарам с учётом остатка: иначе возврат включил бы учёт для безлимитного товара
+		if merch.HasStockLimit() {
+			stock, err := s.repo.RestockMerch(txCtx, merch.ID, purchase.Quantity)
+			if err != nil {
+				logger.WithContext(ctx, s.logger).Errorw("Failed to restore merch stock",
+					"merchName", merch.Name,
+					"error", err,
+				)
+				return fmt.Errorf("failed to restore merch stock: %w", err)
+			}
+			remainingStock = &stock
+		}
+
+		refundedAt, err := s.repo.MarkPurchaseRefunded(txCtx, purchase.ID, requesterID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrAlreadyRefunded
+			}
+			logger.WithContext(ctx, s.logger).Errorw("Failed to mark purchase refunded",
+				"purchaseID", purchase.ID,
+				"error", err,
+			)
+			return fmt.Errorf("failed to mark purchase refunded: %w", err)
+		}
+
+		purchase.RefundedAt = &refundedAt
+		purchase.RefundedBy = &requesterID
+
+		if err = s.changeStatus(txCtx, purchase, models.PurchaseStatusCancelled, requesterID); err != nil {
+			return err
+		}
+		refunded = purchase
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if remainingStock != nil {
+		metrics.RecordMerchStock(merchName, *remainingStock)
+	}
+	return refunded, nil
+}
+
+// UpdatePurchaseStatus переводит покупку по жизненному циклу выдачи. Отмена оформляется как возврат администратором:
+// покупателю возвращаются монеты, а товар - на склад
+func (s *purchaseService) UpdatePurchaseStatus(ctx context.Context, adminID int, purchaseID int, status string) (*models.Purchase, error) {
+	if !models.IsValidPurchaseStatus(status) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPurchaseStatus, status)
+	}
+
+	if status == models.PurchaseStatusCancelled {
+		return s.RefundPurchase(ctx, adminID, models.RoleAdmin, purchaseID)
+	}
+
+	var updated *models.Purchase
+	err := s.withSerializableRetry(ctx, "UpdatePurchaseStatus", func(txCtx context.Context) error {
+		updated = nil
+
+		purchase, err := s.findPurchase(txCtx, purchaseID)
+		if err != nil {
+			return err
+		}
+
+		if !models.CanTransitionPurchaseStatus(purchase.Status, status) {
+			logger.WithContext(ctx, s.logger).Warnw("Invalid purchase status transition",
+				"purchaseID", purchaseID,
+				"from", purchase.Status,
+				"to", status,
+			)
+			return fmt.Errorf("%w from %s to %s", ErrInvalidStatusTransition, purchase.Status, status)
+		}
+
+		if err = s.changeStatus(txCtx, purchase, status, adminID); err != nil {
+			return err
+		}
+		updated = purchase
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
 
+func (s *purchaseService) GetPurchaseStatusHistory(ctx context.Context, purchaseID int) ([]*models.PurchaseStatusChange, error) {
+	if _, err := s.findPurchase(ctx, purchaseID); err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.GetPurchaseStatusHistory(ctx, purchaseID)
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to fetch purchase status history",
+			"purchaseID", purchaseID,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (s *purchaseService) findPurchase(ctx context.Context, purchaseID int) (*models.Purchase, error) {
+	purchase, err := s.repo.GetPurchaseByID(ctx, purchaseID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPurchaseNotFound
+		}
+		logger.WithContext(ctx, s.logger).Errorw("Failed to get purchase",
+			"purchaseID", purchaseID,
+			"error", err,
+		)
+		return nil, fmt.Errorf("failed to get purchase: %w", err)
+	}
+
+	return purchase, nil
+}
+
+// changeStatus переводит покупку в новый статус и записывает переход в историю в текущей транзакции
+func (s *purchaseService) changeStatus(ctx context.Context, purchase *models.Purchase, status string, changedBy int) error {
+	if err := s.repo.UpdatePurchaseStatus(ctx, purchase.ID, purchase.Status, status); err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to update purchase status",
+			"purchaseID", purchase.ID,
+			"status", status,
+			"error", err,
+		)
+		return fmt.Errorf("failed to update purchase status: %w", err)
+	}
+
+	if err := s.recordStatus(ctx, purchase.ID, status, changedBy); err != nil {
+		return err
+	}
+
+	purchase.Status = status
+	return nil
+}
+
+func (s *purchaseService) recordStatus(ctx context.Context, purchaseID int, status string, changedBy int) error {
+	change := &models.PurchaseStatusChange{
+		PurchaseID: purchaseID,
+		Status:     status,
+		ChangedBy:  &changedBy,
+	}
+
+	if _, err := s.repo.CreatePurchaseStatusChange(ctx, change); err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to record purchase status",
+			"purchaseID", purchaseID,
+			"status", status,
+			"error", err,
+		)
+		return fmt.Errorf("failed to record purchase status: %w", err)
+	}
+
+	return nil
+}
+
+// withSerializableRetry выполняет fn в serializable-транзакции и повторяет её целиком при конфликте сериализации
+func (s *purchaseService) withSerializableRetry(ctx context.Context, operation string, fn func(txCtx context.Context) error) error {
+	const maxRetries = 3
+	var err error
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		err = s.txManager.WithTx(tracing.WithAttempt(ctx, attempt), postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite, fn)
 		if err == nil {
 			return nil
 		}
 
 		if !IsSerializationError(err) {
-			s.logger.Errorw("Non-retryable error during PurchaseMerch", "error", err)
+			logger.WithContext(ctx, s.logger).Errorw("Non-retryable error during "+operation, "error", err)
 			return err
 		}
 
 		if attempt == maxRetries {
-			s.logger.Errorw("Failed to purchase merch after retries", "error", err)
+			logger.WithContext(ctx, s.logger).Errorw("Failed to complete "+operation+" after retries", "error", err)
 			return err
 		}
 
-		s.logger.Infow("Serialization error during PurchaseMerch, retrying", "attempt", attempt, "error", err)
+		logger.WithContext(ctx, s.logger).Infow("Serialization error during "+operation+", retrying", "attempt", attempt, "error", err)
+		trace.SpanFromContext(ctx).AddEvent("transaction retry", trace.WithAttributes(
+			attribute.String("operation", operation),
+			attribute.Int("attempt", attempt),
+		))
 		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
 	}
 
+	return err
+}
+
+// sortedByMerchID возвращает копию позиций, упорядоченную по ID товара
+func sortedByMerchID(lines []orderLine) []orderLine {
+	sorted := slices.Clone(lines)
+	slices.SortFunc(sorted, func(a, b orderLine) int {
+		return cmp.Compare(a.merch.ID, b.merch.ID)
+	})
+	return sorted
+}
+
+func validateQuantity(quantity int) error {
+	if quantity <= 0 || quantity > MaxPurchaseQuantity {
+		return fmt.Errorf("%w: quantity must be between 1 and %d", ErrInvalidAmount, MaxPurchaseQuantity)
+	}
 	return nil
 }
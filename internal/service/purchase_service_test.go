@@ -1,6 +1,7 @@
 package service
 
 import (
+	"avito-tech-merch/internal/config"
 	"avito-tech-merch/internal/models"
 	mockRepo "avito-tech-merch/internal/storage/db/mock"
 	"avito-tech-merch/internal/storage/db/postgres"
@@ -8,16 +9,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"testing"
+	"time"
 )
 
 func TestPurchaseMerch_GetMerchError(t *testing.T) {
 	repoMock := mockRepo.NewRepository(t)
 	loggerMock := mockLog.NewLogger(t)
 	txManagerMock := mockRepo.NewTxManager(t)
-	service := NewPurchaseService(repoMock, loggerMock, txManagerMock)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+	repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 100}, nil).Once()
 
 	ctx := context.Background()
 	userID := 1
@@ -34,14 +40,19 @@ func TestPurchaseMerch_GetMerchError(t *testing.T) {
 			"merchName", merchName,
 			"error", expectedErr,
 		).Return().Once()
+	loggerMock.
+		On("Errorw",
+			"Error during PurchaseMerch operation",
+			"error", fmt.Errorf("failed to get merch: %w", expectedErr),
+		).Return().Once()
 
-	err := service.PurchaseMerch(ctx, userID, merchName)
+	_, err := service.PurchaseMerch(ctx, userID, merchName, 1, "")
 	assert.Error(t, err)
 
 	assert.True(t, errors.Is(err, expectedErr), "expected error to wrap %v but got %v", expectedErr, err)
 
 	repoMock.AssertCalled(t, "GetMerchByName", mock.Anything, merchName)
-	txManagerMock.AssertNotCalled(t, "WithTx", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	repoMock.AssertNotCalled(t, "CreatePurchase", mock.Anything, mock.Anything)
 	loggerMock.AssertCalled(t, "Errorw",
 		"Failed to get merch",
 		"merchName", merchName,
@@ -49,71 +60,66 @@ func TestPurchaseMerch_GetMerchError(t *testing.T) {
 	)
 }
 
-func TestPurchaseMerch_GetBalanceError(t *testing.T) {
+func TestPurchaseMerch_ArchivedMerch(t *testing.T) {
 	repoMock := mockRepo.NewRepository(t)
 	loggerMock := mockLog.NewLogger(t)
 	txManagerMock := mockRepo.NewTxManager(t)
-	service := NewPurchaseService(repoMock, loggerMock, txManagerMock)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+	repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 100}, nil).Once()
 
 	ctx := context.Background()
 	userID := 1
 	merchName := "T-Shirt"
-	expectedErr := errors.New("balance error")
-	merch := &models.Merch{ID: 10, Price: 150}
+	archivedAt := time.Now()
 
 	repoMock.
 		On("GetMerchByName", mock.Anything, merchName).
-		Return(merch, nil).Once()
-
-	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
-			mock.AnythingOfType("func(context.Context) error")).
-		Run(func(args mock.Arguments) {
-			fn := args.Get(3).(func(context.Context) error)
-			_ = fn(context.Background())
-		}).
-		Return(fmt.Errorf("failed to get user balance: %w", expectedErr)).Once()
-
-	repoMock.
-		On("GetBalanceByID", mock.Anything, userID).
-		Return(0, expectedErr).Once()
+		Return(&models.Merch{ID: 1, Name: merchName, Price: 80, ArchivedAt: &archivedAt}, nil).Once()
 
 	loggerMock.
-		On("Errorw",
-			"Failed to get user balance",
+		On("Warnw",
+			"Attempt to purchase archived merch",
 			"userID", userID,
-			"error", expectedErr,
+			"merchName", merchName,
 		).Return().Once()
-
 	loggerMock.
 		On("Errorw",
-			"Non-retryable error during PurchaseMerch",
-			"error", fmt.Errorf("failed to get user balance: %w", expectedErr),
+			"Error during PurchaseMerch operation",
+			"error", fmt.Errorf("merch is not available"),
 		).Return().Once()
 
-	err := service.PurchaseMerch(ctx, userID, merchName)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to get user balance")
+	_, err := service.PurchaseMerch(ctx, userID, merchName, 1, "")
+	assert.EqualError(t, err, "merch is not available")
 
-	repoMock.AssertCalled(t, "GetMerchByName", mock.Anything, merchName)
-	repoMock.AssertCalled(t, "GetBalanceByID", mock.Anything, userID)
-	txManagerMock.AssertExpectations(t)
-	loggerMock.AssertCalled(t, "Errorw",
-		"Failed to get user balance",
-		"userID", userID,
-		"error", expectedErr,
-	)
-	loggerMock.AssertCalled(t, "Errorw",
-		"Non-retryable error during PurchaseMerch",
-		"error", fmt.Errorf("failed to get user balance: %w", expectedErr),
-	)
+	repoMock.AssertNotCalled(t, "CreatePurchase", mock.Anything, mock.Anything)
+}
+
+func TestPurchaseMerch_LockBalanceError(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	expectedErr := errors.New("lock error")
+
+	repoMock.On("LockBalances", mock.Anything, 1).Return(nil, expectedErr).Once()
+	loggerMock.On("Errorw", "Failed to lock user balance", "userID", 1, "error", expectedErr).Return().Once()
+	loggerMock.On("Errorw", "Error during PurchaseMerch operation",
+		"error", fmt.Errorf("failed to lock user balance: %w", expectedErr)).Return().Once()
+
+	_, err := service.PurchaseMerch(context.Background(), 1, "T-Shirt", 1, "")
+	assert.ErrorIs(t, err, expectedErr)
+	assert.Contains(t, err.Error(), "failed to lock user balance")
+	repoMock.AssertNotCalled(t, "GetMerchByName", mock.Anything, mock.Anything)
 }
 
 func TestPurchaseMerch_InsufficientFunds(t *testing.T) {
 	repoMock := mockRepo.NewRepository(t)
 	loggerMock := mockLog.NewLogger(t)
 	txManagerMock := mockRepo.NewTxManager(t)
-	service := NewPurchaseService(repoMock, loggerMock, txManagerMock)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
 
 	ctx := context.Background()
 	userID := 1
@@ -125,47 +131,47 @@ func TestPurchaseMerch_InsufficientFunds(t *testing.T) {
 		Return(merch, nil).Once()
 
 	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
 			mock.AnythingOfType("func(context.Context) error")).
 		Run(func(args mock.Arguments) {
 			fn := args.Get(3).(func(context.Context) error)
 			_ = fn(context.Background())
 		}).
-		Return(fmt.Errorf("insufficient funds")).Once()
+		Return(ErrInsufficientFunds).Once()
 
 	repoMock.
-		On("GetBalanceByID", mock.Anything, userID).
-		Return(100, nil).Once()
+		On("LockBalances", mock.Anything, userID).
+		Return(map[int]int{userID: 100}, nil).Once()
 
 	loggerMock.
 		On("Warnw",
 			"Insufficient funds",
 			"userID", userID,
 			"balance", 100,
-			"merchPrice", merch.Price,
+			"orderTotal", merch.Price,
 		).Return().Once()
 
 	loggerMock.
 		On("Errorw",
-			"Non-retryable error during PurchaseMerch",
+			"Error during PurchaseMerch operation",
 			"error", fmt.Errorf("insufficient funds"),
 		).Return().Once()
 
-	err := service.PurchaseMerch(ctx, userID, merchName)
+	_, err := service.PurchaseMerch(ctx, userID, merchName, 1, "")
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "insufficient funds")
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
 
 	repoMock.AssertCalled(t, "GetMerchByName", mock.Anything, merchName)
-	repoMock.AssertCalled(t, "GetBalanceByID", mock.Anything, userID)
+	repoMock.AssertCalled(t, "LockBalances", mock.Anything, userID)
 	txManagerMock.AssertExpectations(t)
 	loggerMock.AssertCalled(t, "Warnw",
 		"Insufficient funds",
 		"userID", userID,
 		"balance", 100,
-		"merchPrice", merch.Price,
+		"orderTotal", merch.Price,
 	)
 	loggerMock.AssertCalled(t, "Errorw",
-		"Non-retryable error during PurchaseMerch",
+		"Error during PurchaseMerch operation",
 		"error", fmt.Errorf("insufficient funds"),
 	)
 }
@@ -174,193 +180,316 @@ func TestPurchaseMerch_BeginTxError(t *testing.T) {
 	repoMock := mockRepo.NewRepository(t)
 	loggerMock := mockLog.NewLogger(t)
 	txManagerMock := mockRepo.NewTxManager(t)
-	service := NewPurchaseService(repoMock, loggerMock, txManagerMock)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
 
 	ctx := context.Background()
 	userID := 1
 	merchName := "T-Shirt"
 	expectedErr := errors.New("begin tx error")
 
-	repoMock.
-		On("GetMerchByName", mock.Anything, merchName).
-		Return(&models.Merch{ID: 10, Price: 50}, nil).Once()
-
 	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
 			mock.AnythingOfType("func(context.Context) error")).
 		Return(expectedErr).Once()
 
 	loggerMock.
 		On("Errorw",
-			"Non-retryable error during PurchaseMerch",
+			"Error during PurchaseMerch operation",
 			"error", expectedErr,
 		).Return().Once()
 
-	err := service.PurchaseMerch(ctx, userID, merchName)
+	_, err := service.PurchaseMerch(ctx, userID, merchName, 1, "")
 	assert.Error(t, err)
 
 	assert.Contains(t, err.Error(), expectedErr.Error())
 
-	repoMock.AssertCalled(t, "GetMerchByName", mock.Anything, merchName)
+	repoMock.AssertNotCalled(t, "GetMerchByName", mock.Anything, mock.Anything)
 	txManagerMock.AssertExpectations(t)
 	loggerMock.AssertCalled(t, "Errorw",
-		"Non-retryable error during PurchaseMerch",
+		"Error during PurchaseMerch operation",
 		"error", expectedErr,
 	)
 }
 
-func TestPurchaseMerch_UpdateBalanceError(t *testing.T) {
+func TestPurchaseMerch_LedgerEntryError(t *testing.T) {
 	repoMock := mockRepo.NewRepository(t)
 	loggerMock := mockLog.NewLogger(t)
 	txManagerMock := mockRepo.NewTxManager(t)
-	service := NewPurchaseService(repoMock, loggerMock, txManagerMock)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	merch := &models.Merch{ID: 10, Name: "T-Shirt", Price: 50}
+	expectedErr := errors.New("ledger error")
+
+	repoMock.On("GetMerchByName", mock.Anything, "T-Shirt").Return(merch, nil).Once()
+	repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 100}, nil).Once()
+	repoMock.On("DecrementMerchStock", mock.Anything, merch.ID, merch.Price, 1).Return(nil, nil).Once()
+	repoMock.On("CreatePurchase", mock.Anything, mock.AnythingOfType("*models.Purchase")).Return(1, nil).Once()
+	repoMock.On("CreatePurchaseStatusChange", mock.Anything, mock.AnythingOfType("*models.PurchaseStatusChange")).Return(1, nil).Once()
+	repoMock.On("DebitBalance", mock.Anything, 1, 50).Return(50, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, mock.AnythingOfType("*models.LedgerEntry")).Return(0, expectedErr).Once()
+
+	loggerMock.On("Errorw", "Failed to create ledger entry",
+		"reason", models.LedgerReasonPurchase,
+		"referenceID", 0,
+		"error", expectedErr,
+	).Return().Once()
+	loggerMock.On("Errorw", "Error during PurchaseMerch operation", "error", mock.Anything).Return().Once()
+
+	_, err := service.PurchaseMerch(context.Background(), 1, "T-Shirt", 1, "")
+	assert.ErrorIs(t, err, expectedErr)
+	assert.Contains(t, err.Error(), "failed to create ledger entry")
+}
+
+func TestPurchaseMerch_CreatePurchaseError(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
 
 	ctx := context.Background()
 	userID := 1
 	merchName := "T-Shirt"
 	merch := &models.Merch{ID: 10, Name: merchName, Price: 50}
-	expectedErr := errors.New("update balance error")
+	expectedErr := errors.New("create purchase error")
 
 	repoMock.
 		On("GetMerchByName", mock.Anything, merchName).
 		Return(merch, nil).Once()
 
 	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
 			mock.AnythingOfType("func(context.Context) error")).
 		Run(func(args mock.Arguments) {
 			_ = args.Get(3).(func(context.Context) error)(context.Background())
 		}).
-		Return(fmt.Errorf("failed to update user balance: %w", expectedErr)).Once()
+		Return(fmt.Errorf("failed to create purchase: %w", expectedErr)).Once()
 
 	repoMock.
-		On("GetBalanceByID", mock.Anything, userID).
-		Return(100, nil).Once()
-	newBalance := 100 - merch.Price
+		On("LockBalances", mock.Anything, userID).
+		Return(map[int]int{userID: 100}, nil).Once()
 	repoMock.
-		On("UpdateBalance", mock.Anything, userID, newBalance).
-		Return(expectedErr).Once()
+		On("DecrementMerchStock", mock.Anything, merch.ID, merch.Price, 1).
+		Return(nil, nil).Once()
+	repoMock.
+		On("CreatePurchase", mock.Anything, mock.AnythingOfType("*models.Purchase")).
+		Return(0, expectedErr).Once()
 
 	loggerMock.
 		On("Errorw",
-			"Failed to update user balance",
+			"Failed to create purchase",
 			"userID", userID,
-			"newBalance", newBalance,
+			"merchName", merchName,
 			"error", expectedErr,
 		).Return().Once()
 
 	loggerMock.
 		On("Errorw",
-			"Non-retryable error during PurchaseMerch",
-			"error", fmt.Errorf("failed to update user balance: %w", expectedErr),
+			"Error during PurchaseMerch operation",
+			"error", fmt.Errorf("failed to create purchase: %w", expectedErr),
 		).Return().Once()
 
-	err := service.PurchaseMerch(ctx, userID, merchName)
+	_, err := service.PurchaseMerch(ctx, userID, merchName, 1, "")
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to update user balance")
+	assert.Contains(t, err.Error(), "failed to create purchase")
 	assert.True(t, errors.Is(err, expectedErr), "expected error to wrap %v but got %v", expectedErr, err)
 
 	loggerMock.AssertCalled(t, "Errorw",
-		"Failed to update user balance",
+		"Failed to create purchase",
 		"userID", userID,
-		"newBalance", newBalance,
+		"merchName", merchName,
 		"error", expectedErr,
 	)
 	loggerMock.AssertCalled(t, "Errorw",
-		"Non-retryable error during PurchaseMerch",
-		"error", fmt.Errorf("failed to update user balance: %w", expectedErr),
+		"Error during PurchaseMerch operation",
+		"error", fmt.Errorf("failed to create purchase: %w", expectedErr),
 	)
-	repoMock.AssertCalled(t, "UpdateBalance", mock.Anything, userID, newBalance)
+	repoMock.AssertCalled(t, "CreatePurchase", mock.Anything, mock.AnythingOfType("*models.Purchase"))
 	txManagerMock.AssertExpectations(t)
 }
 
-func TestPurchaseMerch_CreatePurchaseError(t *testing.T) {
+func TestPurchaseMerch_Success(t *testing.T) {
 	repoMock := mockRepo.NewRepository(t)
 	loggerMock := mockLog.NewLogger(t)
 	txManagerMock := mockRepo.NewTxManager(t)
-	service := NewPurchaseService(repoMock, loggerMock, txManagerMock)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
 
 	ctx := context.Background()
 	userID := 1
 	merchName := "T-Shirt"
 	merch := &models.Merch{ID: 10, Name: merchName, Price: 50}
-	expectedErr := errors.New("create purchase error")
+	balance := 100
 
 	repoMock.
 		On("GetMerchByName", mock.Anything, merchName).
 		Return(merch, nil).Once()
 
 	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
 			mock.AnythingOfType("func(context.Context) error")).
 		Run(func(args mock.Arguments) {
 			_ = args.Get(3).(func(context.Context) error)(context.Background())
 		}).
-		Return(fmt.Errorf("failed to create purchase: %w", expectedErr)).Once()
+		Return(nil).Once()
 
 	repoMock.
-		On("GetBalanceByID", mock.Anything, userID).
-		Return(100, nil).Once()
-	newBalance := 100 - merch.Price
+		On("LockBalances", mock.Anything, userID).
+		Return(map[int]int{userID: balance}, nil).Once()
 	repoMock.
-		On("UpdateBalance", mock.Anything, userID, newBalance).
-		Return(nil).Once()
+		On("DecrementMerchStock", mock.Anything, merch.ID, merch.Price, 1).
+		Return(nil, nil).Once()
 	repoMock.
-		On("CreatePurchase", mock.Anything, mock.AnythingOfType("*models.Purchase")).
-		Return(0, expectedErr).Once()
+		On("CreatePurchase", mock.Anything, mock.MatchedBy(func(p *models.Purchase) bool {
+			return p.UserID == userID && p.MerchID == merch.ID
+		})).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*models.Purchase).ID = 1
+		}).
+		Return(1, nil).Once()
+	repoMock.
+		On("DebitBalance", mock.Anything, userID, merch.Price).
+		Return(balance-merch.Price, nil).Once()
+	repoMock.
+		On("CreateLedgerEntry", mock.Anything, matchLedgerEntry(models.LedgerReasonPurchase, 1,
+			models.UserPosting(userID, -merch.Price),
+			models.SystemPosting(models.AccountMerchStore, merch.Price),
+		)).
+		Return(1, nil).Once()
+	repoMock.
+		On("CreatePurchaseStatusChange", mock.Anything, mock.MatchedBy(func(c *models.PurchaseStatusChange) bool {
+			return c.Status == models.PurchaseStatusPlaced
+		})).
+		Return(1, nil).Once()
+
+	_, err := service.PurchaseMerch(ctx, userID, merchName, 1, "")
+	assert.NoError(t, err)
+
+	repoMock.AssertCalled(t, "GetMerchByName", mock.Anything, merchName)
+	repoMock.AssertCalled(t, "LockBalances", mock.Anything, userID)
+	repoMock.AssertCalled(t, "CreatePurchase", mock.Anything, mock.AnythingOfType("*models.Purchase"))
+	txManagerMock.AssertExpectations(t)
+}
+
+func TestPurchaseMerch_OutOfStock(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+
+	ctx := context.Background()
+	userID := 1
+	merchName := "T-Shirt"
+	merch := &models.Merch{ID: 10, Name: merchName, Price: 50, Stock: intPtr(0)}
+
+	repoMock.
+		On("GetMerchByName", mock.Anything, merchName).
+		Return(merch, nil).Once()
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			_ = args.Get(3).(func(context.Context) error)(context.Background())
+		}).
+		Return(fmt.Errorf("out of stock: T-Shirt")).Once()
+
+	repoMock.
+		On("LockBalances", mock.Anything, userID).
+		Return(map[int]int{userID: 100}, nil).Once()
+	repoMock.
+		On("DecrementMerchStock", mock.Anything, merch.ID, merch.Price, 1).
+		Return(nil, fmt.Errorf("merch is not available at this price and quantity: %w", pgx.ErrNoRows)).Once()
+	repoMock.
+		On("GetMerchByID", mock.Anything, merch.ID).
+		Return(merch, nil).Once()
 
 	loggerMock.
-		On("Errorw",
-			"Failed to create purchase",
+		On("Warnw",
+			"Merch out of stock",
 			"userID", userID,
 			"merchName", merchName,
-			"error", expectedErr,
+			"quantity", 1,
 		).Return().Once()
-
 	loggerMock.
 		On("Errorw",
-			"Non-retryable error during PurchaseMerch",
-			"error", fmt.Errorf("failed to create purchase: %w", expectedErr),
+			"Error during PurchaseMerch operation",
+			"error", fmt.Errorf("out of stock: T-Shirt"),
 		).Return().Once()
 
-	err := service.PurchaseMerch(ctx, userID, merchName)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to create purchase")
-	assert.True(t, errors.Is(err, expectedErr), "expected error to wrap %v but got %v", expectedErr, err)
+	_, err := service.PurchaseMerch(ctx, userID, merchName, 1, "")
+	assert.EqualError(t, err, "out of stock: T-Shirt")
 
-	loggerMock.AssertCalled(t, "Errorw",
-		"Failed to create purchase",
-		"userID", userID,
-		"merchName", merchName,
-		"error", expectedErr,
-	)
-	loggerMock.AssertCalled(t, "Errorw",
-		"Non-retryable error during PurchaseMerch",
-		"error", fmt.Errorf("failed to create purchase: %w", expectedErr),
-	)
-	repoMock.AssertCalled(t, "CreatePurchase", mock.Anything, mock.AnythingOfType("*models.Purchase"))
-	txManagerMock.AssertExpectations(t)
+	repoMock.AssertNotCalled(t, "CreateLedgerEntry", mock.Anything, mock.Anything)
+	repoMock.AssertNotCalled(t, "CreatePurchase", mock.Anything, mock.Anything)
 }
 
-func TestPurchaseMerch_Success(t *testing.T) {
+func TestPurchaseMerch_StaleCachedMerch(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     *models.Merch
+		warning     []interface{}
+		expectedErr error
+	}{
+		{
+			name:        "Price changed",
+			current:     &models.Merch{ID: 10, Name: "pen", Price: 15},
+			warning:     []interface{}{"Merch price changed during purchase", "userID", 1, "merchName", "pen", "expectedPrice", 10, "price", 15},
+			expectedErr: ErrPriceChanged,
+		},
+		{
+			name:        "Archived",
+			current:     &models.Merch{ID: 10, Name: "pen", Price: 10, ArchivedAt: &time.Time{}},
+			warning:     []interface{}{"Attempt to purchase archived merch", "userID", 1, "merchName", "pen"},
+			expectedErr: ErrMerchUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoMock := mockRepo.NewRepository(t)
+			loggerMock := mockLog.NewLogger(t)
+			txManagerMock := mockRepo.NewTxManager(t)
+			service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+			setupReadCommittedTx(txManagerMock)
+
+			// Кеш ещё отдаёт товар по старой цене; списание сверяет её с базой и не проходит
+			cached := &models.Merch{ID: 10, Name: "pen", Price: 10}
+			repoMock.On("GetMerchByName", mock.Anything, "pen").Return(cached, nil).Once()
+			repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 100}, nil).Once()
+			repoMock.
+				On("DecrementMerchStock", mock.Anything, 10, 10, 1).
+				Return(nil, fmt.Errorf("merch is not available at this price and quantity: %w", pgx.ErrNoRows)).Once()
+			repoMock.On("GetMerchByID", mock.Anything, 10).Return(tt.current, nil).Once()
+
+			loggerMock.On("Warnw", tt.warning...).Once()
+			loggerMock.On("Errorw", "Error during PurchaseMerch operation", "error", mock.Anything).Once()
+
+			_, err := service.PurchaseMerch(context.Background(), 1, "pen", 1, "")
+			assert.ErrorIs(t, err, tt.expectedErr)
+
+			repoMock.AssertNotCalled(t, "DebitBalance", mock.Anything, mock.Anything, mock.Anything)
+			repoMock.AssertNotCalled(t, "CreatePurchase", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestPurchaseMerch_SuccessWithStock(t *testing.T) {
 	repoMock := mockRepo.NewRepository(t)
 	loggerMock := mockLog.NewLogger(t)
 	txManagerMock := mockRepo.NewTxManager(t)
-	service := NewPurchaseService(repoMock, loggerMock, txManagerMock)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
 
 	ctx := context.Background()
 	userID := 1
 	merchName := "T-Shirt"
-	merch := &models.Merch{ID: 10, Name: merchName, Price: 50}
-	balance := 100
-	newBalance := balance - merch.Price
+	merch := &models.Merch{ID: 10, Name: merchName, Price: 50, Stock: intPtr(3)}
 
 	repoMock.
 		On("GetMerchByName", mock.Anything, merchName).
 		Return(merch, nil).Once()
 
 	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
 			mock.AnythingOfType("func(context.Context) error")).
 		Run(func(args mock.Arguments) {
 			_ = args.Get(3).(func(context.Context) error)(context.Background())
@@ -368,23 +497,567 @@ func TestPurchaseMerch_Success(t *testing.T) {
 		Return(nil).Once()
 
 	repoMock.
-		On("GetBalanceByID", mock.Anything, userID).
-		Return(balance, nil).Once()
+		On("LockBalances", mock.Anything, userID).
+		Return(map[int]int{userID: 100}, nil).Once()
 	repoMock.
-		On("UpdateBalance", mock.Anything, userID, newBalance).
-		Return(nil).Once()
+		On("DecrementMerchStock", mock.Anything, merch.ID, merch.Price, 1).
+		Return(intPtr(2), nil).Once()
+	repoMock.
+		On("CreatePurchase", mock.Anything, mock.AnythingOfType("*models.Purchase")).
+		Return(1, nil).Once()
+	repoMock.
+		On("CreatePurchaseStatusChange", mock.Anything, mock.MatchedBy(func(c *models.PurchaseStatusChange) bool {
+			return c.Status == models.PurchaseStatusPlaced
+		})).
+		Return(1, nil).Once()
+	repoMock.
+		On("DebitBalance", mock.Anything, userID, 50).
+		Return(50, nil).Once()
+	repoMock.
+		On("CreateLedgerEntry", mock.Anything, mock.AnythingOfType("*models.LedgerEntry")).
+		Return(1, nil).Once()
+
+	_, err := service.PurchaseMerch(ctx, userID, merchName, 1, "")
+	assert.NoError(t, err)
+
+	repoMock.AssertCalled(t, "DecrementMerchStock", mock.Anything, merch.ID, merch.Price, 1)
+}
+
+var testPurchaseConfig = config.PurchaseConfig{RefundWindow: 900}
+
+func setupSerializableTx(txManagerMock *mockRepo.TxManager) {
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Return(func(ctx context.Context, _ pgx.TxIsoLevel, _ pgx.TxAccessMode, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+}
+
+func TestPurchaseMerch_Quantity(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	ctx := context.Background()
+	merch := &models.Merch{ID: 10, Name: "pen", Price: 10}
+
+	repoMock.On("GetMerchByName", mock.Anything, "pen").Return(merch, nil).Once()
+	repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 100}, nil).Once()
+	repoMock.On("DecrementMerchStock", mock.Anything, merch.ID, merch.Price, 5).Return(nil, nil).Once()
 	repoMock.
 		On("CreatePurchase", mock.Anything, mock.MatchedBy(func(p *models.Purchase) bool {
-			return p.UserID == userID && p.MerchID == merch.ID
+			return p.MerchID == merch.ID && p.Quantity == 5 && p.UnitPrice == 10
 		})).
 		Return(1, nil).Once()
+	repoMock.
+		On("CreatePurchaseStatusChange", mock.Anything, mock.MatchedBy(func(c *models.PurchaseStatusChange) bool {
+			return c.Status == models.PurchaseStatusPlaced
+		})).
+		Return(1, nil).Once()
+	repoMock.On("DebitBalance", mock.Anything, 1, 50).Return(50, nil).Once()
+	repoMock.
+		On("CreateLedgerEntry", mock.Anything, matchLedgerEntry(models.LedgerReasonPurchase, 0,
+			models.UserPosting(1, -50),
+			models.SystemPosting(models.AccountMerchStore, 50),
+		)).
+		Return(1, nil).Once()
 
-	err := service.PurchaseMerch(ctx, userID, merchName)
+	_, err := service.PurchaseMerch(ctx, 1, "pen", 5, "")
 	assert.NoError(t, err)
+}
 
-	repoMock.AssertCalled(t, "GetMerchByName", mock.Anything, merchName)
-	repoMock.AssertCalled(t, "GetBalanceByID", mock.Anything, userID)
-	repoMock.AssertCalled(t, "UpdateBalance", mock.Anything, userID, newBalance)
-	repoMock.AssertCalled(t, "CreatePurchase", mock.Anything, mock.AnythingOfType("*models.Purchase"))
-	txManagerMock.AssertExpectations(t)
+func TestPurchaseMerch_InvalidQuantity(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+
+	for _, quantity := range []int{0, -1, MaxPurchaseQuantity + 1} {
+		_, err := service.PurchaseMerch(context.Background(), 1, "pen", quantity, "")
+		assert.ErrorIs(t, err, ErrInvalidAmount)
+	}
+
+	repoMock.AssertNotCalled(t, "GetMerchByName", mock.Anything, mock.Anything)
+}
+
+func TestCheckout_Success(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	ctx := context.Background()
+	items := []*models.CartItem{
+		{UserID: 1, MerchID: 3, Quantity: 5, Merch: &models.Merch{ID: 3, Name: "pen", Price: 10}},
+		{UserID: 1, MerchID: 4, Quantity: 2, Merch: &models.Merch{ID: 4, Name: "badge", Price: 15, Stock: intPtr(4)}},
+	}
+
+	repoMock.On("GetCartItems", mock.Anything, 1).Return(items, nil).Once()
+	repoMock.On("ClearCart", mock.Anything, 1).Return(nil).Once()
+	repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 100}, nil).Once()
+	repoMock.On("DecrementMerchStock", mock.Anything, 3, 10, 5).Return(nil, nil).Once()
+	repoMock.On("DecrementMerchStock", mock.Anything, 4, 15, 2).Return(intPtr(2), nil).Once()
+	repoMock.On("CreatePurchase", mock.Anything, mock.AnythingOfType("*models.Purchase")).Return(1, nil).Twice()
+	repoMock.
+		On("CreatePurchaseStatusChange", mock.Anything, mock.MatchedBy(func(c *models.PurchaseStatusChange) bool {
+			return c.Status == models.PurchaseStatusPlaced
+		})).
+		Return(1, nil).Twice()
+	repoMock.On("DebitBalance", mock.Anything, 1, 50).Return(50, nil).Once()
+	repoMock.On("DebitBalance", mock.Anything, 1, 30).Return(20, nil).Once()
+	repoMock.
+		On("CreateLedgerEntry", mock.Anything, mock.MatchedBy(func(e *models.LedgerEntry) bool {
+			return e.Reason == models.LedgerReasonPurchase && e.IsBalanced()
+		})).
+		Return(1, nil).Twice()
+
+	purchases, err := service.Checkout(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, purchases, 2)
+	assert.Equal(t, 50, purchases[0].TotalPrice())
+	assert.Equal(t, 30, purchases[1].TotalPrice())
+}
+
+func TestCheckout_EmptyCart(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+	repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 100}, nil).Once()
+
+	repoMock.On("GetCartItems", mock.Anything, 1).Return([]*models.CartItem{}, nil).Once()
+	loggerMock.On("Errorw", "Error during Checkout operation", "error", fmt.Errorf("cart is empty")).Return().Once()
+
+	purchases, err := service.Checkout(context.Background(), 1)
+	assert.EqualError(t, err, "cart is empty")
+	assert.Nil(t, purchases)
+	repoMock.AssertNotCalled(t, "CreatePurchase", mock.Anything, mock.Anything)
+}
+
+func TestCheckout_InsufficientFunds(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	items := []*models.CartItem{
+		{UserID: 1, MerchID: 3, Quantity: 5, Merch: &models.Merch{ID: 3, Name: "pen", Price: 10}},
+		{UserID: 1, MerchID: 4, Quantity: 1, Merch: &models.Merch{ID: 4, Name: "hoody", Price: 300}},
+	}
+
+	repoMock.On("GetCartItems", mock.Anything, 1).Return(items, nil).Once()
+	repoMock.On("ClearCart", mock.Anything, 1).Return(nil).Once()
+	repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 100}, nil).Once()
+	loggerMock.On("Warnw", "Insufficient funds", "userID", 1, "balance", 100, "orderTotal", 350).Return().Once()
+	loggerMock.On("Errorw", "Error during Checkout operation", "error", fmt.Errorf("insufficient funds")).Return().Once()
+
+	purchases, err := service.Checkout(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	assert.Nil(t, purchases)
+	repoMock.AssertNotCalled(t, "CreateLedgerEntry", mock.Anything, mock.Anything)
+	repoMock.AssertNotCalled(t, "CreatePurchase", mock.Anything, mock.Anything)
+}
+
+func TestCheckout_LineOverLimit(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+	repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 10000}, nil).Once()
+
+	items := []*models.CartItem{
+		{UserID: 1, MerchID: 3, Quantity: MaxPurchaseQuantity + 1, Merch: &models.Merch{ID: 3, Name: "pen", Price: 10}},
+	}
+
+	repoMock.On("GetCartItems", mock.Anything, 1).Return(items, nil).Once()
+	loggerMock.On("Errorw", "Error during Checkout operation", "error", mock.Anything).Return().Once()
+
+	_, err := service.Checkout(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+	assert.EqualError(t, err, "invalid amount: quantity must be between 1 and 100 (pen)")
+	repoMock.AssertNotCalled(t, "ClearCart", mock.Anything, mock.Anything)
+	repoMock.AssertNotCalled(t, "DecrementMerchStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckout_ArchivedItem(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+	repoMock.On("LockBalances", mock.Anything, 1).Return(map[int]int{1: 100}, nil).Once()
+
+	archivedAt := time.Now()
+	items := []*models.CartItem{
+		{UserID: 1, MerchID: 3, Quantity: 1, Merch: &models.Merch{ID: 3, Name: "old-cup", Price: 10, ArchivedAt: &archivedAt}},
+	}
+
+	repoMock.On("GetCartItems", mock.Anything, 1).Return(items, nil).Once()
+	loggerMock.On("Warnw", "Attempt to checkout archived merch", "userID", 1, "merchName", "old-cup").Return().Once()
+	loggerMock.On("Errorw", "Error during Checkout operation", "error", fmt.Errorf("%w: old-cup", ErrMerchUnavailable)).Return().Once()
+
+	_, err := service.Checkout(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrMerchUnavailable)
+	assert.EqualError(t, err, "merch is not available: old-cup")
+	repoMock.AssertNotCalled(t, "ClearCart", mock.Anything, mock.Anything)
+}
+
+func TestRefundPurchase_OwnerWithinWindow(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupSerializableTx(txManagerMock)
+
+	purchase := &models.Purchase{ID: 7, Status: models.PurchaseStatusPlaced, UserID: 1, MerchID: 3, Quantity: 2, UnitPrice: 20, CreatedAt: time.Now().Add(-time.Minute)}
+	refundedAt := time.Now()
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(purchase, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 1, 40).Return(40, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, matchLedgerEntry(models.LedgerReasonRefund, 7,
+		models.SystemPosting(models.AccountMerchStore, -40),
+		models.UserPosting(1, 40),
+	)).Return(1, nil).Once()
+	repoMock.On("GetMerchByID", mock.Anything, 3).Return(&models.Merch{ID: 3, Name: "cup", Price: 20, Stock: intPtr(4)}, nil).Once()
+	repoMock.On("RestockMerch", mock.Anything, 3, 2).Return(6, nil).Once()
+	repoMock.On("MarkPurchaseRefunded", mock.Anything, 7, 1).Return(refundedAt, nil).Once()
+	repoMock.On("UpdatePurchaseStatus", mock.Anything, 7, models.PurchaseStatusPlaced, models.PurchaseStatusCancelled).Return(nil).Once()
+	repoMock.On("CreatePurchaseStatusChange", mock.Anything, mock.MatchedBy(func(c *models.PurchaseStatusChange) bool {
+		return c.PurchaseID == 7 && c.Status == models.PurchaseStatusCancelled && *c.ChangedBy == 1
+	})).Return(1, nil).Once()
+
+	refunded, err := service.RefundPurchase(context.Background(), 1, models.RoleEmployee, 7)
+	assert.NoError(t, err)
+	assert.True(t, refunded.IsRefunded())
+	assert.Equal(t, refundedAt, *refunded.RefundedAt)
+	assert.Equal(t, 1, *refunded.RefundedBy)
+}
+
+func TestRefundPurchase_UnlimitedMerchKeepsStockUntracked(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupSerializableTx(txManagerMock)
+
+	purchase := &models.Purchase{ID: 7, Status: models.PurchaseStatusPlaced, UserID: 1, MerchID: 3, Quantity: 1, UnitPrice: 20, CreatedAt: time.Now()}
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(purchase, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 1, 20).Return(20, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, matchLedgerEntry(models.LedgerReasonRefund, 7,
+		models.SystemPosting(models.AccountMerchStore, -20),
+		models.UserPosting(1, 20),
+	)).Return(1, nil).Once()
+	repoMock.On("GetMerchByID", mock.Anything, 3).Return(&models.Merch{ID: 3, Name: "cup", Price: 20}, nil).Once()
+	repoMock.On("MarkPurchaseRefunded", mock.Anything, 7, 1).Return(time.Now(), nil).Once()
+	repoMock.On("UpdatePurchaseStatus", mock.Anything, 7, models.PurchaseStatusPlaced, models.PurchaseStatusCancelled).Return(nil).Once()
+	repoMock.On("CreatePurchaseStatusChange", mock.Anything, mock.MatchedBy(func(c *models.PurchaseStatusChange) bool {
+		return c.PurchaseID == 7 && c.Status == models.PurchaseStatusCancelled && *c.ChangedBy == 1
+	})).Return(1, nil).Once()
+
+	_, err := service.RefundPurchase(context.Background(), 1, models.RoleEmployee, 7)
+	assert.NoError(t, err)
+	repoMock.AssertNotCalled(t, "RestockMerch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRefundPurchase_AdminOutsideWindow(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupSerializableTx(txManagerMock)
+
+	purchase := &models.Purchase{ID: 7, Status: models.PurchaseStatusPlaced, UserID: 2, MerchID: 3, Quantity: 1, UnitPrice: 20, CreatedAt: time.Now().Add(-48 * time.Hour)}
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(purchase, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 2, 20).Return(20, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, matchLedgerEntry(models.LedgerReasonRefund, 7,
+		models.SystemPosting(models.AccountMerchStore, -20),
+		models.UserPosting(2, 20),
+	)).Return(1, nil).Once()
+	repoMock.On("GetMerchByID", mock.Anything, 3).Return(&models.Merch{ID: 3, Name: "cup", Price: 25}, nil).Once()
+	repoMock.On("MarkPurchaseRefunded", mock.Anything, 7, 1).Return(time.Now(), nil).Once()
+	repoMock.On("UpdatePurchaseStatus", mock.Anything, 7, models.PurchaseStatusPlaced, models.PurchaseStatusCancelled).Return(nil).Once()
+	repoMock.On("CreatePurchaseStatusChange", mock.Anything, mock.MatchedBy(func(c *models.PurchaseStatusChange) bool {
+		return c.PurchaseID == 7 && c.Status == models.PurchaseStatusCancelled && *c.ChangedBy == 1
+	})).Return(1, nil).Once()
+
+	refunded, err := service.RefundPurchase(context.Background(), 1, models.RoleAdmin, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *refunded.RefundedBy)
+}
+
+func TestRefundPurchase_WindowExpired(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupSerializableTx(txManagerMock)
+
+	purchase := &models.Purchase{ID: 7, Status: models.PurchaseStatusPlaced, UserID: 1, MerchID: 3, Quantity: 1, UnitPrice: 20, CreatedAt: time.Now().Add(-time.Hour)}
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(purchase, nil).Once()
+	loggerMock.On("Warnw", "Refund window has expired", "requesterID", 1, "purchaseID", 7, "createdAt", purchase.CreatedAt).Return().Once()
+	loggerMock.On("Errorw", "Non-retryable error during RefundPurchase", "error", fmt.Errorf("%w: refund window has expired", ErrRefundNotAllowed)).Return().Once()
+
+	_, err := service.RefundPurchase(context.Background(), 1, models.RoleEmployee, 7)
+	assert.ErrorIs(t, err, ErrRefundNotAllowed)
+	repoMock.AssertNotCalled(t, "CreateLedgerEntry", mock.Anything, mock.Anything)
+}
+
+func TestRefundPurchase_SelfServiceDisabled(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, config.PurchaseConfig{}, txManagerMock)
+	setupSerializableTx(txManagerMock)
+
+	purchase := &models.Purchase{ID: 7, Status: models.PurchaseStatusPlaced, UserID: 1, MerchID: 3, Quantity: 1, UnitPrice: 20, CreatedAt: time.Now()}
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(purchase, nil).Once()
+	loggerMock.On("Warnw", "Refund window has expired", "requesterID", 1, "purchaseID", 7, "createdAt", purchase.CreatedAt).Return().Once()
+	loggerMock.On("Errorw", "Non-retryable error during RefundPurchase", "error", fmt.Errorf("%w: refund window has expired", ErrRefundNotAllowed)).Return().Once()
+
+	_, err := service.RefundPurchase(context.Background(), 1, models.RoleEmployee, 7)
+	assert.ErrorIs(t, err, ErrRefundNotAllowed)
+}
+
+func TestRefundPurchase_AnotherUsersPurchase(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupSerializableTx(txManagerMock)
+
+	purchase := &models.Purchase{ID: 7, Status: models.PurchaseStatusPlaced, UserID: 2, MerchID: 3, Quantity: 1, UnitPrice: 20, CreatedAt: time.Now()}
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(purchase, nil).Once()
+	loggerMock.On("Warnw", "Attempt to refund another user's purchase", "requesterID", 1, "purchaseID", 7).Return().Once()
+	loggerMock.On("Errorw", "Non-retryable error during RefundPurchase", "error", fmt.Errorf("purchase not found")).Return().Once()
+
+	_, err := service.RefundPurchase(context.Background(), 1, models.RoleEmployee, 7)
+	assert.ErrorIs(t, err, ErrPurchaseNotFound)
+}
+
+func TestRefundPurchase_AlreadyRefunded(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupSerializableTx(txManagerMock)
+
+	refundedAt := time.Now()
+	purchase := &models.Purchase{ID: 7, Status: models.PurchaseStatusPlaced, UserID: 1, MerchID: 3, Quantity: 1, UnitPrice: 20, CreatedAt: time.Now(), RefundedAt: &refundedAt}
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(purchase, nil).Once()
+	loggerMock.On("Errorw", "Non-retryable error during RefundPurchase", "error", fmt.Errorf("purchase is already refunded")).Return().Once()
+
+	_, err := service.RefundPurchase(context.Background(), 1, models.RoleAdmin, 7)
+	assert.EqualError(t, err, "purchase is already refunded")
+}
+
+func TestRefundPurchase_NotFound(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupSerializableTx(txManagerMock)
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(nil, fmt.Errorf("failed to retrieve purchase: %w", pgx.ErrNoRows)).Once()
+	loggerMock.On("Errorw", "Non-retryable error during RefundPurchase", "error", fmt.Errorf("purchase not found")).Return().Once()
+
+	_, err := service.RefundPurchase(context.Background(), 1, models.RoleAdmin, 7)
+	assert.EqualError(t, err, "purchase not found")
+}
+
+func TestRefundPurchase_RetriesOnSerializationError(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+
+	serializationErr := &pgconn.PgError{Code: "40001"}
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
+			mock.AnythingOfType("func(context.Context) error")).
+		Return(serializationErr).Once()
+	setupSerializableTx(txManagerMock)
+
+	purchase := &models.Purchase{ID: 7, Status: models.PurchaseStatusPlaced, UserID: 1, MerchID: 3, Quantity: 1, UnitPrice: 20, CreatedAt: time.Now()}
+
+	loggerMock.On("Infow", "Serialization error during RefundPurchase, retrying", "attempt", 1, "error", serializationErr).Return().Once()
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(purchase, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 1, 20).Return(20, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, matchLedgerEntry(models.LedgerReasonRefund, 7,
+		models.SystemPosting(models.AccountMerchStore, -20),
+		models.UserPosting(1, 20),
+	)).Return(1, nil).Once()
+	repoMock.On("GetMerchByID", mock.Anything, 3).Return(&models.Merch{ID: 3, Name: "cup", Price: 20}, nil).Once()
+	repoMock.On("MarkPurchaseRefunded", mock.Anything, 7, 1).Return(time.Now(), nil).Once()
+	repoMock.On("UpdatePurchaseStatus", mock.Anything, 7, models.PurchaseStatusPlaced, models.PurchaseStatusCancelled).Return(nil).Once()
+	repoMock.On("CreatePurchaseStatusChange", mock.Anything, mock.MatchedBy(func(c *models.PurchaseStatusChange) bool {
+		return c.PurchaseID == 7 && c.Status == models.PurchaseStatusCancelled && *c.ChangedBy == 1
+	})).Return(1, nil).Once()
+
+	_, err := service.RefundPurchase(context.Background(), 1, models.RoleEmployee, 7)
+	assert.NoError(t, err)
+}
+
+func TestRefundPurchase_DeliveredPurchase(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupSerializableTx(txManagerMock)
+
+	purchase := &models.Purchase{ID: 7, Status: models.PurchaseStatusDelivered, UserID: 1, MerchID: 3, Quantity: 1, UnitPrice: 20, CreatedAt: time.Now()}
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(purchase, nil).Once()
+	loggerMock.On("Errorw", "Non-retryable error during RefundPurchase", "error", fmt.Errorf("%w: purchase is delivered", ErrRefundNotAllowed)).Return().Once()
+
+	_, err := service.RefundPurchase(context.Background(), 1, models.RoleAdmin, 7)
+	assert.EqualError(t, err, "refund is not allowed: purchase is delivered")
+	repoMock.AssertNotCalled(t, "CreateLedgerEntry", mock.Anything, mock.Anything)
+}
+
+func TestUpdatePurchaseStatus_Transitions(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{name: "placed to ready for pickup", from: models.PurchaseStatusPlaced, to: models.PurchaseStatusReadyForPickup},
+		{name: "ready for pickup to delivered", from: models.PurchaseStatusReadyForPickup, to: models.PurchaseStatusDelivered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoMock := mockRepo.NewRepository(t)
+			loggerMock := mockLog.NewLogger(t)
+			txManagerMock := mockRepo.NewTxManager(t)
+			service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+			setupSerializableTx(txManagerMock)
+
+			purchase := &models.Purchase{ID: 7, UserID: 2, Status: tt.from}
+
+			repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(purchase, nil).Once()
+			repoMock.On("UpdatePurchaseStatus", mock.Anything, 7, tt.from, tt.to).Return(nil).Once()
+			repoMock.On("CreatePurchaseStatusChange", mock.Anything, mock.MatchedBy(func(c *models.PurchaseStatusChange) bool {
+				return c.PurchaseID == 7 && c.Status == tt.to && *c.ChangedBy == 1
+			})).Return(1, nil).Once()
+
+			updated, err := service.UpdatePurchaseStatus(context.Background(), 1, 7, tt.to)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.to, updated.Status)
+		})
+	}
+}
+
+func TestUpdatePurchaseStatus_InvalidTransition(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{name: "skip ready for pickup", from: models.PurchaseStatusPlaced, to: models.PurchaseStatusDelivered},
+		{name: "back to placed", from: models.PurchaseStatusReadyForPickup, to: models.PurchaseStatusPlaced},
+		{name: "from delivered", from: models.PurchaseStatusDelivered, to: models.PurchaseStatusReadyForPickup},
+		{name: "from cancelled", from: models.PurchaseStatusCancelled, to: models.PurchaseStatusReadyForPickup},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoMock := mockRepo.NewRepository(t)
+			loggerMock := mockLog.NewLogger(t)
+			txManagerMock := mockRepo.NewTxManager(t)
+			service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+			setupSerializableTx(txManagerMock)
+
+			expectedErr := fmt.Errorf("%w from %s to %s", ErrInvalidStatusTransition, tt.from, tt.to)
+
+			repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(&models.Purchase{ID: 7, Status: tt.from}, nil).Once()
+			loggerMock.On("Warnw", "Invalid purchase status transition", "purchaseID", 7, "from", tt.from, "to", tt.to).Return().Once()
+			loggerMock.On("Errorw", "Non-retryable error during UpdatePurchaseStatus", "error", expectedErr).Return().Once()
+
+			_, err := service.UpdatePurchaseStatus(context.Background(), 1, 7, tt.to)
+			assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+			assert.EqualError(t, err, expectedErr.Error())
+			repoMock.AssertNotCalled(t, "UpdatePurchaseStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUpdatePurchaseStatus_UnknownStatus(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+
+	_, err := service.UpdatePurchaseStatus(context.Background(), 1, 7, "lost")
+	assert.EqualError(t, err, "invalid purchase status: lost")
+}
+
+func TestUpdatePurchaseStatus_CancelRefunds(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+	setupSerializableTx(txManagerMock)
+
+	purchase := &models.Purchase{ID: 7, Status: models.PurchaseStatusReadyForPickup, UserID: 2, MerchID: 3, Quantity: 1, UnitPrice: 20, CreatedAt: time.Now().Add(-48 * time.Hour)}
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(purchase, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 2, 20).Return(20, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, matchLedgerEntry(models.LedgerReasonRefund, 7,
+		models.SystemPosting(models.AccountMerchStore, -20),
+		models.UserPosting(2, 20),
+	)).Return(1, nil).Once()
+	repoMock.On("GetMerchByID", mock.Anything, 3).Return(&models.Merch{ID: 3, Name: "cup", Price: 20}, nil).Once()
+	repoMock.On("MarkPurchaseRefunded", mock.Anything, 7, 1).Return(time.Now(), nil).Once()
+	repoMock.On("UpdatePurchaseStatus", mock.Anything, 7, models.PurchaseStatusReadyForPickup, models.PurchaseStatusCancelled).Return(nil).Once()
+	repoMock.On("CreatePurchaseStatusChange", mock.Anything, mock.AnythingOfType("*models.PurchaseStatusChange")).Return(1, nil).Once()
+
+	cancelled, err := service.UpdatePurchaseStatus(context.Background(), 1, 7, models.PurchaseStatusCancelled)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PurchaseStatusCancelled, cancelled.Status)
+	assert.True(t, cancelled.IsRefunded())
+}
+
+func TestGetPurchaseStatusHistory(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+
+	history := []*models.PurchaseStatusChange{
+		{ID: 1, PurchaseID: 7, Status: models.PurchaseStatusPlaced},
+		{ID: 2, PurchaseID: 7, Status: models.PurchaseStatusReadyForPickup},
+	}
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(&models.Purchase{ID: 7}, nil).Once()
+	repoMock.On("GetPurchaseStatusHistory", mock.Anything, 7).Return(history, nil).Once()
+
+	result, err := service.GetPurchaseStatusHistory(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.Equal(t, history, result)
+}
+
+func TestGetPurchaseStatusHistory_NotFound(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewPurchaseService(repoMock, loggerMock, testPurchaseConfig, txManagerMock)
+
+	repoMock.On("GetPurchaseByID", mock.Anything, 7).Return(nil, fmt.Errorf("failed to retrieve purchase: %w", pgx.ErrNoRows)).Once()
+
+	_, err := service.GetPurchaseStatusHistory(context.Background(), 7)
+	assert.EqualError(t, err, "purchase not found")
+	repoMock.AssertNotCalled(t, "GetPurchaseStatusHistory", mock.Anything, mock.Anything)
 }
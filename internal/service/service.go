@@ -2,6 +2,7 @@ package service
 
 import (
 	"avito-tech-merch/internal/models"
+	myjwt "avito-tech-merch/pkg/jwt"
 	"context"
 )
 
@@ -10,30 +11,60 @@ type Service interface {
 	UserService
 	MerchService
 	PurchaseService
+	CartService
 	TransactionService
+	AdminService
 }
 
 type AuthService interface {
-	Register(ctx context.Context, username string, password string) (string, error)
-	Login(ctx context.Context, username string, password string) (string, error)
-	ValidateToken(token string) (int, error)
+	Register(ctx context.Context, username string, password string) (*models.TokenPair, error)
+	Login(ctx context.Context, username string, password string) (*models.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	Logout(ctx context.Context, claims *myjwt.Claims, refreshToken string) error
+	LogoutAll(ctx context.Context, userID int) error
+	ValidateToken(ctx context.Context, token string) (*myjwt.Claims, error)
+	GetJWKS() myjwt.JWKS
 }
 
 type UserService interface {
-	GetInfo(ctx context.Context, userID int) (*models.UserInfo, error)
+	GetInfo(ctx context.Context, userID int, filter models.TransactionFilter) (*models.UserInfo, error)
+	GetTransactionHistory(ctx context.Context, userID int, filter models.TransactionFilter, page models.HistoryPage) (*models.TransferPage, error)
+	GetPurchaseHistory(ctx context.Context, userID int, filter models.PurchaseFilter, page models.HistoryPage) (*models.PurchasePage, error)
 }
 
 type MerchService interface {
 	ListMerch(ctx context.Context) ([]*models.Merch, error)
 	GetMerch(ctx context.Context, merchID int) (*models.Merch, error)
+	ListAllMerch(ctx context.Context) ([]*models.Merch, error)
+	CreateMerch(ctx context.Context, adminID int, name string, price int, stock *int) (*models.Merch, error)
+	UpdateMerch(ctx context.Context, adminID int, merchID int, name string, price int) (*models.Merch, error)
+	ArchiveMerch(ctx context.Context, merchID int) error
+	RestockMerch(ctx context.Context, merchID int, quantity int) (*models.Merch, error)
+	SetMerchStock(ctx context.Context, merchID int, stock *int) (*models.Merch, error)
+	GetMerchPriceHistory(ctx context.Context, merchID int) ([]*models.MerchPrice, error)
 }
 
 type PurchaseService interface {
-	PurchaseMerch(ctx context.Context, userID int, merchName string) error
+	PurchaseMerch(ctx context.Context, userID int, merchName string, quantity int, idempotencyKey string) (*models.Purchase, error)
+	Checkout(ctx context.Context, userID int) ([]*models.Purchase, error)
+	RefundPurchase(ctx context.Context, requesterID int, requesterRole string, purchaseID int) (*models.Purchase, error)
+	UpdatePurchaseStatus(ctx context.Context, adminID int, purchaseID int, status string) (*models.Purchase, error)
+	GetPurchaseStatusHistory(ctx context.Context, purchaseID int) ([]*models.PurchaseStatusChange, error)
+}
+
+type CartService interface {
+	GetCart(ctx context.Context, userID int) (*models.Cart, error)
+	AddToCart(ctx context.Context, userID int, merchName string, quantity int) (*models.Cart, error)
+	RemoveFromCart(ctx context.Context, userID int, merchName string) (*models.Cart, error)
 }
 
 type TransactionService interface {
-	TransferCoins(ctx context.Context, senderID int, receiverID int, amount int) error
+	TransferCoins(ctx context.Context, senderID int, recipient models.TransferRecipient, amount int, memo models.TransferMemo, idempotencyKey string) (*models.Transaction, error)
+}
+
+type AdminService interface {
+	SetUserRole(ctx context.Context, adminID int, userID int, role string) (*models.User, error)
+	VerifyLedger(ctx context.Context) (*models.LedgerReport, error)
 }
 
 type service struct {
@@ -41,7 +72,9 @@ type service struct {
 	UserService
 	MerchService
 	PurchaseService
+	CartService
 	TransactionService
+	AdminService
 }
 
 func NewService(
@@ -49,13 +82,17 @@ func NewService(
 	user UserService,
 	merch MerchService,
 	purchase PurchaseService,
+	cart CartService,
 	transaction TransactionService,
+	admin AdminService,
 ) Service {
 	return &service{
 		AuthService:        auth,
 		UserService:        user,
 		MerchService:       merch,
 		PurchaseService:    purchase,
+		CartService:        cart,
 		TransactionService: transaction,
+		AdminService:       admin,
 	}
 }
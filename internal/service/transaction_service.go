@@ -7,9 +7,13 @@ import (
 	"avito-tech-merch/internal/storage/db/postgres"
 	"avito-tech-merch/pkg/logger"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type transactionService struct {
@@ -22,119 +26,194 @@ func NewTransactionService(repo db.Repository, log logger.Logger, txManager db.T
 	return &transactionService{repo: repo, logger: log, txManager: txManager}
 }
 
-func (s *transactionService) TransferCoins(ctx context.Context, senderID int, receiverID int, amount int) error {
+// TransferCoins переводит монеты и возвращает созданную транзакцию. Получатель задаётся логином или ID;
+// логин разрешается внутри транзакции перевода. С непустым idempotencyKey повтор запроса
+// возвращает исходную транзакцию, не списывая монеты второй раз. Сообщение и категория необязательны
+func (s *transactionService) TransferCoins(ctx context.Context, senderID int, recipient models.TransferRecipient, amount int, memo models.TransferMemo, idempotencyKey string) (*models.Transaction, error) {
 	metrics.RecordCoinTransfer()
 
 	if amount <= 0 {
-		s.logger.Warnw("Invalid transfer amount",
+		logger.WithContext(ctx, s.logger).Warnw("Invalid transfer amount",
 			"senderID", senderID,
-			"receiverID", receiverID,
+			"receiverID", recipient.ID,
 			"amount", amount,
 		)
-		return fmt.Errorf("invalid transfer amount: amount must be positive")
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidAmount)
 	}
 
-	if senderID == receiverID {
-		s.logger.Warnw("Sender and receiver are the same",
+	if recipient.Username == "" && senderID == recipient.ID {
+		logger.WithContext(ctx, s.logger).Warnw("Sender and receiver are the same",
 			"senderID", senderID,
-			"receiverID", receiverID,
+			"receiverID", recipient.ID,
 		)
-		return fmt.Errorf("cannot transfer to yourself")
+		return nil, ErrSelfTransfer
 	}
 
-	const maxRetries = 3
-	var err error
-
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		err = s.txManager.WithTx(ctx, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
-			senderBalance, err := s.repo.GetBalanceByID(txCtx, senderID)
-			if err != nil {
-				s.logger.Errorw("Failed to get sender balance",
-					"senderID", senderID,
-					"error", err,
-				)
-				return fmt.Errorf("failed to get sender balance: %w", err)
-			}
+	memo.Message = strings.TrimSpace(memo.Message)
+	if err := validateTransferMemo(memo); err != nil {
+		logger.WithContext(ctx, s.logger).Warnw("Invalid transfer memo",
+			"senderID", senderID,
+			"category", memo.Category,
+			"error", err,
+		)
+		return nil, err
+	}
 
-			if senderBalance < amount {
-				s.logger.Warnw("Insufficient funds",
-					"senderID", senderID,
-					"balance", senderBalance,
-					"amount", amount,
-				)
-				return fmt.Errorf("insufficient funds")
-			}
+	idempotency := newIdempotencyRequest(senderID, idempotencyKey, "TransferCoins",
+		recipient.ID, recipient.Username, amount, memo.Message, memo.Category)
 
-			receiverBalance, err := s.repo.GetBalanceByID(txCtx, receiverID)
-			if err != nil {
-				s.logger.Errorw("Failed to get receiver balance",
-					"receiverID", receiverID,
-					"error", err,
-				)
-				return fmt.Errorf("failed to get receiver balance: %w", err)
-			}
+	var result *models.Transaction
 
-			newSenderBalance := senderBalance - amount
-			if err = s.repo.UpdateBalance(txCtx, senderID, newSenderBalance); err != nil {
-				s.logger.Errorw("Failed to update sender balance",
-					"senderID", senderID,
-					"newBalance", newSenderBalance,
-					"error", err,
-				)
-				return fmt.Errorf("failed to update sender balance: %w", err)
-			}
+	// Строки отправителя и получателя блокируются, а списание выполняется условным UPDATE,
+	// поэтому перевод корректен при READ COMMITTED и не требует повторов при конфликтах сериализации
+	err := s.txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
+		receiverID, err := s.resolveReceiver(txCtx, recipient)
+		if err != nil {
+			return err
+		}
 
-			newReceiverBalance := receiverBalance + amount
-			if err = s.repo.UpdateBalance(txCtx, receiverID, newReceiverBalance); err != nil {
-				s.logger.Errorw("Failed to update receiver balance",
-					"receiverID", receiverID,
-					"newBalance", newReceiverBalance,
-					"error", err,
-				)
-				return fmt.Errorf("failed to update receiver balance: %w", err)
-			}
+		if senderID == receiverID {
+			logger.WithContext(ctx, s.logger).Warnw("Sender and receiver are the same",
+				"senderID", senderID,
+				"receiverID", receiverID,
+			)
+			return ErrSelfTransfer
+		}
 
-			transaction := &models.Transaction{
-				SenderID:   senderID,
-				ReceiverID: receiverID,
-				Amount:     amount,
-				CreatedAt:  time.Now(),
-			}
+		balances, err := s.repo.LockBalances(txCtx, senderID, receiverID)
+		if err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to lock balances",
+				"senderID", senderID,
+				"receiverID", receiverID,
+				"error", err,
+			)
+			return fmt.Errorf("failed to lock balances: %w", err)
+		}
 
-			if _, err = s.repo.CreateTransaction(txCtx, transaction); err != nil {
-				s.logger.Errorw("Failed to create transaction",
-					"senderID", senderID,
-					"receiverID", receiverID,
-					"amount", amount,
-					"error", err,
-				)
-				return fmt.Errorf("failed to create transaction: %w", err)
+		// Повтор проверяется после блокировки: параллельный запрос с тем же ключом к этому моменту уже зафиксирован
+		var replayed models.Transaction
+		if ok, err := replayIdempotent(txCtx, s.repo, s.logger, idempotency, &replayed); err != nil || ok {
+			if ok {
+				result = &replayed
 			}
+			return err
+		}
+
+		senderBalance, ok := balances[senderID]
+		if !ok {
+			logger.WithContext(ctx, s.logger).Errorw("Sender not found",
+				"senderID", senderID,
+			)
+			return fmt.Errorf("%w: id %d", ErrUserNotFound, senderID)
+		}
 
-			return nil
-		})
+		if _, ok = balances[receiverID]; !ok {
+			return fmt.Errorf("%w: id %d", ErrReceiverNotFound, receiverID)
+		}
+
+		if senderBalance < amount {
+			logger.WithContext(ctx, s.logger).Warnw("Insufficient funds",
+				"senderID", senderID,
+				"balance", senderBalance,
+				"amount", amount,
+			)
+			return ErrInsufficientFunds
+		}
+
+		transaction := &models.Transaction{
+			SenderID:   senderID,
+			ReceiverID: receiverID,
+			Amount:     amount,
+			Message:    memo.Message,
+			Category:   memo.Category,
+			CreatedAt:  time.Now(),
+		}
 
-		if err == nil {
-			return nil
+		if transaction.ID, err = s.repo.CreateTransaction(txCtx, transaction); err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to create transaction",
+				"senderID", senderID,
+				"receiverID", receiverID,
+				"amount", amount,
+				"error", err,
+			)
+			return fmt.Errorf("failed to create transaction: %w", err)
 		}
 
-		if !IsSerializationError(err) {
-			s.logger.Errorw("Non-retryable error during TransferCoins", "error", err)
+		entry := models.NewLedgerEntry(models.LedgerReasonTransfer, transaction.ID,
+			models.UserPosting(senderID, -amount),
+			models.UserPosting(receiverID, amount),
+		)
+		if err = postLedgerEntry(txCtx, s.repo, s.logger, entry); err != nil {
 			return err
 		}
 
-		if attempt == maxRetries {
-			s.logger.Errorw("Failed to transfer coins after retries", "error", err)
+		if err = saveIdempotent(txCtx, s.repo, s.logger, idempotency, transaction); err != nil {
 			return err
 		}
 
-		s.logger.Infow("Serialization error during coin transfer, retrying", "attempt", attempt, "error", err)
-		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond) // Экспоненциальная задержка: 100ms, 200ms, 300ms
+		result = transaction
+		return nil
+	})
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Error during TransferCoins operation", "error", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// resolveReceiver возвращает ID получателя: по логину, если он задан, иначе ID из запроса
+func (s *transactionService) resolveReceiver(ctx context.Context, recipient models.TransferRecipient) (int, error) {
+	if recipient.Username == "" {
+		return recipient.ID, nil
+	}
+
+	user, err := s.repo.GetUserByUsername(ctx, recipient.Username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.WithContext(ctx, s.logger).Warnw("Transfer receiver not found",
+				"username", recipient.Username,
+			)
+			return 0, fmt.Errorf("%w: %s", ErrReceiverNotFound, recipient.Username)
+		}
+		logger.WithContext(ctx, s.logger).Errorw("Failed to get receiver by username",
+			"username", recipient.Username,
+			"error", err,
+		)
+		return 0, fmt.Errorf("failed to get receiver: %w", err)
+	}
+
+	return user.ID, nil
+}
+
+// validateTransferMemo проверяет длину и содержимое сообщения и известность категории
+func validateTransferMemo(memo models.TransferMemo) error {
+	if memo.Category != "" && !models.IsValidTransferCategory(memo.Category) {
+		return fmt.Errorf("%w: unknown category %q", ErrInvalidTransferMemo, memo.Category)
+	}
 
+	if !utf8.ValidString(memo.Message) {
+		return fmt.Errorf("%w: message is not valid UTF-8", ErrInvalidTransferMemo)
 	}
-	return err
+
+	if utf8.RuneCountInString(memo.Message) > models.MaxTransferMessageLength {
+		return fmt.Errorf("%w: message is longer than %d characters", ErrInvalidTransferMemo, models.MaxTransferMessageLength)
+	}
+
+	// Переносы строк допустимы, остальные управляющие и невидимые символы форматирования - нет
+	for _, r := range memo.Message {
+		if r != '\n' && (unicode.IsControl(r) || unicode.Is(unicode.Cf, r)) {
+			return fmt.Errorf("%w: message contains control characters", ErrInvalidTransferMemo)
+		}
+	}
+
+	return nil
 }
 
 func IsSerializationError(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "SQLSTATE 40001")
 }
+
+func IsUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "SQLSTATE 23505")
+}
@@ -8,8 +8,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"strings"
 	"testing"
 )
 
@@ -31,9 +33,8 @@ func TestTransferCoins_InvalidAmount(t *testing.T) {
 			"amount", amount,
 		).Return()
 
-	err := service.TransferCoins(ctx, senderID, receiverID, amount)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "invalid transfer amount")
+	_, err := service.TransferCoins(ctx, senderID, models.TransferRecipient{ID: receiverID}, amount, models.TransferMemo{}, "")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
 	loggerMock.AssertCalled(t, "Warnw",
 		"Invalid transfer amount",
 		"senderID", senderID,
@@ -59,7 +60,7 @@ func TestTransferCoins_SameSenderReceiver(t *testing.T) {
 			"receiverID", receiverID,
 		).Return()
 
-	err := service.TransferCoins(ctx, senderID, receiverID, amount)
+	_, err := service.TransferCoins(ctx, senderID, models.TransferRecipient{ID: receiverID}, amount, models.TransferMemo{}, "")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "cannot transfer to yourself")
 	loggerMock.AssertCalled(t, "Warnw",
@@ -69,57 +70,26 @@ func TestTransferCoins_SameSenderReceiver(t *testing.T) {
 	)
 }
 
-func TestTransferCoins_GetSenderBalanceError(t *testing.T) {
+func TestTransferCoins_LockBalancesError(t *testing.T) {
 	repoMock := mockRepo.NewRepository(t)
 	loggerMock := mockLog.NewLogger(t)
 	txManagerMock := mockRepo.NewTxManager(t)
 	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
 
-	ctx := context.Background()
-	senderID, receiverID, amount := 1, 2, 100
-	expectedErr := errors.New("sender balance error")
-
-	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
-			mock.AnythingOfType("func(context.Context) error")).
-		Run(func(args mock.Arguments) {
-			fn := args.Get(3).(func(context.Context) error)
-			_ = fn(context.Background())
-		}).
-		Return(fmt.Errorf("failed to get sender balance: %w", expectedErr)).Once()
-
-	repoMock.
-		On("GetBalanceByID", mock.Anything, senderID).
-		Return(0, expectedErr).Once()
-
-	loggerMock.
-		On("Errorw",
-			"Failed to get sender balance",
-			"senderID", senderID,
-			"error", expectedErr,
-		).Return().Once()
-
-	loggerMock.
-		On("Errorw",
-			"Non-retryable error during TransferCoins",
-			"error", fmt.Errorf("failed to get sender balance: %w", expectedErr),
-		).Return().Once()
-
-	err := service.TransferCoins(ctx, senderID, receiverID, amount)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to get sender balance")
-
-	loggerMock.AssertCalled(t, "Errorw",
-		"Failed to get sender balance",
-		"senderID", senderID,
+	expectedErr := errors.New("lock error")
+	repoMock.On("LockBalances", mock.Anything, 1, 2).Return(nil, expectedErr).Once()
+	loggerMock.On("Errorw", "Failed to lock balances",
+		"senderID", 1,
+		"receiverID", 2,
 		"error", expectedErr,
-	)
-	loggerMock.AssertCalled(t, "Errorw",
-		"Non-retryable error during TransferCoins",
-		"error", fmt.Errorf("failed to get sender balance: %w", expectedErr),
-	)
-	repoMock.AssertCalled(t, "GetBalanceByID", mock.Anything, senderID)
-	txManagerMock.AssertExpectations(t)
+	).Return().Once()
+	loggerMock.On("Errorw", "Error during TransferCoins operation", "error", mock.Anything).Return().Once()
+
+	_, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{ID: 2}, 100, models.TransferMemo{}, "")
+	assert.ErrorIs(t, err, expectedErr)
+	assert.Contains(t, err.Error(), "failed to lock balances")
+	repoMock.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
 }
 
 func TestTransferCoins_InsufficientFunds(t *testing.T) {
@@ -133,7 +103,7 @@ func TestTransferCoins_InsufficientFunds(t *testing.T) {
 	balance := 50
 
 	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
 			mock.AnythingOfType("func(context.Context) error")).
 		Run(func(args mock.Arguments) {
 			fn := args.Get(3).(func(context.Context) error)
@@ -142,7 +112,7 @@ func TestTransferCoins_InsufficientFunds(t *testing.T) {
 		Return(fmt.Errorf("insufficient funds")).Once()
 
 	repoMock.
-		On("GetBalanceByID", mock.Anything, senderID).Return(balance, nil).Once()
+		On("LockBalances", mock.Anything, senderID, receiverID).Return(map[int]int{senderID: balance, receiverID: 0}, nil).Once()
 
 	loggerMock.
 		On("Warnw",
@@ -154,11 +124,11 @@ func TestTransferCoins_InsufficientFunds(t *testing.T) {
 
 	loggerMock.
 		On("Errorw",
-			"Non-retryable error during TransferCoins",
+			"Error during TransferCoins operation",
 			"error", fmt.Errorf("insufficient funds"),
 		).Return().Once()
 
-	err := service.TransferCoins(ctx, senderID, receiverID, amount)
+	_, err := service.TransferCoins(ctx, senderID, models.TransferRecipient{ID: receiverID}, amount, models.TransferMemo{}, "")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "insufficient funds")
 
@@ -169,173 +139,38 @@ func TestTransferCoins_InsufficientFunds(t *testing.T) {
 		"amount", amount,
 	)
 	loggerMock.AssertCalled(t, "Errorw",
-		"Non-retryable error during TransferCoins",
+		"Error during TransferCoins operation",
 		"error", fmt.Errorf("insufficient funds"),
 	)
-	repoMock.AssertCalled(t, "GetBalanceByID", mock.Anything, senderID)
+	repoMock.AssertNotCalled(t, "DebitBalance", mock.Anything, mock.Anything, mock.Anything)
 	txManagerMock.AssertExpectations(t)
 }
 
-func TestTransferCoins_GetReceiverBalanceError(t *testing.T) {
+func TestTransferCoins_LedgerEntryError(t *testing.T) {
 	repoMock := mockRepo.NewRepository(t)
 	loggerMock := mockLog.NewLogger(t)
 	txManagerMock := mockRepo.NewTxManager(t)
 	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
 
-	ctx := context.Background()
-	senderID, receiverID, amount := 1, 2, 100
-	senderBalance := 200
-	expectedErr := errors.New("receiver balance error")
-
-	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
-			mock.AnythingOfType("func(context.Context) error")).
-		Run(func(args mock.Arguments) {
-			fn := args.Get(3).(func(context.Context) error)
-			_ = fn(context.Background())
-		}).
-		Return(fmt.Errorf("failed to get receiver balance: %w", expectedErr)).Once()
-
-	repoMock.
-		On("GetBalanceByID", mock.Anything, senderID).Return(senderBalance, nil).Once()
-	repoMock.
-		On("GetBalanceByID", mock.Anything, receiverID).Return(0, expectedErr).Once()
-
-	loggerMock.
-		On("Errorw",
-			"Failed to get receiver balance",
-			"receiverID", receiverID,
-			"error", expectedErr,
-		).Return().Once()
-	loggerMock.
-		On("Errorw",
-			"Non-retryable error during TransferCoins",
-			"error", fmt.Errorf("failed to get receiver balance: %w", expectedErr),
-		).Return().Once()
+	expectedErr := errors.New("ledger error")
 
-	err := service.TransferCoins(ctx, senderID, receiverID, amount)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to get receiver balance")
+	repoMock.On("LockBalances", mock.Anything, 1, 2).Return(map[int]int{1: 200, 2: 50}, nil).Once()
+	repoMock.On("CreateTransaction", mock.Anything, mock.AnythingOfType("*models.Transaction")).Return(42, nil).Once()
+	repoMock.On("DebitBalance", mock.Anything, 1, 100).Return(100, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 2, 100).Return(150, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, mock.AnythingOfType("*models.LedgerEntry")).Return(0, expectedErr).Once()
 
-	loggerMock.AssertCalled(t, "Errorw",
-		"Failed to get receiver balance",
-		"receiverID", receiverID,
+	loggerMock.On("Errorw", "Failed to create ledger entry",
+		"reason", models.LedgerReasonTransfer,
+		"referenceID", 42,
 		"error", expectedErr,
-	)
-	loggerMock.AssertCalled(t, "Errorw",
-		"Non-retryable error during TransferCoins",
-		"error", fmt.Errorf("failed to get receiver balance: %w", expectedErr),
-	)
-	repoMock.AssertCalled(t, "GetBalanceByID", mock.Anything, senderID)
-	repoMock.AssertCalled(t, "GetBalanceByID", mock.Anything, receiverID)
-	txManagerMock.AssertExpectations(t)
-}
-
-func TestTransferCoins_UpdateSenderBalanceError(t *testing.T) {
-	repoMock := mockRepo.NewRepository(t)
-	loggerMock := mockLog.NewLogger(t)
-	txManagerMock := mockRepo.NewTxManager(t)
-	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
-
-	ctx := context.Background()
-	senderID, receiverID, amount := 1, 2, 100
-	senderBalance := 200
-	receiverBalance := 100
-	expectedErr := errors.New("update sender balance error")
-	newSenderBalance := senderBalance - amount
-
-	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
-			mock.AnythingOfType("func(context.Context) error")).
-		Run(func(args mock.Arguments) {
-			fn := args.Get(3).(func(context.Context) error)
-			_ = fn(context.Background())
-		}).
-		Return(fmt.Errorf("failed to update sender balance: %w", expectedErr)).Once()
-
-	repoMock.
-		On("GetBalanceByID", mock.Anything, senderID).Return(senderBalance, nil).Once()
-	repoMock.
-		On("GetBalanceByID", mock.Anything, receiverID).Return(receiverBalance, nil).Once()
-	repoMock.
-		On("UpdateBalance", mock.Anything, senderID, newSenderBalance).
-		Return(expectedErr).Once()
-
-	loggerMock.
-		On("Errorw",
-			"Failed to update sender balance",
-			"senderID", senderID,
-			"newBalance", newSenderBalance,
-			"error", expectedErr,
-		).Return().Once()
-	loggerMock.
-		On("Errorw",
-			"Non-retryable error during TransferCoins",
-			"error", fmt.Errorf("failed to update sender balance: %w", expectedErr),
-		).Return().Once()
+	).Return().Once()
+	loggerMock.On("Errorw", "Error during TransferCoins operation", "error", mock.Anything).Return().Once()
 
-	err := service.TransferCoins(ctx, senderID, receiverID, amount)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to update sender balance")
-	repoMock.AssertCalled(t, "UpdateBalance", mock.Anything, senderID, newSenderBalance)
-	txManagerMock.AssertExpectations(t)
-	loggerMock.AssertCalled(t, "Errorw",
-		"Non-retryable error during TransferCoins",
-		"error", fmt.Errorf("failed to update sender balance: %w", expectedErr),
-	)
-}
-
-func TestTransferCoins_UpdateReceiverBalanceError(t *testing.T) {
-	repoMock := mockRepo.NewRepository(t)
-	loggerMock := mockLog.NewLogger(t)
-	txManagerMock := mockRepo.NewTxManager(t)
-	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
-
-	ctx := context.Background()
-	senderID, receiverID, amount := 1, 2, 100
-	senderBalance := 200
-	receiverBalance := 50
-	newSenderBalance := senderBalance - amount
-	newReceiverBalance := receiverBalance + amount
-	expectedErr := errors.New("update receiver balance error")
-
-	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
-			mock.AnythingOfType("func(context.Context) error")).
-		Run(func(args mock.Arguments) {
-			fn := args.Get(3).(func(context.Context) error)
-			_ = fn(context.Background())
-		}).
-		Return(fmt.Errorf("failed to update receiver balance: %w", expectedErr)).Once()
-
-	repoMock.
-		On("GetBalanceByID", mock.Anything, senderID).Return(senderBalance, nil).Once()
-	repoMock.
-		On("GetBalanceByID", mock.Anything, receiverID).Return(receiverBalance, nil).Once()
-	repoMock.
-		On("UpdateBalance", mock.Anything, senderID, newSenderBalance).Return(nil).Once()
-	repoMock.
-		On("UpdateBalance", mock.Anything, receiverID, newReceiverBalance).Return(expectedErr).Once()
-
-	loggerMock.
-		On("Errorw",
-			"Failed to update receiver balance",
-			"receiverID", receiverID,
-			"newBalance", newReceiverBalance,
-			"error", expectedErr,
-		).Return().Once()
-
-	loggerMock.
-		On("Errorw",
-			"Non-retryable error during TransferCoins",
-			"error", fmt.Errorf("failed to update receiver balance: %w", expectedErr),
-		).Return().Once()
-
-	err := service.TransferCoins(ctx, senderID, receiverID, amount)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to update receiver balance")
-	repoMock.AssertCalled(t, "UpdateBalance", mock.Anything, receiverID, newReceiverBalance)
-	txManagerMock.AssertExpectations(t)
+	_, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{ID: 2}, 100, models.TransferMemo{}, "")
+	assert.ErrorIs(t, err, expectedErr)
+	assert.Contains(t, err.Error(), "failed to create ledger entry")
 }
 
 func TestTransferCoins_CreateTransactionError(t *testing.T) {
@@ -348,12 +183,10 @@ func TestTransferCoins_CreateTransactionError(t *testing.T) {
 	senderID, receiverID, amount := 1, 2, 100
 	senderBalance := 200
 	receiverBalance := 50
-	newSenderBalance := senderBalance - amount
-	newReceiverBalance := receiverBalance + amount
 	expectedErr := errors.New("create transaction error")
 
 	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
 			mock.AnythingOfType("func(context.Context) error")).
 		Run(func(args mock.Arguments) {
 			fn := args.Get(3).(func(context.Context) error)
@@ -362,13 +195,8 @@ func TestTransferCoins_CreateTransactionError(t *testing.T) {
 		Return(fmt.Errorf("failed to create transaction: %w", expectedErr)).Once()
 
 	repoMock.
-		On("GetBalanceByID", mock.Anything, senderID).Return(senderBalance, nil).Once()
-	repoMock.
-		On("GetBalanceByID", mock.Anything, receiverID).Return(receiverBalance, nil).Once()
-	repoMock.
-		On("UpdateBalance", mock.Anything, senderID, newSenderBalance).Return(nil).Once()
-	repoMock.
-		On("UpdateBalance", mock.Anything, receiverID, newReceiverBalance).Return(nil).Once()
+		On("LockBalances", mock.Anything, senderID, receiverID).
+		Return(map[int]int{senderID: senderBalance, receiverID: receiverBalance}, nil).Once()
 	repoMock.
 		On("CreateTransaction", mock.Anything, mock.AnythingOfType("*models.Transaction")).
 		Return(0, expectedErr).Once()
@@ -384,11 +212,11 @@ func TestTransferCoins_CreateTransactionError(t *testing.T) {
 
 	loggerMock.
 		On("Errorw",
-			"Non-retryable error during TransferCoins",
+			"Error during TransferCoins operation",
 			"error", fmt.Errorf("failed to create transaction: %w", expectedErr),
 		).Return().Once()
 
-	err := service.TransferCoins(ctx, senderID, receiverID, amount)
+	_, err := service.TransferCoins(ctx, senderID, models.TransferRecipient{ID: receiverID}, amount, models.TransferMemo{}, "")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create transaction")
 
@@ -413,12 +241,10 @@ func TestTransferCoins_CommitTxError(t *testing.T) {
 	senderID, receiverID, amount := 1, 2, 100
 	senderBalance := 200
 	receiverBalance := 50
-	newSenderBalance := senderBalance - amount
-	newReceiverBalance := receiverBalance + amount
 	expectedErr := errors.New("commit tx error")
 
 	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
 			mock.AnythingOfType("func(context.Context) error")).
 		Run(func(args mock.Arguments) {
 			fn := args.Get(3).(func(context.Context) error)
@@ -427,28 +253,29 @@ func TestTransferCoins_CommitTxError(t *testing.T) {
 		Return(expectedErr).Once()
 
 	repoMock.
-		On("GetBalanceByID", mock.Anything, senderID).Return(senderBalance, nil).Once()
+		On("LockBalances", mock.Anything, senderID, receiverID).
+		Return(map[int]int{senderID: senderBalance, receiverID: receiverBalance}, nil).Once()
 	repoMock.
-		On("GetBalanceByID", mock.Anything, receiverID).Return(receiverBalance, nil).Once()
+		On("CreateTransaction", mock.Anything, mock.AnythingOfType("*models.Transaction")).Return(1, nil).Once()
 	repoMock.
-		On("UpdateBalance", mock.Anything, senderID, newSenderBalance).Return(nil).Once()
+		On("DebitBalance", mock.Anything, senderID, amount).Return(senderBalance-amount, nil).Once()
 	repoMock.
-		On("UpdateBalance", mock.Anything, receiverID, newReceiverBalance).Return(nil).Once()
+		On("CreditBalance", mock.Anything, receiverID, amount).Return(receiverBalance+amount, nil).Once()
 	repoMock.
-		On("CreateTransaction", mock.Anything, mock.AnythingOfType("*models.Transaction")).Return(1, nil).Once()
+		On("CreateLedgerEntry", mock.Anything, mock.AnythingOfType("*models.LedgerEntry")).Return(1, nil).Once()
 
 	loggerMock.
 		On("Errorw",
-			"Non-retryable error during TransferCoins",
+			"Error during TransferCoins operation",
 			"error", expectedErr,
 		).Return().Once()
 
-	err := service.TransferCoins(ctx, senderID, receiverID, amount)
+	_, err := service.TransferCoins(ctx, senderID, models.TransferRecipient{ID: receiverID}, amount, models.TransferMemo{}, "")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "commit tx error")
 
 	loggerMock.AssertCalled(t, "Errorw",
-		"Non-retryable error during TransferCoins",
+		"Error during TransferCoins operation",
 		"error", expectedErr,
 	)
 	txManagerMock.AssertExpectations(t)
@@ -464,11 +291,9 @@ func TestTransferCoins_Success(t *testing.T) {
 	senderID, receiverID, amount := 1, 2, 100
 	senderBalance := 200
 	receiverBalance := 50
-	newSenderBalance := senderBalance - amount
-	newReceiverBalance := receiverBalance + amount
 
 	txManagerMock.
-		On("WithTx", mock.Anything, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite,
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite,
 			mock.AnythingOfType("func(context.Context) error")).
 		Run(func(args mock.Arguments) {
 			fn := args.Get(3).(func(context.Context) error)
@@ -477,25 +302,168 @@ func TestTransferCoins_Success(t *testing.T) {
 		Return(nil)
 
 	repoMock.
-		On("GetBalanceByID", mock.Anything, senderID).Return(senderBalance, nil)
+		On("LockBalances", mock.Anything, senderID, receiverID).
+		Return(map[int]int{senderID: senderBalance, receiverID: receiverBalance}, nil)
 	repoMock.
-		On("GetBalanceByID", mock.Anything, receiverID).Return(receiverBalance, nil)
+		On("DebitBalance", mock.Anything, senderID, amount).Return(senderBalance-amount, nil)
 	repoMock.
-		On("UpdateBalance", mock.Anything, senderID, newSenderBalance).Return(nil)
+		On("CreditBalance", mock.Anything, receiverID, amount).Return(receiverBalance+amount, nil)
 	repoMock.
-		On("UpdateBalance", mock.Anything, receiverID, newReceiverBalance).Return(nil)
+		On("CreateLedgerEntry", mock.Anything, matchLedgerEntry(models.LedgerReasonTransfer, 1,
+			models.UserPosting(senderID, -amount),
+			models.UserPosting(receiverID, amount),
+		)).Return(1, nil)
 	repoMock.
 		On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tr *models.Transaction) bool {
 			return tr.SenderID == senderID && tr.ReceiverID == receiverID && tr.Amount == amount
 		})).Return(1, nil)
 
-	err := service.TransferCoins(ctx, senderID, receiverID, amount)
+	_, err := service.TransferCoins(ctx, senderID, models.TransferRecipient{ID: receiverID}, amount, models.TransferMemo{}, "")
 	assert.NoError(t, err)
 
-	repoMock.AssertCalled(t, "GetBalanceByID", mock.Anything, senderID)
-	repoMock.AssertCalled(t, "GetBalanceByID", mock.Anything, receiverID)
-	repoMock.AssertCalled(t, "UpdateBalance", mock.Anything, senderID, newSenderBalance)
-	repoMock.AssertCalled(t, "UpdateBalance", mock.Anything, receiverID, newReceiverBalance)
+	repoMock.AssertCalled(t, "LockBalances", mock.Anything, senderID, receiverID)
+	repoMock.AssertCalled(t, "CreateLedgerEntry", mock.Anything, mock.AnythingOfType("*models.LedgerEntry"))
 	repoMock.AssertCalled(t, "CreateTransaction", mock.Anything, mock.AnythingOfType("*models.Transaction"))
 	txManagerMock.AssertExpectations(t)
 }
+
+func TestTransferCoins_ByUsername(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	repoMock.On("GetUserByUsername", mock.Anything, "colleague").Return(&models.User{ID: 2, Username: "colleague"}, nil).Once()
+	repoMock.On("LockBalances", mock.Anything, 1, 2).Return(map[int]int{1: 500, 2: 100}, nil).Once()
+	repoMock.On("DebitBalance", mock.Anything, 1, 100).Return(400, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 2, 100).Return(200, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, mock.AnythingOfType("*models.LedgerEntry")).Return(1, nil).Once()
+	repoMock.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tr *models.Transaction) bool {
+		return tr.SenderID == 1 && tr.ReceiverID == 2 && tr.Amount == 100
+	})).Return(42, nil).Once()
+
+	transaction, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{Username: "colleague"}, 100, models.TransferMemo{}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, transaction.ReceiverID)
+}
+
+func TestTransferCoins_UnknownUsername(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	repoMock.On("GetUserByUsername", mock.Anything, "ghost").Return(nil, fmt.Errorf("failed to get a user by username: %w", pgx.ErrNoRows)).Once()
+	loggerMock.On("Warnw", "Transfer receiver not found", "username", "ghost").Return().Once()
+	loggerMock.On("Errorw", "Error during TransferCoins operation", "error", mock.Anything).Return().Once()
+
+	_, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{Username: "ghost"}, 100, models.TransferMemo{}, "")
+	assert.ErrorIs(t, err, ErrReceiverNotFound)
+	assert.EqualError(t, err, "receiver not found: ghost")
+	repoMock.AssertNotCalled(t, "LockBalances", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransferCoins_UnknownReceiverID(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	repoMock.On("LockBalances", mock.Anything, 1, 99).Return(map[int]int{1: 500}, nil).Once()
+	loggerMock.On("Errorw", "Error during TransferCoins operation", "error", mock.Anything).Return().Once()
+
+	_, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{ID: 99}, 100, models.TransferMemo{}, "")
+	assert.ErrorIs(t, err, ErrReceiverNotFound)
+	repoMock.AssertNotCalled(t, "CreateLedgerEntry", mock.Anything, mock.Anything)
+}
+
+func TestTransferCoins_UsernameResolvesToSender(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	repoMock.On("GetUserByUsername", mock.Anything, "me").Return(&models.User{ID: 1, Username: "me"}, nil).Once()
+	loggerMock.On("Warnw", "Sender and receiver are the same", "senderID", 1, "receiverID", 1).Return().Once()
+	loggerMock.On("Errorw", "Error during TransferCoins operation", "error", fmt.Errorf("cannot transfer to yourself")).Return().Once()
+
+	_, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{Username: "me"}, 100, models.TransferMemo{}, "")
+	assert.EqualError(t, err, "cannot transfer to yourself")
+}
+
+func TestTransferCoins_WithMemo(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+	setupReadCommittedTx(txManagerMock)
+
+	repoMock.On("LockBalances", mock.Anything, 1, 2).Return(map[int]int{1: 500, 2: 100}, nil).Once()
+	repoMock.On("DebitBalance", mock.Anything, 1, 100).Return(400, nil).Once()
+	repoMock.On("CreditBalance", mock.Anything, 2, 100).Return(200, nil).Once()
+	repoMock.On("CreateLedgerEntry", mock.Anything, mock.AnythingOfType("*models.LedgerEntry")).Return(1, nil).Once()
+	repoMock.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tr *models.Transaction) bool {
+		return tr.Message == "Спасибо за ревью!" && tr.Category == models.TransferCategoryHelpedMe
+	})).Return(42, nil).Once()
+
+	memo := models.TransferMemo{Message: "  Спасибо за ревью!\n", Category: models.TransferCategoryHelpedMe}
+	transaction, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{ID: 2}, 100, memo, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Спасибо за ревью!", transaction.Message)
+}
+
+func TestTransferCoins_InvalidMemo(t *testing.T) {
+	tests := []struct {
+		name        string
+		memo        models.TransferMemo
+		expectedErr string
+	}{
+		{
+			name:        "unknown category",
+			memo:        models.TransferMemo{Category: "bribe"},
+			expectedErr: `invalid transfer memo: unknown category "bribe"`,
+		},
+		{
+			name:        "message too long",
+			memo:        models.TransferMemo{Message: strings.Repeat("я", models.MaxTransferMessageLength+1)},
+			expectedErr: "invalid transfer memo: message is longer than 280 characters",
+		},
+		{
+			name:        "control characters",
+			memo:        models.TransferMemo{Message: "hi\x1b[31mthere"},
+			expectedErr: "invalid transfer memo: message contains control characters",
+		},
+		{
+			name:        "invalid utf-8",
+			memo:        models.TransferMemo{Message: "hi\xff"},
+			expectedErr: "invalid transfer memo: message is not valid UTF-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoMock := mockRepo.NewRepository(t)
+			loggerMock := mockLog.NewLogger(t)
+			txManagerMock := mockRepo.NewTxManager(t)
+			service := NewTransactionService(repoMock, loggerMock, txManagerMock)
+
+			loggerMock.On("Warnw", "Invalid transfer memo", "senderID", 1, "category", tt.memo.Category, "error", mock.Anything).Return().Once()
+
+			_, err := service.TransferCoins(context.Background(), 1, models.TransferRecipient{ID: 2}, 100, tt.memo, "")
+			assert.ErrorIs(t, err, ErrInvalidTransferMemo)
+			assert.EqualError(t, err, tt.expectedErr)
+			txManagerMock.AssertNotCalled(t, "WithTx", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestValidateTransferMemo_MessageAtLimit(t *testing.T) {
+	assert.NoError(t, validateTransferMemo(models.TransferMemo{
+		Message:  strings.Repeat("я", models.MaxTransferMessageLength-1) + "\n",
+		Category: models.TransferCategoryThankYou,
+	}))
+}
@@ -6,6 +6,9 @@ import (
 	"avito-tech-merch/internal/storage/db/postgres"
 	"avito-tech-merch/pkg/logger"
 	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
 )
 
 type userService struct {
@@ -18,31 +21,59 @@ func NewUserService(repo db.Repository, log logger.Logger, txManager db.TxManage
 	return &userService{repo: repo, logger: log, txManager: txManager}
 }
 
-func (s *userService) GetInfo(ctx context.Context, userID int) (*models.UserInfo, error) {
+// GetInfo возвращает баланс, инвентарь, последние покупки с их статусами и последние переводы пользователя;
+// filter ограничивает только историю переводов
+func (s *userService) GetInfo(ctx context.Context, userID int, filter models.TransactionFilter) (*models.UserInfo, error) {
 	var result *models.UserInfo
 
 	err := s.txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadOnly, func(txCtx context.Context) error {
 		user, err := s.repo.GetUserByID(txCtx, userID)
 		if err != nil {
-			s.logger.Errorw("Failed to get user info",
+			// Токен мог пережить удалённого пользователя
+			if errors.Is(err, pgx.ErrNoRows) {
+				logger.WithContext(ctx, s.logger).Warnw("User not found",
+					"userID", userID,
+				)
+				return fmt.Errorf("%w: id %d", ErrUserNotFound, userID)
+			}
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get user info",
 				"userID", userID,
 				"error", err,
 			)
 			return err
 		}
 
-		purchases, err := s.repo.GetPurchaseByUserID(txCtx, userID)
+		// Инвентарь, траты и история переводов агрегируются в SQL, без загрузки всех строк покупок и переводов
+		inventory, err := s.repo.GetInventoryByUserID(txCtx, userID)
 		if err != nil {
-			s.logger.Errorw("Failed to get purchases",
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get inventory",
 				"userID", userID,
 				"error", err,
 			)
 			return err
 		}
 
-		transactions, err := s.repo.GetTransactionByUserID(txCtx, userID)
+		spent, err := s.repo.GetSpentByUserID(txCtx, userID)
 		if err != nil {
-			s.logger.Errorw("Failed to get transactions",
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get spent coins",
+				"userID", userID,
+				"error", err,
+			)
+			return err
+		}
+
+		coinHistory, err := s.repo.GetCoinHistoryByUserID(txCtx, userID, filter, models.InfoHistoryLimit)
+		if err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get coin history",
+				"userID", userID,
+				"error", err,
+			)
+			return err
+		}
+
+		purchases, err := s.repo.GetPurchaseByUserID(txCtx, userID, models.PurchaseFilter{}, models.HistoryPage{Limit: models.InfoHistoryLimit})
+		if err != nil {
+			logger.WithContext(ctx, s.logger).Errorw("Failed to get purchases",
 				"userID", userID,
 				"error", err,
 			)
@@ -50,16 +81,18 @@ func (s *userService) GetInfo(ctx context.Context, userID int) (*models.UserInfo
 		}
 
 		result = &models.UserInfo{
-			UserID:       user.ID,
-			Username:     user.Username,
-			Balance:      user.Balance,
-			Purchases:    purchases,
-			Transactions: transactions,
+			UserID:      user.ID,
+			Username:    user.Username,
+			Balance:     user.Balance,
+			Spent:       spent,
+			Inventory:   inventory,
+			Purchases:   purchases,
+			CoinHistory: coinHistory,
 		}
 		return nil
 	})
 	if err != nil {
-		s.logger.Errorw("Error getting user info",
+		logger.WithContext(ctx, s.logger).Errorw("Error getting user info",
 			"error", err,
 		)
 		return nil, err
@@ -67,3 +100,58 @@ func (s *userService) GetInfo(ctx context.Context, userID int) (*models.UserInfo
 
 	return result, nil
 }
+
+// GetTransactionHistory возвращает страницу истории переводов от новых к старым
+func (s *userService) GetTransactionHistory(ctx context.Context, userID int, filter models.TransactionFilter, page models.HistoryPage) (*models.TransferPage, error) {
+	page.Limit = historyPageSize(page.Limit)
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	transfers, err := s.repo.GetTransfersByUserID(ctx, userID, filter, models.HistoryPage{Limit: page.Limit + 1, After: page.After})
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to get transaction history",
+			"userID", userID,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	result := &models.TransferPage{Transfers: transfers}
+	if len(transfers) > page.Limit {
+		result.Transfers = transfers[:page.Limit]
+		last := result.Transfers[page.Limit-1]
+		result.NextCursor = &models.HistoryCursor{CreatedAt: last.CreatedAt, ID: last.TransactionID}
+	}
+
+	return result, nil
+}
+
+// GetPurchaseHistory возвращает страницу истории покупок от новых к старым
+func (s *userService) GetPurchaseHistory(ctx context.Context, userID int, filter models.PurchaseFilter, page models.HistoryPage) (*models.PurchasePage, error) {
+	page.Limit = historyPageSize(page.Limit)
+
+	purchases, err := s.repo.GetPurchaseByUserID(ctx, userID, filter, models.HistoryPage{Limit: page.Limit + 1, After: page.After})
+	if err != nil {
+		logger.WithContext(ctx, s.logger).Errorw("Failed to get purchase history",
+			"userID", userID,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	result := &models.PurchasePage{Purchases: purchases}
+	if len(purchases) > page.Limit {
+		result.Purchases = purchases[:page.Limit]
+		last := result.Purchases[page.Limit-1]
+		result.NextCursor = &models.HistoryCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return result, nil
+}
+
+// historyPageSize подставляет размер страницы по умолчанию и ограничивает его сверху
+func historyPageSize(limit int) int {
+	if limit <= 0 {
+		return models.DefaultHistoryPageSize
+	}
+	return min(limit, models.MaxHistoryPageSize)
+}
@@ -23,12 +23,20 @@ func TestGetInfo_Success(t *testing.T) {
 		Balance:  100,
 	}
 
-	expectedPurchases := []*models.Purchase{
-		{ID: 1, UserID: 1, MerchID: 10, CreatedAt: time.Now()},
+	expectedInventory := []*models.InventoryItem{
+		{MerchName: "cup", Quantity: 1},
+		{MerchName: "pen", Quantity: 2},
+	}
+
+	refundedAt := time.Now()
+	expectedHistory := &models.CoinHistory{
+		Received: []*models.CoinTransfer{{TransactionID: 2, Counterparty: "user3", Amount: 30, CreatedAt: time.Now()}},
+		Sent:     []*models.CoinTransfer{{TransactionID: 1, Counterparty: "user2", Amount: 50, CreatedAt: time.Now()}},
 	}
 
-	expectedTransactions := []*models.Transaction{
-		{ID: 1, SenderID: 1, ReceiverID: 2, Amount: 50, CreatedAt: time.Now()},
+	expectedPurchases := []*models.Purchase{
+		{ID: 2, UserID: 1, MerchID: 3, Quantity: 2, UnitPrice: 10, Status: models.PurchaseStatusReadyForPickup},
+		{ID: 1, UserID: 1, MerchID: 5, Quantity: 1, UnitPrice: 20, Status: models.PurchaseStatusCancelled, RefundedAt: &refundedAt},
 	}
 
 	txManagerMock.
@@ -41,19 +49,23 @@ func TestGetInfo_Success(t *testing.T) {
 		Return(nil)
 
 	repoMock.On("GetUserByID", mock.Anything, expectedUser.ID).Return(expectedUser, nil)
-	repoMock.On("GetPurchaseByUserID", mock.Anything, expectedUser.ID).Return(expectedPurchases, nil)
-	repoMock.On("GetTransactionByUserID", mock.Anything, expectedUser.ID).Return(expectedTransactions, nil)
+	repoMock.On("GetInventoryByUserID", mock.Anything, expectedUser.ID).Return(expectedInventory, nil)
+	repoMock.On("GetSpentByUserID", mock.Anything, expectedUser.ID).Return(90, nil)
+	repoMock.On("GetCoinHistoryByUserID", mock.Anything, expectedUser.ID, models.TransactionFilter{}, models.InfoHistoryLimit).Return(expectedHistory, nil)
+	repoMock.On("GetPurchaseByUserID", mock.Anything, expectedUser.ID, models.PurchaseFilter{}, models.HistoryPage{Limit: models.InfoHistoryLimit}).Return(expectedPurchases, nil)
 
 	service := NewUserService(repoMock, loggerMock, txManagerMock)
 
-	userInfo, err := service.GetInfo(context.Background(), expectedUser.ID)
+	userInfo, err := service.GetInfo(context.Background(), expectedUser.ID, models.TransactionFilter{})
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedUser.ID, userInfo.UserID)
 	assert.Equal(t, expectedUser.Username, userInfo.Username)
 	assert.Equal(t, expectedUser.Balance, userInfo.Balance)
+	assert.Equal(t, expectedInventory, userInfo.Inventory)
+	assert.Equal(t, 90, userInfo.Spent)
 	assert.Equal(t, expectedPurchases, userInfo.Purchases)
-	assert.Equal(t, expectedTransactions, userInfo.Transactions)
+	assert.Equal(t, expectedHistory, userInfo.CoinHistory)
 
 	repoMock.AssertExpectations(t)
 	txManagerMock.AssertExpectations(t)
@@ -91,7 +103,7 @@ func TestGetInfo_Error_GetUser(t *testing.T) {
 
 	service := NewUserService(repoMock, loggerMock, txManagerMock)
 
-	userInfo, err := service.GetInfo(context.Background(), userID)
+	userInfo, err := service.GetInfo(context.Background(), userID, models.TransactionFilter{})
 
 	assert.Error(t, err)
 	assert.Nil(t, userInfo)
@@ -110,7 +122,7 @@ func TestGetInfo_Error_GetUser(t *testing.T) {
 	txManagerMock.AssertExpectations(t)
 }
 
-func TestGetInfo_Error_GetPurchases(t *testing.T) {
+func TestGetInfo_Error_GetInventory(t *testing.T) {
 	repoMock := mockRepo.NewRepository(t)
 	loggerMock := mockLog.NewLogger(t)
 	txManagerMock := mockRepo.NewTxManager(t)
@@ -131,11 +143,11 @@ func TestGetInfo_Error_GetPurchases(t *testing.T) {
 		Return(expectedError)
 
 	repoMock.On("GetUserByID", mock.Anything, expectedUser.ID).Return(expectedUser, nil)
-	repoMock.On("GetPurchaseByUserID", mock.Anything, expectedUser.ID).Return(nil, expectedError)
+	repoMock.On("GetInventoryByUserID", mock.Anything, expectedUser.ID).Return(nil, expectedError)
 
 	loggerMock.
 		On("Errorw",
-			"Failed to get purchases",
+			"Failed to get inventory",
 			"userID", expectedUser.ID,
 			"error", expectedError,
 		).Return()
@@ -147,13 +159,13 @@ func TestGetInfo_Error_GetPurchases(t *testing.T) {
 
 	service := NewUserService(repoMock, loggerMock, txManagerMock)
 
-	userInfo, err := service.GetInfo(context.Background(), expectedUser.ID)
+	userInfo, err := service.GetInfo(context.Background(), expectedUser.ID, models.TransactionFilter{})
 
 	assert.Error(t, err)
 	assert.Nil(t, userInfo)
 
 	loggerMock.AssertCalled(t, "Errorw",
-		"Failed to get purchases",
+		"Failed to get inventory",
 		"userID", expectedUser.ID,
 		"error", expectedError,
 	)
@@ -163,11 +175,11 @@ func TestGetInfo_Error_GetPurchases(t *testing.T) {
 	)
 
 	repoMock.AssertCalled(t, "GetUserByID", mock.Anything, expectedUser.ID)
-	repoMock.AssertCalled(t, "GetPurchaseByUserID", mock.Anything, expectedUser.ID)
+	repoMock.AssertCalled(t, "GetInventoryByUserID", mock.Anything, expectedUser.ID)
 	txManagerMock.AssertExpectations(t)
 }
 
-func TestGetInfo_Error_GetTransactions(t *testing.T) {
+func TestGetInfo_Error_GetCoinHistory(t *testing.T) {
 	repoMock := mockRepo.NewRepository(t)
 	loggerMock := mockLog.NewLogger(t)
 	txManagerMock := mockRepo.NewTxManager(t)
@@ -178,8 +190,8 @@ func TestGetInfo_Error_GetTransactions(t *testing.T) {
 		Username: "user1",
 		Balance:  100,
 	}
-	expectedPurchases := []*models.Purchase{
-		{ID: 1, UserID: 1, MerchID: 10, CreatedAt: time.Now()},
+	expectedInventory := []*models.InventoryItem{
+		{MerchName: "pen", Quantity: 1},
 	}
 
 	txManagerMock.
@@ -191,12 +203,13 @@ func TestGetInfo_Error_GetTransactions(t *testing.T) {
 		Return(expectedError)
 
 	repoMock.On("GetUserByID", mock.Anything, expectedUser.ID).Return(expectedUser, nil)
-	repoMock.On("GetPurchaseByUserID", mock.Anything, expectedUser.ID).Return(expectedPurchases, nil)
-	repoMock.On("GetTransactionByUserID", mock.Anything, expectedUser.ID).Return(nil, expectedError)
+	repoMock.On("GetInventoryByUserID", mock.Anything, expectedUser.ID).Return(expectedInventory, nil)
+	repoMock.On("GetSpentByUserID", mock.Anything, expectedUser.ID).Return(10, nil)
+	repoMock.On("GetCoinHistoryByUserID", mock.Anything, expectedUser.ID, models.TransactionFilter{}, models.InfoHistoryLimit).Return(nil, expectedError)
 
 	loggerMock.
 		On("Errorw",
-			"Failed to get transactions",
+			"Failed to get coin history",
 			"userID", expectedUser.ID,
 			"error", expectedError,
 		).Return()
@@ -208,13 +221,13 @@ func TestGetInfo_Error_GetTransactions(t *testing.T) {
 
 	service := NewUserService(repoMock, loggerMock, txManagerMock)
 
-	userInfo, err := service.GetInfo(context.Background(), expectedUser.ID)
+	userInfo, err := service.GetInfo(context.Background(), expectedUser.ID, models.TransactionFilter{})
 
 	assert.Error(t, err)
 	assert.Nil(t, userInfo)
 
 	loggerMock.AssertCalled(t, "Errorw",
-		"Failed to get transactions",
+		"Failed to get coin history",
 		"userID", expectedUser.ID,
 		"error", expectedError,
 	)
@@ -224,8 +237,8 @@ func TestGetInfo_Error_GetTransactions(t *testing.T) {
 	)
 
 	repoMock.AssertCalled(t, "GetUserByID", mock.Anything, expectedUser.ID)
-	repoMock.AssertCalled(t, "GetPurchaseByUserID", mock.Anything, expectedUser.ID)
-	repoMock.AssertCalled(t, "GetTransactionByUserID", mock.Anything, expectedUser.ID)
+	repoMock.AssertCalled(t, "GetInventoryByUserID", mock.Anything, expectedUser.ID)
+	repoMock.AssertCalled(t, "GetCoinHistoryByUserID", mock.Anything, expectedUser.ID, models.TransactionFilter{}, models.InfoHistoryLimit)
 	txManagerMock.AssertExpectations(t)
 }
 
@@ -240,11 +253,12 @@ func TestGetInfo_Error_FromTxManager(t *testing.T) {
 		Username: "user1",
 		Balance:  100,
 	}
-	expectedPurchases := []*models.Purchase{
-		{ID: 1, UserID: 1, MerchID: 10, CreatedAt: time.Now()},
+	expectedInventory := []*models.InventoryItem{
+		{MerchName: "pen", Quantity: 1},
 	}
-	expectedTransactions := []*models.Transaction{
-		{ID: 1, SenderID: 1, ReceiverID: 2, Amount: 50, CreatedAt: time.Now()},
+	expectedHistory := &models.CoinHistory{
+		Received: []*models.CoinTransfer{},
+		Sent:     []*models.CoinTransfer{{TransactionID: 1, Counterparty: "user2", Amount: 50, CreatedAt: time.Now()}},
 	}
 
 	// Даже если репозиторий отдает корректные данные, txManager возвращает ошибку (например, на commit)
@@ -257,8 +271,10 @@ func TestGetInfo_Error_FromTxManager(t *testing.T) {
 		Return(expectedError)
 
 	repoMock.On("GetUserByID", mock.Anything, expectedUser.ID).Return(expectedUser, nil)
-	repoMock.On("GetPurchaseByUserID", mock.Anything, expectedUser.ID).Return(expectedPurchases, nil)
-	repoMock.On("GetTransactionByUserID", mock.Anything, expectedUser.ID).Return(expectedTransactions, nil)
+	repoMock.On("GetInventoryByUserID", mock.Anything, expectedUser.ID).Return(expectedInventory, nil)
+	repoMock.On("GetSpentByUserID", mock.Anything, expectedUser.ID).Return(10, nil)
+	repoMock.On("GetCoinHistoryByUserID", mock.Anything, expectedUser.ID, models.TransactionFilter{}, models.InfoHistoryLimit).Return(expectedHistory, nil)
+	repoMock.On("GetPurchaseByUserID", mock.Anything, expectedUser.ID, models.PurchaseFilter{}, models.HistoryPage{Limit: models.InfoHistoryLimit}).Return([]*models.Purchase{}, nil)
 
 	loggerMock.
 		On("Errorw",
@@ -267,7 +283,7 @@ func TestGetInfo_Error_FromTxManager(t *testing.T) {
 		).Return()
 
 	service := NewUserService(repoMock, loggerMock, txManagerMock)
-	userInfo, err := service.GetInfo(context.Background(), expectedUser.ID)
+	userInfo, err := service.GetInfo(context.Background(), expectedUser.ID, models.TransactionFilter{})
 
 	assert.Error(t, err)
 	assert.Nil(t, userInfo)
@@ -278,7 +294,202 @@ func TestGetInfo_Error_FromTxManager(t *testing.T) {
 	)
 
 	repoMock.AssertCalled(t, "GetUserByID", mock.Anything, expectedUser.ID)
-	repoMock.AssertCalled(t, "GetPurchaseByUserID", mock.Anything, expectedUser.ID)
-	repoMock.AssertCalled(t, "GetTransactionByUserID", mock.Anything, expectedUser.ID)
+	repoMock.AssertCalled(t, "GetInventoryByUserID", mock.Anything, expectedUser.ID)
+	repoMock.AssertCalled(t, "GetCoinHistoryByUserID", mock.Anything, expectedUser.ID, models.TransactionFilter{}, models.InfoHistoryLimit)
 	txManagerMock.AssertExpectations(t)
 }
+
+func TestGetInfo_CategoryFilter(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	filter := models.TransactionFilter{Category: models.TransferCategoryGreatTalk}
+	expectedHistory := &models.CoinHistory{
+		Received: []*models.CoinTransfer{
+			{TransactionID: 3, Counterparty: "user2", Amount: 30, Message: "Отличный доклад", Category: models.TransferCategoryGreatTalk},
+		},
+		Sent: []*models.CoinTransfer{},
+	}
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadOnly, mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			_ = fn(context.Background())
+		}).
+		Return(nil)
+
+	repoMock.On("GetUserByID", mock.Anything, 1).Return(&models.User{ID: 1, Username: "user1"}, nil)
+	repoMock.On("GetInventoryByUserID", mock.Anything, 1).Return([]*models.InventoryItem{}, nil)
+	repoMock.On("GetSpentByUserID", mock.Anything, 1).Return(0, nil)
+	repoMock.On("GetCoinHistoryByUserID", mock.Anything, 1, filter, models.InfoHistoryLimit).Return(expectedHistory, nil)
+	repoMock.On("GetPurchaseByUserID", mock.Anything, 1, models.PurchaseFilter{}, models.HistoryPage{Limit: models.InfoHistoryLimit}).Return([]*models.Purchase{}, nil)
+
+	service := NewUserService(repoMock, loggerMock, txManagerMock)
+
+	userInfo, err := service.GetInfo(context.Background(), 1, filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedHistory, userInfo.CoinHistory)
+}
+
+func TestGetInfo_Error_GetSpent(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	expectedError := assert.AnError
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadOnly, mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			_ = fn(context.Background())
+		}).
+		Return(expectedError)
+
+	repoMock.On("GetUserByID", mock.Anything, 1).Return(&models.User{ID: 1, Username: "user1"}, nil)
+	repoMock.On("GetInventoryByUserID", mock.Anything, 1).Return([]*models.InventoryItem{}, nil)
+	repoMock.On("GetSpentByUserID", mock.Anything, 1).Return(0, expectedError)
+
+	loggerMock.On("Errorw", "Failed to get spent coins", "userID", 1, "error", expectedError).Return().Once()
+	loggerMock.On("Errorw", "Error getting user info", "error", expectedError).Return().Once()
+
+	service := NewUserService(repoMock, loggerMock, txManagerMock)
+
+	userInfo, err := service.GetInfo(context.Background(), 1, models.TransactionFilter{})
+
+	assert.Error(t, err)
+	assert.Nil(t, userInfo)
+	repoMock.AssertNotCalled(t, "GetCoinHistoryByUserID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetInfo_Error_GetPurchases(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+
+	expectedError := assert.AnError
+
+	txManagerMock.
+		On("WithTx", mock.Anything, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadOnly, mock.AnythingOfType("func(context.Context) error")).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(context.Context) error)
+			_ = fn(context.Background())
+		}).
+		Return(expectedError)
+
+	repoMock.On("GetUserByID", mock.Anything, 1).Return(&models.User{ID: 1, Username: "user1"}, nil)
+	repoMock.On("GetInventoryByUserID", mock.Anything, 1).Return([]*models.InventoryItem{}, nil)
+	repoMock.On("GetSpentByUserID", mock.Anything, 1).Return(0, nil)
+	repoMock.On("GetCoinHistoryByUserID", mock.Anything, 1, models.TransactionFilter{}, models.InfoHistoryLimit).Return(&models.CoinHistory{}, nil)
+	repoMock.On("GetPurchaseByUserID", mock.Anything, 1, models.PurchaseFilter{}, models.HistoryPage{Limit: models.InfoHistoryLimit}).Return(nil, expectedError)
+
+	loggerMock.On("Errorw", "Failed to get purchases", "userID", 1, "error", expectedError).Return().Once()
+	loggerMock.On("Errorw", "Error getting user info", "error", expectedError).Return().Once()
+
+	service := NewUserService(repoMock, loggerMock, txManagerMock)
+
+	userInfo, err := service.GetInfo(context.Background(), 1, models.TransactionFilter{})
+
+	assert.Error(t, err)
+	assert.Nil(t, userInfo)
+}
+
+func TestGetTransactionHistory_NextPage(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewUserService(repoMock, loggerMock, txManagerMock)
+
+	now := time.Now()
+	filter := models.TransactionFilter{Direction: models.DirectionSent}
+	after := &models.HistoryCursor{CreatedAt: now, ID: 10}
+	transfers := []*models.CoinTransfer{
+		{TransactionID: 9, CreatedAt: now.Add(-time.Minute)},
+		{TransactionID: 8, CreatedAt: now.Add(-2 * time.Minute)},
+		{TransactionID: 7, CreatedAt: now.Add(-3 * time.Minute)},
+	}
+
+	// Репозиторий запрашивается на одну запись больше страницы
+	repoMock.On("GetTransfersByUserID", mock.Anything, 1, filter, models.HistoryPage{Limit: 3, After: after}).Return(transfers, nil).Once()
+
+	page, err := service.GetTransactionHistory(context.Background(), 1, filter, models.HistoryPage{Limit: 2, After: after})
+
+	assert.NoError(t, err)
+	assert.Equal(t, transfers[:2], page.Transfers)
+	assert.Equal(t, &models.HistoryCursor{CreatedAt: transfers[1].CreatedAt, ID: 8}, page.NextCursor)
+}
+
+func TestGetTransactionHistory_LastPage(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewUserService(repoMock, loggerMock, txManagerMock)
+
+	transfers := []*models.CoinTransfer{{TransactionID: 1}}
+	repoMock.On("GetTransfersByUserID", mock.Anything, 1, models.TransactionFilter{}, models.HistoryPage{Limit: models.DefaultHistoryPageSize + 1}).
+		Return(transfers, nil).Once()
+
+	page, err := service.GetTransactionHistory(context.Background(), 1, models.TransactionFilter{}, models.HistoryPage{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, transfers, page.Transfers)
+	assert.Nil(t, page.NextCursor)
+}
+
+func TestGetTransactionHistory_Error(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewUserService(repoMock, loggerMock, txManagerMock)
+
+	repoMock.On("GetTransfersByUserID", mock.Anything, 1, models.TransactionFilter{}, models.HistoryPage{Limit: models.MaxHistoryPageSize + 1}).
+		Return(nil, assert.AnError).Once()
+	loggerMock.On("Errorw", "Failed to get transaction history", "userID", 1, "error", assert.AnError).Return().Once()
+
+	page, err := service.GetTransactionHistory(context.Background(), 1, models.TransactionFilter{}, models.HistoryPage{Limit: 1000})
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, page)
+}
+
+func TestGetPurchaseHistory_NextPage(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewUserService(repoMock, loggerMock, txManagerMock)
+
+	now := time.Now()
+	from := now.Add(-time.Hour)
+	filter := models.PurchaseFilter{From: &from}
+	purchases := []*models.Purchase{
+		{ID: 5, CreatedAt: now},
+		{ID: 4, CreatedAt: now.Add(-time.Minute)},
+	}
+
+	repoMock.On("GetPurchaseByUserID", mock.Anything, 1, filter, models.HistoryPage{Limit: 2}).Return(purchases, nil).Once()
+
+	page, err := service.GetPurchaseHistory(context.Background(), 1, filter, models.HistoryPage{Limit: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, purchases[:1], page.Purchases)
+	assert.Equal(t, &models.HistoryCursor{CreatedAt: now, ID: 5}, page.NextCursor)
+}
+
+func TestGetPurchaseHistory_Error(t *testing.T) {
+	repoMock := mockRepo.NewRepository(t)
+	loggerMock := mockLog.NewLogger(t)
+	txManagerMock := mockRepo.NewTxManager(t)
+	service := NewUserService(repoMock, loggerMock, txManagerMock)
+
+	repoMock.On("GetPurchaseByUserID", mock.Anything, 1, models.PurchaseFilter{}, models.HistoryPage{Limit: models.DefaultHistoryPageSize + 1}).
+		Return(nil, assert.AnError).Once()
+	loggerMock.On("Errorw", "Failed to get purchase history", "userID", 1, "error", assert.AnError).Return().Once()
+
+	page, err := service.GetPurchaseHistory(context.Background(), 1, models.PurchaseFilter{}, models.HistoryPage{})
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, page)
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+	until time.Time
+}
+
+// lru - in-process кеш ограниченного размера с TTL. При переполнении вытесняется запись,
+// к которой дольше всего не обращались.
+// Поколение растёт при каждой инвалидации: значение, прочитанное из базы до инвалидации,
+// не попадает в кеш и не переживает изменение, которое произошло, пока шёл запрос
+type lru[K comparable, V any] struct {
+	size int
+	ttl  time.Duration
+
+	mu         sync.Mutex
+	items      map[K]*list.Element
+	order      *list.List
+	generation uint64
+}
+
+func newLRU[K comparable, V any](size int, ttl time.Duration) *lru[K, V] {
+	return &lru[K, V]{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[K]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+func (c *lru[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	entry := elem.Value.(*lruEntry[K, V])
+	if !time.Now().Before(entry.until) {
+		c.removeElement(elem)
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// currentGeneration возвращает поколение, которое нужно передать в add после чтения из базы
+func (c *lru[K, V]) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// add кладёт значение в кеш, если после generation не было инвалидаций
+func (c *lru[K, V]) add(key K, value V, generation uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return false
+	}
+
+	c.putLocked(key, value, time.Now().Add(c.ttl))
+	return true
+}
+
+// put кладёт значение, записанное через этот экземпляр, до момента until. Как и инвалидация,
+// увеличивает поколение, чтобы значение не перезаписал ответ базы, прочитанный раньше записи
+func (c *lru[K, V]) put(key K, value V, until time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.putLocked(key, value, until)
+}
+
+func (c *lru[K, V]) putLocked(key K, value V, until time.Time) {
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.until = until
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, until: until})
+	if c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// removeFunc удаляет записи, для которых match возвращает true
+func (c *lru[K, V]) removeFunc(match func(key K, value V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		entry := elem.Value.(*lruEntry[K, V])
+		if match(entry.key, entry.value) {
+			c.removeElement(elem)
+		}
+		elem = next
+	}
+}
+
+func (c *lru[K, V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *lru[K, V]) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry[K, V]).key)
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newLRU[string, int](2, time.Minute)
+
+	cache.add("a", 1, cache.currentGeneration())
+	cache.add("b", 2, cache.currentGeneration())
+
+	// Обращение к "a" делает вытесняемым "b"
+	_, ok := cache.get("a")
+	assert.True(t, ok)
+
+	cache.add("c", 3, cache.currentGeneration())
+
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	value, ok := cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, cache.len())
+}
+
+func TestLRU_ExpiredEntry(t *testing.T) {
+	cache := newLRU[string, int](2, time.Nanosecond)
+
+	cache.add("a", 1, cache.currentGeneration())
+	time.Sleep(time.Millisecond)
+
+	_, ok := cache.get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.len())
+}
+
+func TestLRU_RemoveFunc(t *testing.T) {
+	cache := newLRU[string, int](4, time.Minute)
+
+	generation := cache.currentGeneration()
+	cache.add("a", 1, generation)
+	cache.add("b", 2, generation)
+
+	cache.removeFunc(func(_ string, value int) bool { return value == 1 })
+
+	_, ok := cache.get("a")
+	assert.False(t, ok)
+	_, ok = cache.get("b")
+	assert.True(t, ok)
+
+	// Значение, прочитанное до инвалидации, в кеш не попадает
+	assert.False(t, cache.add("a", 1, generation))
+	assert.True(t, cache.add("a", 1, cache.currentGeneration()))
+}
+
+func TestLRU_PutWinsOverEarlierRead(t *testing.T) {
+	cache := newLRU[string, bool](2, time.Minute)
+
+	// Чтение из базы началось до записи: его ответ не должен затереть записанное значение
+	generation := cache.currentGeneration()
+	cache.put("jti", true, time.Now().Add(time.Hour))
+	assert.False(t, cache.add("jti", false, generation))
+
+	value, ok := cache.get("jti")
+	assert.True(t, ok)
+	assert.True(t, value)
+}
@@ -0,0 +1,271 @@
+package cache
+
+import (
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/tracing"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+const (
+	// merchCatalogKey - ключ Redis, под которым лежит весь каталог, включая архивные товары.
+	// Фигурные скобки держат его в одном слоте Redis Cluster с merchCatalogVersionKey
+	merchCatalogKey = "{merch}:catalog"
+
+	// merchCatalogVersionKey - счётчик инвалидаций каталога
+	merchCatalogVersionKey = "{merch}:catalog:version"
+
+	// merchCacheName - значение метки cache в метриках
+	merchCacheName = "merch"
+)
+
+// setCatalogScript кладёт каталог в Redis, только если версия не менялась с момента, когда её
+// прочитали перед запросом в базу. Иначе каталог, прочитанный до коммита изменения, вернулся бы
+// в кеш уже после инвалидации и жил бы там до конца ttl
+var setCatalogScript = redis.NewScript(`
+if (redis.call('GET', KEYS[2]) or '') ~= ARGV[2] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[3])
+return 1
+`)
+
+// merchCache - кеш каталога в Redis поверх репозитория товаров.
+// Каталог небольшой, поэтому кешируется целиком одним ключом: GetAllMerch и GetMerchByName
+// отвечают из него, а после коммита любого изменения товара или остатка ключ удаляется.
+// Если Redis недоступен, запросы обслуживаются из базы данных.
+type merchCache struct {
+	db.MerchRepository
+	conn   db.TxManager
+	writes *txWrites
+	client redis.UniversalClient
+	ttl    time.Duration
+	logger logger.Logger
+}
+
+func NewMerchCache(repo db.MerchRepository, conn db.TxManager, client redis.UniversalClient, ttl time.Duration, log logger.Logger) db.MerchRepository {
+	return &merchCache{
+		MerchRepository: repo,
+		conn:            conn,
+		writes:          newTxWrites(conn),
+		client:          client,
+		ttl:             ttl,
+		logger:          log,
+	}
+}
+
+func (c *merchCache) GetAllMerch(ctx context.Context, includeArchived bool) ([]*models.Merch, error) {
+	ctx, span := tracing.StartSpan(ctx, "MerchCache.GetAllMerch")
+	defer span.End()
+
+	if c.writes.dirty(ctx) {
+		return c.MerchRepository.GetAllMerch(ctx, includeArchived)
+	}
+
+	catalog, ok := c.getCatalog(ctx)
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+	if !ok {
+		var err error
+		catalog, err = c.loadCatalog(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if includeArchived {
+		return catalog, nil
+	}
+
+	active := make([]*models.Merch, 0, len(catalog))
+	for _, merch := range catalog {
+		if !merch.IsArchived() {
+			active = append(active, merch)
+		}
+	}
+	return active, nil
+}
+
+func (c *merchCache) GetMerchByName(ctx context.Context, merchName string) (*models.Merch, error) {
+	ctx, span := tracing.StartSpan(ctx, "MerchCache.GetMerchByName")
+	defer span.End()
+
+	if c.writes.dirty(ctx) {
+		return c.MerchRepository.GetMerchByName(ctx, merchName)
+	}
+
+	catalog, ok := c.getCatalog(ctx)
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+	if !ok {
+		var err error
+		catalog, err = c.loadCatalog(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, merch := range catalog {
+		if merch.Name == merchName {
+			return merch, nil
+		}
+	}
+	// Сервисы отличают отсутствующий товар по pgx.ErrNoRows, как и при чтении из базы
+	return nil, fmt.Errorf("failed to retrieve merchandise by name: %w", pgx.ErrNoRows)
+}
+
+func (c *merchCache) CreateMerch(ctx context.Context, merch *models.Merch) (int, error) {
+	id, err := c.MerchRepository.CreateMerch(ctx, merch)
+	if err != nil {
+		return 0, err
+	}
+	c.written(ctx)
+	return id, nil
+}
+
+func (c *merchCache) UpdateMerch(ctx context.Context, merch *models.Merch) error {
+	if err := c.MerchRepository.UpdateMerch(ctx, merch); err != nil {
+		return err
+	}
+	c.written(ctx)
+	return nil
+}
+
+func (c *merchCache) ArchiveMerch(ctx context.Context, id int) error {
+	if err := c.MerchRepository.ArchiveMerch(ctx, id); err != nil {
+		return err
+	}
+	c.written(ctx)
+	return nil
+}
+
+// DecrementMerchStock сбрасывает каталог, только если изменился остаток: покупка товара
+// без учёта остатка каталог не меняет
+func (c *merchCache) DecrementMerchStock(ctx context.Context, merchID int, price int, quantity int) (*int, error) {
+	stock, err := c.MerchRepository.DecrementMerchStock(ctx, merchID, price, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if stock != nil {
+		c.written(ctx)
+	}
+	return stock, nil
+}
+
+func (c *merchCache) RestockMerch(ctx context.Context, merchID int, quantity int) (int, error) {
+	stock, err := c.MerchRepository.RestockMerch(ctx, merchID, quantity)
+	if err != nil {
+		return 0, err
+	}
+	c.written(ctx)
+	return stock, nil
+}
+
+func (c *merchCache) SetMerchStock(ctx context.Context, merchID int, stock *int) error {
+	if err := c.MerchRepository.SetMerchStock(ctx, merchID, stock); err != nil {
+		return err
+	}
+	c.written(ctx)
+	return nil
+}
+
+// written отмечает транзакцию как изменившую товары и откладывает удаление каталога до её коммита:
+// до коммита параллельный промах прочитал бы из базы старые данные и вернул их в кеш, а при откате
+// удалять нечего. Каталог показывает остатки, поэтому удаляется и после покупки; транзакция,
+// изменившая несколько товаров (например, оформление корзины), удаляет его один раз
+func (c *merchCache) written(ctx context.Context) {
+	if c.writes.mark(ctx) {
+		c.conn.AfterCommit(ctx, c.invalidate)
+	}
+}
+
+// getCatalog читает каталог из Redis. false означает, что каталог нужно взять из базы:
+// его нет в кеше, Redis недоступен или запись не удалось разобрать
+func (c *merchCache) getCatalog(ctx context.Context) ([]*models.Merch, bool) {
+	data, err := c.client.Get(ctx, merchCatalogKey).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			metrics.RecordCacheMiss(merchCacheName)
+			return nil, false
+		}
+		logger.WithContext(ctx, c.logger).Warnw("Failed to read merch catalog from cache, falling back to database",
+			"error", err,
+		)
+		metrics.RecordCacheError(merchCacheName, "get")
+		return nil, false
+	}
+
+	var catalog []*models.Merch
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		logger.WithContext(ctx, c.logger).Warnw("Failed to decode cached merch catalog",
+			"error", err,
+		)
+		metrics.RecordCacheError(merchCacheName, "decode")
+		return nil, false
+	}
+
+	metrics.RecordCacheHit(merchCacheName)
+	return catalog, true
+}
+
+// loadCatalog берёт каталог из базы и кладёт его в Redis; ошибка записи в кеш не мешает ответу.
+// Каталог читается из основной базы: отстающая реплика вернула бы его в кеш до только что сделанного изменения
+func (c *merchCache) loadCatalog(ctx context.Context) ([]*models.Merch, error) {
+	version, versionErr := c.client.Get(ctx, merchCatalogVersionKey).Result()
+
+	catalog, err := c.MerchRepository.GetAllMerch(db.WithPrimaryRead(ctx), true)
+	if err != nil {
+		return nil, err
+	}
+
+	if versionErr != nil && !errors.Is(versionErr, redis.Nil) {
+		logger.WithContext(ctx, c.logger).Warnw("Failed to write merch catalog to cache",
+			"error", versionErr,
+		)
+		metrics.RecordCacheError(merchCacheName, "set")
+		return catalog, nil
+	}
+
+	data, err := json.Marshal(catalog)
+	if err != nil {
+		logger.WithContext(ctx, c.logger).Warnw("Failed to encode merch catalog for cache",
+			"error", err,
+		)
+		metrics.RecordCacheError(merchCacheName, "encode")
+		return catalog, nil
+	}
+
+	keys := []string{merchCatalogKey, merchCatalogVersionKey}
+	if err := setCatalogScript.Run(ctx, c.client, keys, data, version, c.ttl.Milliseconds()).Err(); err != nil {
+		logger.WithContext(ctx, c.logger).Warnw("Failed to write merch catalog to cache",
+			"error", err,
+		)
+		metrics.RecordCacheError(merchCacheName, "set")
+	}
+
+	return catalog, nil
+}
+
+// invalidate удаляет каталог из Redis и меняет версию, чтобы каталог, который читается из базы
+// прямо сейчас, не попал в кеш. Если удалить не удалось, устаревший каталог отдаётся не дольше ttl,
+// поэтому изменение в базе не откатывается
+func (c *merchCache) invalidate(ctx context.Context) {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, merchCatalogVersionKey)
+		pipe.Del(ctx, merchCatalogKey)
+		return nil
+	})
+	if err != nil {
+		logger.WithContext(ctx, c.logger).Errorw("Failed to invalidate merch catalog cache",
+			"error", err,
+		)
+		metrics.RecordCacheError(merchCacheName, "delete")
+	}
+}
@@ -0,0 +1,355 @@
+package cache
+
+import (
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/internal/models"
+	mockRepo "avito-tech-merch/internal/storage/db/mock"
+	mockLog "avito-tech-merch/pkg/logger/mock"
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// fakeTx - транзакция на отдельном соединении для проверки поведения кеша внутри WithTx;
+// остальные методы pgx.Tx в тестах не вызываются
+type fakeTx struct {
+	pgx.Tx
+	conn *pgx.Conn
+}
+
+func newFakeTx() *fakeTx {
+	return &fakeTx{conn: &pgx.Conn{}}
+}
+
+func (tx *fakeTx) Conn() *pgx.Conn {
+	return tx.conn
+}
+
+type merchCacheFixture struct {
+	cache     *merchCache
+	repo      *mockRepo.MerchRepository
+	txManager *mockRepo.TxManager
+	logger    *mockLog.Logger
+	redis     *miniredis.Miniredis
+}
+
+func newMerchCacheFixture(t *testing.T) *merchCacheFixture {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+
+	f := &merchCacheFixture{
+		repo:      mockRepo.NewMerchRepository(t),
+		txManager: mockRepo.NewTxManager(t),
+		logger:    mockLog.NewLogger(t),
+		redis:     server,
+	}
+	f.cache = NewMerchCache(f.repo, f.txManager, client, time.Minute, f.logger).(*merchCache)
+	return f
+}
+
+// mockOutsideTx настраивает TxManager так, будто вызовы идут вне транзакции: отложенные функции выполняются сразу
+func mockOutsideTx(txManager *mockRepo.TxManager) {
+	txManager.On("GetExecutor", mock.Anything).Return(&mockRepo.Executor{})
+	txManager.On("AfterCommit", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(func(context.Context))(args.Get(0).(context.Context))
+	}).Maybe()
+}
+
+// mockInsideTx настраивает TxManager так, будто вызовы идут внутри транзакции, и возвращает
+// функции, отложенные до её коммита
+func mockInsideTx(txManager *mockRepo.TxManager) *[]func(context.Context) {
+	txManager.On("GetExecutor", mock.Anything).Return(newFakeTx())
+	hooks := &[]func(context.Context){}
+	txManager.On("AfterCommit", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*hooks = append(*hooks, args.Get(1).(func(context.Context)))
+	}).Maybe()
+	return hooks
+}
+
+func (f *merchCacheFixture) outsideTx() {
+	mockOutsideTx(f.txManager)
+}
+
+func (f *merchCacheFixture) insideTx() *[]func(context.Context) {
+	return mockInsideTx(f.txManager)
+}
+
+func testCatalog() []*models.Merch {
+	archivedAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	stock := 5
+	return []*models.Merch{
+		{ID: 1, Name: "t-shirt", Price: 80},
+		{ID: 2, Name: "hoody", Price: 300, Stock: &stock},
+		{ID: 3, Name: "umbrella", Price: 200, ArchivedAt: &archivedAt},
+	}
+}
+
+func TestMerchCache_GetAllMerch_CachesCatalog(t *testing.T) {
+	f := newMerchCacheFixture(t)
+	f.outsideTx()
+	ctx := context.Background()
+
+	hits := testutil.ToFloat64(metrics.CacheHitsTotal.WithLabelValues(merchCacheName))
+	misses := testutil.ToFloat64(metrics.CacheMissesTotal.WithLabelValues(merchCacheName))
+
+	f.repo.On("GetAllMerch", mock.Anything, true).Return(testCatalog(), nil).Once()
+
+	all, err := f.cache.GetAllMerch(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, testCatalog(), all)
+
+	active, err := f.cache.GetAllMerch(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, testCatalog()[:2], active)
+
+	merch, err := f.cache.GetMerchByName(ctx, "hoody")
+	require.NoError(t, err)
+	assert.Equal(t, testCatalog()[1], merch)
+
+	assert.Equal(t, time.Minute, f.redis.TTL(merchCatalogKey))
+	assert.Equal(t, misses+1, testutil.ToFloat64(metrics.CacheMissesTotal.WithLabelValues(merchCacheName)))
+	assert.Equal(t, hits+2, testutil.ToFloat64(metrics.CacheHitsTotal.WithLabelValues(merchCacheName)))
+}
+
+func TestMerchCache_GetMerchByName_NotFound(t *testing.T) {
+	f := newMerchCacheFixture(t)
+	f.outsideTx()
+
+	f.repo.On("GetAllMerch", mock.Anything, true).Return(testCatalog(), nil).Once()
+
+	merch, err := f.cache.GetMerchByName(context.Background(), "cup")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	assert.Nil(t, merch)
+}
+
+func TestMerchCache_ExpiredCatalog(t *testing.T) {
+	f := newMerchCacheFixture(t)
+	f.outsideTx()
+	ctx := context.Background()
+
+	updated := testCatalog()
+	updated[0].Price = 90
+	f.repo.On("GetAllMerch", mock.Anything, true).Return(testCatalog(), nil).Once()
+	f.repo.On("GetAllMerch", mock.Anything, true).Return(updated, nil).Once()
+
+	_, err := f.cache.GetAllMerch(ctx, true)
+	require.NoError(t, err)
+
+	f.redis.FastForward(time.Minute)
+
+	merch, err := f.cache.GetMerchByName(ctx, "t-shirt")
+	require.NoError(t, err)
+	assert.Equal(t, 90, merch.Price)
+}
+
+func TestMerchCache_WritesInvalidateCatalog(t *testing.T) {
+	stock := 10
+	tests := []struct {
+		name  string
+		setup func(repo *mockRepo.MerchRepository)
+		write func(ctx context.Context, cache *merchCache) error
+	}{
+		{
+			name: "CreateMerch",
+			setup: func(repo *mockRepo.MerchRepository) {
+				repo.On("CreateMerch", mock.Anything, mock.Anything).Return(4, nil)
+			},
+			write: func(ctx context.Context, cache *merchCache) error {
+				_, err := cache.CreateMerch(ctx, &models.Merch{Name: "cup", Price: 20})
+				return err
+			},
+		},
+		{
+			name: "UpdateMerch",
+			setup: func(repo *mockRepo.MerchRepository) {
+				repo.On("UpdateMerch", mock.Anything, mock.Anything).Return(nil)
+			},
+			write: func(ctx context.Context, cache *merchCache) error {
+				return cache.UpdateMerch(ctx, &models.Merch{ID: 1, Name: "t-shirt", Price: 90})
+			},
+		},
+		{
+			name: "ArchiveMerch",
+			setup: func(repo *mockRepo.MerchRepository) {
+				repo.On("ArchiveMerch", mock.Anything, 1).Return(nil)
+			},
+			write: func(ctx context.Context, cache *merchCache) error {
+				return cache.ArchiveMerch(ctx, 1)
+			},
+		},
+		{
+			name: "DecrementMerchStock",
+			setup: func(repo *mockRepo.MerchRepository) {
+				repo.On("DecrementMerchStock", mock.Anything, 2, 300, 1).Return(&stock, nil)
+			},
+			write: func(ctx context.Context, cache *merchCache) error {
+				_, err := cache.DecrementMerchStock(ctx, 2, 300, 1)
+				return err
+			},
+		},
+		{
+			name: "RestockMerch",
+			setup: func(repo *mockRepo.MerchRepository) {
+				repo.On("RestockMerch", mock.Anything, 2, 5).Return(10, nil)
+			},
+			write: func(ctx context.Context, cache *merchCache) error {
+				_, err := cache.RestockMerch(ctx, 2, 5)
+				return err
+			},
+		},
+		{
+			name: "SetMerchStock",
+			setup: func(repo *mockRepo.MerchRepository) {
+				repo.On("SetMerchStock", mock.Anything, 2, &stock).Return(nil)
+			},
+			write: func(ctx context.Context, cache *merchCache) error {
+				return cache.SetMerchStock(ctx, 2, &stock)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newMerchCacheFixture(t)
+			f.outsideTx()
+			require.NoError(t, f.redis.Set(merchCatalogKey, "[]"))
+			tt.setup(f.repo)
+
+			require.NoError(t, tt.write(context.Background(), f.cache))
+			assert.False(t, f.redis.Exists(merchCatalogKey))
+		})
+	}
+}
+
+func TestMerchCache_FailedWriteKeepsCatalog(t *testing.T) {
+	f := newMerchCacheFixture(t)
+	require.NoError(t, f.redis.Set(merchCatalogKey, "[]"))
+
+	f.repo.On("DecrementMerchStock", mock.Anything, 2, 300, 10).Return(nil, pgx.ErrNoRows)
+
+	_, err := f.cache.DecrementMerchStock(context.Background(), 2, 300, 10)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	assert.True(t, f.redis.Exists(merchCatalogKey))
+}
+
+func TestMerchCache_UnlimitedPurchaseKeepsCatalog(t *testing.T) {
+	f := newMerchCacheFixture(t)
+	require.NoError(t, f.redis.Set(merchCatalogKey, "[]"))
+
+	// Покупка товара без учёта остатка не меняет каталог
+	f.repo.On("DecrementMerchStock", mock.Anything, 1, 80, 1).Return(nil, nil)
+
+	stock, err := f.cache.DecrementMerchStock(context.Background(), 1, 80, 1)
+	require.NoError(t, err)
+	assert.Nil(t, stock)
+	assert.True(t, f.redis.Exists(merchCatalogKey))
+}
+
+func TestMerchCache_RedisUnavailable(t *testing.T) {
+	f := newMerchCacheFixture(t)
+	f.outsideTx()
+	ctx := context.Background()
+	f.redis.Close()
+
+	getErrors := testutil.ToFloat64(metrics.CacheErrorsTotal.WithLabelValues(merchCacheName, "get"))
+
+	f.repo.On("GetAllMerch", mock.Anything, true).Return(testCatalog(), nil).Once()
+	f.repo.On("UpdateMerch", mock.Anything, mock.Anything).Return(nil).Once()
+	f.logger.On("Warnw", "Failed to read merch catalog from cache, falling back to database", "error", mock.Anything).Once()
+	f.logger.On("Warnw", "Failed to write merch catalog to cache", "error", mock.Anything).Once()
+	f.logger.On("Errorw", "Failed to invalidate merch catalog cache", "error", mock.Anything).Once()
+
+	merch, err := f.cache.GetMerchByName(ctx, "t-shirt")
+	require.NoError(t, err)
+	assert.Equal(t, 80, merch.Price)
+
+	assert.NoError(t, f.cache.UpdateMerch(ctx, &models.Merch{ID: 1, Name: "t-shirt", Price: 90}))
+	assert.Equal(t, getErrors+1, testutil.ToFloat64(metrics.CacheErrorsTotal.WithLabelValues(merchCacheName, "get")))
+}
+
+func TestMerchCache_InvalidatesAfterCommit(t *testing.T) {
+	f := newMerchCacheFixture(t)
+	hooks := f.insideTx()
+	ctx := context.Background()
+	require.NoError(t, f.redis.Set(merchCatalogKey, "[]"))
+
+	first, second := 4, 9
+	f.repo.On("DecrementMerchStock", mock.Anything, 1, 80, 1).Return(&first, nil).Once()
+	f.repo.On("DecrementMerchStock", mock.Anything, 2, 300, 1).Return(&second, nil).Once()
+
+	_, err := f.cache.DecrementMerchStock(ctx, 1, 80, 1)
+	require.NoError(t, err)
+	_, err = f.cache.DecrementMerchStock(ctx, 2, 300, 1)
+	require.NoError(t, err)
+
+	// До коммита другие запросы видят в базе старые данные, и каталог в кеше им соответствует
+	assert.True(t, f.redis.Exists(merchCatalogKey))
+	require.Len(t, *hooks, 1)
+
+	(*hooks)[0](ctx)
+	assert.False(t, f.redis.Exists(merchCatalogKey))
+}
+
+func TestMerchCache_StaleLoadNotCached(t *testing.T) {
+	f := newMerchCacheFixture(t)
+	f.outsideTx()
+	ctx := context.Background()
+
+	updated := testCatalog()
+	updated[0].Price = 90
+	// Изменение коммитится, пока каталог читается из базы: прочитанный каталог уже устарел
+	f.repo.On("GetAllMerch", mock.Anything, true).Run(func(mock.Arguments) {
+		f.cache.invalidate(ctx)
+	}).Return(testCatalog(), nil).Once()
+	f.repo.On("GetAllMerch", mock.Anything, true).Return(updated, nil).Once()
+
+	merch, err := f.cache.GetMerchByName(ctx, "t-shirt")
+	require.NoError(t, err)
+	assert.Equal(t, 80, merch.Price)
+	assert.False(t, f.redis.Exists(merchCatalogKey))
+
+	merch, err = f.cache.GetMerchByName(ctx, "t-shirt")
+	require.NoError(t, err)
+	assert.Equal(t, 90, merch.Price)
+	assert.True(t, f.redis.Exists(merchCatalogKey))
+}
+
+func TestMerchCache_MissInsideTransaction(t *testing.T) {
+	f := newMerchCacheFixture(t)
+	f.txManager.On("GetExecutor", mock.Anything).Return(newFakeTx())
+
+	f.repo.On("GetAllMerch", mock.Anything, true).Return(testCatalog(), nil).Once()
+
+	merch, err := f.cache.GetMerchByName(context.Background(), "hoody")
+	require.NoError(t, err)
+	assert.Equal(t, testCatalog()[1], merch)
+	assert.True(t, f.redis.Exists(merchCatalogKey))
+}
+
+func TestMerchCache_ReadAfterWriteInsideTransaction(t *testing.T) {
+	f := newMerchCacheFixture(t)
+	f.insideTx()
+	ctx := context.Background()
+
+	updated := testCatalog()[1]
+	updated.Price = 350
+	f.repo.On("UpdateMerch", mock.Anything, updated).Return(nil).Once()
+	f.repo.On("GetMerchByName", mock.Anything, "hoody").Return(updated, nil).Once()
+
+	require.NoError(t, f.cache.UpdateMerch(ctx, updated))
+
+	merch, err := f.cache.GetMerchByName(ctx, "hoody")
+	require.NoError(t, err)
+	assert.Equal(t, 350, merch.Price)
+
+	// Транзакция видит своё незакоммиченное изменение, поэтому каталог из неё не кладётся в кеш
+	assert.False(t, f.redis.Exists(merchCatalogKey))
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/storage/db"
+	"context"
+	"golang.org/x/sync/singleflight"
+	"time"
+)
+
+// merchMemoryCacheName - значение метки cache в метриках
+const merchMemoryCacheName = "merch_memory"
+
+// merchMemoryCache - in-process кеш товаров по названию поверх репозитория товаров.
+// Убирает из покупки обращение к базе за товаром и работает без Redis. Одновременные
+// промахи по одному названию объединяются в один запрос. Изменение товара или остатка
+// через этот экземпляр удаляет товар из кеша после коммита; изменение, сделанное другим
+// экземпляром сервиса, станет видно не позже чем через ttl. Устаревший товар не приводит
+// к покупке по старой цене: цену, архивацию и остаток сверяет с базой DecrementMerchStock.
+type merchMemoryCache struct {
+	db.MerchRepository
+	conn   db.TxManager
+	writes *txWrites
+	byName *lru[string, models.Merch]
+	group  singleflight.Group
+}
+
+func NewMerchMemoryCache(repo db.MerchRepository, conn db.TxManager, size int, ttl time.Duration) db.MerchRepository {
+	return &merchMemoryCache{
+		MerchRepository: repo,
+		conn:            conn,
+		writes:          newTxWrites(conn),
+		byName:          newLRU[string, models.Merch](size, ttl),
+	}
+}
+
+func (c *merchMemoryCache) GetMerchByName(ctx context.Context, merchName string) (*models.Merch, error) {
+	if c.writes.dirty(ctx) {
+		return c.MerchRepository.GetMerchByName(ctx, merchName)
+	}
+
+	if merch, ok := c.byName.get(merchName); ok {
+		metrics.RecordCacheHit(merchMemoryCacheName)
+		return &merch, nil
+	}
+	metrics.RecordCacheMiss(merchMemoryCacheName)
+
+	loaded, err, _ := c.group.Do(merchName, func() (interface{}, error) {
+		generation := c.byName.currentGeneration()
+		merch, err := c.MerchRepository.GetMerchByName(ctx, merchName)
+		if err != nil {
+			return nil, err
+		}
+		c.byName.add(merchName, *merch, generation)
+		return *merch, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Каждый вызывающий получает свою копию, чтобы изменения товара не попали в кеш
+	merch := loaded.(models.Merch)
+	return &merch, nil
+}
+
+func (c *merchMemoryCache) CreateMerch(ctx context.Context, merch *models.Merch) (int, error) {
+	id, err := c.MerchRepository.CreateMerch(ctx, merch)
+	if err != nil {
+		return 0, err
+	}
+	c.writes.mark(ctx)
+	name := merch.Name
+	c.conn.AfterCommit(ctx, func(context.Context) {
+		c.byName.removeFunc(func(cached string, _ models.Merch) bool { return cached == name })
+	})
+	return id, nil
+}
+
+func (c *merchMemoryCache) UpdateMerch(ctx context.Context, merch *models.Merch) error {
+	if err := c.MerchRepository.UpdateMerch(ctx, merch); err != nil {
+		return err
+	}
+	c.invalidateAfterCommit(ctx, merch.ID)
+	return nil
+}
+
+func (c *merchMemoryCache) ArchiveMerch(ctx context.Context, id int) error {
+	if err := c.MerchRepository.ArchiveMerch(ctx, id); err != nil {
+		return err
+	}
+	c.invalidateAfterCommit(ctx, id)
+	return nil
+}
+
+func (c *merchMemoryCache) DecrementMerchStock(ctx context.Context, merchID int, price int, quantity int) (*int, error) {
+	stock, err := c.MerchRepository.DecrementMerchStock(ctx, merchID, price, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if stock != nil {
+		c.invalidateAfterCommit(ctx, merchID)
+	}
+	return stock, nil
+}
+
+func (c *merchMemoryCache) RestockMerch(ctx context.Context, merchID int, quantity int) (int, error) {
+	stock, err := c.MerchRepository.RestockMerch(ctx, merchID, quantity)
+	if err != nil {
+		return 0, err
+	}
+	c.invalidateAfterCommit(ctx, merchID)
+	return stock, nil
+}
+
+func (c *merchMemoryCache) SetMerchStock(ctx context.Context, merchID int, stock *int) error {
+	if err := c.MerchRepository.SetMerchStock(ctx, merchID, stock); err != nil {
+		return err
+	}
+	c.invalidateAfterCommit(ctx, merchID)
+	return nil
+}
+
+// invalidateAfterCommit отмечает транзакцию как изменившую товары и удаляет товар из кеша по ID после
+// её коммита: удалённый раньше товар успел бы вернуться в кеш из параллельного промаха, который ещё
+// видит в базе старую строку. Товар удаляется по ID, потому что при переименовании должно пропасть
+// и старое название
+func (c *merchMemoryCache) invalidateAfterCommit(ctx context.Context, merchID int) {
+	c.writes.mark(ctx)
+	c.conn.AfterCommit(ctx, func(context.Context) {
+		c.byName.removeFunc(func(_ string, merch models.Merch) bool { return merch.ID == merchID })
+	})
+}
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/internal/models"
+	mockRepo "avito-tech-merch/internal/storage/db/mock"
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestMerchMemoryCache(t *testing.T) (*merchMemoryCache, *mockRepo.MerchRepository, *mockRepo.TxManager) {
+	repo := mockRepo.NewMerchRepository(t)
+	txManager := mockRepo.NewTxManager(t)
+	return NewMerchMemoryCache(repo, txManager, 16, time.Minute).(*merchMemoryCache), repo, txManager
+}
+
+func TestMerchMemoryCache_GetMerchByName_CachesResult(t *testing.T) {
+	cache, repo, txManager := newTestMerchMemoryCache(t)
+	txManager.On("GetExecutor", mock.Anything).Return(&mockRepo.Executor{})
+	ctx := context.Background()
+
+	hits := testutil.ToFloat64(metrics.CacheHitsTotal.WithLabelValues(merchMemoryCacheName))
+	misses := testutil.ToFloat64(metrics.CacheMissesTotal.WithLabelValues(merchMemoryCacheName))
+
+	repo.On("GetMerchByName", mock.Anything, "hoody").Return(testCatalog()[1], nil).Once()
+
+	for i := 0; i < 3; i++ {
+		merch, err := cache.GetMerchByName(ctx, "hoody")
+		require.NoError(t, err)
+		assert.Equal(t, testCatalog()[1], merch)
+	}
+
+	assert.Equal(t, misses+1, testutil.ToFloat64(metrics.CacheMissesTotal.WithLabelValues(merchMemoryCacheName)))
+	assert.Equal(t, hits+2, testutil.ToFloat64(metrics.CacheHitsTotal.WithLabelValues(merchMemoryCacheName)))
+}
+
+func TestMerchMemoryCache_GetMerchByName_ErrorNotCached(t *testing.T) {
+	cache, repo, txManager := newTestMerchMemoryCache(t)
+	txManager.On("GetExecutor", mock.Anything).Return(&mockRepo.Executor{})
+	ctx := context.Background()
+	expectedErr := errors.New("db error")
+
+	repo.On("GetMerchByName", mock.Anything, "hoody").Return(nil, expectedErr).Once()
+	repo.On("GetMerchByName", mock.Anything, "hoody").Return(testCatalog()[1], nil).Once()
+
+	_, err := cache.GetMerchByName(ctx, "hoody")
+	assert.ErrorIs(t, err, expectedErr)
+
+	merch, err := cache.GetMerchByName(ctx, "hoody")
+	require.NoError(t, err)
+	assert.Equal(t, 300, merch.Price)
+}
+
+func TestMerchMemoryCache_GetMerchByName_ConcurrentMisses(t *testing.T) {
+	cache, repo, txManager := newTestMerchMemoryCache(t)
+	txManager.On("GetExecutor", mock.Anything).Return(&mockRepo.Executor{})
+	ctx := context.Background()
+
+	// Ответ базы задерживается, чтобы остальные запросы успели присоединиться к первому
+	repo.On("GetMerchByName", mock.Anything, "hoody").Return(testCatalog()[1], nil).After(50 * time.Millisecond).Once()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			merch, err := cache.GetMerchByName(ctx, "hoody")
+			assert.NoError(t, err)
+			assert.Equal(t, 300, merch.Price)
+		}()
+	}
+	wg.Wait()
+
+	repo.AssertNumberOfCalls(t, "GetMerchByName", 1)
+}
+
+func TestMerchMemoryCache_WritesInvalidate(t *testing.T) {
+	stock := 10
+	tests := []struct {
+		name  string
+		setup func(repo *mockRepo.MerchRepository)
+		write func(ctx context.Context, cache *merchMemoryCache) error
+	}{
+		{
+			name: "UpdateMerch",
+			setup: func(repo *mockRepo.MerchRepository) {
+				repo.On("UpdateMerch", mock.Anything, mock.Anything).Return(nil)
+			},
+			write: func(ctx context.Context, cache *merchMemoryCache) error {
+				return cache.UpdateMerch(ctx, &models.Merch{ID: 2, Name: "hoodie", Price: 350})
+			},
+		},
+		{
+			name: "ArchiveMerch",
+			setup: func(repo *mockRepo.MerchRepository) {
+				repo.On("ArchiveMerch", mock.Anything, 2).Return(nil)
+			},
+			write: func(ctx context.Context, cache *merchMemoryCache) error {
+				return cache.ArchiveMerch(ctx, 2)
+			},
+		},
+		{
+			name: "DecrementMerchStock",
+			setup: func(repo *mockRepo.MerchRepository) {
+				repo.On("DecrementMerchStock", mock.Anything, 2, 300, 1).Return(&stock, nil)
+			},
+			write: func(ctx context.Context, cache *merchMemoryCache) error {
+				_, err := cache.DecrementMerchStock(ctx, 2, 300, 1)
+				return err
+			},
+		},
+		{
+			name: "RestockMerch",
+			setup: func(repo *mockRepo.MerchRepository) {
+				repo.On("RestockMerch", mock.Anything, 2, 5).Return(10, nil)
+			},
+			write: func(ctx context.Context, cache *merchMemoryCache) error {
+				_, err := cache.RestockMerch(ctx, 2, 5)
+				return err
+			},
+		},
+		{
+			name: "SetMerchStock",
+			setup: func(repo *mockRepo.MerchRepository) {
+				repo.On("SetMerchStock", mock.Anything, 2, &stock).Return(nil)
+			},
+			write: func(ctx context.Context, cache *merchMemoryCache) error {
+				return cache.SetMerchStock(ctx, 2, &stock)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, repo, txManager := newTestMerchMemoryCache(t)
+			mockOutsideTx(txManager)
+			ctx := context.Background()
+
+			repo.On("GetMerchByName", mock.Anything, "hoody").Return(testCatalog()[1], nil).Twice()
+			tt.setup(repo)
+
+			_, err := cache.GetMerchByName(ctx, "hoody")
+			require.NoError(t, err)
+			require.NoError(t, tt.write(ctx, cache))
+			_, err = cache.GetMerchByName(ctx, "hoody")
+			require.NoError(t, err)
+
+			repo.AssertNumberOfCalls(t, "GetMerchByName", 2)
+		})
+	}
+}
+
+func TestMerchMemoryCache_ReadAfterWriteInsideTransaction(t *testing.T) {
+	cache, repo, txManager := newTestMerchMemoryCache(t)
+	mockInsideTx(txManager)
+	ctx := context.Background()
+
+	created := &models.Merch{ID: 4, Name: "cup", Price: 20}
+	repo.On("CreateMerch", mock.Anything, created).Return(4, nil).Once()
+	repo.On("GetMerchByName", mock.Anything, "cup").Return(created, nil).Twice()
+
+	_, err := cache.CreateMerch(ctx, created)
+	require.NoError(t, err)
+
+	// Незакоммиченный товар не кладётся в кеш: оба чтения уходят в базу
+	for i := 0; i < 2; i++ {
+		merch, err := cache.GetMerchByName(ctx, "cup")
+		require.NoError(t, err)
+		assert.Equal(t, created, merch)
+	}
+	assert.Equal(t, 0, cache.byName.len())
+}
+
+func TestMerchMemoryCache_InvalidatesAfterCommit(t *testing.T) {
+	cache, repo, txManager := newTestMerchMemoryCache(t)
+	hooks := mockInsideTx(txManager)
+	ctx := context.Background()
+
+	cache.byName.add("hoody", *testCatalog()[1], cache.byName.currentGeneration())
+	repo.On("UpdateMerch", mock.Anything, mock.Anything).Return(nil).Once()
+
+	require.NoError(t, cache.UpdateMerch(ctx, &models.Merch{ID: 2, Name: "hoodie", Price: 350}))
+
+	// До коммита другие запросы видят в базе старый товар, и он остаётся в кеше; при откате так и будет
+	assert.Equal(t, 1, cache.byName.len())
+	require.Len(t, *hooks, 1)
+
+	(*hooks)[0](ctx)
+	assert.Equal(t, 0, cache.byName.len())
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"avito-tech-merch/internal/storage/db"
+	"context"
+	"time"
+)
+
+// maxRevocationEntries - сколько отзывов токенов и столько же отметок logout-all держит кеш;
+// при переполнении вытесняются записи, к которым дольше всего не обращались
+const maxRevocationEntries = 10000
+
+// revocationCache - in-process кеш поверх хранилища отзывов.
+// Токен, отозванный через этот экземпляр, хранится в кеше до своего истечения, если его не вытеснят
+// более свежие записи; вытесненный отзыв снова читается из базы.
+// Ответы из базы и отметки logout-all кешируются на ttl: отзыв, сделанный другим
+// экземпляром сервиса, вступит в силу не позже чем через ttl.
+type revocationCache struct {
+	db.TokenRevocationRepository
+	ttl time.Duration
+
+	tokens     *lru[string, bool]
+	watermarks *lru[int, time.Time]
+}
+
+func NewRevocationCache(repo db.TokenRevocationRepository, ttl time.Duration) db.TokenRevocationRepository {
+	return &revocationCache{
+		TokenRevocationRepository: repo,
+		ttl:                       ttl,
+		tokens:                    newLRU[string, bool](maxRevocationEntries, ttl),
+		watermarks:                newLRU[int, time.Time](maxRevocationEntries, ttl),
+	}
+}
+
+func (c *revocationCache) RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	if err := c.TokenRevocationRepository.RevokeToken(ctx, tokenID, userID, expiresAt); err != nil {
+		return err
+	}
+
+	c.tokens.put(tokenID, true, expiresAt)
+	return nil
+}
+
+func (c *revocationCache) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	if revoked, ok := c.tokens.get(tokenID); ok {
+		return revoked, nil
+	}
+
+	generation := c.tokens.currentGeneration()
+	revoked, err := c.TokenRevocationRepository.IsTokenRevoked(ctx, tokenID)
+	if err != nil {
+		return false, err
+	}
+
+	c.tokens.add(tokenID, revoked, generation)
+	return revoked, nil
+}
+
+func (c *revocationCache) SetTokensValidAfter(ctx context.Context, userID int, validAfter time.Time) error {
+	if err := c.TokenRevocationRepository.SetTokensValidAfter(ctx, userID, validAfter); err != nil {
+		return err
+	}
+
+	c.watermarks.put(userID, validAfter, time.Now().Add(c.ttl))
+	return nil
+}
+
+func (c *revocationCache) GetTokensValidAfter(ctx context.Context, userID int) (time.Time, error) {
+	if validAfter, ok := c.watermarks.get(userID); ok {
+		return validAfter, nil
+	}
+
+	generation := c.watermarks.currentGeneration()
+	validAfter, err := c.TokenRevocationRepository.GetTokensValidAfter(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	c.watermarks.add(userID, validAfter, generation)
+	return validAfter, nil
+}
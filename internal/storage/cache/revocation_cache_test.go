@@ -0,0 +1,128 @@
+package cache
+
+import (
+	mockRepo "avito-tech-merch/internal/storage/db/mock"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestRevocationCache_IsTokenRevoked_CachesResult(t *testing.T) {
+	repoMock := mockRepo.NewTokenRevocationRepository(t)
+	cache := NewRevocationCache(repoMock, time.Minute)
+	ctx := context.Background()
+
+	repoMock.On("IsTokenRevoked", ctx, "jti").Return(false, nil).Once()
+
+	for i := 0; i < 3; i++ {
+		revoked, err := cache.IsTokenRevoked(ctx, "jti")
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+	}
+
+	repoMock.AssertNumberOfCalls(t, "IsTokenRevoked", 1)
+}
+
+func TestRevocationCache_IsTokenRevoked_ExpiredEntry(t *testing.T) {
+	repoMock := mockRepo.NewTokenRevocationRepository(t)
+	cache := NewRevocationCache(repoMock, time.Nanosecond)
+	ctx := context.Background()
+
+	repoMock.On("IsTokenRevoked", ctx, "jti").Return(false, nil).Once()
+	repoMock.On("IsTokenRevoked", ctx, "jti").Return(true, nil).Once()
+
+	revoked, err := cache.IsTokenRevoked(ctx, "jti")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	time.Sleep(time.Millisecond)
+
+	revoked, err = cache.IsTokenRevoked(ctx, "jti")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestRevocationCache_RevokeToken_UpdatesCache(t *testing.T) {
+	repoMock := mockRepo.NewTokenRevocationRepository(t)
+	cache := NewRevocationCache(repoMock, time.Minute)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	repoMock.On("IsTokenRevoked", ctx, "jti").Return(false, nil).Once()
+	repoMock.On("RevokeToken", ctx, "jti", 1, expiresAt).Return(nil).Once()
+
+	revoked, err := cache.IsTokenRevoked(ctx, "jti")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	assert.NoError(t, cache.RevokeToken(ctx, "jti", 1, expiresAt))
+
+	// Отрицательный ответ в кеше должен быть заменён без обращения к базе
+	revoked, err = cache.IsTokenRevoked(ctx, "jti")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	repoMock.AssertNumberOfCalls(t, "IsTokenRevoked", 1)
+}
+
+func TestRevocationCache_RevokeToken_Error(t *testing.T) {
+	repoMock := mockRepo.NewTokenRevocationRepository(t)
+	cache := NewRevocationCache(repoMock, time.Minute)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+	dbErr := errors.New("db error")
+
+	repoMock.On("RevokeToken", ctx, "jti", 1, expiresAt).Return(dbErr).Once()
+	repoMock.On("IsTokenRevoked", ctx, "jti").Return(false, nil).Once()
+
+	assert.Equal(t, dbErr, cache.RevokeToken(ctx, "jti", 1, expiresAt))
+
+	revoked, err := cache.IsTokenRevoked(ctx, "jti")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestRevocationCache_TokensValidAfter(t *testing.T) {
+	repoMock := mockRepo.NewTokenRevocationRepository(t)
+	cache := NewRevocationCache(repoMock, time.Minute)
+	ctx := context.Background()
+	watermark := time.Now()
+
+	repoMock.On("GetTokensValidAfter", ctx, 1).Return(time.Time{}, nil).Once()
+	repoMock.On("SetTokensValidAfter", ctx, 1, watermark).Return(nil).Once()
+
+	validAfter, err := cache.GetTokensValidAfter(ctx, 1)
+	assert.NoError(t, err)
+	assert.True(t, validAfter.IsZero())
+
+	assert.NoError(t, cache.SetTokensValidAfter(ctx, 1, watermark))
+
+	validAfter, err = cache.GetTokensValidAfter(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, watermark, validAfter)
+	repoMock.AssertNumberOfCalls(t, "GetTokensValidAfter", 1)
+}
+
+func TestRevocationCache_RevokeToken_Bounded(t *testing.T) {
+	repoMock := mockRepo.NewTokenRevocationRepository(t)
+	revocations := NewRevocationCache(repoMock, time.Minute).(*revocationCache)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	repoMock.On("RevokeToken", ctx, mock.Anything, 1, expiresAt).Return(nil)
+	repoMock.On("IsTokenRevoked", ctx, "jti-0").Return(true, nil).Once()
+
+	// Всплеск выходов с долгоживущими токенами не раздувает кеш: старые отзывы вытесняются
+	for i := 0; i <= maxRevocationEntries; i++ {
+		assert.NoError(t, revocations.RevokeToken(ctx, fmt.Sprintf("jti-%d", i), 1, expiresAt))
+	}
+	assert.Equal(t, maxRevocationEntries, revocations.tokens.len())
+
+	// Вытесненный отзыв по-прежнему действует: он читается из базы
+	revoked, err := revocations.IsTokenRevoked(ctx, "jti-0")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
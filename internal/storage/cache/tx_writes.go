@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"avito-tech-merch/internal/storage/db"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"sync"
+	"time"
+)
+
+// txWritesRetention - сколько помнится транзакция, изменившая товары. Транзакции сервиса
+// короче, а старые записи о соединениях, закрытых пулом, вычищаются
+const txWritesRetention = 10 * time.Minute
+
+type markedTx struct {
+	tx       pgx.Tx
+	markedAt time.Time
+}
+
+// txWrites запоминает транзакции, в которых товары менялись через кеш. Такая транзакция видит
+// свои незакоммиченные изменения, поэтому её чтения идут мимо кеша и не кладут в него ничего.
+// Транзакции хранятся по соединению: на соединении одновременно идёт не больше одной транзакции,
+// поэтому записей не больше, чем соединений пула, а следующая транзакция на том же соединении
+// уже не считается изменившей товары
+type txWrites struct {
+	conn db.TxManager
+
+	mu  sync.Mutex
+	txs map[*pgx.Conn]markedTx
+}
+
+func newTxWrites(conn db.TxManager) *txWrites {
+	return &txWrites{
+		conn: conn,
+		txs:  make(map[*pgx.Conn]markedTx),
+	}
+}
+
+// mark отмечает текущую транзакцию как изменившую товары и сообщает, первое ли это изменение в ней;
+// вне транзакции каждое изменение первое
+func (w *txWrites) mark(ctx context.Context) bool {
+	tx, ok := w.conn.GetExecutor(ctx).(pgx.Tx)
+	if !ok {
+		return true
+	}
+
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for conn, marked := range w.txs {
+		if now.Sub(marked.markedAt) > txWritesRetention {
+			delete(w.txs, conn)
+		}
+	}
+	if marked, ok := w.txs[tx.Conn()]; ok && marked.tx == tx {
+		return false
+	}
+	w.txs[tx.Conn()] = markedTx{tx: tx, markedAt: now}
+	return true
+}
+
+// dirty сообщает, менялись ли товары в текущей транзакции
+func (w *txWrites) dirty(ctx context.Context) bool {
+	tx, ok := w.conn.GetExecutor(ctx).(pgx.Tx)
+	if !ok {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	marked, ok := w.txs[tx.Conn()]
+	return ok && marked.tx == tx
+}
@@ -0,0 +1,17 @@
+package db
+
+import "context"
+
+type primaryReadKey struct{}
+
+// WithPrimaryRead помечает контекст: чтения через GetReadExecutor идут в основную базу.
+// Нужен там, где прочитанное кешируется и не должно отставать от только что сделанной записи
+func WithPrimaryRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadKey{}, true)
+}
+
+// PrimaryRead сообщает, требует ли контекст чтения из основной базы
+func PrimaryRead(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadKey{}).(bool)
+	return primary
+}
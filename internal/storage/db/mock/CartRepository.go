@@ -0,0 +1,124 @@
+// Code generated by mockery v2.53.0. DO NOT EDIT.
+
+package mock
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "avito-tech-merch/internal/models"
+)
+
+// CartRepository is an autogenerated mock type for the CartRepository type
+type CartRepository struct {
+	mock.Mock
+}
+
+// AddCartItem provides a mock function with given fields: ctx, userID, merchID, quantity, maxQuantity
+func (_m *CartRepository) AddCartItem(ctx context.Context, userID int, merchID int, quantity int, maxQuantity int) (int, error) {
+	ret := _m.Called(ctx, userID, merchID, quantity, maxQuantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddCartItem")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, int) (int, error)); ok {
+		return rf(ctx, userID, merchID, quantity, maxQuantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, int) int); ok {
+		r0 = rf(ctx, userID, merchID, quantity, maxQuantity)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int, int) error); ok {
+		r1 = rf(ctx, userID, merchID, quantity, maxQuantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClearCart provides a mock function with given fields: ctx, userID
+func (_m *CartRepository) ClearCart(ctx context.Context, userID int) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearCart")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetCartItems provides a mock function with given fields: ctx, userID
+func (_m *CartRepository) GetCartItems(ctx context.Context, userID int) ([]*models.CartItem, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCartItems")
+	}
+
+	var r0 []*models.CartItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.CartItem, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.CartItem); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.CartItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveCartItem provides a mock function with given fields: ctx, userID, merchID
+func (_m *CartRepository) RemoveCartItem(ctx context.Context, userID int, merchID int) error {
+	ret := _m.Called(ctx, userID, merchID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveCartItem")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
+		r0 = rf(ctx, userID, merchID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewCartRepository creates a new instance of CartRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCartRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CartRepository {
+	mock := &CartRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
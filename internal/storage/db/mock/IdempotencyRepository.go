@@ -0,0 +1,78 @@
+// Code generated by mockery v2.53.0. DO NOT EDIT.
+
+package mock
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "avito-tech-merch/internal/models"
+)
+
+// IdempotencyRepository is an autogenerated mock type for the IdempotencyRepository type
+type IdempotencyRepository struct {
+	mock.Mock
+}
+
+// CreateIdempotencyKey provides a mock function with given fields: ctx, key
+func (_m *IdempotencyRepository) CreateIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateIdempotencyKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.IdempotencyKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetIdempotencyKey provides a mock function with given fields: ctx, userID, key
+func (_m *IdempotencyRepository) GetIdempotencyKey(ctx context.Context, userID int, key string) (*models.IdempotencyKey, error) {
+	ret := _m.Called(ctx, userID, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetIdempotencyKey")
+	}
+
+	var r0 *models.IdempotencyKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (*models.IdempotencyKey, error)); ok {
+		return rf(ctx, userID, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) *models.IdempotencyKey); ok {
+		r0 = rf(ctx, userID, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.IdempotencyKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, userID, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewIdempotencyRepository creates a new instance of IdempotencyRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewIdempotencyRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *IdempotencyRepository {
+	mock := &IdempotencyRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,88 @@
+// Code generated by mockery v2.53.0. DO NOT EDIT.
+
+package mock
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "avito-tech-merch/internal/models"
+)
+
+// LedgerRepository is an autogenerated mock type for the LedgerRepository type
+type LedgerRepository struct {
+	mock.Mock
+}
+
+// CreateLedgerEntry provides a mock function with given fields: ctx, entry
+func (_m *LedgerRepository) CreateLedgerEntry(ctx context.Context, entry *models.LedgerEntry) (int, error) {
+	ret := _m.Called(ctx, entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLedgerEntry")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.LedgerEntry) (int, error)); ok {
+		return rf(ctx, entry)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.LedgerEntry) int); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.LedgerEntry) error); ok {
+		r1 = rf(ctx, entry)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyLedger provides a mock function with given fields: ctx
+func (_m *LedgerRepository) VerifyLedger(ctx context.Context) (*models.LedgerReport, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyLedger")
+	}
+
+	var r0 *models.LedgerReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*models.LedgerReport, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *models.LedgerReport); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.LedgerReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewLedgerRepository creates a new instance of LedgerRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLedgerRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LedgerRepository {
+	mock := &LedgerRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
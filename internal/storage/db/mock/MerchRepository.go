@@ -15,9 +15,113 @@ type MerchRepository struct {
 	mock.Mock
 }
 
-// GetAllMerch provides a mock function with given fields: ctx
-func (_m *MerchRepository) GetAllMerch(ctx context.Context) ([]*models.Merch, error) {
-	ret := _m.Called(ctx)
+// ArchiveMerch provides a mock function with given fields: ctx, id
+func (_m *MerchRepository) ArchiveMerch(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ArchiveMerch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateMerch provides a mock function with given fields: ctx, merch
+func (_m *MerchRepository) CreateMerch(ctx context.Context, merch *models.Merch) (int, error) {
+	ret := _m.Called(ctx, merch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMerch")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Merch) (int, error)); ok {
+		return rf(ctx, merch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Merch) int); ok {
+		r0 = rf(ctx, merch)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Merch) error); ok {
+		r1 = rf(ctx, merch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMerchPrice provides a mock function with given fields: ctx, price
+func (_m *MerchRepository) CreateMerchPrice(ctx context.Context, price *models.MerchPrice) (int, error) {
+	ret := _m.Called(ctx, price)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMerchPrice")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.MerchPrice) (int, error)); ok {
+		return rf(ctx, price)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.MerchPrice) int); ok {
+		r0 = rf(ctx, price)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.MerchPrice) error); ok {
+		r1 = rf(ctx, price)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DecrementMerchStock provides a mock function with given fields: ctx, merchID, price, quantity
+func (_m *MerchRepository) DecrementMerchStock(ctx context.Context, merchID int, price int, quantity int) (*int, error) {
+	ret := _m.Called(ctx, merchID, price, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DecrementMerchStock")
+	}
+
+	var r0 *int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) (*int, error)); ok {
+		return rf(ctx, merchID, price, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) *int); ok {
+		r0 = rf(ctx, merchID, price, quantity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, merchID, price, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllMerch provides a mock function with given fields: ctx, includeArchived
+func (_m *MerchRepository) GetAllMerch(ctx context.Context, includeArchived bool) ([]*models.Merch, error) {
+	ret := _m.Called(ctx, includeArchived)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAllMerch")
@@ -25,19 +129,19 @@ func (_m *MerchRepository) GetAllMerch(ctx context.Context) ([]*models.Merch, er
 
 	var r0 []*models.Merch
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]*models.Merch, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, bool) ([]*models.Merch, error)); ok {
+		return rf(ctx, includeArchived)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []*models.Merch); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, bool) []*models.Merch); ok {
+		r0 = rf(ctx, includeArchived)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Merch)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, includeArchived)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -105,6 +209,100 @@ func (_m *MerchRepository) GetMerchByName(ctx context.Context, merchName string)
 	return r0, r1
 }
 
+// GetMerchPriceHistory provides a mock function with given fields: ctx, merchID
+func (_m *MerchRepository) GetMerchPriceHistory(ctx context.Context, merchID int) ([]*models.MerchPrice, error) {
+	ret := _m.Called(ctx, merchID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMerchPriceHistory")
+	}
+
+	var r0 []*models.MerchPrice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.MerchPrice, error)); ok {
+		return rf(ctx, merchID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.MerchPrice); ok {
+		r0 = rf(ctx, merchID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.MerchPrice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, merchID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestockMerch provides a mock function with given fields: ctx, merchID, quantity
+func (_m *MerchRepository) RestockMerch(ctx context.Context, merchID int, quantity int) (int, error) {
+	ret := _m.Called(ctx, merchID, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestockMerch")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (int, error)); ok {
+		return rf(ctx, merchID, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) int); ok {
+		r0 = rf(ctx, merchID, quantity)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, merchID, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetMerchStock provides a mock function with given fields: ctx, merchID, stock
+func (_m *MerchRepository) SetMerchStock(ctx context.Context, merchID int, stock *int) error {
+	ret := _m.Called(ctx, merchID, stock)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMerchStock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, *int) error); ok {
+		r0 = rf(ctx, merchID, stock)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateMerch provides a mock function with given fields: ctx, merch
+func (_m *MerchRepository) UpdateMerch(ctx context.Context, merch *models.Merch) error {
+	ret := _m.Called(ctx, merch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMerch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Merch) error); ok {
+		r0 = rf(ctx, merch)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewMerchRepository creates a new instance of MerchRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMerchRepository(t interface {
@@ -8,6 +8,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	models "avito-tech-merch/internal/models"
+
+	time "time"
 )
 
 // PurchaseRepository is an autogenerated mock type for the PurchaseRepository type
@@ -43,27 +45,173 @@ func (_m *PurchaseRepository) CreatePurchase(ctx context.Context, purchase *mode
 	return r0, r1
 }
 
-// GetPurchaseByUserID provides a mock function with given fields: ctx, userID
-func (_m *PurchaseRepository) GetPurchaseByUserID(ctx context.Context, userID int) ([]*models.Purchase, error) {
+// CreatePurchaseStatusChange provides a mock function with given fields: ctx, change
+func (_m *PurchaseRepository) CreatePurchaseStatusChange(ctx context.Context, change *models.PurchaseStatusChange) (int, error) {
+	ret := _m.Called(ctx, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePurchaseStatusChange")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.PurchaseStatusChange) (int, error)); ok {
+		return rf(ctx, change)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.PurchaseStatusChange) int); ok {
+		r0 = rf(ctx, change)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.PurchaseStatusChange) error); ok {
+		r1 = rf(ctx, change)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInventoryByUserID provides a mock function with given fields: ctx, userID
+func (_m *PurchaseRepository) GetInventoryByUserID(ctx context.Context, userID int) ([]*models.InventoryItem, error) {
 	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetPurchaseByUserID")
+		panic("no return value specified for GetInventoryByUserID")
 	}
 
-	var r0 []*models.Purchase
+	var r0 []*models.InventoryItem
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.Purchase, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.InventoryItem, error)); ok {
 		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.Purchase); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.InventoryItem); ok {
 		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.InventoryItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPurchaseByID provides a mock function with given fields: ctx, purchaseID
+func (_m *PurchaseRepository) GetPurchaseByID(ctx context.Context, purchaseID int) (*models.Purchase, error) {
+	ret := _m.Called(ctx, purchaseID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPurchaseByID")
+	}
+
+	var r0 *models.Purchase
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.Purchase, error)); ok {
+		return rf(ctx, purchaseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.Purchase); ok {
+		r0 = rf(ctx, purchaseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Purchase)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, purchaseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPurchaseByUserID provides a mock function with given fields: ctx, userID, filter, page
+func (_m *PurchaseRepository) GetPurchaseByUserID(ctx context.Context, userID int, filter models.PurchaseFilter, page models.HistoryPage) ([]*models.Purchase, error) {
+	ret := _m.Called(ctx, userID, filter, page)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPurchaseByUserID")
+	}
+
+	var r0 []*models.Purchase
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.PurchaseFilter, models.HistoryPage) ([]*models.Purchase, error)); ok {
+		return rf(ctx, userID, filter, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.PurchaseFilter, models.HistoryPage) []*models.Purchase); ok {
+		r0 = rf(ctx, userID, filter, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Purchase)
 		}
 	}
 
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.PurchaseFilter, models.HistoryPage) error); ok {
+		r1 = rf(ctx, userID, filter, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPurchaseStatusHistory provides a mock function with given fields: ctx, purchaseID
+func (_m *PurchaseRepository) GetPurchaseStatusHistory(ctx context.Context, purchaseID int) ([]*models.PurchaseStatusChange, error) {
+	ret := _m.Called(ctx, purchaseID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPurchaseStatusHistory")
+	}
+
+	var r0 []*models.PurchaseStatusChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.PurchaseStatusChange, error)); ok {
+		return rf(ctx, purchaseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.PurchaseStatusChange); ok {
+		r0 = rf(ctx, purchaseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.PurchaseStatusChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, purchaseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSpentByUserID provides a mock function with given fields: ctx, userID
+func (_m *PurchaseRepository) GetSpentByUserID(ctx context.Context, userID int) (int, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSpentByUserID")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
 	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
 		r1 = rf(ctx, userID)
 	} else {
@@ -73,6 +221,52 @@ func (_m *PurchaseRepository) GetPurchaseByUserID(ctx context.Context, userID in
 	return r0, r1
 }
 
+// MarkPurchaseRefunded provides a mock function with given fields: ctx, purchaseID, refundedBy
+func (_m *PurchaseRepository) MarkPurchaseRefunded(ctx context.Context, purchaseID int, refundedBy int) (time.Time, error) {
+	ret := _m.Called(ctx, purchaseID, refundedBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkPurchaseRefunded")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (time.Time, error)); ok {
+		return rf(ctx, purchaseID, refundedBy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) time.Time); ok {
+		r0 = rf(ctx, purchaseID, refundedBy)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, purchaseID, refundedBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdatePurchaseStatus provides a mock function with given fields: ctx, purchaseID, from, to
+func (_m *PurchaseRepository) UpdatePurchaseStatus(ctx context.Context, purchaseID int, from string, to string) error {
+	ret := _m.Called(ctx, purchaseID, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePurchaseStatus")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) error); ok {
+		r0 = rf(ctx, purchaseID, from, to)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewPurchaseRepository creates a new instance of PurchaseRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewPurchaseRepository(t interface {
@@ -0,0 +1,142 @@
+// Code generated by mockery v2.53.0. DO NOT EDIT.
+
+package mock
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "avito-tech-merch/internal/models"
+)
+
+// RefreshTokenRepository is an autogenerated mock type for the RefreshTokenRepository type
+type RefreshTokenRepository struct {
+	mock.Mock
+}
+
+// CreateRefreshToken provides a mock function with given fields: ctx, token
+func (_m *RefreshTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) (int, error) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRefreshToken")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.RefreshToken) (int, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.RefreshToken) int); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.RefreshToken) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRefreshTokenByHash provides a mock function with given fields: ctx, tokenHash
+func (_m *RefreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRefreshTokenByHash")
+	}
+
+	var r0 *models.RefreshToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.RefreshToken, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.RefreshToken); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RefreshToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkRefreshTokenUsed provides a mock function with given fields: ctx, tokenID
+func (_m *RefreshTokenRepository) MarkRefreshTokenUsed(ctx context.Context, tokenID int) error {
+	ret := _m.Called(ctx, tokenID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkRefreshTokenUsed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, tokenID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeRefreshTokenFamily provides a mock function with given fields: ctx, familyID
+func (_m *RefreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ret := _m.Called(ctx, familyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeRefreshTokenFamily")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, familyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUserRefreshTokens provides a mock function with given fields: ctx, userID
+func (_m *RefreshTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserRefreshTokens")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewRefreshTokenRepository creates a new instance of RefreshTokenRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRefreshTokenRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RefreshTokenRepository {
+	mock := &RefreshTokenRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
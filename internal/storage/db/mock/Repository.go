@@ -8,6 +8,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	models "avito-tech-merch/internal/models"
+
+	time "time"
 )
 
 // Repository is an autogenerated mock type for the Repository type
@@ -15,6 +17,172 @@ type Repository struct {
 	mock.Mock
 }
 
+// AddCartItem provides a mock function with given fields: ctx, userID, merchID, quantity, maxQuantity
+func (_m *Repository) AddCartItem(ctx context.Context, userID int, merchID int, quantity int, maxQuantity int) (int, error) {
+	ret := _m.Called(ctx, userID, merchID, quantity, maxQuantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddCartItem")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, int) (int, error)); ok {
+		return rf(ctx, userID, merchID, quantity, maxQuantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int, int) int); ok {
+		r0 = rf(ctx, userID, merchID, quantity, maxQuantity)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int, int) error); ok {
+		r1 = rf(ctx, userID, merchID, quantity, maxQuantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ArchiveMerch provides a mock function with given fields: ctx, id
+func (_m *Repository) ArchiveMerch(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ArchiveMerch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClearCart provides a mock function with given fields: ctx, userID
+func (_m *Repository) ClearCart(ctx context.Context, userID int) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearCart")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateIdempotencyKey provides a mock function with given fields: ctx, key
+func (_m *Repository) CreateIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateIdempotencyKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.IdempotencyKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateLedgerEntry provides a mock function with given fields: ctx, entry
+func (_m *Repository) CreateLedgerEntry(ctx context.Context, entry *models.LedgerEntry) (int, error) {
+	ret := _m.Called(ctx, entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLedgerEntry")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.LedgerEntry) (int, error)); ok {
+		return rf(ctx, entry)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.LedgerEntry) int); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.LedgerEntry) error); ok {
+		r1 = rf(ctx, entry)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMerch provides a mock function with given fields: ctx, merch
+func (_m *Repository) CreateMerch(ctx context.Context, merch *models.Merch) (int, error) {
+	ret := _m.Called(ctx, merch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMerch")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Merch) (int, error)); ok {
+		return rf(ctx, merch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Merch) int); ok {
+		r0 = rf(ctx, merch)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Merch) error); ok {
+		r1 = rf(ctx, merch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMerchPrice provides a mock function with given fields: ctx, price
+func (_m *Repository) CreateMerchPrice(ctx context.Context, price *models.MerchPrice) (int, error) {
+	ret := _m.Called(ctx, price)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMerchPrice")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.MerchPrice) (int, error)); ok {
+		return rf(ctx, price)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.MerchPrice) int); ok {
+		r0 = rf(ctx, price)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.MerchPrice) error); ok {
+		r1 = rf(ctx, price)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreatePurchase provides a mock function with given fields: ctx, purchase
 func (_m *Repository) CreatePurchase(ctx context.Context, purchase *models.Purchase) (int, error) {
 	ret := _m.Called(ctx, purchase)
@@ -43,6 +211,62 @@ func (_m *Repository) CreatePurchase(ctx context.Context, purchase *models.Purch
 	return r0, r1
 }
 
+// CreatePurchaseStatusChange provides a mock function with given fields: ctx, change
+func (_m *Repository) CreatePurchaseStatusChange(ctx context.Context, change *models.PurchaseStatusChange) (int, error) {
+	ret := _m.Called(ctx, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePurchaseStatusChange")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.PurchaseStatusChange) (int, error)); ok {
+		return rf(ctx, change)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.PurchaseStatusChange) int); ok {
+		r0 = rf(ctx, change)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.PurchaseStatusChange) error); ok {
+		r1 = rf(ctx, change)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateRefreshToken provides a mock function with given fields: ctx, token
+func (_m *Repository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) (int, error) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRefreshToken")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.RefreshToken) (int, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.RefreshToken) int); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.RefreshToken) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateTransaction provides a mock function with given fields: ctx, transaction
 func (_m *Repository) CreateTransaction(ctx context.Context, transaction *models.Transaction) (int, error) {
 	ret := _m.Called(ctx, transaction)
@@ -99,29 +323,143 @@ func (_m *Repository) CreateUser(ctx context.Context, user *models.User) (int, e
 	return r0, r1
 }
 
-// GetAllMerch provides a mock function with given fields: ctx
-func (_m *Repository) GetAllMerch(ctx context.Context) ([]*models.Merch, error) {
+// CreditBalance provides a mock function with given fields: ctx, userID, amount
+func (_m *Repository) CreditBalance(ctx context.Context, userID int, amount int) (int, error) {
+	ret := _m.Called(ctx, userID, amount)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreditBalance")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (int, error)); ok {
+		return rf(ctx, userID, amount)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) int); ok {
+		r0 = rf(ctx, userID, amount)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userID, amount)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DebitBalance provides a mock function with given fields: ctx, userID, amount
+func (_m *Repository) DebitBalance(ctx context.Context, userID int, amount int) (int, error) {
+	ret := _m.Called(ctx, userID, amount)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DebitBalance")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (int, error)); ok {
+		return rf(ctx, userID, amount)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) int); ok {
+		r0 = rf(ctx, userID, amount)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userID, amount)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DecrementMerchStock provides a mock function with given fields: ctx, merchID, price, quantity
+func (_m *Repository) DecrementMerchStock(ctx context.Context, merchID int, price int, quantity int) (*int, error) {
+	ret := _m.Called(ctx, merchID, price, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DecrementMerchStock")
+	}
+
+	var r0 *int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) (*int, error)); ok {
+		return rf(ctx, merchID, price, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) *int); ok {
+		r0 = rf(ctx, merchID, price, quantity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, merchID, price, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteExpiredRevokedTokens provides a mock function with given fields: ctx
+func (_m *Repository) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
 	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAllMerch")
+		panic("no return value specified for DeleteExpiredRevokedTokens")
 	}
 
-	var r0 []*models.Merch
+	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]*models.Merch, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
 		return rf(ctx)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []*models.Merch); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
 		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllMerch provides a mock function with given fields: ctx, includeArchived
+func (_m *Repository) GetAllMerch(ctx context.Context, includeArchived bool) ([]*models.Merch, error) {
+	ret := _m.Called(ctx, includeArchived)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllMerch")
+	}
+
+	var r0 []*models.Merch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) ([]*models.Merch, error)); ok {
+		return rf(ctx, includeArchived)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool) []*models.Merch); ok {
+		r0 = rf(ctx, includeArchived)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Merch)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, includeArchived)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -215,29 +553,29 @@ func (_m *Repository) GetBalanceByName(ctx context.Context, username string) (in
 	return r0, r1
 }
 
-// GetMerchByID provides a mock function with given fields: ctx, id
-func (_m *Repository) GetMerchByID(ctx context.Context, id int) (*models.Merch, error) {
-	ret := _m.Called(ctx, id)
+// GetCartItems provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetCartItems(ctx context.Context, userID int) ([]*models.CartItem, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetMerchByID")
+		panic("no return value specified for GetCartItems")
 	}
 
-	var r0 *models.Merch
+	var r0 []*models.CartItem
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.Merch, error)); ok {
-		return rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.CartItem, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) *models.Merch); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.CartItem); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Merch)
+			r0 = ret.Get(0).([]*models.CartItem)
 		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
-		r1 = rf(ctx, id)
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -245,29 +583,29 @@ func (_m *Repository) GetMerchByID(ctx context.Context, id int) (*models.Merch,
 	return r0, r1
 }
 
-// GetMerchByName provides a mock function with given fields: ctx, merchName
-func (_m *Repository) GetMerchByName(ctx context.Context, merchName string) (*models.Merch, error) {
-	ret := _m.Called(ctx, merchName)
+// GetCoinHistoryByUserID provides a mock function with given fields: ctx, userID, filter, limit
+func (_m *Repository) GetCoinHistoryByUserID(ctx context.Context, userID int, filter models.TransactionFilter, limit int) (*models.CoinHistory, error) {
+	ret := _m.Called(ctx, userID, filter, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetMerchByName")
+		panic("no return value specified for GetCoinHistoryByUserID")
 	}
 
-	var r0 *models.Merch
+	var r0 *models.CoinHistory
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.Merch, error)); ok {
-		return rf(ctx, merchName)
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter, int) (*models.CoinHistory, error)); ok {
+		return rf(ctx, userID, filter, limit)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Merch); ok {
-		r0 = rf(ctx, merchName)
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter, int) *models.CoinHistory); ok {
+		r0 = rf(ctx, userID, filter, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Merch)
+			r0 = ret.Get(0).(*models.CoinHistory)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, merchName)
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.TransactionFilter, int) error); ok {
+		r1 = rf(ctx, userID, filter, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -275,27 +613,295 @@ func (_m *Repository) GetMerchByName(ctx context.Context, merchName string) (*mo
 	return r0, r1
 }
 
-// GetPurchaseByUserID provides a mock function with given fields: ctx, userID
-func (_m *Repository) GetPurchaseByUserID(ctx context.Context, userID int) ([]*models.Purchase, error) {
-	ret := _m.Called(ctx, userID)
+// GetIdempotencyKey provides a mock function with given fields: ctx, userID, key
+func (_m *Repository) GetIdempotencyKey(ctx context.Context, userID int, key string) (*models.IdempotencyKey, error) {
+	ret := _m.Called(ctx, userID, key)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetPurchaseByUserID")
+		panic("no return value specified for GetIdempotencyKey")
 	}
 
-	var r0 []*models.Purchase
+	var r0 *models.IdempotencyKey
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.Purchase, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) (*models.IdempotencyKey, error)); ok {
+		return rf(ctx, userID, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) *models.IdempotencyKey); ok {
+		r0 = rf(ctx, userID, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.IdempotencyKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, userID, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetInventoryByUserID provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetInventoryByUserID(ctx context.Context, userID int) ([]*models.InventoryItem, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInventoryByUserID")
+	}
+
+	var r0 []*models.InventoryItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.InventoryItem, error)); ok {
 		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.Purchase); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.InventoryItem); ok {
 		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.InventoryItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMerchByID provides a mock function with given fields: ctx, id
+func (_m *Repository) GetMerchByID(ctx context.Context, id int) (*models.Merch, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMerchByID")
+	}
+
+	var r0 *models.Merch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.Merch, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.Merch); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Merch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMerchByName provides a mock function with given fields: ctx, merchName
+func (_m *Repository) GetMerchByName(ctx context.Context, merchName string) (*models.Merch, error) {
+	ret := _m.Called(ctx, merchName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMerchByName")
+	}
+
+	var r0 *models.Merch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.Merch, error)); ok {
+		return rf(ctx, merchName)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Merch); ok {
+		r0 = rf(ctx, merchName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Merch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, merchName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMerchPriceHistory provides a mock function with given fields: ctx, merchID
+func (_m *Repository) GetMerchPriceHistory(ctx context.Context, merchID int) ([]*models.MerchPrice, error) {
+	ret := _m.Called(ctx, merchID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMerchPriceHistory")
+	}
+
+	var r0 []*models.MerchPrice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.MerchPrice, error)); ok {
+		return rf(ctx, merchID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.MerchPrice); ok {
+		r0 = rf(ctx, merchID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.MerchPrice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, merchID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPurchaseByID provides a mock function with given fields: ctx, purchaseID
+func (_m *Repository) GetPurchaseByID(ctx context.Context, purchaseID int) (*models.Purchase, error) {
+	ret := _m.Called(ctx, purchaseID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPurchaseByID")
+	}
+
+	var r0 *models.Purchase
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*models.Purchase, error)); ok {
+		return rf(ctx, purchaseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *models.Purchase); ok {
+		r0 = rf(ctx, purchaseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Purchase)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, purchaseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPurchaseByUserID provides a mock function with given fields: ctx, userID, filter, page
+func (_m *Repository) GetPurchaseByUserID(ctx context.Context, userID int, filter models.PurchaseFilter, page models.HistoryPage) ([]*models.Purchase, error) {
+	ret := _m.Called(ctx, userID, filter, page)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPurchaseByUserID")
+	}
+
+	var r0 []*models.Purchase
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.PurchaseFilter, models.HistoryPage) ([]*models.Purchase, error)); ok {
+		return rf(ctx, userID, filter, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.PurchaseFilter, models.HistoryPage) []*models.Purchase); ok {
+		r0 = rf(ctx, userID, filter, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Purchase)
 		}
 	}
 
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.PurchaseFilter, models.HistoryPage) error); ok {
+		r1 = rf(ctx, userID, filter, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPurchaseStatusHistory provides a mock function with given fields: ctx, purchaseID
+func (_m *Repository) GetPurchaseStatusHistory(ctx context.Context, purchaseID int) ([]*models.PurchaseStatusChange, error) {
+	ret := _m.Called(ctx, purchaseID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPurchaseStatusHistory")
+	}
+
+	var r0 []*models.PurchaseStatusChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.PurchaseStatusChange, error)); ok {
+		return rf(ctx, purchaseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.PurchaseStatusChange); ok {
+		r0 = rf(ctx, purchaseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.PurchaseStatusChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, purchaseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRefreshTokenByHash provides a mock function with given fields: ctx, tokenHash
+func (_m *Repository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRefreshTokenByHash")
+	}
+
+	var r0 *models.RefreshToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.RefreshToken, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.RefreshToken); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RefreshToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSpentByUserID provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetSpentByUserID(ctx context.Context, userID int) (int, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSpentByUserID")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
 	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
 		r1 = rf(ctx, userID)
 	} else {
@@ -305,25 +911,23 @@ func (_m *Repository) GetPurchaseByUserID(ctx context.Context, userID int) ([]*m
 	return r0, r1
 }
 
-// GetTransactionByUserID provides a mock function with given fields: ctx, userID
-func (_m *Repository) GetTransactionByUserID(ctx context.Context, userID int) ([]*models.Transaction, error) {
+// GetTokensValidAfter provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetTokensValidAfter(ctx context.Context, userID int) (time.Time, error) {
 	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetTransactionByUserID")
+		panic("no return value specified for GetTokensValidAfter")
 	}
 
-	var r0 []*models.Transaction
+	var r0 time.Time
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.Transaction, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) (time.Time, error)); ok {
 		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.Transaction); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) time.Time); ok {
 		r0 = rf(ctx, userID)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Transaction)
-		}
+		r0 = ret.Get(0).(time.Time)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
@@ -335,6 +939,36 @@ func (_m *Repository) GetTransactionByUserID(ctx context.Context, userID int) ([
 	return r0, r1
 }
 
+// GetTransfersByUserID provides a mock function with given fields: ctx, userID, filter, page
+func (_m *Repository) GetTransfersByUserID(ctx context.Context, userID int, filter models.TransactionFilter, page models.HistoryPage) ([]*models.CoinTransfer, error) {
+	ret := _m.Called(ctx, userID, filter, page)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTransfersByUserID")
+	}
+
+	var r0 []*models.CoinTransfer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter, models.HistoryPage) ([]*models.CoinTransfer, error)); ok {
+		return rf(ctx, userID, filter, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter, models.HistoryPage) []*models.CoinTransfer); ok {
+		r0 = rf(ctx, userID, filter, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.CoinTransfer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.TransactionFilter, models.HistoryPage) error); ok {
+		r1 = rf(ctx, userID, filter, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserByID provides a mock function with given fields: ctx, userID
 func (_m *Repository) GetUserByID(ctx context.Context, userID int) (*models.User, error) {
 	ret := _m.Called(ctx, userID)
@@ -395,17 +1029,282 @@ func (_m *Repository) GetUserByUsername(ctx context.Context, username string) (*
 	return r0, r1
 }
 
-// UpdateBalance provides a mock function with given fields: ctx, userID, newBalance
-func (_m *Repository) UpdateBalance(ctx context.Context, userID int, newBalance int) error {
-	ret := _m.Called(ctx, userID, newBalance)
+// IsTokenRevoked provides a mock function with given fields: ctx, tokenID
+func (_m *Repository) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ret := _m.Called(ctx, tokenID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTokenRevoked")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, tokenID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, tokenID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LockBalances provides a mock function with given fields: ctx, userIDs
+func (_m *Repository) LockBalances(ctx context.Context, userIDs ...int) (map[int]int, error) {
+	_va := make([]interface{}, len(userIDs))
+	for _i := range userIDs {
+		_va[_i] = userIDs[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockBalances")
+	}
+
+	var r0 map[int]int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ...int) (map[int]int, error)); ok {
+		return rf(ctx, userIDs...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ...int) map[int]int); ok {
+		r0 = rf(ctx, userIDs...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ...int) error); ok {
+		r1 = rf(ctx, userIDs...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkPurchaseRefunded provides a mock function with given fields: ctx, purchaseID, refundedBy
+func (_m *Repository) MarkPurchaseRefunded(ctx context.Context, purchaseID int, refundedBy int) (time.Time, error) {
+	ret := _m.Called(ctx, purchaseID, refundedBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkPurchaseRefunded")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (time.Time, error)); ok {
+		return rf(ctx, purchaseID, refundedBy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) time.Time); ok {
+		r0 = rf(ctx, purchaseID, refundedBy)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, purchaseID, refundedBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkRefreshTokenUsed provides a mock function with given fields: ctx, tokenID
+func (_m *Repository) MarkRefreshTokenUsed(ctx context.Context, tokenID int) error {
+	ret := _m.Called(ctx, tokenID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateBalance")
+		panic("no return value specified for MarkRefreshTokenUsed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, tokenID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveCartItem provides a mock function with given fields: ctx, userID, merchID
+func (_m *Repository) RemoveCartItem(ctx context.Context, userID int, merchID int) error {
+	ret := _m.Called(ctx, userID, merchID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveCartItem")
 	}
 
 	var r0 error
 	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
-		r0 = rf(ctx, userID, newBalance)
+		r0 = rf(ctx, userID, merchID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RestockMerch provides a mock function with given fields: ctx, merchID, quantity
+func (_m *Repository) RestockMerch(ctx context.Context, merchID int, quantity int) (int, error) {
+	ret := _m.Called(ctx, merchID, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestockMerch")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (int, error)); ok {
+		return rf(ctx, merchID, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) int); ok {
+		r0 = rf(ctx, merchID, quantity)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, merchID, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeRefreshTokenFamily provides a mock function with given fields: ctx, familyID
+func (_m *Repository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ret := _m.Called(ctx, familyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeRefreshTokenFamily")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, familyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeToken provides a mock function with given fields: ctx, tokenID, userID, expiresAt
+func (_m *Repository) RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	ret := _m.Called(ctx, tokenID, userID, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, time.Time) error); ok {
+		r0 = rf(ctx, tokenID, userID, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUserRefreshTokens provides a mock function with given fields: ctx, userID
+func (_m *Repository) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserRefreshTokens")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetMerchStock provides a mock function with given fields: ctx, merchID, stock
+func (_m *Repository) SetMerchStock(ctx context.Context, merchID int, stock *int) error {
+	ret := _m.Called(ctx, merchID, stock)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMerchStock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, *int) error); ok {
+		r0 = rf(ctx, merchID, stock)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetTokensValidAfter provides a mock function with given fields: ctx, userID, validAfter
+func (_m *Repository) SetTokensValidAfter(ctx context.Context, userID int, validAfter time.Time) error {
+	ret := _m.Called(ctx, userID, validAfter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetTokensValidAfter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) error); ok {
+		r0 = rf(ctx, userID, validAfter)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateMerch provides a mock function with given fields: ctx, merch
+func (_m *Repository) UpdateMerch(ctx context.Context, merch *models.Merch) error {
+	ret := _m.Called(ctx, merch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMerch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Merch) error); ok {
+		r0 = rf(ctx, merch)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePurchaseStatus provides a mock function with given fields: ctx, purchaseID, from, to
+func (_m *Repository) UpdatePurchaseStatus(ctx context.Context, purchaseID int, from string, to string) error {
+	ret := _m.Called(ctx, purchaseID, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePurchaseStatus")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) error); ok {
+		r0 = rf(ctx, purchaseID, from, to)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -431,6 +1330,54 @@ func (_m *Repository) UpdateUser(ctx context.Context, user *models.User) error {
 	return r0
 }
 
+// UpdateUserRole provides a mock function with given fields: ctx, userID, role
+func (_m *Repository) UpdateUserRole(ctx context.Context, userID int, role string) error {
+	ret := _m.Called(ctx, userID, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, userID, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyLedger provides a mock function with given fields: ctx
+func (_m *Repository) VerifyLedger(ctx context.Context) (*models.LedgerReport, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyLedger")
+	}
+
+	var r0 *models.LedgerReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*models.LedgerReport, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *models.LedgerReport); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.LedgerReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewRepository(t interface {
@@ -0,0 +1,150 @@
+// Code generated by mockery v2.53.0. DO NOT EDIT.
+
+package mock
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// TokenRevocationRepository is an autogenerated mock type for the TokenRevocationRepository type
+type TokenRevocationRepository struct {
+	mock.Mock
+}
+
+// DeleteExpiredRevokedTokens provides a mock function with given fields: ctx
+func (_m *TokenRevocationRepository) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpiredRevokedTokens")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTokensValidAfter provides a mock function with given fields: ctx, userID
+func (_m *TokenRevocationRepository) GetTokensValidAfter(ctx context.Context, userID int) (time.Time, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTokensValidAfter")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (time.Time, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) time.Time); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsTokenRevoked provides a mock function with given fields: ctx, tokenID
+func (_m *TokenRevocationRepository) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ret := _m.Called(ctx, tokenID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTokenRevoked")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, tokenID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, tokenID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeToken provides a mock function with given fields: ctx, tokenID, userID, expiresAt
+func (_m *TokenRevocationRepository) RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	ret := _m.Called(ctx, tokenID, userID, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, time.Time) error); ok {
+		r0 = rf(ctx, tokenID, userID, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetTokensValidAfter provides a mock function with given fields: ctx, userID, validAfter
+func (_m *TokenRevocationRepository) SetTokensValidAfter(ctx context.Context, userID int, validAfter time.Time) error {
+	ret := _m.Called(ctx, userID, validAfter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetTokensValidAfter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) error); ok {
+		r0 = rf(ctx, userID, validAfter)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewTokenRevocationRepository creates a new instance of TokenRevocationRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTokenRevocationRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TokenRevocationRepository {
+	mock := &TokenRevocationRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -43,29 +43,59 @@ func (_m *TransactionRepository) CreateTransaction(ctx context.Context, transact
 	return r0, r1
 }
 
-// GetTransactionByUserID provides a mock function with given fields: ctx, userID
-func (_m *TransactionRepository) GetTransactionByUserID(ctx context.Context, userID int) ([]*models.Transaction, error) {
-	ret := _m.Called(ctx, userID)
+// GetCoinHistoryByUserID provides a mock function with given fields: ctx, userID, filter, limit
+func (_m *TransactionRepository) GetCoinHistoryByUserID(ctx context.Context, userID int, filter models.TransactionFilter, limit int) (*models.CoinHistory, error) {
+	ret := _m.Called(ctx, userID, filter, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetTransactionByUserID")
+		panic("no return value specified for GetCoinHistoryByUserID")
 	}
 
-	var r0 []*models.Transaction
+	var r0 *models.CoinHistory
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*models.Transaction, error)); ok {
-		return rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter, int) (*models.CoinHistory, error)); ok {
+		return rf(ctx, userID, filter, limit)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) []*models.Transaction); ok {
-		r0 = rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter, int) *models.CoinHistory); ok {
+		r0 = rf(ctx, userID, filter, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*models.Transaction)
+			r0 = ret.Get(0).(*models.CoinHistory)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
-		r1 = rf(ctx, userID)
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.TransactionFilter, int) error); ok {
+		r1 = rf(ctx, userID, filter, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTransfersByUserID provides a mock function with given fields: ctx, userID, filter, page
+func (_m *TransactionRepository) GetTransfersByUserID(ctx context.Context, userID int, filter models.TransactionFilter, page models.HistoryPage) ([]*models.CoinTransfer, error) {
+	ret := _m.Called(ctx, userID, filter, page)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTransfersByUserID")
+	}
+
+	var r0 []*models.CoinTransfer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter, models.HistoryPage) ([]*models.CoinTransfer, error)); ok {
+		return rf(ctx, userID, filter, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransactionFilter, models.HistoryPage) []*models.CoinTransfer); ok {
+		r0 = rf(ctx, userID, filter, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.CoinTransfer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.TransactionFilter, models.HistoryPage) error); ok {
+		r1 = rf(ctx, userID, filter, page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -16,6 +16,11 @@ type TxManager struct {
 	mock.Mock
 }
 
+// AfterCommit provides a mock function with given fields: ctx, fn
+func (_m *TxManager) AfterCommit(ctx context.Context, fn func(context.Context)) {
+	_m.Called(ctx, fn)
+}
+
 // GetExecutor provides a mock function with given fields: ctx
 func (_m *TxManager) GetExecutor(ctx context.Context) db.Executor {
 	ret := _m.Called(ctx)
@@ -36,6 +41,26 @@ func (_m *TxManager) GetExecutor(ctx context.Context) db.Executor {
 	return r0
 }
 
+// GetReadExecutor provides a mock function with given fields: ctx
+func (_m *TxManager) GetReadExecutor(ctx context.Context) db.Executor {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReadExecutor")
+	}
+
+	var r0 db.Executor
+	if rf, ok := ret.Get(0).(func(context.Context) db.Executor); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(db.Executor)
+		}
+	}
+
+	return r0
+}
+
 // WithTx provides a mock function with given fields: ctx, isoLevel, accessMode, fn
 func (_m *TxManager) WithTx(ctx context.Context, isoLevel pgx.TxIsoLevel, accessMode pgx.TxAccessMode, fn func(context.Context) error) error {
 	ret := _m.Called(ctx, isoLevel, accessMode, fn)
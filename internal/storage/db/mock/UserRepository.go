@@ -43,6 +43,62 @@ func (_m *UserRepository) CreateUser(ctx context.Context, user *models.User) (in
 	return r0, r1
 }
 
+// CreditBalance provides a mock function with given fields: ctx, userID, amount
+func (_m *UserRepository) CreditBalance(ctx context.Context, userID int, amount int) (int, error) {
+	ret := _m.Called(ctx, userID, amount)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreditBalance")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (int, error)); ok {
+		return rf(ctx, userID, amount)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) int); ok {
+		r0 = rf(ctx, userID, amount)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userID, amount)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DebitBalance provides a mock function with given fields: ctx, userID, amount
+func (_m *UserRepository) DebitBalance(ctx context.Context, userID int, amount int) (int, error) {
+	ret := _m.Called(ctx, userID, amount)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DebitBalance")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (int, error)); ok {
+		return rf(ctx, userID, amount)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) int); ok {
+		r0 = rf(ctx, userID, amount)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, userID, amount)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAllUsers provides a mock function with given fields: ctx
 func (_m *UserRepository) GetAllUsers(ctx context.Context) ([]*models.User, error) {
 	ret := _m.Called(ctx)
@@ -189,22 +245,41 @@ func (_m *UserRepository) GetUserByUsername(ctx context.Context, username string
 	return r0, r1
 }
 
-// UpdateBalance provides a mock function with given fields: ctx, userID, newBalance
-func (_m *UserRepository) UpdateBalance(ctx context.Context, userID int, newBalance int) error {
-	ret := _m.Called(ctx, userID, newBalance)
+// LockBalances provides a mock function with given fields: ctx, userIDs
+func (_m *UserRepository) LockBalances(ctx context.Context, userIDs ...int) (map[int]int, error) {
+	_va := make([]interface{}, len(userIDs))
+	for _i := range userIDs {
+		_va[_i] = userIDs[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateBalance")
+		panic("no return value specified for LockBalances")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) error); ok {
-		r0 = rf(ctx, userID, newBalance)
+	var r0 map[int]int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ...int) (map[int]int, error)); ok {
+		return rf(ctx, userIDs...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ...int) map[int]int); ok {
+		r0 = rf(ctx, userIDs...)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int]int)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, ...int) error); ok {
+		r1 = rf(ctx, userIDs...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // UpdateUser provides a mock function with given fields: ctx, user
@@ -225,6 +300,24 @@ func (_m *UserRepository) UpdateUser(ctx context.Context, user *models.User) err
 	return r0
 }
 
+// UpdateUserRole provides a mock function with given fields: ctx, userID, role
+func (_m *UserRepository) UpdateUserRole(ctx context.Context, userID int, role string) error {
+	ret := _m.Called(ctx, userID, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, userID, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewUserRepository creates a new instance of UserRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUserRepository(t interface {
@@ -0,0 +1,208 @@
+package postgres
+
+import (
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/tracing"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type postgresCartRepository struct {
+	conn   db.TxManager
+	logger logger.Logger
+}
+
+func NewCartRepository(conn db.TxManager, log logger.Logger) db.CartRepository {
+	return &postgresCartRepository{conn: conn, logger: log}
+}
+
+// AddCartItem добавляет товар в корзину; если товар уже там, количество суммируется. Возвращает итоговое количество.
+// Если сумма превысила бы maxQuantity, позиция не меняется и возвращается ошибка, оборачивающая pgx.ErrNoRows
+func (r *postgresCartRepository) AddCartItem(ctx context.Context, userID int, merchID int, quantity int, maxQuantity int) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "CartRepository.AddCartItem")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("AddCartItem", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		INSERT INTO cart_items (user_id, merch_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, merch_id)
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity
+		WHERE cart_items.quantity + EXCLUDED.quantity <= $4
+		RETURNING quantity
+	`
+
+	var total int
+	err := pool.QueryRow(ctx, query, userID, merchID, quantity, maxQuantity).Scan(&total)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.WithContext(ctx, r.logger).Warnw("Cart item quantity limit exceeded",
+				"userID", userID,
+				"merchID", merchID,
+				"quantity", quantity,
+				"maxQuantity", maxQuantity,
+			)
+			return 0, fmt.Errorf("cart item quantity limit exceeded: %w", err)
+		}
+		logger.WithContext(ctx, r.logger).Errorw("Error adding cart item",
+			"error", err,
+			"userID", userID,
+			"merchID", merchID,
+		)
+		metrics.RecordDBError("AddCartItem")
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("failed to add cart item: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetCartItems возвращает позиции корзины вместе с актуальными данными товаров
+func (r *postgresCartRepository) GetCartItems(ctx context.Context, userID int) ([]*models.CartItem, error) {
+	ctx, span := tracing.StartSpan(ctx, "CartRepository.GetCartItems")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("GetCartItems", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		SELECT c.user_id, c.merch_id, c.quantity, c.added_at,
+		       m.name, m.price, m.stock, m.archived_at
+		FROM cart_items c
+		JOIN merch m ON m.id = c.merch_id
+		WHERE c.user_id = $1
+		ORDER BY c.added_at, c.merch_id
+	`
+
+	rows, err := pool.Query(ctx, query, userID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving cart items",
+			"error", err,
+			"userID", userID,
+		)
+		metrics.RecordDBError("GetCartItems")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to retrieve cart items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*models.CartItem
+	for rows.Next() {
+		item := models.CartItem{Merch: &models.Merch{}}
+		err := rows.Scan(
+			&item.UserID,
+			&item.MerchID,
+			&item.Quantity,
+			&item.AddedAt,
+			&item.Merch.Name,
+			&item.Merch.Price,
+			&item.Merch.Stock,
+			&item.Merch.ArchivedAt,
+		)
+		if err != nil {
+			logger.WithContext(ctx, r.logger).Errorw("Error scanning cart item",
+				"error", err,
+			)
+			metrics.RecordDBError("GetCartItems")
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("error reading cart item: %w", err)
+		}
+		item.Merch.ID = item.MerchID
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error processing query result",
+			"error", err,
+		)
+		metrics.RecordDBError("GetCartItems")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("error processing query result: %w", err)
+	}
+
+	return items, nil
+}
+
+func (r *postgresCartRepository) RemoveCartItem(ctx context.Context, userID int, merchID int) error {
+	ctx, span := tracing.StartSpan(ctx, "CartRepository.RemoveCartItem")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("RemoveCartItem", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		DELETE FROM cart_items
+		WHERE user_id = $1 AND merch_id = $2
+	`
+
+	result, err := pool.Exec(ctx, query, userID, merchID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error removing cart item",
+			"error", err,
+			"userID", userID,
+			"merchID", merchID,
+		)
+		metrics.RecordDBError("RemoveCartItem")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to remove cart item: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		logger.WithContext(ctx, r.logger).Warnw("Cart item not found",
+			"userID", userID,
+			"merchID", merchID,
+		)
+		return fmt.Errorf("cart item with merch ID %d not found: %w", merchID, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *postgresCartRepository) ClearCart(ctx context.Context, userID int) error {
+	ctx, span := tracing.StartSpan(ctx, "CartRepository.ClearCart")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("ClearCart", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		DELETE FROM cart_items
+		WHERE user_id = $1
+	`
+
+	if _, err := pool.Exec(ctx, query, userID); err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error clearing cart",
+			"error", err,
+			"userID", userID,
+		)
+		metrics.RecordDBError("ClearCart")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+
+	return nil
+}
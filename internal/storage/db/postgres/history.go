@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"avito-tech-merch/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queryBuilder собирает условия WHERE и параметры запроса с необязательными фильтрами
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// param добавляет параметр запроса и возвращает его плейсхолдер
+func (q *queryBuilder) param(value any) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *queryBuilder) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// period ограничивает записи полуинтервалом [from, to); nil-границы не добавляют условий
+func (q *queryBuilder) period(column string, from, to *time.Time) {
+	if from != nil {
+		q.where(fmt.Sprintf("%s >= %s", column, q.param(*from)))
+	}
+	if to != nil {
+		q.where(fmt.Sprintf("%s < %s", column, q.param(*to)))
+	}
+}
+
+// after оставляет записи строго старше курсора в порядке (created_at DESC, id DESC)
+func (q *queryBuilder) after(createdAtColumn, idColumn string, cursor *models.HistoryCursor) {
+	if cursor == nil {
+		return
+	}
+	q.where(fmt.Sprintf("(%s, %s) < (%s, %s)", createdAtColumn, idColumn, q.param(cursor.CreatedAt), q.param(cursor.ID)))
+}
+
+func (q *queryBuilder) whereClause() string {
+	return strings.Join(q.conditions, " AND ")
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/tracing"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"fmt"
+	"time"
+)
+
+type postgresIdempotencyRepository struct {
+	conn   db.TxManager
+	logger logger.Logger
+}
+
+func NewIdempotencyRepository(conn db.TxManager, log logger.Logger) db.IdempotencyRepository {
+	return &postgresIdempotencyRepository{conn: conn, logger: log}
+}
+
+func (r *postgresIdempotencyRepository) GetIdempotencyKey(ctx context.Context, userID int, key string) (*models.IdempotencyKey, error) {
+	ctx, span := tracing.StartSpan(ctx, "IdempotencyRepository.GetIdempotencyKey")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("GetIdempotencyKey", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		SELECT user_id, key, operation, request_hash, response, created_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2
+	`
+
+	var idempotencyKey models.IdempotencyKey
+	err := pool.QueryRow(ctx, query, userID, key).Scan(
+		&idempotencyKey.UserID,
+		&idempotencyKey.Key,
+		&idempotencyKey.Operation,
+		&idempotencyKey.RequestHash,
+		&idempotencyKey.Response,
+		&idempotencyKey.CreatedAt,
+	)
+	if err != nil {
+		// Отсутствие ключа - обычная ситуация для первого запроса, поэтому ошибка не логируется
+		return nil, fmt.Errorf("failed to retrieve idempotency key: %w", err)
+	}
+
+	return &idempotencyKey, nil
+}
+
+func (r *postgresIdempotencyRepository) CreateIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	ctx, span := tracing.StartSpan(ctx, "IdempotencyRepository.CreateIdempotencyKey")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("CreateIdempotencyKey", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, operation, request_hash, response)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`
+
+	err := pool.QueryRow(ctx, query, key.UserID, key.Key, key.Operation, key.RequestHash, key.Response).Scan(&key.CreatedAt)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error creating idempotency key",
+			"error", err,
+			"userID", key.UserID,
+			"operation", key.Operation,
+		)
+		metrics.RecordDBError("CreateIdempotencyKey")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to create idempotency key: %w", err)
+	}
+
+	return nil
+}
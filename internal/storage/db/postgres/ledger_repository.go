@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/tracing"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"fmt"
+	"time"
+)
+
+type postgresLedgerRepository struct {
+	conn   db.TxManager
+	logger logger.Logger
+}
+
+func NewLedgerRepository(conn db.TxManager, log logger.Logger) db.LedgerRepository {
+	return &postgresLedgerRepository{conn: conn, logger: log}
+}
+
+// CreateLedgerEntry записывает проводку с движениями. users.balance - проекция журнала,
+// поэтому вызывать метод нужно в той же транзакции, что и изменение балансов
+func (r *postgresLedgerRepository) CreateLedgerEntry(ctx context.Context, entry *models.LedgerEntry) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "LedgerRepository.CreateLedgerEntry")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("CreateLedgerEntry", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	entryQuery := `
+		INSERT INTO ledger_entries (reason, reference_id, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	err := pool.QueryRow(ctx, entryQuery, entry.Reason, entry.ReferenceID, entry.CreatedAt).Scan(&entry.ID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error creating ledger entry",
+			"error", err,
+			"reason", entry.Reason,
+			"referenceID", entry.ReferenceID,
+		)
+		metrics.RecordDBError("CreateLedgerEntry")
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("failed to create ledger entry: %w", err)
+	}
+
+	postingQuery := `
+		INSERT INTO ledger_postings (entry_id, account, user_id, delta)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	for _, posting := range entry.Postings {
+		posting.EntryID = entry.ID
+		err = pool.QueryRow(ctx, postingQuery, entry.ID, posting.Account, posting.UserID, posting.Delta).Scan(&posting.ID)
+		if err != nil {
+			logger.WithContext(ctx, r.logger).Errorw("Error creating ledger posting",
+				"error", err,
+				"entryID", entry.ID,
+				"account", posting.Account,
+			)
+			metrics.RecordDBError("CreateLedgerEntry")
+			tracing.RecordError(span, err)
+			return 0, fmt.Errorf("failed to create ledger posting: %w", err)
+		}
+	}
+
+	return entry.ID, nil
+}
+
+func (r *postgresLedgerRepository) VerifyLedger(ctx context.Context) (*models.LedgerReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "LedgerRepository.VerifyLedger")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("VerifyLedger", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	totalsQuery := `
+		SELECT
+			COALESCE(SUM(delta), 0),
+			COALESCE(-SUM(delta) FILTER (WHERE account = $1), 0),
+			(SELECT COUNT(*) FROM (
+				SELECT entry_id FROM ledger_postings GROUP BY entry_id HAVING SUM(delta) <> 0
+			) AS unbalanced)
+		FROM ledger_postings
+	`
+
+	report := &models.LedgerReport{Mismatches: []*models.BalanceMismatch{}}
+	err := pool.QueryRow(ctx, totalsQuery, models.AccountIssuance).Scan(&report.Total, &report.Issued, &report.UnbalancedEntries)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving ledger totals",
+			"error", err,
+		)
+		metrics.RecordDBError("VerifyLedger")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to retrieve ledger totals: %w", err)
+	}
+
+	mismatchQuery := `
+		SELECT u.id, u.balance, COALESCE(p.total, 0)
+		FROM users u
+		LEFT JOIN (
+			SELECT user_id, SUM(delta) AS total
+			FROM ledger_postings
+			WHERE user_id IS NOT NULL
+			GROUP BY user_id
+		) p ON p.user_id = u.id
+		WHERE u.balance <> COALESCE(p.total, 0)
+		ORDER BY u.id
+	`
+
+	rows, err := pool.Query(ctx, mismatchQuery)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving balance mismatches",
+			"error", err,
+		)
+		metrics.RecordDBError("VerifyLedger")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to retrieve balance mismatches: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mismatch models.BalanceMismatch
+		if err = rows.Scan(&mismatch.UserID, &mismatch.Balance, &mismatch.LedgerBalance); err != nil {
+			logger.WithContext(ctx, r.logger).Errorw("Error scanning balance mismatch",
+				"error", err,
+			)
+			metrics.RecordDBError("VerifyLedger")
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("failed to scan balance mismatch: %w", err)
+		}
+		report.Mismatches = append(report.Mismatches, &mismatch)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error iterating over balance mismatches",
+			"error", err,
+		)
+		metrics.RecordDBError("VerifyLedger")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to iterate over balance mismatches: %w", err)
+	}
+
+	return report, nil
+}
@@ -4,9 +4,12 @@ import (
 	"avito-tech-merch/internal/metrics"
 	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/tracing"
 	"avito-tech-merch/pkg/logger"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"time"
 )
 
@@ -19,25 +22,32 @@ func NewMerchRepository(conn db.TxManager, log logger.Logger) db.MerchRepository
 	return &postgresMerchRepository{conn: conn, logger: log}
 }
 
-func (r *postgresMerchRepository) GetAllMerch(ctx context.Context) ([]*models.Merch, error) {
+// GetAllMerch возвращает каталог; архивные товары попадают в выборку только при includeArchived
+func (r *postgresMerchRepository) GetAllMerch(ctx context.Context, includeArchived bool) ([]*models.Merch, error) {
+	ctx, span := tracing.StartSpan(ctx, "MerchRepository.GetAllMerch")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
 		metrics.RecordDBQueryDuration("GetAllMerch", time.Since(start).Seconds())
 	}()
 
-	pool := r.conn.GetExecutor(ctx)
+	pool := r.conn.GetReadExecutor(ctx)
 
 	query := `
-		SELECT id, name, price 
+		SELECT id, name, price, stock, archived_at
 		FROM merch
+		WHERE $1 OR archived_at IS NULL
+		ORDER BY id
 	`
 
-	rows, err := pool.Query(ctx, query)
+	rows, err := pool.Query(ctx, query, includeArchived)
 	if err != nil {
-		r.logger.Errorw("Error retrieving merch list",
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving merch list",
 			"error", err,
 		)
 		metrics.RecordDBError("GetAllMerch")
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("failed to retrieve merch list: %w", err)
 	}
 	defer rows.Close()
@@ -49,22 +59,26 @@ func (r *postgresMerchRepository) GetAllMerch(ctx context.Context) ([]*models.Me
 			&merch.ID,
 			&merch.Name,
 			&merch.Price,
+			&merch.Stock,
+			&merch.ArchivedAt,
 		)
 		if err != nil {
-			r.logger.Errorw("Error scanning merch data",
+			logger.WithContext(ctx, r.logger).Errorw("Error scanning merch data",
 				"error", err,
 			)
 			metrics.RecordDBError("GetAllMerch")
+			tracing.RecordError(span, err)
 			return nil, fmt.Errorf("error reading merch data: %w", err)
 		}
 		merchList = append(merchList, &merch)
 	}
 
 	if err := rows.Err(); err != nil {
-		r.logger.Errorw("Error processing query result",
+		logger.WithContext(ctx, r.logger).Errorw("Error processing query result",
 			"error", err,
 		)
 		metrics.RecordDBError("GetAllMerch")
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("error processing query result: %w", err)
 	}
 
@@ -72,6 +86,9 @@ func (r *postgresMerchRepository) GetAllMerch(ctx context.Context) ([]*models.Me
 }
 
 func (r *postgresMerchRepository) GetMerchByID(ctx context.Context, id int) (*models.Merch, error) {
+	ctx, span := tracing.StartSpan(ctx, "MerchRepository.GetMerchByID")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
 		metrics.RecordDBQueryDuration("GetMerchByID", time.Since(start).Seconds())
@@ -80,19 +97,20 @@ func (r *postgresMerchRepository) GetMerchByID(ctx context.Context, id int) (*mo
 	pool := r.conn.GetExecutor(ctx)
 
 	query := `
-		SELECT id, name, price 
+		SELECT id, name, price, stock, archived_at
 		FROM merch
 		WHERE id = $1
 	`
 
 	var merch models.Merch
-	err := pool.QueryRow(ctx, query, id).Scan(&merch.ID, &merch.Name, &merch.Price)
+	err := pool.QueryRow(ctx, query, id).Scan(&merch.ID, &merch.Name, &merch.Price, &merch.Stock, &merch.ArchivedAt)
 	if err != nil {
-		r.logger.Errorw("Error retrieving merch by ID",
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving merch by ID",
 			"error", err,
 			"merchID", id,
 		)
 		metrics.RecordDBError("GetMerchByID")
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("failed to retrieve merch: %w", err)
 	}
 
@@ -100,6 +118,9 @@ func (r *postgresMerchRepository) GetMerchByID(ctx context.Context, id int) (*mo
 }
 
 func (r *postgresMerchRepository) GetMerchByName(ctx context.Context, merchName string) (*models.Merch, error) {
+	ctx, span := tracing.StartSpan(ctx, "MerchRepository.GetMerchByName")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
 		metrics.RecordDBQueryDuration("GetMerchByName", time.Since(start).Seconds())
@@ -108,7 +129,7 @@ func (r *postgresMerchRepository) GetMerchByName(ctx context.Context, merchName
 	pool := r.conn.GetExecutor(ctx)
 
 	query := `
-        SELECT id, name, price
+        SELECT id, name, price, stock, archived_at
         FROM merch
         WHERE name = $1
     `
@@ -118,15 +139,351 @@ func (r *postgresMerchRepository) GetMerchByName(ctx context.Context, merchName
 		&merch.ID,
 		&merch.Name,
 		&merch.Price,
+		&merch.Stock,
+		&merch.ArchivedAt,
 	)
 	if err != nil {
-		r.logger.Errorw("Error retrieving merchandise by name",
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving merchandise by name",
 			"error", err,
 			"merchName", merchName,
 		)
 		metrics.RecordDBError("GetMerchByName")
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("failed to retrieve merchandise: %w", err)
 	}
 
 	return &merch, nil
 }
+
+func (r *postgresMerchRepository) CreateMerch(ctx context.Context, merch *models.Merch) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "MerchRepository.CreateMerch")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("CreateMerch", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		INSERT INTO merch (name, price, stock)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	var merchID int
+	err := pool.QueryRow(ctx, query, merch.Name, merch.Price, merch.Stock).Scan(&merchID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error creating merch",
+			"error", err,
+			"merchName", merch.Name,
+		)
+		metrics.RecordDBError("CreateMerch")
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("failed to create merch: %w", err)
+	}
+
+	merch.ID = merchID
+
+	return merchID, nil
+}
+
+func (r *postgresMerchRepository) UpdateMerch(ctx context.Context, merch *models.Merch) error {
+	ctx, span := tracing.StartSpan(ctx, "MerchRepository.UpdateMerch")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("UpdateMerch", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE merch
+		SET name = $1, price = $2
+		WHERE id = $3
+	`
+
+	result, err := pool.Exec(ctx, query, merch.Name, merch.Price, merch.ID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error updating merch",
+			"error", err,
+			"merchID", merch.ID,
+		)
+		metrics.RecordDBError("UpdateMerch")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to update merch: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		logger.WithContext(ctx, r.logger).Warnw("Merch not found",
+			"merchID", merch.ID,
+		)
+		return fmt.Errorf("merch with ID %d not found", merch.ID)
+	}
+
+	return nil
+}
+
+func (r *postgresMerchRepository) ArchiveMerch(ctx context.Context, id int) error {
+	ctx, span := tracing.StartSpan(ctx, "MerchRepository.ArchiveMerch")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("ArchiveMerch", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE merch
+		SET archived_at = now()
+		WHERE id = $1 AND archived_at IS NULL
+	`
+
+	result, err := pool.Exec(ctx, query, id)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error archiving merch",
+			"error", err,
+			"merchID", id,
+		)
+		metrics.RecordDBError("ArchiveMerch")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to archive merch: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		logger.WithContext(ctx, r.logger).Warnw("Merch not found or already archived",
+			"merchID", id,
+		)
+		return fmt.Errorf("merch with ID %d not found or already archived", id)
+	}
+
+	return nil
+}
+
+// DecrementMerchStock списывает quantity единиц товара, если он ещё продаётся по цене price, и возвращает
+// новый остаток (nil - остаток не ведётся). Цена, архивация и остаток проверяются в самом UPDATE, поэтому
+// решение принимается по текущей строке, а не по прочитанному ранее товару; строка товара остаётся
+// заблокированной до конца транзакции. Если товар снят с продажи, цена изменилась или остатка не хватает,
+// возвращается ошибка, оборачивающая pgx.ErrNoRows
+func (r *postgresMerchRepository) DecrementMerchStock(ctx context.Context, merchID int, price int, quantity int) (*int, error) {
+	ctx, span := tracing.StartSpan(ctx, "MerchRepository.DecrementMerchStock")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("DecrementMerchStock", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE merch
+		SET stock = stock - $3
+		WHERE id = $1 AND price = $2 AND archived_at IS NULL AND (stock IS NULL OR stock >= $3)
+		RETURNING stock
+	`
+
+	var stock *int
+	err := pool.QueryRow(ctx, query, merchID, price, quantity).Scan(&stock)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.WithContext(ctx, r.logger).Warnw("Merch is not available at this price and quantity",
+				"merchID", merchID,
+				"price", price,
+				"quantity", quantity,
+			)
+			return nil, fmt.Errorf("merch is not available at this price and quantity: %w", err)
+		}
+		logger.WithContext(ctx, r.logger).Errorw("Error decrementing merch stock",
+			"error", err,
+			"merchID", merchID,
+		)
+		metrics.RecordDBError("DecrementMerchStock")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to decrement merch stock: %w", err)
+	}
+
+	return stock, nil
+}
+
+// RestockMerch добавляет quantity единиц к остатку. Если товар снят с продажи или его остаток не ведётся,
+// остаток не меняется и возвращается ошибка, оборачивающая pgx.ErrNoRows
+func (r *postgresMerchRepository) RestockMerch(ctx context.Context, merchID int, quantity int) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "MerchRepository.RestockMerch")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("RestockMerch", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE merch
+		SET stock = stock + $2
+		WHERE id = $1 AND archived_at IS NULL AND stock IS NOT NULL
+		RETURNING stock
+	`
+
+	var stock int
+	err := pool.QueryRow(ctx, query, merchID, quantity).Scan(&stock)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.WithContext(ctx, r.logger).Warnw("Merch not found, archived or without stock limit",
+				"merchID", merchID,
+			)
+			return 0, fmt.Errorf("merch with ID %d not found, archived or without stock limit: %w", merchID, err)
+		}
+		logger.WithContext(ctx, r.logger).Errorw("Error restocking merch",
+			"error", err,
+			"merchID", merchID,
+			"quantity", quantity,
+		)
+		metrics.RecordDBError("RestockMerch")
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("failed to restock merch: %w", err)
+	}
+
+	return stock, nil
+}
+
+// SetMerchStock задаёт остаток напрямую; nil снимает ограничение по количеству. Если товара нет
+// или он снят с продажи, возвращается ошибка, оборачивающая pgx.ErrNoRows
+func (r *postgresMerchRepository) SetMerchStock(ctx context.Context, merchID int, stock *int) error {
+	ctx, span := tracing.StartSpan(ctx, "MerchRepository.SetMerchStock")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("SetMerchStock", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE merch
+		SET stock = $2
+		WHERE id = $1 AND archived_at IS NULL
+	`
+
+	result, err := pool.Exec(ctx, query, merchID, stock)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error setting merch stock",
+			"error", err,
+			"merchID", merchID,
+		)
+		metrics.RecordDBError("SetMerchStock")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to set merch stock: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		logger.WithContext(ctx, r.logger).Warnw("Merch not found or archived",
+			"merchID", merchID,
+		)
+		return fmt.Errorf("merch with ID %d not found or archived: %w", merchID, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *postgresMerchRepository) CreateMerchPrice(ctx context.Context, price *models.MerchPrice) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "MerchRepository.CreateMerchPrice")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("CreateMerchPrice", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		INSERT INTO merch_price_history (merch_id, price, changed_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, valid_from
+	`
+
+	err := pool.QueryRow(ctx, query, price.MerchID, price.Price, price.ChangedBy).Scan(&price.ID, &price.ValidFrom)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error creating merch price",
+			"error", err,
+			"merchID", price.MerchID,
+		)
+		metrics.RecordDBError("CreateMerchPrice")
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("failed to create merch price: %w", err)
+	}
+
+	return price.ID, nil
+}
+
+// GetMerchPriceHistory возвращает историю цен товара от самой ранней к текущей
+func (r *postgresMerchRepository) GetMerchPriceHistory(ctx context.Context, merchID int) ([]*models.MerchPrice, error) {
+	ctx, span := tracing.StartSpan(ctx, "MerchRepository.GetMerchPriceHistory")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("GetMerchPriceHistory", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		SELECT id, merch_id, price, changed_by, valid_from
+		FROM merch_price_history
+		WHERE merch_id = $1
+		ORDER BY valid_from, id
+	`
+
+	rows, err := pool.Query(ctx, query, merchID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving merch price history",
+			"error", err,
+			"merchID", merchID,
+		)
+		metrics.RecordDBError("GetMerchPriceHistory")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to retrieve merch price history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*models.MerchPrice
+	for rows.Next() {
+		var price models.MerchPrice
+		err := rows.Scan(
+			&price.ID,
+			&price.MerchID,
+			&price.Price,
+			&price.ChangedBy,
+			&price.ValidFrom,
+		)
+		if err != nil {
+			logger.WithContext(ctx, r.logger).Errorw("Error scanning merch price",
+				"error", err,
+			)
+			metrics.RecordDBError("GetMerchPriceHistory")
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("error reading merch price: %w", err)
+		}
+		history = append(history, &price)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error processing query result",
+			"error", err,
+		)
+		metrics.RecordDBError("GetMerchPriceHistory")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("error processing query result: %w", err)
+	}
+
+	return history, nil
+}
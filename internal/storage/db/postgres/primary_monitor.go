@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sync"
+	"time"
+)
+
+// defaultPrimaryCheckPeriod - период проверки основной базы, если он не задан в конфигурации
+const defaultPrimaryCheckPeriod = 5 * time.Second
+
+// errPrimaryInRecovery - сервер, к которому подключён пул основной базы, стал репликой
+var errPrimaryInRecovery = errors.New("server is in recovery")
+
+// PrimaryMonitor следит за пулом основной базы. Новые соединения пула сами выбирают сервер,
+// который принимает запись, а монитор замечает недоступность и смену сервера, пишет их в лог
+// и метрики и сбрасывает пул, если его соединения ведут на бывшую основную базу
+type PrimaryMonitor struct {
+	pool   *pgxpool.Pool
+	logger logger.Logger
+
+	mu      sync.Mutex
+	checked bool
+	up      bool
+	address string
+}
+
+func NewPrimaryMonitor(pool *pgxpool.Pool, log logger.Logger) *PrimaryMonitor {
+	return &PrimaryMonitor{
+		pool:   pool,
+		logger: log,
+	}
+}
+
+// Run проверяет основную базу сразу и затем каждые period, пока не отменён ctx
+func (m *PrimaryMonitor) Run(ctx context.Context, period time.Duration) {
+	if period <= 0 {
+		period = defaultPrimaryCheckPeriod
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		m.check(ctx, period)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			m.logger.Infow("Stopping PostgreSQL primary health checks")
+			return
+		}
+	}
+}
+
+func (m *PrimaryMonitor) check(ctx context.Context, timeout time.Duration) {
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	address, err := m.query(checkCtx)
+	if ctx.Err() != nil {
+		// Проверка прервана остановкой сервиса
+		return
+	}
+	m.setStatus(address, err)
+}
+
+// query возвращает адрес сервера, к которому ведёт соединение пула, и ошибку, если он недоступен или стал репликой
+func (m *PrimaryMonitor) query(ctx context.Context) (string, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Release()
+
+	address := conn.Conn().PgConn().Conn().RemoteAddr().String()
+
+	var inRecovery bool
+	if err := conn.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return address, err
+	}
+	if inRecovery {
+		return address, errPrimaryInRecovery
+	}
+	return address, nil
+}
+
+// setStatus применяет результат проверки: пишет в лог первую проверку, потерю и восстановление
+// основной базы и смену сервера, а соединения с бывшей основной базой закрывает
+func (m *PrimaryMonitor) setStatus(address string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	first := !m.checked
+	m.checked = true
+	metrics.RecordDBPrimaryUp(err == nil)
+
+	if errors.Is(err, errPrimaryInRecovery) {
+		m.logger.Warnw("PostgreSQL primary became a replica, reconnecting",
+			"address", address,
+		)
+		m.up = false
+		m.pool.Reset()
+		return
+	}
+	if err != nil {
+		if m.up || first {
+			m.logger.Errorw("PostgreSQL primary is unavailable",
+				"address", m.address,
+				"error", err,
+			)
+		}
+		m.up = false
+		return
+	}
+
+	switch {
+	case m.address == "":
+		m.logger.Infow("Connected to PostgreSQL primary",
+			"address", address,
+		)
+	case address != m.address:
+		m.logger.Warnw("PostgreSQL primary changed",
+			"from", m.address,
+			"to", address,
+		)
+		metrics.RecordDBPrimaryFailover()
+	case !m.up:
+		m.logger.Infow("PostgreSQL primary connection restored",
+			"address", address,
+		)
+	}
+	m.up = true
+	m.address = address
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"avito-tech-merch/internal/metrics"
+	mockLog "avito-tech-merch/pkg/logger/mock"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var errConnectionRefused = errors.New("connection refused")
+
+func newTestPrimaryMonitor(t *testing.T, log *mockLog.Logger) *PrimaryMonitor {
+	pool, err := pgxpool.New(context.Background(), "host=primary,standby port=5432,5432 user=test dbname=test target_session_attrs=read-write")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return NewPrimaryMonitor(pool, log)
+}
+
+func TestPrimaryMonitor_Failover(t *testing.T) {
+	log := mockLog.NewLogger(t)
+	monitor := newTestPrimaryMonitor(t, log)
+	failovers := testutil.ToFloat64(metrics.DBPrimaryFailoversTotal)
+
+	log.On("Infow", "Connected to PostgreSQL primary", "address", "10.0.0.1:5432").Once()
+	log.On("Errorw", "PostgreSQL primary is unavailable", "address", "10.0.0.1:5432", "error", errConnectionRefused).Once()
+	log.On("Warnw", "PostgreSQL primary changed", "from", "10.0.0.1:5432", "to", "10.0.0.2:5432").Once()
+
+	monitor.setStatus("10.0.0.1:5432", nil)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DBPrimaryUp))
+
+	monitor.setStatus("", errConnectionRefused)
+	// Повторная ошибка не пишет в лог
+	monitor.setStatus("", errConnectionRefused)
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.DBPrimaryUp))
+
+	monitor.setStatus("10.0.0.2:5432", nil)
+	monitor.setStatus("10.0.0.2:5432", nil)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DBPrimaryUp))
+	assert.Equal(t, failovers+1, testutil.ToFloat64(metrics.DBPrimaryFailoversTotal))
+}
+
+func TestPrimaryMonitor_Restored(t *testing.T) {
+	log := mockLog.NewLogger(t)
+	monitor := newTestPrimaryMonitor(t, log)
+	failovers := testutil.ToFloat64(metrics.DBPrimaryFailoversTotal)
+
+	log.On("Errorw", "PostgreSQL primary is unavailable", "address", "", "error", errConnectionRefused).Once()
+	log.On("Infow", "Connected to PostgreSQL primary", "address", "10.0.0.1:5432").Once()
+	log.On("Warnw", "PostgreSQL primary became a replica, reconnecting", "address", "10.0.0.1:5432").Once()
+	log.On("Infow", "PostgreSQL primary connection restored", "address", "10.0.0.1:5432").Once()
+
+	monitor.setStatus("", errConnectionRefused)
+	monitor.setStatus("10.0.0.1:5432", nil)
+
+	monitor.setStatus("10.0.0.1:5432", errPrimaryInRecovery)
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.DBPrimaryUp))
+
+	// Тот же сервер снова принимает запись: это восстановление, а не failover
+	monitor.setStatus("10.0.0.1:5432", nil)
+	assert.Equal(t, failovers, testutil.ToFloat64(metrics.DBPrimaryFailoversTotal))
+}
@@ -4,9 +4,11 @@ import (
 	"avito-tech-merch/internal/metrics"
 	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/tracing"
 	"avito-tech-merch/pkg/logger"
 	"context"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"time"
 )
 
@@ -20,6 +22,9 @@ func NewPurchaseRepository(conn db.TxManager, log logger.Logger) db.PurchaseRepo
 }
 
 func (r *postgresPurchaseRepository) CreatePurchase(ctx context.Context, purchase *models.Purchase) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "PurchaseRepository.CreatePurchase")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
 		metrics.RecordDBQueryDuration("CreatePurchase", time.Since(start).Seconds())
@@ -28,27 +33,35 @@ func (r *postgresPurchaseRepository) CreatePurchase(ctx context.Context, purchas
 	pool := r.conn.GetExecutor(ctx)
 
 	query := `
-        INSERT INTO purchases (user_id, merch_id)
-        VALUES ($1, $2)
-        RETURNING id
+        INSERT INTO purchases (user_id, merch_id, quantity, unit_price, status)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
     `
 
 	var purchaseID int
-	err := pool.QueryRow(ctx, query, purchase.UserID, purchase.MerchID).Scan(&purchaseID)
+	err := pool.QueryRow(ctx, query, purchase.UserID, purchase.MerchID, purchase.Quantity, purchase.UnitPrice, purchase.Status).
+		Scan(&purchaseID, &purchase.CreatedAt)
 	if err != nil {
-		r.logger.Errorw("Error creating purchase",
+		logger.WithContext(ctx, r.logger).Errorw("Error creating purchase",
 			"error", err,
 			"userID", purchase.UserID,
 			"merchID", purchase.MerchID,
 		)
 		metrics.RecordDBError("CreatePurchase")
+		tracing.RecordError(span, err)
 		return 0, fmt.Errorf("failed to create purchase: %w", err)
 	}
 
+	purchase.ID = purchaseID
+
 	return purchaseID, nil
 }
 
-func (r *postgresPurchaseRepository) GetPurchaseByUserID(ctx context.Context, userID int) ([]*models.Purchase, error) {
+// GetPurchaseByUserID возвращает страницу покупок пользователя от новых к старым
+func (r *postgresPurchaseRepository) GetPurchaseByUserID(ctx context.Context, userID int, filter models.PurchaseFilter, page models.HistoryPage) ([]*models.Purchase, error) {
+	ctx, span := tracing.StartSpan(ctx, "PurchaseRepository.GetPurchaseByUserID")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
 		metrics.RecordDBQueryDuration("GetPurchaseByUserID", time.Since(start).Seconds())
@@ -56,49 +69,367 @@ func (r *postgresPurchaseRepository) GetPurchaseByUserID(ctx context.Context, us
 
 	pool := r.conn.GetExecutor(ctx)
 
-	query := `
-        SELECT id, user_id, merch_id, created_at
+	q := &queryBuilder{}
+	q.where("user_id = " + q.param(userID))
+	q.period("created_at", filter.From, filter.To)
+	q.after("created_at", "id", page.After)
+
+	query := fmt.Sprintf(`
+        SELECT id, user_id, merch_id, quantity, unit_price, created_at, status, refunded_at, refunded_by
         FROM purchases
-        WHERE user_id = $1
-    `
+        WHERE %s
+        ORDER BY created_at DESC, id DESC
+        LIMIT %s
+    `, q.whereClause(), q.param(page.Limit))
 
-	rows, err := pool.Query(ctx, query, userID)
+	rows, err := pool.Query(ctx, query, q.args...)
 	if err != nil {
-		r.logger.Errorw("Error retrieving purchase list",
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving purchase list",
 			"error", err,
 			"userID", userID,
 		)
 		metrics.RecordDBError("GetPurchaseByUserID")
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("failed to retrieve purchase list: %w", err)
 	}
 	defer rows.Close()
 
-	var purchases []*models.Purchase
+	purchases := []*models.Purchase{}
 	for rows.Next() {
 		var purchase models.Purchase
 		err := rows.Scan(
 			&purchase.ID,
 			&purchase.UserID,
 			&purchase.MerchID,
+			&purchase.Quantity,
+			&purchase.UnitPrice,
 			&purchase.CreatedAt,
+			&purchase.Status,
+			&purchase.RefundedAt,
+			&purchase.RefundedBy,
 		)
 		if err != nil {
-			r.logger.Errorw("Error scanning purchase data",
+			logger.WithContext(ctx, r.logger).Errorw("Error scanning purchase data",
 				"error", err,
 			)
 			metrics.RecordDBError("GetPurchaseByUserID")
+			tracing.RecordError(span, err)
 			return nil, fmt.Errorf("error reading purchase data: %w", err)
 		}
 		purchases = append(purchases, &purchase)
 	}
 
 	if err := rows.Err(); err != nil {
-		r.logger.Errorw("Error processing query result",
+		logger.WithContext(ctx, r.logger).Errorw("Error processing query result",
 			"error", err,
 		)
 		metrics.RecordDBError("GetPurchaseByUserID")
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("error processing query result: %w", err)
 	}
 
 	return purchases, nil
 }
+
+func (r *postgresPurchaseRepository) GetPurchaseByID(ctx context.Context, purchaseID int) (*models.Purchase, error) {
+	ctx, span := tracing.StartSpan(ctx, "PurchaseRepository.GetPurchaseByID")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("GetPurchaseByID", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		SELECT id, user_id, merch_id, quantity, unit_price, created_at, status, refunded_at, refunded_by
+		FROM purchases
+		WHERE id = $1
+	`
+
+	var purchase models.Purchase
+	err := pool.QueryRow(ctx, query, purchaseID).Scan(
+		&purchase.ID,
+		&purchase.UserID,
+		&purchase.MerchID,
+		&purchase.Quantity,
+		&purchase.UnitPrice,
+		&purchase.CreatedAt,
+		&purchase.Status,
+		&purchase.RefundedAt,
+		&purchase.RefundedBy,
+	)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving purchase by ID",
+			"error", err,
+			"purchaseID", purchaseID,
+		)
+		metrics.RecordDBError("GetPurchaseByID")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to retrieve purchase: %w", err)
+	}
+
+	return &purchase, nil
+}
+
+// MarkPurchaseRefunded помечает покупку возвращённой; повторный возврат не проходит
+func (r *postgresPurchaseRepository) MarkPurchaseRefunded(ctx context.Context, purchaseID int, refundedBy int) (time.Time, error) {
+	ctx, span := tracing.StartSpan(ctx, "PurchaseRepository.MarkPurchaseRefunded")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("MarkPurchaseRefunded", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE purchases
+		SET refunded_at = now(), refunded_by = $2
+		WHERE id = $1 AND refunded_at IS NULL
+		RETURNING refunded_at
+	`
+
+	var refundedAt time.Time
+	err := pool.QueryRow(ctx, query, purchaseID, refundedBy).Scan(&refundedAt)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error marking purchase refunded",
+			"error", err,
+			"purchaseID", purchaseID,
+		)
+		metrics.RecordDBError("MarkPurchaseRefunded")
+		tracing.RecordError(span, err)
+		return time.Time{}, fmt.Errorf("failed to mark purchase refunded: %w", err)
+	}
+
+	return refundedAt, nil
+}
+
+// UpdatePurchaseStatus переводит покупку в новый статус, только если она всё ещё в статусе from
+func (r *postgresPurchaseRepository) UpdatePurchaseStatus(ctx context.Context, purchaseID int, from, to string) error {
+	ctx, span := tracing.StartSpan(ctx, "PurchaseRepository.UpdatePurchaseStatus")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("UpdatePurchaseStatus", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE purchases
+		SET status = $3
+		WHERE id = $1 AND status = $2
+	`
+
+	result, err := pool.Exec(ctx, query, purchaseID, from, to)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error updating purchase status",
+			"error", err,
+			"purchaseID", purchaseID,
+			"status", to,
+		)
+		metrics.RecordDBError("UpdatePurchaseStatus")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to update purchase status: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		logger.WithContext(ctx, r.logger).Warnw("Purchase not found in expected status",
+			"purchaseID", purchaseID,
+			"status", from,
+		)
+		return fmt.Errorf("purchase %d is not in status %s: %w", purchaseID, from, pgx.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *postgresPurchaseRepository) CreatePurchaseStatusChange(ctx context.Context, change *models.PurchaseStatusChange) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "PurchaseRepository.CreatePurchaseStatusChange")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("CreatePurchaseStatusChange", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		INSERT INTO purchase_status_history (purchase_id, status, changed_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, changed_at
+	`
+
+	err := pool.QueryRow(ctx, query, change.PurchaseID, change.Status, change.ChangedBy).Scan(&change.ID, &change.ChangedAt)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error creating purchase status change",
+			"error", err,
+			"purchaseID", change.PurchaseID,
+		)
+		metrics.RecordDBError("CreatePurchaseStatusChange")
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("failed to create purchase status change: %w", err)
+	}
+
+	return change.ID, nil
+}
+
+// GetPurchaseStatusHistory возвращает историю статусов покупки от оформления к текущему
+func (r *postgresPurchaseRepository) GetPurchaseStatusHistory(ctx context.Context, purchaseID int) ([]*models.PurchaseStatusChange, error) {
+	ctx, span := tracing.StartSpan(ctx, "PurchaseRepository.GetPurchaseStatusHistory")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("GetPurchaseStatusHistory", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		SELECT id, purchase_id, status, changed_by, changed_at
+		FROM purchase_status_history
+		WHERE purchase_id = $1
+		ORDER BY changed_at, id
+	`
+
+	rows, err := pool.Query(ctx, query, purchaseID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving purchase status history",
+			"error", err,
+			"purchaseID", purchaseID,
+		)
+		metrics.RecordDBError("GetPurchaseStatusHistory")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to retrieve purchase status history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*models.PurchaseStatusChange
+	for rows.Next() {
+		var change models.PurchaseStatusChange
+		err := rows.Scan(
+			&change.ID,
+			&change.PurchaseID,
+			&change.Status,
+			&change.ChangedBy,
+			&change.ChangedAt,
+		)
+		if err != nil {
+			logger.WithContext(ctx, r.logger).Errorw("Error scanning purchase status change",
+				"error", err,
+			)
+			metrics.RecordDBError("GetPurchaseStatusHistory")
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("error reading purchase status change: %w", err)
+		}
+		history = append(history, &change)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error processing query result",
+			"error", err,
+		)
+		metrics.RecordDBError("GetPurchaseStatusHistory")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("error processing query result: %w", err)
+	}
+
+	return history, nil
+}
+
+// GetInventoryByUserID суммирует невозвращённые покупки пользователя по товарам
+func (r *postgresPurchaseRepository) GetInventoryByUserID(ctx context.Context, userID int) ([]*models.InventoryItem, error) {
+	ctx, span := tracing.StartSpan(ctx, "PurchaseRepository.GetInventoryByUserID")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("GetInventoryByUserID", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		SELECT m.name, SUM(p.quantity)
+		FROM purchases p
+		JOIN merch m ON m.id = p.merch_id
+		WHERE p.user_id = $1 AND p.refunded_at IS NULL
+		GROUP BY m.id, m.name
+		ORDER BY m.name
+	`
+
+	rows, err := pool.Query(ctx, query, userID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving inventory",
+			"error", err,
+			"userID", userID,
+		)
+		metrics.RecordDBError("GetInventoryByUserID")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to retrieve inventory: %w", err)
+	}
+	defer rows.Close()
+
+	var inventory []*models.InventoryItem
+	for rows.Next() {
+		var item models.InventoryItem
+		if err := rows.Scan(&item.MerchName, &item.Quantity); err != nil {
+			logger.WithContext(ctx, r.logger).Errorw("Error scanning inventory item",
+				"error", err,
+			)
+			metrics.RecordDBError("GetInventoryByUserID")
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("error reading inventory item: %w", err)
+		}
+		inventory = append(inventory, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error processing query result",
+			"error", err,
+		)
+		metrics.RecordDBError("GetInventoryByUserID")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("error processing query result: %w", err)
+	}
+
+	return inventory, nil
+}
+
+// GetSpentByUserID возвращает сумму, списанную за невозвращённые покупки, по ценам на момент покупки
+func (r *postgresPurchaseRepository) GetSpentByUserID(ctx context.Context, userID int) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "PurchaseRepository.GetSpentByUserID")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("GetSpentByUserID", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		SELECT COALESCE(SUM(unit_price * quantity), 0)
+		FROM purchases
+		WHERE user_id = $1 AND refunded_at IS NULL
+	`
+
+	var spent int
+	if err := pool.QueryRow(ctx, query, userID).Scan(&spent); err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error calculating spent coins",
+			"error", err,
+			"userID", userID,
+		)
+		metrics.RecordDBError("GetSpentByUserID")
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("failed to calculate spent coins: %w", err)
+	}
+
+	return spent, nil
+}
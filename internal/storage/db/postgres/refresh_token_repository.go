@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/tracing"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"fmt"
+	"time"
+)
+
+type postgresRefreshTokenRepository struct {
+	conn   db.TxManager
+	logger logger.Logger
+}
+
+func NewRefreshTokenRepository(conn db.TxManager, log logger.Logger) db.RefreshTokenRepository {
+	return &postgresRefreshTokenRepository{conn: conn, logger: log}
+}
+
+func (r *postgresRefreshTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "RefreshTokenRepository.CreateRefreshToken")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("CreateRefreshToken", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	// Пустой family_id означает новую цепочку токенов (новый вход)
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, COALESCE(NULLIF($2, '')::uuid, gen_random_uuid()), $3, $4)
+		RETURNING id, family_id::text, created_at
+	`
+
+	var tokenID int
+	err := pool.QueryRow(ctx, query, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt).
+		Scan(&tokenID, &token.FamilyID, &token.CreatedAt)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error creating refresh token",
+			"error", err,
+			"userID", token.UserID,
+		)
+		metrics.RecordDBError("CreateRefreshToken")
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	token.ID = tokenID
+
+	return tokenID, nil
+}
+
+func (r *postgresRefreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	ctx, span := tracing.StartSpan(ctx, "RefreshTokenRepository.GetRefreshTokenByHash")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("GetRefreshTokenByHash", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	// FOR UPDATE не даёт двум параллельным запросам обменять один и тот же токен
+	query := `
+		SELECT id, user_id, family_id::text, token_hash, expires_at, used_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	var token models.RefreshToken
+	err := pool.QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Warnw("Error retrieving refresh token",
+			"error", err,
+		)
+		metrics.RecordDBError("GetRefreshTokenByHash")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to retrieve refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *postgresRefreshTokenRepository) MarkRefreshTokenUsed(ctx context.Context, tokenID int) error {
+	ctx, span := tracing.StartSpan(ctx, "RefreshTokenRepository.MarkRefreshTokenUsed")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("MarkRefreshTokenUsed", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE refresh_tokens
+		SET used_at = now()
+		WHERE id = $1 AND used_at IS NULL
+	`
+
+	result, err := pool.Exec(ctx, query, tokenID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error marking refresh token as used",
+			"error", err,
+			"tokenID", tokenID,
+		)
+		metrics.RecordDBError("MarkRefreshTokenUsed")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to mark refresh token as used: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		logger.WithContext(ctx, r.logger).Warnw("Refresh token not found or already used",
+			"tokenID", tokenID,
+		)
+		return fmt.Errorf("refresh token with ID %d not found or already used", tokenID)
+	}
+
+	return nil
+}
+
+func (r *postgresRefreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ctx, span := tracing.StartSpan(ctx, "RefreshTokenRepository.RevokeRefreshTokenFamily")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("RevokeRefreshTokenFamily", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = now()
+		WHERE family_id = $1::uuid AND revoked_at IS NULL
+	`
+
+	_, err := pool.Exec(ctx, query, familyID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error revoking refresh token family",
+			"error", err,
+			"familyID", familyID,
+		)
+		metrics.RecordDBError("RevokeRefreshTokenFamily")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRefreshTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID int) error {
+	ctx, span := tracing.StartSpan(ctx, "RefreshTokenRepository.RevokeUserRefreshTokens")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("RevokeUserRefreshTokens", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := pool.Exec(ctx, query, userID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error revoking user refresh tokens",
+			"error", err,
+			"userID", userID,
+		)
+		metrics.RecordDBError("RevokeUserRefreshTokens")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to revoke user refresh tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// PrimaryPoolName - значение метки pool в метриках для основной базы
+	PrimaryPoolName = "primary"
+
+	// defaultReplicaCheckPeriod - период проверки реплик, если он не задан в конфигурации
+	defaultReplicaCheckPeriod = 5 * time.Second
+)
+
+// replicationLagQuery возвращает отставание реплики в секундах. Реплика, которая получает WAL
+// и проиграла весь полученный, не отстаёт, даже если последняя транзакция на основной базе была
+// давно. Если WAL receiver не передаёт WAL, совпадение LSN ничего не значит, и отставание
+// считается от времени последней проигранной транзакции; NULL - транзакций ещё не было.
+// Без роли pg_read_all_stats status в pg_stat_wal_receiver не виден, и тогда о работе
+// receiver говорит только наличие строки с его pid
+const replicationLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn()
+			AND EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming' OR status IS NULL) THEN 0
+		ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+	END::float8
+`
+
+// errReplicationLagUnknown - реплика не получает WAL и ещё не проиграла ни одной транзакции
+var errReplicationLagUnknown = errors.New("WAL receiver is not streaming and no transactions have been replayed")
+
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+	checked atomic.Bool
+}
+
+// ReplicaSet - реплики для чтения. Реплика получает чтения, пока последняя проверка прошла
+// успешно и отставание не больше maxLag. До первой проверки и когда подходящих реплик нет,
+// чтения идут в основную базу
+type ReplicaSet struct {
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint64
+	logger   logger.Logger
+}
+
+func NewReplicaSet(pools []*pgxpool.Pool, maxLag time.Duration, log logger.Logger) *ReplicaSet {
+	replicas := make([]*replica, 0, len(pools))
+	for _, pool := range pools {
+		connConfig := pool.Config().ConnConfig
+		replicas = append(replicas, &replica{
+			name: fmt.Sprintf("%s:%d", connConfig.Host, connConfig.Port),
+			pool: pool,
+		})
+	}
+
+	return &ReplicaSet{
+		replicas: replicas,
+		maxLag:   maxLag,
+		logger:   log,
+	}
+}
+
+// Run проверяет реплики сразу и затем каждые period, пока не отменён ctx
+func (s *ReplicaSet) Run(ctx context.Context, period time.Duration) {
+	if len(s.replicas) == 0 {
+		return
+	}
+	if period <= 0 {
+		period = defaultReplicaCheckPeriod
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		s.check(ctx, period)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			s.logger.Infow("Stopping replica health checks")
+			return
+		}
+	}
+}
+
+// RecordPoolConnections записывает в метрики соединения пулов реплик
+func (s *ReplicaSet) RecordPoolConnections() {
+	for _, r := range s.replicas {
+		stats := r.pool.Stat()
+		metrics.RecordDBPoolConnections(r.name, int(stats.TotalConns()), int(stats.IdleConns()), int(stats.AcquiredConns()))
+	}
+}
+
+func (s *ReplicaSet) Close() {
+	for _, r := range s.replicas {
+		r.pool.Close()
+	}
+}
+
+func (s *ReplicaSet) check(ctx context.Context, timeout time.Duration) {
+	for _, r := range s.replicas {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		var lagSeconds *float64
+		err := r.pool.QueryRow(checkCtx, replicationLagQuery).Scan(&lagSeconds)
+		cancel()
+
+		lag, err := replicationLag(lagSeconds, err)
+		s.setStatus(r, lag, err)
+	}
+}
+
+// replicationLag переводит результат replicationLagQuery в отставание
+func replicationLag(seconds *float64, err error) (time.Duration, error) {
+	if err != nil {
+		return 0, err
+	}
+	if seconds == nil {
+		return 0, errReplicationLagUnknown
+	}
+	return time.Duration(*seconds * float64(time.Second)), nil
+}
+
+// setStatus применяет результат проверки и пишет в лог результат первой проверки,
+// а затем - когда реплика выходит из ротации или возвращается
+func (s *ReplicaSet) setStatus(r *replica, lag time.Duration, err error) {
+	healthy := err == nil && lag <= s.maxLag
+	metrics.RecordDBReplicaHealthy(r.name, healthy)
+	if err == nil {
+		metrics.RecordDBReplicaLag(r.name, lag.Seconds())
+	}
+
+	first := !r.checked.Swap(true)
+	if r.healthy.Swap(healthy) == healthy && !first {
+		return
+	}
+	switch {
+	case healthy:
+		s.logger.Infow("Replica returned to reads",
+			"replica", r.name,
+			"lag", lag.String(),
+		)
+	case err != nil:
+		s.logger.Warnw("Replica excluded from reads",
+			"replica", r.name,
+			"error", err,
+		)
+	default:
+		s.logger.Warnw("Replica excluded from reads: replication lag is too high",
+			"replica", r.name,
+			"lag", lag.String(),
+			"max_lag", s.maxLag.String(),
+		)
+	}
+}
+
+// pick возвращает следующую по кругу реплику, которая получает чтения, или nil
+func (s *ReplicaSet) pick() *replica {
+	if s == nil || len(s.replicas) == 0 {
+		return nil
+	}
+
+	start := s.next.Add(1)
+	for i := range s.replicas {
+		r := s.replicas[(start+uint64(i))%uint64(len(s.replicas))]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	mockLog "avito-tech-merch/pkg/logger/mock"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// newTestReplicaSet создаёт реплики без соединений: pgxpool подключается только при первом запросе
+func newTestReplicaSet(t *testing.T, log *mockLog.Logger, hosts ...string) *ReplicaSet {
+	pools := make([]*pgxpool.Pool, 0, len(hosts))
+	for _, host := range hosts {
+		pool, err := pgxpool.New(context.Background(), "host="+host+" port=5432 user=test dbname=test")
+		require.NoError(t, err)
+		t.Cleanup(pool.Close)
+		pools = append(pools, pool)
+	}
+	return NewReplicaSet(pools, time.Second, log)
+}
+
+func TestReplicaSet_PickNoReplicas(t *testing.T) {
+	var nilSet *ReplicaSet
+	assert.Nil(t, nilSet.pick())
+
+	replicas := newTestReplicaSet(t, mockLog.NewLogger(t))
+	assert.Nil(t, replicas.pick())
+}
+
+func TestReplicaSet_PickBeforeFirstCheck(t *testing.T) {
+	replicas := newTestReplicaSet(t, mockLog.NewLogger(t), "replica1")
+
+	// Реплика, которую ещё не проверили, чтения не получает
+	assert.Nil(t, replicas.pick())
+}
+
+func TestReplicaSet_PickRoundRobin(t *testing.T) {
+	log := mockLog.NewLogger(t)
+	replicas := newTestReplicaSet(t, log, "replica1", "replica2")
+	log.On("Infow", "Replica returned to reads", "replica", mock.Anything, "lag", mock.Anything).Twice()
+
+	replicas.setStatus(replicas.replicas[0], 0, nil)
+	replicas.setStatus(replicas.replicas[1], 0, nil)
+
+	picked := map[string]int{}
+	for i := 0; i < 4; i++ {
+		picked[replicas.pick().name]++
+	}
+	assert.Equal(t, map[string]int{"replica1:5432": 2, "replica2:5432": 2}, picked)
+}
+
+func TestReplicaSet_ExcludesLaggingAndFailedReplicas(t *testing.T) {
+	log := mockLog.NewLogger(t)
+	replicas := newTestReplicaSet(t, log, "replica1", "replica2")
+	lagging, failed := replicas.replicas[0], replicas.replicas[1]
+
+	log.On("Infow", "Replica returned to reads", "replica", mock.Anything, "lag", mock.Anything).Times(3)
+	log.On("Warnw", "Replica excluded from reads: replication lag is too high",
+		"replica", "replica1:5432", "lag", "3s", "max_lag", "1s").Once()
+	log.On("Warnw", "Replica excluded from reads", "replica", "replica2:5432", "error", mock.Anything).Once()
+
+	replicas.setStatus(lagging, 500*time.Millisecond, nil)
+	replicas.setStatus(failed, 0, nil)
+
+	replicas.setStatus(lagging, 3*time.Second, nil)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, failed, replicas.pick())
+	}
+
+	replicas.setStatus(failed, 0, errors.New("connection refused"))
+	assert.Nil(t, replicas.pick())
+
+	// Повторная проверка с тем же результатом не пишет в лог
+	replicas.setStatus(failed, 0, errors.New("connection refused"))
+
+	replicas.setStatus(lagging, 0, nil)
+	assert.Equal(t, lagging, replicas.pick())
+}
+
+func TestReplicationLag(t *testing.T) {
+	seconds := 1.5
+	lag, err := replicationLag(&seconds, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, lag)
+
+	// Реплика без WAL receiver и без проигранных транзакций не получает чтения
+	_, err = replicationLag(nil, nil)
+	assert.ErrorIs(t, err, errReplicationLagUnknown)
+
+	queryErr := errors.New("connection refused")
+	_, err = replicationLag(nil, queryErr)
+	assert.ErrorIs(t, err, queryErr)
+}
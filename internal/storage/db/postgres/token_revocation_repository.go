@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/tracing"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"fmt"
+	"time"
+)
+
+type postgresTokenRevocationRepository struct {
+	conn   db.TxManager
+	logger logger.Logger
+}
+
+func NewTokenRevocationRepository(conn db.TxManager, log logger.Logger) db.TokenRevocationRepository {
+	return &postgresTokenRevocationRepository{conn: conn, logger: log}
+}
+
+func (r *postgresTokenRevocationRepository) RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	ctx, span := tracing.StartSpan(ctx, "TokenRevocationRepository.RevokeToken")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("RevokeToken", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		INSERT INTO revoked_tokens (token_id, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (token_id) DO NOTHING
+	`
+
+	_, err := pool.Exec(ctx, query, tokenID, userID, expiresAt.UTC())
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error revoking token",
+			"error", err,
+			"tokenID", tokenID,
+			"userID", userID,
+		)
+		metrics.RecordDBError("RevokeToken")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresTokenRevocationRepository) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ctx, span := tracing.StartSpan(ctx, "TokenRevocationRepository.IsTokenRevoked")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("IsTokenRevoked", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM revoked_tokens WHERE token_id = $1
+		)
+	`
+
+	var revoked bool
+	err := pool.QueryRow(ctx, query, tokenID).Scan(&revoked)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error checking token revocation",
+			"error", err,
+			"tokenID", tokenID,
+		)
+		metrics.RecordDBError("IsTokenRevoked")
+		tracing.RecordError(span, err)
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	return revoked, nil
+}
+
+func (r *postgresTokenRevocationRepository) SetTokensValidAfter(ctx context.Context, userID int, validAfter time.Time) error {
+	ctx, span := tracing.StartSpan(ctx, "TokenRevocationRepository.SetTokensValidAfter")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("SetTokensValidAfter", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE users
+		SET tokens_valid_after = $1
+		WHERE id = $2
+	`
+
+	result, err := pool.Exec(ctx, query, validAfter.UTC(), userID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error updating tokens watermark",
+			"error", err,
+			"userID", userID,
+		)
+		metrics.RecordDBError("SetTokensValidAfter")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to update tokens watermark: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		logger.WithContext(ctx, r.logger).Warnw("User not found",
+			"userID", userID,
+		)
+		return fmt.Errorf("user with ID %d not found", userID)
+	}
+
+	return nil
+}
+
+func (r *postgresTokenRevocationRepository) GetTokensValidAfter(ctx context.Context, userID int) (time.Time, error) {
+	ctx, span := tracing.StartSpan(ctx, "TokenRevocationRepository.GetTokensValidAfter")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("GetTokensValidAfter", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		SELECT tokens_valid_after
+		FROM users
+		WHERE id = $1
+	`
+
+	var validAfter *time.Time
+	err := pool.QueryRow(ctx, query, userID).Scan(&validAfter)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving tokens watermark",
+			"error", err,
+			"userID", userID,
+		)
+		metrics.RecordDBError("GetTokensValidAfter")
+		tracing.RecordError(span, err)
+		return time.Time{}, fmt.Errorf("failed to retrieve tokens watermark: %w", err)
+	}
+
+	if validAfter == nil {
+		return time.Time{}, nil
+	}
+
+	return *validAfter, nil
+}
+
+func (r *postgresTokenRevocationRepository) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	ctx, span := tracing.StartSpan(ctx, "TokenRevocationRepository.DeleteExpiredRevokedTokens")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("DeleteExpiredRevokedTokens", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	// Истёкший токен не пройдёт проверку exp, хранить запись об отзыве больше не нужно
+	query := `
+		DELETE FROM revoked_tokens
+		WHERE expires_at < $1
+	`
+
+	result, err := pool.Exec(ctx, query, time.Now().UTC())
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error deleting expired revoked tokens",
+			"error", err,
+		)
+		metrics.RecordDBError("DeleteExpiredRevokedTokens")
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("failed to delete expired revoked tokens: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -4,6 +4,7 @@ import (
 	"avito-tech-merch/internal/metrics"
 	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/tracing"
 	"avito-tech-merch/pkg/logger"
 	"context"
 	"fmt"
@@ -20,6 +21,9 @@ func NewTransactionRepository(conn db.TxManager, log logger.Logger) db.Transacti
 }
 
 func (r *postgresTransactionRepository) CreateTransaction(ctx context.Context, transaction *models.Transaction) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "TransactionRepository.CreateTransaction")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
 		metrics.RecordDBQueryDuration("CreateTransaction", time.Since(start).Seconds())
@@ -28,78 +32,168 @@ func (r *postgresTransactionRepository) CreateTransaction(ctx context.Context, t
 	pool := r.conn.GetExecutor(ctx)
 
 	query := `
-        INSERT INTO transactions (sender_id, receiver_id, amount, created_at)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO transactions (sender_id, receiver_id, amount, message, category, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
         RETURNING id
     `
 
 	var transactionID int
-	err := pool.QueryRow(ctx, query, transaction.SenderID, transaction.ReceiverID, transaction.Amount, transaction.CreatedAt).Scan(&transactionID)
+	err := pool.QueryRow(ctx, query,
+		transaction.SenderID,
+		transaction.ReceiverID,
+		transaction.Amount,
+		transaction.Message,
+		transaction.Category,
+		transaction.CreatedAt,
+	).Scan(&transactionID)
 	if err != nil {
-		r.logger.Errorw("Error creating transaction",
+		logger.WithContext(ctx, r.logger).Errorw("Error creating transaction",
 			"error", err,
 			"senderID", transaction.SenderID,
 			"receiverID", transaction.ReceiverID,
 		)
 		metrics.RecordDBError("CreateTransaction")
+		tracing.RecordError(span, err)
 		return 0, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
 	return transactionID, nil
 }
 
-func (r *postgresTransactionRepository) GetTransactionByUserID(ctx context.Context, userID int) ([]*models.Transaction, error) {
+// GetTransfersByUserID возвращает страницу переводов пользователя от новых к старым
+// с именем второй стороны перевода
+func (r *postgresTransactionRepository) GetTransfersByUserID(ctx context.Context, userID int, filter models.TransactionFilter, page models.HistoryPage) ([]*models.CoinTransfer, error) {
+	ctx, span := tracing.StartSpan(ctx, "TransactionRepository.GetTransfersByUserID")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
-		metrics.RecordDBQueryDuration("GetTransactionByUserID", time.Since(start).Seconds())
+		metrics.RecordDBQueryDuration("GetTransfersByUserID", time.Since(start).Seconds())
 	}()
 
+	return r.queryTransfers(ctx, "GetTransfersByUserID", userID, filter, page)
+}
+
+// GetCoinHistoryByUserID возвращает не больше limit последних входящих и limit последних исходящих
+// переводов пользователя с именем второй стороны перевода. Направления выбираются отдельными
+// запросами, имена подставляются в них же
+func (r *postgresTransactionRepository) GetCoinHistoryByUserID(ctx context.Context, userID int, filter models.TransactionFilter, limit int) (*models.CoinHistory, error) {
+	ctx, span := tracing.StartSpan(ctx, "TransactionRepository.GetCoinHistoryByUserID")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("GetCoinHistoryByUserID", time.Since(start).Seconds())
+	}()
+
+	history := &models.CoinHistory{
+		Received: []*models.CoinTransfer{},
+		Sent:     []*models.CoinTransfer{},
+	}
+	for _, direction := range []string{models.DirectionReceived, models.DirectionSent} {
+		if filter.Direction != "" && filter.Direction != direction {
+			continue
+		}
+
+		directionFilter := filter
+		directionFilter.Direction = direction
+		transfers, err := r.queryTransfers(ctx, "GetCoinHistoryByUserID", userID, directionFilter, models.HistoryPage{Limit: limit})
+		if err != nil {
+			return nil, err
+		}
+
+		if direction == models.DirectionReceived {
+			history.Received = transfers
+		} else {
+			history.Sent = transfers
+		}
+	}
+
+	return history, nil
+}
+
+// queryTransfers выбирает страницу переводов пользователя по фильтру от новых к старым
+func (r *postgresTransactionRepository) queryTransfers(ctx context.Context, operation string, userID int, filter models.TransactionFilter, page models.HistoryPage) ([]*models.CoinTransfer, error) {
+	ctx, span := tracing.StartSpan(ctx, "TransactionRepository.queryTransfers")
+	defer span.End()
+
 	pool := r.conn.GetExecutor(ctx)
 
-	query := `
-        SELECT id, sender_id, receiver_id, amount, created_at
-        FROM transactions
-        WHERE sender_id = $1 OR receiver_id = $1
-    `
+	q := &queryBuilder{}
+	user := q.param(userID)
+
+	switch filter.Direction {
+	case models.DirectionSent:
+		q.where("t.sender_id = " + user)
+	case models.DirectionReceived:
+		q.where("t.receiver_id = " + user)
+	default:
+		q.where(fmt.Sprintf("(t.sender_id = %[1]s OR t.receiver_id = %[1]s)", user))
+	}
+	if filter.Category != "" {
+		q.where("t.category = " + q.param(filter.Category))
+	}
+	if filter.Counterparty != "" {
+		q.where("u.username = " + q.param(filter.Counterparty))
+	}
+	q.period("t.created_at", filter.From, filter.To)
+
+	q.after("t.created_at", "t.id", page.After)
+
+	query := fmt.Sprintf(`
+        SELECT t.id,
+               CASE WHEN t.receiver_id = %[1]s THEN '%[2]s' ELSE '%[3]s' END,
+               COALESCE(u.username, ''), t.amount, t.message, t.category, t.created_at
+        FROM transactions t
+        LEFT JOIN users u ON u.id = CASE WHEN t.receiver_id = %[1]s THEN t.sender_id ELSE t.receiver_id END
+        WHERE %[4]s
+        ORDER BY t.created_at DESC, t.id DESC
+        LIMIT %[5]s
+    `, user, models.DirectionReceived, models.DirectionSent, q.whereClause(), q.param(page.Limit))
 
-	rows, err := pool.Query(ctx, query, userID)
+	rows, err := pool.Query(ctx, query, q.args...)
 	if err != nil {
-		r.logger.Errorw("Error retrieving transaction list",
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving coin history",
 			"error", err,
 			"userID", userID,
 		)
-		metrics.RecordDBError("GetTransactionByUserID")
-		return nil, fmt.Errorf("failed to retrieve transaction list: %w", err)
+		metrics.RecordDBError(operation)
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to retrieve coin history: %w", err)
 	}
 	defer rows.Close()
 
-	var transactions []*models.Transaction
+	transfers := []*models.CoinTransfer{}
 	for rows.Next() {
-		var transaction models.Transaction
+		var transfer models.CoinTransfer
 		err := rows.Scan(
-			&transaction.ID,
-			&transaction.SenderID,
-			&transaction.ReceiverID,
-			&transaction.Amount,
-			&transaction.CreatedAt,
+			&transfer.TransactionID,
+			&transfer.Direction,
+			&transfer.Counterparty,
+			&transfer.Amount,
+			&transfer.Message,
+			&transfer.Category,
+			&transfer.CreatedAt,
 		)
 		if err != nil {
-			r.logger.Errorw("Error scanning transaction data",
+			logger.WithContext(ctx, r.logger).Errorw("Error scanning coin history entry",
 				"error", err,
 			)
-			metrics.RecordDBError("GetTransactionByUserID")
-			return nil, fmt.Errorf("error reading transaction data: %w", err)
+			metrics.RecordDBError(operation)
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("error reading coin history entry: %w", err)
 		}
-		transactions = append(transactions, &transaction)
+		transfers = append(transfers, &transfer)
 	}
 
 	if err := rows.Err(); err != nil {
-		r.logger.Errorw("Error processing query result",
+		logger.WithContext(ctx, r.logger).Errorw("Error processing query result",
 			"error", err,
 		)
-		metrics.RecordDBError("GetTransactionByUserID")
+		metrics.RecordDBError(operation)
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("error processing query result: %w", err)
 	}
 
-	return transactions, nil
+	return transfers, nil
 }
@@ -1,12 +1,16 @@
 package postgres
 
 import (
+	"avito-tech-merch/internal/metrics"
 	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/tracing"
 	"avito-tech-merch/pkg/logger"
 	"context"
 	"errors"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"sync"
 )
 
 var (
@@ -20,13 +24,28 @@ var (
 
 type txKey struct{}
 
+type afterCommitKey struct{}
+
+// afterCommitHooks - функции, отложенные до коммита транзакции
+type afterCommitHooks struct {
+	mu    sync.Mutex
+	hooks []func(ctx context.Context)
+}
+
 type postgresTxManager struct {
-	pool   *pgxpool.Pool
-	logger logger.Logger
+	pool     *pgxpool.Pool
+	replicas *ReplicaSet
+	logger   logger.Logger
 }
 
 func NewTxManager(pool *pgxpool.Pool, log logger.Logger) db.TxManager {
-	return &postgresTxManager{pool: pool, logger: log}
+	return NewReplicatedTxManager(pool, nil, log)
+}
+
+// NewReplicatedTxManager создаёт менеджер, который отправляет чтения вне транзакций и
+// read-only транзакции на реплики; запись и остальные транзакции идут в pool
+func NewReplicatedTxManager(pool *pgxpool.Pool, replicas *ReplicaSet, log logger.Logger) db.TxManager {
+	return &postgresTxManager{pool: pool, replicas: replicas, logger: log}
 }
 
 func (t *postgresTxManager) GetExecutor(ctx context.Context) db.Executor {
@@ -36,6 +55,32 @@ func (t *postgresTxManager) GetExecutor(ctx context.Context) db.Executor {
 	return t.pool
 }
 
+func (t *postgresTxManager) GetReadExecutor(ctx context.Context) db.Executor {
+	if tx, ok := ctx.Value(txKey{}).(db.Executor); ok {
+		return tx
+	}
+	if !db.PrimaryRead(ctx) {
+		if r := t.replicas.pick(); r != nil {
+			metrics.RecordDBRead(r.name)
+			return r.pool
+		}
+	}
+	metrics.RecordDBRead(PrimaryPoolName)
+	return t.pool
+}
+
+func (t *postgresTxManager) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	hooks, ok := ctx.Value(afterCommitKey{}).(*afterCommitHooks)
+	if !ok {
+		fn(ctx)
+		return
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.hooks = append(hooks.hooks, fn)
+}
+
 func (t *postgresTxManager) WithTx(ctx context.Context, isoLevel pgx.TxIsoLevel, accessMode pgx.TxAccessMode, fn func(ctx context.Context) error) error {
 	opts := pgx.TxOptions{
 		IsoLevel:   isoLevel,
@@ -44,9 +89,20 @@ func (t *postgresTxManager) WithTx(ctx context.Context, isoLevel pgx.TxIsoLevel,
 
 	var err error
 
-	tx, err := t.pool.BeginTx(ctx, opts)
+	ctx, span := tracing.StartSpan(ctx, "postgres.WithTx",
+		attribute.String("db.transaction.isolation_level", string(isoLevel)),
+		attribute.String("db.transaction.access_mode", string(accessMode)),
+		attribute.Int("db.transaction.attempt", tracing.Attempt(ctx)),
+	)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	tx, poolName, err := t.beginTx(ctx, opts)
+	span.SetAttributes(attribute.String("db.pool", poolName))
 	if err != nil {
-		t.logger.Errorw("Failed to begin transaction",
+		logger.WithContext(ctx, t.logger).Errorw("Failed to begin transaction",
 			"error", err,
 		)
 		return err
@@ -55,7 +111,7 @@ func (t *postgresTxManager) WithTx(ctx context.Context, isoLevel pgx.TxIsoLevel,
 	defer func() {
 		if err != nil {
 			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
-				t.logger.Errorw("Failed to rollback transaction",
+				logger.WithContext(ctx, t.logger).Errorw("Failed to rollback transaction",
 					"error", err,
 				)
 			}
@@ -63,16 +119,47 @@ func (t *postgresTxManager) WithTx(ctx context.Context, isoLevel pgx.TxIsoLevel,
 
 	}()
 
-	ctx = context.WithValue(ctx, txKey{}, tx)
-	if err = fn(ctx); err != nil {
+	hooks := &afterCommitHooks{}
+	txCtx := context.WithValue(context.WithValue(ctx, txKey{}, tx), afterCommitKey{}, hooks)
+	if err = fn(txCtx); err != nil {
 		return err
 	}
 
 	err = tx.Commit(ctx)
 	if err != nil {
-		t.logger.Errorw("Failed to commit transaction",
+		logger.WithContext(ctx, t.logger).Errorw("Failed to commit transaction",
 			"error", err,
 		)
+		return err
+	}
+
+	// Отложенные функции работают уже вне транзакции и доходят до конца, даже если запрос отменён
+	hooksCtx := context.WithoutCancel(ctx)
+	for _, hook := range hooks.hooks {
+		hook(hooksCtx)
 	}
-	return err
+	return nil
+}
+
+// beginTx открывает транзакцию и возвращает имя пула, в котором она открыта. Read-only транзакции
+// идут на реплику; serializable остаётся на основной базе, потому что реплика его не поддерживает.
+// Если реплика не открыла транзакцию, она исключается из чтений до следующей проверки
+func (t *postgresTxManager) beginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, string, error) {
+	if opts.AccessMode == AccessModeReadOnly && opts.IsoLevel != IsolationLevelSerializable && !db.PrimaryRead(ctx) {
+		if r := t.replicas.pick(); r != nil {
+			tx, err := r.pool.BeginTx(ctx, opts)
+			if err == nil {
+				metrics.RecordDBRead(r.name)
+				return tx, r.name, nil
+			}
+			if ctx.Err() != nil {
+				return nil, r.name, err
+			}
+			t.replicas.setStatus(r, 0, err)
+		}
+		metrics.RecordDBRead(PrimaryPoolName)
+	}
+
+	tx, err := t.pool.BeginTx(ctx, opts)
+	return tx, PrimaryPoolName, err
 }
@@ -4,9 +4,12 @@ import (
 	"avito-tech-merch/internal/metrics"
 	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/tracing"
 	"avito-tech-merch/pkg/logger"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"time"
 )
 
@@ -20,6 +23,9 @@ func NewUserRepository(conn db.TxManager, log logger.Logger) db.UserRepository {
 }
 
 func (r *postgresUserRepository) CreateUser(ctx context.Context, user *models.User) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "UserRepository.CreateUser")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
 		metrics.RecordDBQueryDuration("CreateUser", time.Since(start).Seconds())
@@ -28,21 +34,22 @@ func (r *postgresUserRepository) CreateUser(ctx context.Context, user *models.Us
 	pool := r.conn.GetExecutor(ctx)
 
 	query := `
-		INSERT INTO users (username, password_hash, balance, created_at)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id
+		INSERT INTO users (username, password_hash, balance, role, created_at)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'employee'), $5)
+		RETURNING id, role
 	`
 
 	user.CreatedAt = time.Now()
 
 	var userID int
-	err := pool.QueryRow(ctx, query, user.Username, user.PasswordHash, user.Balance, user.CreatedAt).Scan(&userID)
+	err := pool.QueryRow(ctx, query, user.Username, user.PasswordHash, user.Balance, user.Role, user.CreatedAt).Scan(&userID, &user.Role)
 	if err != nil {
-		r.logger.Errorw("Error when creating a user",
+		logger.WithContext(ctx, r.logger).Errorw("Error when creating a user",
 			"error", err,
 			"username", user.Username,
 		)
 		metrics.RecordDBError("CreateUser")
+		tracing.RecordError(span, err)
 		return 0, fmt.Errorf("failed to create user: %w", err)
 	}
 
@@ -50,6 +57,9 @@ func (r *postgresUserRepository) CreateUser(ctx context.Context, user *models.Us
 }
 
 func (r *postgresUserRepository) GetUserByID(ctx context.Context, userID int) (*models.User, error) {
+	ctx, span := tracing.StartSpan(ctx, "UserRepository.GetUserByID")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
 		metrics.RecordDBQueryDuration("GetUserByID", time.Since(start).Seconds())
@@ -58,7 +68,7 @@ func (r *postgresUserRepository) GetUserByID(ctx context.Context, userID int) (*
 	pool := r.conn.GetExecutor(ctx)
 
 	query := `
-		SELECT id, username, password_hash, balance, created_at
+		SELECT id, username, password_hash, balance, role, created_at
 		FROM users
 		WHERE id = $1
 	`
@@ -69,14 +79,16 @@ func (r *postgresUserRepository) GetUserByID(ctx context.Context, userID int) (*
 		&user.Username,
 		&user.PasswordHash,
 		&user.Balance,
+		&user.Role,
 		&user.CreatedAt,
 	)
 	if err != nil {
-		r.logger.Errorw("Error when getting a user by ID",
+		logger.WithContext(ctx, r.logger).Errorw("Error when getting a user by ID",
 			"error", err,
 			"userID", userID,
 		)
 		metrics.RecordDBError("GetUserByID")
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("failed to get a user by ID: %w", err)
 	}
 
@@ -84,6 +96,9 @@ func (r *postgresUserRepository) GetUserByID(ctx context.Context, userID int) (*
 }
 
 func (r *postgresUserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, span := tracing.StartSpan(ctx, "UserRepository.GetUserByUsername")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
 		metrics.RecordDBQueryDuration("GetUserByUsername", time.Since(start).Seconds())
@@ -92,7 +107,7 @@ func (r *postgresUserRepository) GetUserByUsername(ctx context.Context, username
 	pool := r.conn.GetExecutor(ctx)
 
 	query := `
-		SELECT id, username, password_hash, balance, created_at
+		SELECT id, username, password_hash, balance, role, created_at
 		FROM users
 		WHERE username = $1
 	`
@@ -103,14 +118,16 @@ func (r *postgresUserRepository) GetUserByUsername(ctx context.Context, username
 		&user.Username,
 		&user.PasswordHash,
 		&user.Balance,
+		&user.Role,
 		&user.CreatedAt,
 	)
 	if err != nil {
-		r.logger.Warnw("Error when getting a user by username",
+		logger.WithContext(ctx, r.logger).Warnw("Error when getting a user by username",
 			"error", err,
 			"username", username,
 		)
 		metrics.RecordDBError("GetUserByUsername")
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("failed to get a user by username: %w", err)
 	}
 
@@ -118,6 +135,9 @@ func (r *postgresUserRepository) GetUserByUsername(ctx context.Context, username
 }
 
 func (r *postgresUserRepository) GetBalanceByID(ctx context.Context, userID int) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "UserRepository.GetBalanceByID")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
 		metrics.RecordDBQueryDuration("GetBalanceByID", time.Since(start).Seconds())
@@ -133,10 +153,11 @@ func (r *postgresUserRepository) GetBalanceByID(ctx context.Context, userID int)
 	var balance int
 	err := pool.QueryRow(ctx, query, userID).Scan(&balance)
 	if err != nil {
-		r.logger.Errorw("Error when getting a user balance by userID",
+		logger.WithContext(ctx, r.logger).Errorw("Error when getting a user balance by userID",
 			"error", err,
 			"userID", userID)
 		metrics.RecordDBError("GetBalanceByID")
+		tracing.RecordError(span, err)
 		return 0, fmt.Errorf("failed to get a user balance by userID: %w", err)
 	}
 
@@ -144,6 +165,9 @@ func (r *postgresUserRepository) GetBalanceByID(ctx context.Context, userID int)
 }
 
 func (r *postgresUserRepository) GetBalanceByName(ctx context.Context, username string) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "UserRepository.GetBalanceByName")
+	defer span.End()
+
 	pool := r.conn.GetExecutor(ctx)
 
 	query := `
@@ -155,7 +179,7 @@ func (r *postgresUserRepository) GetBalanceByName(ctx context.Context, username
 	var balance int
 	err := pool.QueryRow(ctx, query, username).Scan(&balance)
 	if err != nil {
-		r.logger.Errorw("Error when getting a user balance by username",
+		logger.WithContext(ctx, r.logger).Errorw("Error when getting a user balance by username",
 			"error", err,
 			"username", username)
 		return 0, fmt.Errorf("failed to get a user balance by username: %w", err)
@@ -164,52 +188,154 @@ func (r *postgresUserRepository) GetBalanceByName(ctx context.Context, username
 	return balance, nil
 }
 
-func (r *postgresUserRepository) UpdateBalance(ctx context.Context, userID int, newBalance int) error {
+// LockBalances блокирует строки пользователей до конца транзакции и возвращает их балансы.
+// Строки блокируются в порядке возрастания ID, поэтому встречные переводы не взаимоблокируются.
+// Отсутствующие пользователи в результат не попадают
+func (r *postgresUserRepository) LockBalances(ctx context.Context, userIDs ...int) (map[int]int, error) {
+	ctx, span := tracing.StartSpan(ctx, "UserRepository.LockBalances")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
-		metrics.RecordDBQueryDuration("UpdateBalance", time.Since(start).Seconds())
+		metrics.RecordDBQueryDuration("LockBalances", time.Since(start).Seconds())
 	}()
 
 	pool := r.conn.GetExecutor(ctx)
 
 	query := `
-		UPDATE users
-		SET balance = $1
-		WHERE id = $2
+		SELECT id, balance
+		FROM users
+		WHERE id = ANY($1)
+		ORDER BY id
+		FOR UPDATE
 	`
 
-	result, err := pool.Exec(ctx, query, newBalance, userID)
+	rows, err := pool.Query(ctx, query, userIDs)
 	if err != nil {
-		r.logger.Errorw("Error when updating a user balance",
+		logger.WithContext(ctx, r.logger).Errorw("Error locking user balances",
 			"error", err,
-			"userID", userID,
-			"newBalance", newBalance,
+			"userIDs", userIDs,
 		)
-		metrics.RecordDBError("UpdateBalance")
-		return fmt.Errorf("failed to update user balance: %w", err)
+		metrics.RecordDBError("LockBalances")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to lock user balances: %w", err)
 	}
+	defer rows.Close()
 
-	if result.RowsAffected() == 0 {
-		r.logger.Warnw("User not found",
+	balances := make(map[int]int, len(userIDs))
+	for rows.Next() {
+		var userID, balance int
+		if err = rows.Scan(&userID, &balance); err != nil {
+			logger.WithContext(ctx, r.logger).Errorw("Error scanning locked user balance",
+				"error", err,
+			)
+			metrics.RecordDBError("LockBalances")
+			tracing.RecordError(span, err)
+			return nil, fmt.Errorf("failed to scan user balance: %w", err)
+		}
+		balances[userID] = balance
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error iterating over locked user balances",
+			"error", err,
+		)
+		metrics.RecordDBError("LockBalances")
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to iterate over user balances: %w", err)
+	}
+
+	return balances, nil
+}
+
+// DebitBalance списывает amount монет одним условным UPDATE и возвращает новый баланс.
+// Если монет недостаточно или пользователь не найден, возвращается pgx.ErrNoRows
+func (r *postgresUserRepository) DebitBalance(ctx context.Context, userID int, amount int) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "UserRepository.DebitBalance")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("DebitBalance", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE users
+		SET balance = balance - $1
+		WHERE id = $2 AND balance >= $1
+		RETURNING balance
+	`
+
+	var balance int
+	err := pool.QueryRow(ctx, query, amount, userID).Scan(&balance)
+	if err != nil {
+		// Нехватка монет - ожидаемый исход, поэтому ErrNoRows не логируется как ошибка
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logger.WithContext(ctx, r.logger).Errorw("Error when debiting a user balance",
+				"error", err,
+				"userID", userID,
+				"amount", amount,
+			)
+			metrics.RecordDBError("DebitBalance")
+			tracing.RecordError(span, err)
+		}
+		return 0, fmt.Errorf("failed to debit user balance: %w", err)
+	}
+
+	return balance, nil
+}
+
+// CreditBalance начисляет amount монет и возвращает новый баланс; для несуществующего пользователя возвращает pgx.ErrNoRows
+func (r *postgresUserRepository) CreditBalance(ctx context.Context, userID int, amount int) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "UserRepository.CreditBalance")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("CreditBalance", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE users
+		SET balance = balance + $1
+		WHERE id = $2
+		RETURNING balance
+	`
+
+	var balance int
+	err := pool.QueryRow(ctx, query, amount, userID).Scan(&balance)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error when crediting a user balance",
+			"error", err,
 			"userID", userID,
+			"amount", amount,
 		)
-		return fmt.Errorf("user with ID %d not found", userID)
+		metrics.RecordDBError("CreditBalance")
+		tracing.RecordError(span, err)
+		return 0, fmt.Errorf("failed to credit user balance: %w", err)
 	}
 
-	return nil
+	return balance, nil
 }
 
 func (r *postgresUserRepository) GetAllUsers(ctx context.Context) ([]*models.User, error) {
+	ctx, span := tracing.StartSpan(ctx, "UserRepository.GetAllUsers")
+	defer span.End()
+
 	pool := r.conn.GetExecutor(ctx)
 
 	query := `
-		SELECT id, username, password_hash, balance, created_at
+		SELECT id, username, password_hash, balance, role, created_at
 		FROM users
 	`
 
 	rows, err := pool.Query(ctx, query)
 	if err != nil {
-		r.logger.Errorw("Error retrieving user list",
+		logger.WithContext(ctx, r.logger).Errorw("Error retrieving user list",
 			"error", err,
 		)
 		return nil, fmt.Errorf("failed to retrieve user list: %w", err)
@@ -224,10 +350,11 @@ func (r *postgresUserRepository) GetAllUsers(ctx context.Context) ([]*models.Use
 			&user.Username,
 			&user.PasswordHash,
 			&user.Balance,
+			&user.Role,
 			&user.CreatedAt,
 		)
 		if err != nil {
-			r.logger.Errorw("Error scanning user data",
+			logger.WithContext(ctx, r.logger).Errorw("Error scanning user data",
 				"error", err,
 			)
 			return nil, fmt.Errorf("error reading user data: %w", err)
@@ -236,7 +363,7 @@ func (r *postgresUserRepository) GetAllUsers(ctx context.Context) ([]*models.Use
 	}
 
 	if err := rows.Err(); err != nil {
-		r.logger.Errorw("Error processing query result",
+		logger.WithContext(ctx, r.logger).Errorw("Error processing query result",
 			"error", err,
 		)
 		return nil, fmt.Errorf("error processing query result: %w", err)
@@ -246,17 +373,20 @@ func (r *postgresUserRepository) GetAllUsers(ctx context.Context) ([]*models.Use
 }
 
 func (r *postgresUserRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.StartSpan(ctx, "UserRepository.UpdateUser")
+	defer span.End()
+
 	pool := r.conn.GetExecutor(ctx)
 
 	query := `
 		UPDATE users
-		SET username = $1, password_hash = $2, balance = $3
+		SET username = $1, password_hash = $2, role = $3
 		WHERE id = $4
 	`
 
-	result, err := pool.Exec(ctx, query, user.Username, user.PasswordHash, user.Balance, user.ID)
+	result, err := pool.Exec(ctx, query, user.Username, user.PasswordHash, user.Role, user.ID)
 	if err != nil {
-		r.logger.Errorw("Error updating user data",
+		logger.WithContext(ctx, r.logger).Errorw("Error updating user data",
 			"error", err,
 			"userID", user.ID,
 		)
@@ -264,7 +394,7 @@ func (r *postgresUserRepository) UpdateUser(ctx context.Context, user *models.Us
 	}
 
 	if result.RowsAffected() == 0 {
-		r.logger.Warnw("User not found",
+		logger.WithContext(ctx, r.logger).Warnw("User not found",
 			"userID", user.ID,
 		)
 		return fmt.Errorf("user with ID %d not found", user.ID)
@@ -272,3 +402,42 @@ func (r *postgresUserRepository) UpdateUser(ctx context.Context, user *models.Us
 
 	return nil
 }
+
+func (r *postgresUserRepository) UpdateUserRole(ctx context.Context, userID int, role string) error {
+	ctx, span := tracing.StartSpan(ctx, "UserRepository.UpdateUserRole")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.RecordDBQueryDuration("UpdateUserRole", time.Since(start).Seconds())
+	}()
+
+	pool := r.conn.GetExecutor(ctx)
+
+	query := `
+		UPDATE users
+		SET role = $1
+		WHERE id = $2
+	`
+
+	result, err := pool.Exec(ctx, query, role, userID)
+	if err != nil {
+		logger.WithContext(ctx, r.logger).Errorw("Error when updating a user role",
+			"error", err,
+			"userID", userID,
+			"role", role,
+		)
+		metrics.RecordDBError("UpdateUserRole")
+		tracing.RecordError(span, err)
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		logger.WithContext(ctx, r.logger).Warnw("User not found",
+			"userID", userID,
+		)
+		return fmt.Errorf("user with ID %d not found", userID)
+	}
+
+	return nil
+}
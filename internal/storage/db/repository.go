@@ -5,13 +5,19 @@ import (
 	"context"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"time"
 )
 
 type Repository interface {
 	UserRepository
 	MerchRepository
 	PurchaseRepository
+	CartRepository
 	TransactionRepository
+	RefreshTokenRepository
+	TokenRevocationRepository
+	IdempotencyRepository
+	LedgerRepository
 }
 
 type UserRepository interface {
@@ -20,25 +26,78 @@ type UserRepository interface {
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
 	GetBalanceByID(ctx context.Context, userID int) (int, error)
 	GetBalanceByName(ctx context.Context, username string) (int, error)
-	UpdateBalance(ctx context.Context, userID int, newBalance int) error
+	LockBalances(ctx context.Context, userIDs ...int) (map[int]int, error)
+	DebitBalance(ctx context.Context, userID int, amount int) (int, error)
+	CreditBalance(ctx context.Context, userID int, amount int) (int, error)
 	GetAllUsers(ctx context.Context) ([]*models.User, error)
 	UpdateUser(ctx context.Context, user *models.User) error
+	UpdateUserRole(ctx context.Context, userID int, role string) error
 }
 
 type MerchRepository interface {
-	GetAllMerch(ctx context.Context) ([]*models.Merch, error)
+	GetAllMerch(ctx context.Context, includeArchived bool) ([]*models.Merch, error)
 	GetMerchByID(ctx context.Context, id int) (*models.Merch, error)
 	GetMerchByName(ctx context.Context, merchName string) (*models.Merch, error)
+	CreateMerch(ctx context.Context, merch *models.Merch) (int, error)
+	UpdateMerch(ctx context.Context, merch *models.Merch) error
+	ArchiveMerch(ctx context.Context, id int) error
+	DecrementMerchStock(ctx context.Context, merchID int, price int, quantity int) (*int, error)
+	RestockMerch(ctx context.Context, merchID int, quantity int) (int, error)
+	SetMerchStock(ctx context.Context, merchID int, stock *int) error
+	CreateMerchPrice(ctx context.Context, price *models.MerchPrice) (int, error)
+	GetMerchPriceHistory(ctx context.Context, merchID int) ([]*models.MerchPrice, error)
 }
 
 type PurchaseRepository interface {
 	CreatePurchase(ctx context.Context, purchase *models.Purchase) (int, error)
-	GetPurchaseByUserID(ctx context.Context, userID int) ([]*models.Purchase, error)
+	GetPurchaseByUserID(ctx context.Context, userID int, filter models.PurchaseFilter, page models.HistoryPage) ([]*models.Purchase, error)
+	GetInventoryByUserID(ctx context.Context, userID int) ([]*models.InventoryItem, error)
+	GetSpentByUserID(ctx context.Context, userID int) (int, error)
+	GetPurchaseByID(ctx context.Context, purchaseID int) (*models.Purchase, error)
+	MarkPurchaseRefunded(ctx context.Context, purchaseID int, refundedBy int) (time.Time, error)
+	UpdatePurchaseStatus(ctx context.Context, purchaseID int, from, to string) error
+	CreatePurchaseStatusChange(ctx context.Context, change *models.PurchaseStatusChange) (int, error)
+	GetPurchaseStatusHistory(ctx context.Context, purchaseID int) ([]*models.PurchaseStatusChange, error)
+}
+
+type CartRepository interface {
+	AddCartItem(ctx context.Context, userID int, merchID int, quantity int, maxQuantity int) (int, error)
+	GetCartItems(ctx context.Context, userID int) ([]*models.CartItem, error)
+	RemoveCartItem(ctx context.Context, userID int, merchID int) error
+	ClearCart(ctx context.Context, userID int) error
 }
 
 type TransactionRepository interface {
 	CreateTransaction(ctx context.Context, transaction *models.Transaction) (int, error)
-	GetTransactionByUserID(ctx context.Context, userID int) ([]*models.Transaction, error)
+	GetTransfersByUserID(ctx context.Context, userID int, filter models.TransactionFilter, page models.HistoryPage) ([]*models.CoinTransfer, error)
+	GetCoinHistoryByUserID(ctx context.Context, userID int, filter models.TransactionFilter, limit int) (*models.CoinHistory, error)
+}
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) (int, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, tokenID int) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int) error
+}
+
+type TokenRevocationRepository interface {
+	RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	SetTokensValidAfter(ctx context.Context, userID int, validAfter time.Time) error
+	GetTokensValidAfter(ctx context.Context, userID int) (time.Time, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+}
+
+type IdempotencyRepository interface {
+	GetIdempotencyKey(ctx context.Context, userID int, key string) (*models.IdempotencyKey, error)
+	CreateIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error
+}
+
+// LedgerRepository - журнал двойной записи, по которому сверяется users.balance
+type LedgerRepository interface {
+	CreateLedgerEntry(ctx context.Context, entry *models.LedgerEntry) (int, error)
+	VerifyLedger(ctx context.Context) (*models.LedgerReport, error)
 }
 
 type Executor interface {
@@ -49,26 +108,47 @@ type Executor interface {
 
 type TxManager interface {
 	GetExecutor(ctx context.Context) Executor
+	// GetReadExecutor возвращает исполнитель для чтения: внутри транзакции - её саму,
+	// вне транзакции - реплику, если она есть и не отстаёт, иначе основную базу
+	GetReadExecutor(ctx context.Context) Executor
 	WithTx(ctx context.Context, isoLevel pgx.TxIsoLevel, accessMode pgx.TxAccessMode, fn func(ctx context.Context) error) error
+	// AfterCommit выполняет fn после коммита текущей транзакции, вне транзакции - сразу.
+	// Если транзакция откатилась, fn не выполняется
+	AfterCommit(ctx context.Context, fn func(ctx context.Context))
 }
 
 type postgresRepository struct {
 	UserRepository
 	MerchRepository
 	PurchaseRepository
+	CartRepository
 	TransactionRepository
+	RefreshTokenRepository
+	TokenRevocationRepository
+	IdempotencyRepository
+	LedgerRepository
 }
 
 func NewRepository(
 	userRepo UserRepository,
 	merchRepo MerchRepository,
 	purchaseRepo PurchaseRepository,
+	cartRepo CartRepository,
 	transactionRepo TransactionRepository,
+	refreshTokenRepo RefreshTokenRepository,
+	tokenRevocationRepo TokenRevocationRepository,
+	idempotencyRepo IdempotencyRepository,
+	ledgerRepo LedgerRepository,
 ) Repository {
 	return &postgresRepository{
-		UserRepository:        userRepo,
-		MerchRepository:       merchRepo,
-		PurchaseRepository:    purchaseRepo,
-		TransactionRepository: transactionRepo,
+		UserRepository:            userRepo,
+		MerchRepository:           merchRepo,
+		PurchaseRepository:        purchaseRepo,
+		CartRepository:            cartRepo,
+		TransactionRepository:     transactionRepo,
+		RefreshTokenRepository:    refreshTokenRepo,
+		TokenRevocationRepository: tokenRevocationRepo,
+		IdempotencyRepository:     idempotencyRepo,
+		LedgerRepository:          ledgerRepo,
 	}
 }
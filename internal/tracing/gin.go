@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"avito-tech-merch/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// GinMiddleware открывает серверный спан на каждый HTTP-запрос. Родительский контекст берётся
+// из заголовков traceparent/tracestate, trace_id добавляется к полям корреляции логов.
+// Должен стоять после middleware.RequestID, чтобы спан получил идентификатор запроса
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		attrs := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(c.Request.URL.Path),
+		}
+		if id, ok := logger.Value(ctx, logger.RequestIDKey); ok {
+			if requestID, ok := id.(string); ok {
+				attrs = append(attrs, attribute.String(logger.RequestIDKey, requestID))
+			}
+		}
+
+		ctx, span := Tracer().Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attrs...),
+		)
+		defer span.End()
+
+		if span.SpanContext().HasTraceID() {
+			ctx = logger.NewContext(ctx, "trace_id", span.SpanContext().TraceID().String())
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if userID, ok := logger.Value(c.Request.Context(), logger.UserIDKey); ok {
+			if id, ok := userID.(int); ok {
+				span.SetAttributes(attribute.Int(logger.UserIDKey, id))
+			}
+		}
+		// Ответы 4xx - ошибки клиента, серверный спан помечается ошибкой только на 5xx
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		for _, ginErr := range c.Errors {
+			span.RecordError(ginErr.Err)
+		}
+	}
+}
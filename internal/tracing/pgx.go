@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+// PgxTracer открывает спаны на запросы pgx и на ожидание соединения из пула.
+// Подключается через pgxpool.Config.ConnConfig.Tracer. Аргументы запросов в спаны не попадают
+type PgxTracer struct {
+	database string
+}
+
+var (
+	_ pgx.QueryTracer       = (*PgxTracer)(nil)
+	_ pgxpool.AcquireTracer = (*PgxTracer)(nil)
+)
+
+// NewPgxTracer создаёт трейсер для базы database
+func NewPgxTracer(database string) *PgxTracer {
+	return &PgxTracer{database: database}
+}
+
+func (t *PgxTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := queryOperation(data.SQL)
+	ctx, _ = Tracer().Start(ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBNamespace(t.database),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(strings.TrimSpace(data.SQL)),
+		),
+	)
+	return ctx
+}
+
+func (t *PgxTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	if data.Err != nil {
+		RecordError(span, data.Err)
+		return
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+}
+
+// TraceAcquireStart показывает, сколько запрос ждал свободного соединения из пула
+func (t *PgxTracer) TraceAcquireStart(ctx context.Context, _ *pgxpool.Pool, _ pgxpool.TraceAcquireStartData) context.Context {
+	ctx, _ = Tracer().Start(ctx, "postgres pool.acquire",
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBNamespace(t.database)),
+	)
+	return ctx
+}
+
+func (t *PgxTracer) TraceAcquireEnd(ctx context.Context, _ *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	RecordError(span, data.Err)
+}
+
+// queryOperation возвращает первое ключевое слово запроса (SELECT, UPDATE, WITH...)
+func queryOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName - имя инструментирующей библиотеки, под которым создаются все спаны сервиса
+const TracerName = "avito-tech-merch"
+
+type attemptKey struct{}
+
+// Tracer возвращает трейсер глобального провайдера. Провайдер берётся при каждом вызове,
+// поэтому спаны попадают в провайдер, заданный через otel.SetTracerProvider уже после старта
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// StartSpan начинает внутренний спан с именем name
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// RecordError отмечает спан как завершившийся ошибкой
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// WithAttempt сохраняет в контексте номер попытки, с которой выполняется транзакция
+func WithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// Attempt возвращает номер попытки из контекста; без повторов это всегда первая попытка
+func Attempt(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
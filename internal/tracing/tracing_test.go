@@ -0,0 +1,172 @@
+package tracing
+
+import (
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setupExporter(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	return exporter
+}
+
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	result := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, attr := range span.Attributes {
+		result[attr.Key] = attr.Value
+	}
+	return result
+}
+
+func performTraced(status int, header http.Header) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(func(c *gin.Context) {
+		ctx := logger.NewContext(c.Request.Context(), logger.RequestIDKey, "req-1")
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}, GinMiddleware())
+	router.GET("/items/:id", func(c *gin.Context) {
+		_, span := StartSpan(c, "ItemRepository.GetItem")
+		span.End()
+		c.Status(status)
+	})
+
+	req, _ := http.NewRequest("GET", "/items/1", nil)
+	for name := range header {
+		req.Header.Set(name, header.Get(name))
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGinMiddleware_ServerSpan(t *testing.T) {
+	exporter := setupExporter(t)
+
+	performTraced(http.StatusOK, nil)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "GET /items/:id", server.Name)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, codes.Unset, server.Status.Code)
+
+	attrs := attributes(server)
+	assert.Equal(t, "GET", attrs["http.request.method"].AsString())
+	assert.Equal(t, "/items/:id", attrs["http.route"].AsString())
+	assert.Equal(t, int64(http.StatusOK), attrs["http.response.status_code"].AsInt64())
+	assert.Equal(t, "req-1", attrs[logger.RequestIDKey].AsString())
+
+	// Спаны из обработчика становятся дочерними серверного
+	assert.Equal(t, "ItemRepository.GetItem", child.Name)
+	assert.Equal(t, server.SpanContext.TraceID(), child.SpanContext.TraceID())
+	assert.Equal(t, server.SpanContext.SpanID(), child.Parent.SpanID())
+}
+
+func TestGinMiddleware_ServerError(t *testing.T) {
+	exporter := setupExporter(t)
+
+	performTraced(http.StatusInternalServerError, nil)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+}
+
+func TestGinMiddleware_ContinuesIncomingTrace(t *testing.T) {
+	exporter := setupExporter(t)
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	performTraced(http.StatusOK, http.Header{"Traceparent": {traceparent}})
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[1].SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[1].Parent.SpanID().String())
+}
+
+func TestPgxTracer_Query(t *testing.T) {
+	exporter := setupExporter(t)
+	tracer := NewPgxTracer("merch-store")
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+		SQL:  "\n\t\tupdate users SET balance = balance - $1 WHERE id = $2",
+		Args: []any{100, 1},
+	})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 1")})
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "postgres UPDATE", spans[0].Name)
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind)
+
+	attrs := attributes(spans[0])
+	assert.Equal(t, "postgresql", attrs["db.system"].AsString())
+	assert.Equal(t, "merch-store", attrs["db.namespace"].AsString())
+	assert.Equal(t, "update users SET balance = balance - $1 WHERE id = $2", attrs["db.query.text"].AsString())
+	assert.Equal(t, int64(1), attrs["db.rows_affected"].AsInt64())
+}
+
+func TestPgxTracer_QueryError(t *testing.T) {
+	exporter := setupExporter(t)
+	tracer := NewPgxTracer("merch-store")
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("connection reset")})
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "connection reset", spans[0].Status.Description)
+}
+
+func TestPgxTracer_Acquire(t *testing.T) {
+	exporter := setupExporter(t)
+	tracer := NewPgxTracer("merch-store")
+
+	ctx := tracer.TraceAcquireStart(context.Background(), nil, pgxpool.TraceAcquireStartData{})
+	tracer.TraceAcquireEnd(ctx, nil, pgxpool.TraceAcquireEndData{})
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "postgres pool.acquire", spans[0].Name)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+}
+
+func TestAttempt(t *testing.T) {
+	assert.Equal(t, 1, Attempt(context.Background()))
+	assert.Equal(t, 3, Attempt(WithAttempt(context.Background(), 3)))
+}
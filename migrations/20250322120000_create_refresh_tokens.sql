@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id UUID NOT NULL DEFAULT gen_random_uuid(),
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+-- +goose Down
+DROP TABLE refresh_tokens;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE revoked_tokens (
+    token_id TEXT PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+-- Токены, выпущенные раньше этой отметки, считаются отозванными (logout-all)
+ALTER TABLE users ADD COLUMN tokens_valid_after TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN tokens_valid_after;
+DROP TABLE revoked_tokens;
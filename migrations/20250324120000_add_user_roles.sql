@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'employee'
+        CHECK (role IN ('employee', 'admin'));
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN role;
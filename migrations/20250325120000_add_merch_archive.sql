@@ -0,0 +1,6 @@
+-- +goose Up
+-- Архивный товар не продаётся, но остаётся в каталоге для истории покупок
+ALTER TABLE merch ADD COLUMN archived_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE merch DROP COLUMN archived_at;
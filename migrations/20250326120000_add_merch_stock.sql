@@ -0,0 +1,6 @@
+-- +goose Up
+-- NULL означает, что количество товара не ограничено
+ALTER TABLE merch ADD COLUMN stock INT CHECK (stock >= 0);
+
+-- +goose Down
+ALTER TABLE merch DROP COLUMN stock;
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE purchases
+    ADD COLUMN quantity INT NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    ADD COLUMN unit_price INT;
+
+-- Для уже совершённых покупок известна только текущая цена товара
+UPDATE purchases p SET unit_price = m.price FROM merch m WHERE m.id = p.merch_id;
+
+ALTER TABLE purchases
+    ALTER COLUMN unit_price SET NOT NULL,
+    ADD CONSTRAINT purchases_unit_price_check CHECK (unit_price >= 0);
+
+CREATE TABLE cart_items (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    merch_id INT NOT NULL REFERENCES merch(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    added_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, merch_id)
+);
+
+-- +goose Down
+DROP TABLE cart_items;
+
+ALTER TABLE purchases
+    DROP COLUMN unit_price,
+    DROP COLUMN quantity;
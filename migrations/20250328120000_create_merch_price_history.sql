@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE merch_price_history (
+    id SERIAL PRIMARY KEY,
+    merch_id INT NOT NULL REFERENCES merch(id) ON DELETE CASCADE,
+    price INT NOT NULL CHECK (price > 0),
+    changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    valid_from TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_merch_price_history_merch_id ON merch_price_history (merch_id, valid_from);
+
+-- Текущие цены каталога становятся начальными записями истории
+INSERT INTO merch_price_history (merch_id, price)
+SELECT id, price FROM merch;
+
+-- +goose Down
+DROP TABLE merch_price_history;
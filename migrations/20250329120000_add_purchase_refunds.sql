@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE purchases
+    ADD COLUMN refunded_at TIMESTAMP,
+    ADD COLUMN refunded_by INT REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE purchases
+    DROP COLUMN refunded_by,
+    DROP COLUMN refunded_at;
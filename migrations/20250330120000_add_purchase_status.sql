@@ -0,0 +1,29 @@
+-- +goose Up
+ALTER TABLE purchases
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'placed'
+        CHECK (status IN ('placed', 'ready_for_pickup', 'delivered', 'cancelled'));
+
+-- Возвращённые покупки считаются отменёнными
+UPDATE purchases SET status = 'cancelled' WHERE refunded_at IS NOT NULL;
+
+CREATE TABLE purchase_status_history (
+    id SERIAL PRIMARY KEY,
+    purchase_id INT NOT NULL REFERENCES purchases(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    changed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_purchase_status_history_purchase_id ON purchase_status_history (purchase_id, changed_at);
+
+-- Существующие покупки получают начальную запись истории на момент оформления
+INSERT INTO purchase_status_history (purchase_id, status, changed_by, changed_at)
+SELECT id, 'placed', user_id, created_at FROM purchases;
+
+INSERT INTO purchase_status_history (purchase_id, status, changed_by, changed_at)
+SELECT id, 'cancelled', refunded_by, refunded_at FROM purchases WHERE refunded_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE purchase_status_history;
+
+ALTER TABLE purchases DROP COLUMN status;
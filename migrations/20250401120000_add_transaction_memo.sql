@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE transactions
+    ADD COLUMN message VARCHAR(280) NOT NULL DEFAULT '',
+    ADD COLUMN category VARCHAR(32) NOT NULL DEFAULT ''
+        CHECK (category IN ('', 'helped_me', 'great_talk', 'teamwork', 'mentoring', 'thank_you'));
+
+-- +goose Down
+ALTER TABLE transactions
+    DROP COLUMN category,
+    DROP COLUMN message;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Индексы под keyset-пагинацию истории: записи пользователя от новых к старым по (created_at, id)
+CREATE INDEX idx_transactions_sender_created_at ON transactions (sender_id, created_at DESC, id DESC);
+CREATE INDEX idx_transactions_receiver_created_at ON transactions (receiver_id, created_at DESC, id DESC);
+CREATE INDEX idx_purchases_user_created_at ON purchases (user_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX idx_purchases_user_created_at;
+DROP INDEX idx_transactions_receiver_created_at;
+DROP INDEX idx_transactions_sender_created_at;
@@ -0,0 +1,79 @@
+-- +goose Up
+CREATE TABLE ledger_entries (
+    id SERIAL PRIMARY KEY,
+    reason VARCHAR(20) NOT NULL
+        CHECK (reason IN ('grant', 'transfer', 'purchase', 'refund', 'opening_balance')),
+    reference_id INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE ledger_postings (
+    id SERIAL PRIMARY KEY,
+    entry_id INT NOT NULL REFERENCES ledger_entries(id),
+    account VARCHAR(64) NOT NULL,
+    -- Для счетов пользователей; без внешнего ключа, чтобы журнал не зависел от удаления пользователей
+    user_id INT,
+    delta INT NOT NULL CHECK (delta <> 0),
+    CHECK ((user_id IS NOT NULL) = (account LIKE 'user:%'))
+);
+
+CREATE INDEX idx_ledger_postings_entry_id ON ledger_postings (entry_id);
+CREATE INDEX idx_ledger_postings_user_id ON ledger_postings (user_id) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_ledger_entries_reference ON ledger_entries (reason, reference_id);
+
+-- Журнал только дополняется: изменение и удаление записей запрещены
+-- +goose StatementBegin
+CREATE FUNCTION ledger_reject_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER ledger_entries_append_only BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
+CREATE TRIGGER ledger_postings_append_only BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
+
+-- Сумма движений каждой проводки проверяется при фиксации транзакции, когда записаны все её движения
+-- +goose StatementBegin
+CREATE FUNCTION ledger_check_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT SUM(delta) FROM ledger_postings WHERE entry_id = NEW.entry_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger entry % is not balanced', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_balanced();
+
+-- Текущие балансы переносятся в журнал как начальные остатки, выпущенные со счёта эмиссии
+INSERT INTO ledger_entries (reason, reference_id)
+SELECT 'opening_balance', id FROM users WHERE balance <> 0;
+
+INSERT INTO ledger_postings (entry_id, account, user_id, delta)
+SELECT e.id, 'user:' || u.id, u.id, u.balance
+FROM ledger_entries e
+JOIN users u ON u.id = e.reference_id
+WHERE e.reason = 'opening_balance';
+
+INSERT INTO ledger_postings (entry_id, account, user_id, delta)
+SELECT e.id, 'system:issuance', NULL, -u.balance
+FROM ledger_entries e
+JOIN users u ON u.id = e.reference_id
+WHERE e.reason = 'opening_balance';
+
+-- Стартовые монеты теперь начисляются проводкой при регистрации
+ALTER TABLE users ALTER COLUMN balance SET DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE users ALTER COLUMN balance SET DEFAULT 1000;
+
+DROP TABLE ledger_postings;
+DROP TABLE ledger_entries;
+DROP FUNCTION ledger_check_balanced();
+DROP FUNCTION ledger_reject_change();
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Списание выполняется условным UPDATE; ограничение - последняя защита от отрицательного баланса
+ALTER TABLE users ADD CONSTRAINT users_balance_non_negative CHECK (balance >= 0);
+
+-- +goose Down
+ALTER TABLE users DROP CONSTRAINT users_balance_non_negative;
@@ -1,51 +1,167 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"math"
 	"time"
 )
 
+const (
+	refreshTokenBytes = 32
+	tokenIDBytes      = 16
+
+	// DefaultRole - роль для токенов без claim role
+	DefaultRole = "employee"
+)
+
+// Claims - проверенные данные access-токена
+type Claims struct {
+	UserID    int
+	Role      string
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
 type TokenService interface {
-	GenerateToken(userID int, secretKey string, expirationTime int) (string, error)
-	ParseJWTToken(tokenString, secretKey string) (int, error)
+	GenerateToken(userID int, role string, expirationTime int) (string, error)
+	ParseJWTToken(tokenString string) (*Claims, error)
+	JWKS() JWKS
+	GenerateRefreshToken() (string, error)
+	HashRefreshToken(token string) string
 }
 
 type TokenServiceImpl struct {
+	keys *KeyRing
 }
 
-func NewTokenService() TokenService {
-	return &TokenServiceImpl{}
+func NewTokenService(keys *KeyRing) TokenService {
+	return &TokenServiceImpl{keys: keys}
 }
 
-func (t *TokenServiceImpl) GenerateToken(userID int, secretKey string, expirationTime int) (string, error) {
+func (t *TokenServiceImpl) GenerateToken(userID int, role string, expirationTime int) (string, error) {
 	now := time.Now()
 
 	expiration := now.Add(time.Duration(expirationTime) * time.Second)
 
+	tokenID, err := randomString(tokenIDBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	// iat с миллисекундами, чтобы токен, выпущенный сразу после logout-all, не попал под отзыв
 	claims := jwt.MapClaims{
 		"user_id": userID,
+		"role":    role,
+		"jti":     tokenID,
+		"iat":     float64(now.UnixMilli()) / 1000,
 		"exp":     expiration.Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	key := t.keys.Active()
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID
 
-	return token.SignedString([]byte(secretKey))
+	return token.SignedString(key.signKey)
 }
 
-func (t *TokenServiceImpl) ParseJWTToken(tokenString, secretKey string) (int, error) {
+func (t *TokenServiceImpl) ParseJWTToken(tokenString string) (*Claims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
+		keyID := LegacyKeyID
+		if kid, ok := token.Header["kid"]; ok {
+			keyID, ok = kid.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: malformed kid", ErrUnknownKey)
+			}
+		}
+
+		key, err := t.keys.Lookup(keyID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Алгоритм задаёт ключ, а не заголовок токена
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method %q for key %q", token.Method.Alg(), key.ID)
+		}
+
+		return key.verifyKey, nil
 	})
 
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	userID, ok := mapClaims["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid token: missing user_id")
+	}
+
+	// Токены, выпущенные до появления ролей, получают минимальные права
+	role, _ := mapClaims["role"].(string)
+	if role == "" {
+		role = DefaultRole
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := int(claims["user_id"].(float64))
-		return userID, nil
+	tokenID, ok := mapClaims["jti"].(string)
+	if !ok || tokenID == "" {
+		return nil, fmt.Errorf("invalid token: missing jti")
+	}
+
+	issuedAt, ok := mapClaims["iat"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid token: missing iat")
+	}
+
+	expiresAt, err := mapClaims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return nil, fmt.Errorf("invalid token: missing exp")
+	}
+
+	return &Claims{
+		UserID:    int(userID),
+		Role:      role,
+		TokenID:   tokenID,
+		IssuedAt:  time.UnixMilli(int64(math.Round(issuedAt * 1000))),
+		ExpiresAt: expiresAt.Time,
+	}, nil
+}
+
+func (t *TokenServiceImpl) JWKS() JWKS {
+	return t.keys.JWKS()
+}
+
+// GenerateRefreshToken возвращает непрозрачный случайный токен; в базе хранится только его хеш
+func (t *TokenServiceImpl) GenerateRefreshToken() (string, error) {
+	token, err := randomString(refreshTokenBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (t *TokenServiceImpl) HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
 
-	return 0, fmt.Errorf("invalid token")
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
@@ -0,0 +1,235 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newHMACService(t *testing.T, secret string) TokenService {
+	key, err := NewHMACKey(LegacyKeyID, secret)
+	require.NoError(t, err)
+
+	ring, err := NewKeyRing(LegacyKeyID, key)
+	require.NoError(t, err)
+
+	return NewTokenService(ring)
+}
+
+func TestTokenService_GenerateAndParse(t *testing.T) {
+	service := newHMACService(t, "secret")
+	before := time.Now().Truncate(time.Millisecond)
+
+	token, err := service.GenerateToken(42, "admin", 60)
+	assert.NoError(t, err)
+
+	claims, err := service.ParseJWTToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, claims.UserID)
+	assert.Equal(t, "admin", claims.Role)
+	assert.NotEmpty(t, claims.TokenID)
+	assert.False(t, claims.IssuedAt.Before(before))
+	assert.WithinDuration(t, time.Now().Add(time.Minute), claims.ExpiresAt, 2*time.Second)
+}
+
+func TestTokenService_GenerateSetsKeyID(t *testing.T) {
+	service := newHMACService(t, "secret")
+
+	token, err := service.GenerateToken(1, "employee", 60)
+	assert.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, LegacyKeyID, parsed.Header["kid"])
+	assert.Equal(t, AlgorithmHS256, parsed.Header["alg"])
+}
+
+func TestTokenService_UniqueTokenID(t *testing.T) {
+	service := newHMACService(t, "secret")
+
+	first, err := service.GenerateToken(1, "employee", 60)
+	assert.NoError(t, err)
+	second, err := service.GenerateToken(1, "employee", 60)
+	assert.NoError(t, err)
+
+	firstClaims, err := service.ParseJWTToken(first)
+	assert.NoError(t, err)
+	secondClaims, err := service.ParseJWTToken(second)
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, firstClaims.TokenID, secondClaims.TokenID)
+}
+
+func TestTokenService_ParseInvalidSignature(t *testing.T) {
+	token, err := newHMACService(t, "secret").GenerateToken(1, "employee", 60)
+	assert.NoError(t, err)
+
+	claims, err := newHMACService(t, "other-secret").ParseJWTToken(token)
+	assert.ErrorIs(t, err, jwt.ErrSignatureInvalid)
+	assert.Nil(t, claims)
+}
+
+func TestTokenService_ParseMissingTokenID(t *testing.T) {
+	service := newHMACService(t, "secret")
+
+	// Токен старого формата без jti
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	})
+	token, err := legacy.SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	claims, err := service.ParseJWTToken(token)
+	assert.Error(t, err)
+	assert.Nil(t, claims)
+}
+
+func TestTokenService_ParseWithoutKeyIDUsesLegacyKey(t *testing.T) {
+	service := newHMACService(t, "secret")
+
+	// Токен, выпущенный до появления kid в заголовке
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 7,
+		"jti":     "legacy-jti",
+		"iat":     time.Now().Unix(),
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	})
+	token, err := legacy.SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	claims, err := service.ParseJWTToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, claims.UserID)
+	assert.Equal(t, DefaultRole, claims.Role)
+}
+
+func TestTokenService_ParseUnknownKeyID(t *testing.T) {
+	old, err := NewHMACKey("old", "secret")
+	require.NoError(t, err)
+	oldRing, err := NewKeyRing("old", old)
+	require.NoError(t, err)
+
+	token, err := NewTokenService(oldRing).GenerateToken(1, "employee", 60)
+	assert.NoError(t, err)
+
+	claims, err := newHMACService(t, "secret").ParseJWTToken(token)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Nil(t, claims)
+}
+
+func TestTokenService_RotationGracePeriod(t *testing.T) {
+	oldKey, err := NewHMACKey("2025-01", "old-secret")
+	require.NoError(t, err)
+	oldRing, err := NewKeyRing("2025-01", oldKey)
+	require.NoError(t, err)
+
+	oldToken, err := NewTokenService(oldRing).GenerateToken(1, "employee", 3600)
+	require.NoError(t, err)
+
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	newKey := &SigningKey{
+		ID:        "2025-02",
+		Algorithm: AlgorithmEdDSA,
+		method:    jwt.SigningMethodEdDSA,
+		signKey:   edPrivate,
+		verifyKey: edPrivate.Public(),
+	}
+
+	retired, err := NewHMACKey("2025-01", "old-secret")
+	require.NoError(t, err)
+	retired.VerifyUntil = time.Now().Add(time.Hour)
+
+	ring, err := NewKeyRing("2025-02", newKey, retired)
+	require.NoError(t, err)
+	service := NewTokenService(ring)
+
+	// В период отсрочки старый токен ещё принимается, новые подписываются новым ключом
+	claims, err := service.ParseJWTToken(oldToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, claims.UserID)
+
+	newToken, err := service.GenerateToken(2, "employee", 60)
+	assert.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-02", parsed.Header["kid"])
+
+	// После окончания отсрочки старый ключ больше не проверяет токены
+	ring.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	claims, err = service.ParseJWTToken(oldToken)
+	assert.ErrorIs(t, err, ErrKeyRetired)
+	assert.Nil(t, claims)
+}
+
+func TestTokenService_AsymmetricAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys := []*SigningKey{
+		{ID: "rsa", Algorithm: AlgorithmRS256, method: jwt.SigningMethodRS256, signKey: rsaKey, verifyKey: &rsaKey.PublicKey},
+		{ID: "ed", Algorithm: AlgorithmEdDSA, method: jwt.SigningMethodEdDSA, signKey: edKey, verifyKey: edKey.Public()},
+	}
+
+	for _, key := range keys {
+		t.Run(key.Algorithm, func(t *testing.T) {
+			ring, err := NewKeyRing(key.ID, key)
+			require.NoError(t, err)
+			service := NewTokenService(ring)
+
+			token, err := service.GenerateToken(5, "employee", 60)
+			assert.NoError(t, err)
+
+			claims, err := service.ParseJWTToken(token)
+			assert.NoError(t, err)
+			assert.Equal(t, 5, claims.UserID)
+		})
+	}
+}
+
+func TestTokenService_ParseAlgorithmMismatch(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key := &SigningKey{ID: "ed", Algorithm: AlgorithmEdDSA, method: jwt.SigningMethodEdDSA, signKey: edKey, verifyKey: edKey.Public()}
+
+	ring, err := NewKeyRing("ed", key)
+	require.NoError(t, err)
+
+	// HS256-токен с kid асимметричного ключа должен отклоняться
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": 1,
+		"jti":     "forged",
+		"iat":     time.Now().Unix(),
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	})
+	forged.Header["kid"] = "ed"
+	token, err := forged.SignedString([]byte(edKey.Public().(ed25519.PublicKey)))
+	require.NoError(t, err)
+
+	claims, err := NewTokenService(ring).ParseJWTToken(token)
+	assert.Error(t, err)
+	assert.Nil(t, claims)
+}
+
+func TestTokenService_RefreshToken(t *testing.T) {
+	service := newHMACService(t, "secret")
+
+	first, err := service.GenerateRefreshToken()
+	assert.NoError(t, err)
+	second, err := service.GenerateRefreshToken()
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, service.HashRefreshToken(first), service.HashRefreshToken(first))
+	assert.NotEqual(t, service.HashRefreshToken(first), service.HashRefreshToken(second))
+	assert.Len(t, service.HashRefreshToken(first), 64)
+}
@@ -0,0 +1,227 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"sort"
+	"time"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	// LegacyKeyID - kid ключа, которым проверяются токены без заголовка kid (выпущенные до ротации ключей)
+	LegacyKeyID = "default"
+
+	minRSAKeyBits = 2048
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown signing key")
+	ErrKeyRetired = errors.New("signing key retired")
+)
+
+// SigningKey - ключ подписи с идентификатором kid.
+// Ненулевой VerifyUntil означает, что ключ выведен из ротации: до этого момента
+// им ещё проверяются выпущенные ранее токены, после - токены с этим kid отклоняются.
+type SigningKey struct {
+	ID          string
+	Algorithm   string
+	VerifyUntil time.Time
+
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKey создаёт симметричный ключ HS256. Такие ключи не публикуются в JWKS
+func NewHMACKey(id, secret string) (*SigningKey, error) {
+	if id == "" {
+		return nil, fmt.Errorf("signing key id is required")
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("signing key %q: empty secret", id)
+	}
+
+	return &SigningKey{
+		ID:        id,
+		Algorithm: AlgorithmHS256,
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}, nil
+}
+
+// ParsePrivateKeyPEM создаёт асимметричный ключ RS256 или EdDSA из приватного ключа в PEM (PKCS#8 или PKCS#1 для RSA)
+func ParsePrivateKeyPEM(id, algorithm string, pemData []byte) (*SigningKey, error) {
+	if id == "" {
+		return nil, fmt.Errorf("signing key id is required")
+	}
+
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %q: no PEM block found", id)
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		rsaKey, rsaErr := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if rsaErr != nil {
+			return nil, fmt.Errorf("signing key %q: failed to parse private key: %w", id, err)
+		}
+		privateKey = rsaKey
+	}
+
+	switch algorithm {
+	case AlgorithmRS256:
+		rsaKey, ok := privateKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key %q: %s requires an RSA private key", id, algorithm)
+		}
+		if rsaKey.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("signing key %q: RSA key must be at least %d bits", id, minRSAKeyBits)
+		}
+		return &SigningKey{
+			ID:        id,
+			Algorithm: algorithm,
+			method:    jwt.SigningMethodRS256,
+			signKey:   rsaKey,
+			verifyKey: &rsaKey.PublicKey,
+		}, nil
+	case AlgorithmEdDSA:
+		edKey, ok := privateKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key %q: %s requires an Ed25519 private key", id, algorithm)
+		}
+		return &SigningKey{
+			ID:        id,
+			Algorithm: algorithm,
+			method:    jwt.SigningMethodEdDSA,
+			signKey:   edKey,
+			verifyKey: edKey.Public(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("signing key %q: unsupported algorithm %q", id, algorithm)
+	}
+}
+
+// JSONWebKey - публичный ключ в формате RFC 7517
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// KeyRing - набор ключей подписи: активным ключом подписываются новые токены,
+// остальными только проверяются ранее выпущенные
+type KeyRing struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+	now    func() time.Time
+}
+
+func NewKeyRing(activeKeyID string, keys ...*SigningKey) (*KeyRing, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("key ring must contain at least one signing key")
+	}
+
+	ring := &KeyRing{
+		keys: make(map[string]*SigningKey, len(keys)),
+		now:  time.Now,
+	}
+
+	for _, key := range keys {
+		if _, exists := ring.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate signing key id %q", key.ID)
+		}
+		ring.keys[key.ID] = key
+	}
+
+	active, ok := ring.keys[activeKeyID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q not found", activeKeyID)
+	}
+	if !active.VerifyUntil.IsZero() {
+		return nil, fmt.Errorf("active signing key %q cannot be retired", activeKeyID)
+	}
+	ring.active = active
+
+	return ring, nil
+}
+
+// Active возвращает ключ, которым подписываются новые токены
+func (r *KeyRing) Active() *SigningKey {
+	return r.active
+}
+
+// Lookup возвращает ключ для проверки подписи; выведенный из ротации ключ доступен только до VerifyUntil
+func (r *KeyRing) Lookup(keyID string) (*SigningKey, error) {
+	key, ok := r.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+	}
+
+	if !key.VerifyUntil.IsZero() && !r.now().Before(key.VerifyUntil) {
+		return nil, fmt.Errorf("%w: %q", ErrKeyRetired, keyID)
+	}
+
+	return key, nil
+}
+
+// JWKS возвращает публичные части асимметричных ключей, которыми ещё можно проверять токены
+func (r *KeyRing) JWKS() JWKS {
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	jwks := JWKS{Keys: make([]JSONWebKey, 0, len(ids))}
+	for _, id := range ids {
+		key, err := r.Lookup(id)
+		if err != nil {
+			continue
+		}
+
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JSONWebKey{
+				KeyType:   "RSA",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Algorithm,
+				N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JSONWebKey{
+				KeyType:   "OKP",
+				KeyID:     key.ID,
+				Use:       "sig",
+				Algorithm: key.Algorithm,
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	return jwks
+}
@@ -0,0 +1,133 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func encodePKCS8(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func TestParsePrivateKeyPEM_RSA(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	key, err := ParsePrivateKeyPEM("rsa", AlgorithmRS256, encodePKCS8(t, rsaKey))
+	assert.NoError(t, err)
+	assert.Equal(t, "rsa", key.ID)
+	assert.Equal(t, AlgorithmRS256, key.Algorithm)
+
+	// PKCS#1 тоже поддерживается
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	_, err = ParsePrivateKeyPEM("rsa", AlgorithmRS256, pkcs1)
+	assert.NoError(t, err)
+}
+
+func TestParsePrivateKeyPEM_EdDSA(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	key, err := ParsePrivateKeyPEM("ed", AlgorithmEdDSA, encodePKCS8(t, edKey))
+	assert.NoError(t, err)
+	assert.Equal(t, AlgorithmEdDSA, key.Algorithm)
+}
+
+func TestParsePrivateKeyPEM_Errors(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	weakRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		algorithm string
+		pem       []byte
+	}{
+		{name: "not a PEM", algorithm: AlgorithmEdDSA, pem: []byte("garbage")},
+		{name: "algorithm mismatch", algorithm: AlgorithmRS256, pem: encodePKCS8(t, edKey)},
+		{name: "weak RSA key", algorithm: AlgorithmRS256, pem: encodePKCS8(t, weakRSA)},
+		{name: "unsupported algorithm", algorithm: "ES256", pem: encodePKCS8(t, edKey)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParsePrivateKeyPEM("key", tt.algorithm, tt.pem)
+			assert.Error(t, err)
+			assert.Nil(t, key)
+		})
+	}
+}
+
+func TestNewKeyRing_Errors(t *testing.T) {
+	first, err := NewHMACKey("first", "secret")
+	require.NoError(t, err)
+	duplicate, err := NewHMACKey("first", "other")
+	require.NoError(t, err)
+	retired, err := NewHMACKey("retired", "secret")
+	require.NoError(t, err)
+	retired.VerifyUntil = time.Now().Add(time.Hour)
+
+	_, err = NewKeyRing("first")
+	assert.Error(t, err)
+
+	_, err = NewKeyRing("first", first, duplicate)
+	assert.Error(t, err)
+
+	_, err = NewKeyRing("missing", first)
+	assert.Error(t, err)
+
+	_, err = NewKeyRing("retired", first, retired)
+	assert.Error(t, err)
+}
+
+func TestKeyRing_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaSigningKey, err := ParsePrivateKeyPEM("rsa", AlgorithmRS256, encodePKCS8(t, rsaKey))
+	require.NoError(t, err)
+	edSigningKey, err := ParsePrivateKeyPEM("ed", AlgorithmEdDSA, encodePKCS8(t, edPrivate))
+	require.NoError(t, err)
+	hmacKey, err := NewHMACKey(LegacyKeyID, "secret")
+	require.NoError(t, err)
+	expiredKey, err := ParsePrivateKeyPEM("expired", AlgorithmEdDSA, encodePKCS8(t, edPrivate))
+	require.NoError(t, err)
+	expiredKey.VerifyUntil = time.Now().Add(-time.Minute)
+
+	ring, err := NewKeyRing("ed", edSigningKey, rsaSigningKey, hmacKey, expiredKey)
+	require.NoError(t, err)
+
+	jwks := ring.JWKS()
+
+	// Симметричные и выведенные из ротации ключи не публикуются
+	assert.Len(t, jwks.Keys, 2)
+
+	assert.Equal(t, JSONWebKey{
+		KeyType:   "OKP",
+		KeyID:     "ed",
+		Use:       "sig",
+		Algorithm: AlgorithmEdDSA,
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(edPublic),
+	}, jwks.Keys[0])
+
+	assert.Equal(t, "RSA", jwks.Keys[1].KeyType)
+	assert.Equal(t, "rsa", jwks.Keys[1].KeyID)
+	assert.Equal(t, AlgorithmRS256, jwks.Keys[1].Algorithm)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()), jwks.Keys[1].N)
+}
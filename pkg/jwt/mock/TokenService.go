@@ -2,16 +2,48 @@
 
 package mock
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	jwt "avito-tech-merch/pkg/jwt"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // TokenService is an autogenerated mock type for the TokenService type
 type TokenService struct {
 	mock.Mock
 }
 
-// GenerateToken provides a mock function with given fields: userID, secretKey, expirationTime
-func (_m *TokenService) GenerateToken(userID int, secretKey string, expirationTime int) (string, error) {
-	ret := _m.Called(userID, secretKey, expirationTime)
+// GenerateRefreshToken provides a mock function with no fields
+func (_m *TokenService) GenerateRefreshToken() (string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateRefreshToken")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateToken provides a mock function with given fields: userID, role, expirationTime
+func (_m *TokenService) GenerateToken(userID int, role string, expirationTime int) (string, error) {
+	ret := _m.Called(userID, role, expirationTime)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GenerateToken")
@@ -20,16 +52,16 @@ func (_m *TokenService) GenerateToken(userID int, secretKey string, expirationTi
 	var r0 string
 	var r1 error
 	if rf, ok := ret.Get(0).(func(int, string, int) (string, error)); ok {
-		return rf(userID, secretKey, expirationTime)
+		return rf(userID, role, expirationTime)
 	}
 	if rf, ok := ret.Get(0).(func(int, string, int) string); ok {
-		r0 = rf(userID, secretKey, expirationTime)
+		r0 = rf(userID, role, expirationTime)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	if rf, ok := ret.Get(1).(func(int, string, int) error); ok {
-		r1 = rf(userID, secretKey, expirationTime)
+		r1 = rf(userID, role, expirationTime)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -37,27 +69,65 @@ func (_m *TokenService) GenerateToken(userID int, secretKey string, expirationTi
 	return r0, r1
 }
 
-// ParseJWTToken provides a mock function with given fields: tokenString, secretKey
-func (_m *TokenService) ParseJWTToken(tokenString string, secretKey string) (int, error) {
-	ret := _m.Called(tokenString, secretKey)
+// HashRefreshToken provides a mock function with given fields: token
+func (_m *TokenService) HashRefreshToken(token string) string {
+	ret := _m.Called(token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HashRefreshToken")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// JWKS provides a mock function with no fields
+func (_m *TokenService) JWKS() jwt.JWKS {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for JWKS")
+	}
+
+	var r0 jwt.JWKS
+	if rf, ok := ret.Get(0).(func() jwt.JWKS); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(jwt.JWKS)
+	}
+
+	return r0
+}
+
+// ParseJWTToken provides a mock function with given fields: tokenString
+func (_m *TokenService) ParseJWTToken(tokenString string) (*jwt.Claims, error) {
+	ret := _m.Called(tokenString)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ParseJWTToken")
 	}
 
-	var r0 int
+	var r0 *jwt.Claims
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (int, error)); ok {
-		return rf(tokenString, secretKey)
+	if rf, ok := ret.Get(0).(func(string) (*jwt.Claims, error)); ok {
+		return rf(tokenString)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) int); ok {
-		r0 = rf(tokenString, secretKey)
+	if rf, ok := ret.Get(0).(func(string) *jwt.Claims); ok {
+		r0 = rf(tokenString)
 	} else {
-		r0 = ret.Get(0).(int)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*jwt.Claims)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(tokenString, secretKey)
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tokenString)
 	} else {
 		r1 = ret.Error(1)
 	}
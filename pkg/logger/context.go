@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"sync/atomic"
+)
+
+// Ключи полей корреляции, которые middleware кладёт в контекст запроса
+const (
+	RequestIDKey = "request_id"
+	UserIDKey    = "user_id"
+	RouteKey     = "route"
+)
+
+type fieldsKey struct{}
+
+var defaultLogger atomic.Value
+
+// SetDefault задаёт логгер, к которому привязывается FromContext
+func SetDefault(l Logger) {
+	defaultLogger.Store(&l)
+}
+
+// Default возвращает логгер, заданный через SetDefault, или логгер, который ничего не пишет
+func Default() Logger {
+	if l, ok := defaultLogger.Load().(*Logger); ok {
+		return *l
+	}
+	return &ZapLogger{logger: zap.NewNop(), sugar: zap.NewNop().Sugar()}
+}
+
+// NewContext возвращает контекст, в котором к уже накопленным полям корреляции добавлены keysAndValues
+func NewContext(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	parent := Fields(ctx)
+	fields := make([]interface{}, 0, len(parent)+len(keysAndValues))
+	fields = append(fields, parent...)
+	fields = append(fields, keysAndValues...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// Fields возвращает поля корреляции из контекста в виде пар ключ-значение
+func Fields(ctx context.Context) []interface{} {
+	fields, _ := ctx.Value(fieldsKey{}).([]interface{})
+	return fields
+}
+
+// Value возвращает значение поля корреляции по ключу
+func Value(ctx context.Context, key string) (interface{}, bool) {
+	fields := Fields(ctx)
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == key {
+			return fields[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// FromContext возвращает логгер по умолчанию, который добавляет к каждой записи поля корреляции из ctx
+func FromContext(ctx context.Context) Logger {
+	return WithContext(ctx, Default())
+}
+
+// WithContext возвращает логгер, который добавляет к каждой записи base поля корреляции из ctx.
+// Если полей нет, возвращается сам base
+func WithContext(ctx context.Context, base Logger) Logger {
+	fields := Fields(ctx)
+	if len(fields) == 0 {
+		return base
+	}
+	return &contextLogger{base: base, fields: fields}
+}
+
+type contextLogger struct {
+	base   Logger
+	fields []interface{}
+}
+
+func (l *contextLogger) Debug(msg string, fields ...zap.Field) {
+	l.base.Debug(msg, l.zapFields(fields)...)
+}
+
+func (l *contextLogger) Info(msg string, fields ...zap.Field) {
+	l.base.Info(msg, l.zapFields(fields)...)
+}
+
+func (l *contextLogger) Warn(msg string, fields ...zap.Field) {
+	l.base.Warn(msg, l.zapFields(fields)...)
+}
+
+func (l *contextLogger) Error(msg string, fields ...zap.Field) {
+	l.base.Error(msg, l.zapFields(fields)...)
+}
+
+func (l *contextLogger) Fatal(msg string, fields ...zap.Field) {
+	l.base.Fatal(msg, l.zapFields(fields)...)
+}
+
+func (l *contextLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.base.Debugw(msg, l.keysAndValues(keysAndValues)...)
+}
+
+func (l *contextLogger) Infow(msg string, keysAndValues ...interface{}) {
+	l.base.Infow(msg, l.keysAndValues(keysAndValues)...)
+}
+
+func (l *contextLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.base.Warnw(msg, l.keysAndValues(keysAndValues)...)
+}
+
+func (l *contextLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.base.Errorw(msg, l.keysAndValues(keysAndValues)...)
+}
+
+func (l *contextLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	l.base.Fatalw(msg, l.keysAndValues(keysAndValues)...)
+}
+
+func (l *contextLogger) Sync() {
+	l.base.Sync()
+}
+
+// Поля корреляции идут первыми, чтобы у всех записей запроса они стояли в одном месте
+func (l *contextLogger) keysAndValues(keysAndValues []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(l.fields)+len(keysAndValues))
+	result = append(result, l.fields...)
+	return append(result, keysAndValues...)
+}
+
+func (l *contextLogger) zapFields(fields []zap.Field) []zap.Field {
+	result := make([]zap.Field, 0, len(l.fields)/2+len(fields))
+	for i := 0; i+1 < len(l.fields); i += 2 {
+		if key, ok := l.fields[i].(string); ok {
+			result = append(result, zap.Any(key, l.fields[i+1]))
+		}
+	}
+	return append(result, fields...)
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+)
+
+func newObservedLogger() (Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(core)
+	return &ZapLogger{logger: log, sugar: log.Sugar()}, logs
+}
+
+func TestWithContext_AddsFields(t *testing.T) {
+	base, logs := newObservedLogger()
+	ctx := NewContext(context.Background(), RequestIDKey, "req-1", RouteKey, "POST /api/send-coin")
+	ctx = NewContext(ctx, UserIDKey, 7)
+
+	WithContext(ctx, base).Errorw("Failed to transfer coins", "error", "boom")
+	WithContext(ctx, base).Info("Transfer completed", zap.Int("amount", 10))
+
+	entries := logs.All()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, map[string]interface{}{
+		RequestIDKey: "req-1",
+		RouteKey:     "POST /api/send-coin",
+		UserIDKey:    int64(7),
+		"error":      "boom",
+	}, entries[0].ContextMap())
+	assert.Equal(t, map[string]interface{}{
+		RequestIDKey: "req-1",
+		RouteKey:     "POST /api/send-coin",
+		UserIDKey:    int64(7),
+		"amount":     int64(10),
+	}, entries[1].ContextMap())
+}
+
+func TestWithContext_WithoutFieldsReturnsBase(t *testing.T) {
+	base, _ := newObservedLogger()
+	assert.Same(t, base, WithContext(context.Background(), base))
+}
+
+func TestNewContext_DoesNotChangeParent(t *testing.T) {
+	parent := NewContext(context.Background(), RequestIDKey, "req-1")
+	_ = NewContext(parent, UserIDKey, 1)
+
+	assert.Equal(t, []interface{}{RequestIDKey, "req-1"}, Fields(parent))
+
+	id, ok := Value(parent, RequestIDKey)
+	assert.True(t, ok)
+	assert.Equal(t, "req-1", id)
+
+	_, ok = Value(parent, UserIDKey)
+	assert.False(t, ok)
+}
+
+func TestFromContext_UsesDefault(t *testing.T) {
+	previous := Default()
+	t.Cleanup(func() { SetDefault(previous) })
+
+	base, logs := newObservedLogger()
+	SetDefault(base)
+
+	FromContext(NewContext(context.Background(), RequestIDKey, "req-2")).Warnw("Unhandled error")
+
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, map[string]interface{}{RequestIDKey: "req-2"}, logs.All()[0].ContextMap())
+}
@@ -0,0 +1,92 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+func (s *TestSuite) adminToken(username string) string {
+	s.registerUser(username)
+	s.promoteToAdmin(username)
+
+	loginBody, err := json.Marshal(dto.LoginRequest{Username: username, Password: "password"})
+	s.Require().NoError(err)
+	resp, err := s.server.Client().Post(s.server.URL+"/api/auth/login", "application/json", bytes.NewBuffer(loginBody))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var authResp dto.AuthResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&authResp))
+	return authResp.Token
+}
+
+func (s *TestSuite) listMerch(token string) []dto.MerchDTO {
+	req, err := http.NewRequest("GET", s.server.URL+"/api/merch", nil)
+	s.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var merchList []dto.MerchDTO
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&merchList))
+	return merchList
+}
+
+// TestAdminMerchIntegration проверяет жизненный цикл товара: создание, покупка, архивирование
+func (s *TestSuite) TestAdminMerchIntegration() {
+	admin := s.adminToken("catalog_admin")
+	employee := s.registerUser("catalog_employee")
+
+	body, err := json.Marshal(dto.CreateMerchRequest{Name: "sticker", Price: 5})
+	s.Require().NoError(err)
+	resp := s.authorizedPost("/api/admin/merch", admin, body)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+	var created dto.MerchDTO
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&created))
+	s.Require().Equal("sticker", created.Name)
+
+	// Сотрудник не может управлять каталогом
+	forbidden := s.authorizedPost("/api/admin/merch", employee.Token, body)
+	forbidden.Body.Close()
+	s.Require().Equal(http.StatusForbidden, forbidden.StatusCode)
+
+	buyResp := s.authorizedPost("/api/merch/buy/sticker", employee.Token, nil)
+	buyResp.Body.Close()
+	s.Require().Equal(http.StatusOK, buyResp.StatusCode)
+
+	archiveResp := s.authorizedPost("/api/admin/merch/"+strconv.Itoa(created.ID)+"/archive", admin, nil)
+	archiveResp.Body.Close()
+	s.Require().Equal(http.StatusOK, archiveResp.StatusCode)
+
+	for _, merch := range s.listMerch(employee.Token) {
+		s.Require().NotEqual("sticker", merch.Name)
+	}
+
+	buyResp = s.authorizedPost("/api/merch/buy/sticker", employee.Token, nil)
+	buyResp.Body.Close()
+	s.Require().NotEqual(http.StatusOK, buyResp.StatusCode)
+
+	// Прошлая покупка архивного товара остаётся в истории
+	req, err := http.NewRequest("GET", s.server.URL+"/api/info", nil)
+	s.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer "+employee.Token)
+	infoResp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer infoResp.Body.Close()
+
+	var info dto.UserInfoResponse
+	s.Require().NoError(json.NewDecoder(infoResp.Body).Decode(&info))
+	s.Require().Equal([]*dto.InventoryItemDTO{{Type: created.Name, Quantity: 1}}, info.Inventory)
+	s.Require().Equal(995, info.Balance)
+}
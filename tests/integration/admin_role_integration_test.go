@@ -0,0 +1,81 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+)
+
+func (s *TestSuite) promoteToAdmin(username string) {
+	db, err := sql.Open("postgres", s.psqlContainer.GetDSN())
+	s.Require().NoError(err)
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE users SET role = 'admin' WHERE username = $1`, username)
+	s.Require().NoError(err)
+}
+
+func (s *TestSuite) setUserRole(token string, userID string, role string) *http.Response {
+	body, err := json.Marshal(dto.SetUserRoleRequest{Role: role})
+	s.Require().NoError(err)
+
+	req, err := http.NewRequest("PUT", s.server.URL+"/api/admin/users/"+userID+"/role", bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	return resp
+}
+
+// TestAdminRoleIntegration проверяет, что admin-маршруты закрыты для сотрудников,
+// а выданная роль попадает в токен после обмена refresh-токена
+func (s *TestSuite) TestAdminRoleIntegration() {
+	employee := s.registerUser("role_employee")
+
+	resp := s.setUserRole(employee.Token, "1", "admin")
+	resp.Body.Close()
+	s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+
+	s.registerUser("role_admin")
+	s.promoteToAdmin("role_admin")
+
+	// Роль читается из базы при входе
+	loginBody, err := json.Marshal(dto.LoginRequest{Username: "role_admin", Password: "password"})
+	s.Require().NoError(err)
+	loginResp, err := s.server.Client().Post(s.server.URL+"/api/auth/login", "application/json", bytes.NewBuffer(loginBody))
+	s.Require().NoError(err)
+	defer loginResp.Body.Close()
+	s.Require().Equal(http.StatusOK, loginResp.StatusCode)
+
+	var admin dto.AuthResponse
+	s.Require().NoError(json.NewDecoder(loginResp.Body).Decode(&admin))
+
+	resp = s.setUserRole(admin.Token, "1", "admin")
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var roleResp dto.UserRoleResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&roleResp))
+	s.Require().Equal("role_employee", roleResp.Username)
+	s.Require().Equal("admin", roleResp.Role)
+
+	// Старый access-токен повышенного пользователя отозван, новая роль приходит с refresh
+	s.Require().Equal(http.StatusUnauthorized, s.getInfoStatus(employee.Token))
+
+	refreshResp := s.refreshTokens(employee.RefreshToken)
+	defer refreshResp.Body.Close()
+	s.Require().Equal(http.StatusOK, refreshResp.StatusCode)
+
+	var refreshed dto.AuthResponse
+	s.Require().NoError(json.NewDecoder(refreshResp.Body).Decode(&refreshed))
+
+	resp = s.setUserRole(refreshed.Token, "2", "employee")
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,30 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+)
+
+// TestJWKSIntegration проверяет, что токены подписываются с kid, а JWKS не раскрывает симметричные ключи
+func (s *TestSuite) TestJWKSIntegration() {
+	authResp := s.registerUser("jwks_user")
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(authResp.Token, jwt.MapClaims{})
+	s.Require().NoError(err)
+	s.Require().NotEmpty(parsed.Header["kid"])
+
+	resp, err := s.server.Client().Get(s.server.URL + "/.well-known/jwks.json")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var jwks dto.JWKSResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&jwks))
+
+	// В тестовой конфигурации используется только HS256-ключ из secret_key
+	s.Require().Empty(jwks.Keys)
+}
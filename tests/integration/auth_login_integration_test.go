@@ -24,11 +24,13 @@ func (s *TestSuite) TestLoginIntegration_InvalidRequest() {
 
 	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
 
-	var errResp dto.ErrorResponse400
+	var errResp dto.Problem
 	err = json.NewDecoder(resp.Body).Decode(&errResp)
 	s.Require().NoError(err)
-	s.Require().Equal(400, errResp.Code)
-	s.Require().Equal("invalid request", errResp.Message)
+	s.Require().Equal(400, errResp.Status)
+	s.Require().Equal("urn:merch-store:problem:validation_failed", errResp.Type)
+	s.Require().Equal("request validation failed", errResp.Detail)
+	s.Require().Equal([]dto.FieldError{{Field: "password", Message: "is required"}}, errResp.Errors)
 }
 
 // TestLoginIntegration_InvalidCredentials проверяет, что при неверном пароле возвращается HTTP 401
@@ -60,11 +62,11 @@ func (s *TestSuite) TestLoginIntegration_InvalidCredentials() {
 
 	s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
 
-	var errResp dto.ErrorResponseInvalidCredentials401
+	var errResp dto.Problem
 	err = json.NewDecoder(resp.Body).Decode(&errResp)
 	s.Require().NoError(err)
-	s.Require().Equal(401, errResp.Code)
-	s.Require().Equal("invalid credentials", errResp.Message)
+	s.Require().Equal(401, errResp.Status)
+	s.Require().Equal("invalid credentials", errResp.Detail)
 }
 
 // TestLoginIntegration_Success проверяет успешный логин.
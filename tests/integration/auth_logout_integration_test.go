@@ -0,0 +1,94 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+func (s *TestSuite) registerUser(username string) dto.AuthResponse {
+	regBody, err := json.Marshal(dto.RegisterRequest{Username: username, Password: "password"})
+	s.Require().NoError(err)
+
+	regResp, err := s.server.Client().Post(s.server.URL+"/api/auth/register", "application/json", bytes.NewBuffer(regBody))
+	s.Require().NoError(err)
+	defer regResp.Body.Close()
+	s.Require().Equal(http.StatusOK, regResp.StatusCode)
+
+	var authResp dto.AuthResponse
+	s.Require().NoError(json.NewDecoder(regResp.Body).Decode(&authResp))
+	s.Require().NotEmpty(authResp.Token)
+
+	return authResp
+}
+
+func (s *TestSuite) authorizedPost(path, token string, body []byte) *http.Response {
+	req, err := http.NewRequest("POST", s.server.URL+path, bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	return resp
+}
+
+func (s *TestSuite) getInfoStatus(token string) int {
+	req, err := http.NewRequest("GET", s.server.URL+"/api/info", nil)
+	s.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+// TestLogoutIntegration_RevokesTokens проверяет, что после logout access- и refresh-токены сессии больше не принимаются
+func (s *TestSuite) TestLogoutIntegration_RevokesTokens() {
+	authResp := s.registerUser("logout_user")
+	s.Require().Equal(http.StatusOK, s.getInfoStatus(authResp.Token))
+
+	body, err := json.Marshal(dto.LogoutRequest{RefreshToken: authResp.RefreshToken})
+	s.Require().NoError(err)
+
+	resp := s.authorizedPost("/api/auth/logout", authResp.Token, body)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	s.Require().Equal(http.StatusUnauthorized, s.getInfoStatus(authResp.Token))
+
+	refreshResp := s.refreshTokens(authResp.RefreshToken)
+	defer refreshResp.Body.Close()
+	s.Require().Equal(http.StatusUnauthorized, refreshResp.StatusCode)
+}
+
+// TestLogoutAllIntegration_RevokesEverySession проверяет, что logout-all завершает все сессии пользователя
+func (s *TestSuite) TestLogoutAllIntegration_RevokesEverySession() {
+	first := s.registerUser("logout_all_user")
+
+	loginBody, err := json.Marshal(dto.LoginRequest{Username: "logout_all_user", Password: "password"})
+	s.Require().NoError(err)
+	loginResp, err := s.server.Client().Post(s.server.URL+"/api/auth/login", "application/json", bytes.NewBuffer(loginBody))
+	s.Require().NoError(err)
+	defer loginResp.Body.Close()
+	s.Require().Equal(http.StatusOK, loginResp.StatusCode)
+
+	var second dto.AuthResponse
+	s.Require().NoError(json.NewDecoder(loginResp.Body).Decode(&second))
+
+	resp := s.authorizedPost("/api/auth/logout-all", first.Token, nil)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	s.Require().Equal(http.StatusUnauthorized, s.getInfoStatus(first.Token))
+	s.Require().Equal(http.StatusUnauthorized, s.getInfoStatus(second.Token))
+
+	refreshResp := s.refreshTokens(second.RefreshToken)
+	defer refreshResp.Body.Close()
+	s.Require().Equal(http.StatusUnauthorized, refreshResp.StatusCode)
+}
@@ -0,0 +1,78 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+func (s *TestSuite) refreshTokens(refreshToken string) *http.Response {
+	reqBody, err := json.Marshal(dto.RefreshRequest{RefreshToken: refreshToken})
+	s.Require().NoError(err)
+
+	req, err := http.NewRequest("POST", s.server.URL+"/api/auth/refresh", bytes.NewBuffer(reqBody))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	return resp
+}
+
+// TestRefreshIntegration_Rotation проверяет, что refresh-токен одноразовый и обменивается на новую пару
+func (s *TestSuite) TestRefreshIntegration_Rotation() {
+	regBody, err := json.Marshal(dto.RegisterRequest{Username: "refresh_user", Password: "password"})
+	s.Require().NoError(err)
+
+	regResp, err := s.server.Client().Post(s.server.URL+"/api/auth/register", "application/json", bytes.NewBuffer(regBody))
+	s.Require().NoError(err)
+	defer regResp.Body.Close()
+	s.Require().Equal(http.StatusOK, regResp.StatusCode)
+
+	var authResp dto.AuthResponse
+	s.Require().NoError(json.NewDecoder(regResp.Body).Decode(&authResp))
+	s.Require().NotEmpty(authResp.RefreshToken)
+
+	resp := s.refreshTokens(authResp.RefreshToken)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var rotated dto.AuthResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&rotated))
+	s.Require().NotEmpty(rotated.Token)
+	s.Require().NotEqual(authResp.RefreshToken, rotated.RefreshToken)
+}
+
+// TestRefreshIntegration_ReuseRevokesFamily проверяет, что повторное использование токена отзывает всю цепочку
+func (s *TestSuite) TestRefreshIntegration_ReuseRevokesFamily() {
+	regBody, err := json.Marshal(dto.RegisterRequest{Username: "refresh_reuse_user", Password: "password"})
+	s.Require().NoError(err)
+
+	regResp, err := s.server.Client().Post(s.server.URL+"/api/auth/register", "application/json", bytes.NewBuffer(regBody))
+	s.Require().NoError(err)
+	defer regResp.Body.Close()
+	s.Require().Equal(http.StatusOK, regResp.StatusCode)
+
+	var authResp dto.AuthResponse
+	s.Require().NoError(json.NewDecoder(regResp.Body).Decode(&authResp))
+
+	first := s.refreshTokens(authResp.RefreshToken)
+	defer first.Body.Close()
+	s.Require().Equal(http.StatusOK, first.StatusCode)
+
+	var rotated dto.AuthResponse
+	s.Require().NoError(json.NewDecoder(first.Body).Decode(&rotated))
+
+	// Повторно предъявляем уже использованный токен
+	reused := s.refreshTokens(authResp.RefreshToken)
+	defer reused.Body.Close()
+	s.Require().Equal(http.StatusUnauthorized, reused.StatusCode)
+
+	// Токен, выданный при ротации, тоже должен быть отозван
+	revoked := s.refreshTokens(rotated.RefreshToken)
+	defer revoked.Body.Close()
+	s.Require().Equal(http.StatusUnauthorized, revoked.StatusCode)
+}
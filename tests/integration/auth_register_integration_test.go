@@ -63,10 +63,10 @@ func (s *TestSuite) TestRegisterIntegration_UserAlreadyExists() {
 
 	s.Require().Equal(http.StatusInternalServerError, resp.StatusCode)
 
-	var errResp dto.ErrorResponse500
+	var errResp dto.Problem
 	err = json.NewDecoder(resp.Body).Decode(&errResp)
 	s.Require().NoError(err)
-	s.Require().Contains(errResp.Message, "user already exists")
+	s.Require().Contains(errResp.Detail, "user already exists")
 }
 
 func (s *TestSuite) TestRegisterIntegration_InvalidRequest() {
@@ -81,9 +81,11 @@ func (s *TestSuite) TestRegisterIntegration_InvalidRequest() {
 
 	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
 
-	var errResp dto.ErrorResponse400
+	var errResp dto.Problem
 	err = json.NewDecoder(resp.Body).Decode(&errResp)
 	s.Require().NoError(err)
-	s.Require().Equal(400, errResp.Code)
-	s.Require().Equal("invalid request", errResp.Message)
+	s.Require().Equal(400, errResp.Status)
+	s.Require().Equal("urn:merch-store:problem:validation_failed", errResp.Type)
+	s.Require().Equal("request validation failed", errResp.Detail)
+	s.Require().Equal([]dto.FieldError{{Field: "password", Message: "is required"}}, errResp.Errors)
 }
@@ -0,0 +1,157 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/service"
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/storage/db/postgres"
+	"avito-tech-merch/pkg/logger"
+	"avito-tech-merch/tests/integration/testutil"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+	"math/rand/v2"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Бенчмарки сравнивают прежнюю схему перевода (чтение балансов и запись вычисленных значений при SERIALIZABLE
+// с повторами) и атомарные условные UPDATE при READ COMMITTED. Запуск:
+//
+//	go test -tags integration -run '^$' -bench BalanceTransfer ./tests/integration/
+//
+// Метрика failures/op показывает долю переводов, которые не прошли даже после повторов.
+
+const (
+	benchmarkUsers       = 8
+	benchmarkBalance     = 1_000_000
+	benchmarkParallelism = 4
+)
+
+type transferFunc func(ctx context.Context, senderID, receiverID int) error
+
+func BenchmarkBalanceTransfer(b *testing.B) {
+	ctx := context.Background()
+
+	psqlContainer, err := testutil.NewPostgreSQLContainer(ctx)
+	require.NoError(b, err)
+	defer psqlContainer.Terminate(context.Background())
+
+	require.NoError(b, testutil.RunMigrations(psqlContainer.GetDSN(), "../../migrations"))
+
+	pgPool, err := pgxpool.New(ctx, psqlContainer.GetDSN())
+	require.NoError(b, err)
+	defer pgPool.Close()
+
+	log := logger.NewLogger("prod")
+	defer log.Sync()
+
+	txManager := postgres.NewTxManager(pgPool, log)
+	repo := db.NewRepository(
+		postgres.NewUserRepository(txManager, log),
+		postgres.NewMerchRepository(txManager, log),
+		postgres.NewPurchaseRepository(txManager, log),
+		postgres.NewCartRepository(txManager, log),
+		postgres.NewTransactionRepository(txManager, log),
+		postgres.NewRefreshTokenRepository(txManager, log),
+		postgres.NewTokenRevocationRepository(txManager, log),
+		postgres.NewIdempotencyRepository(txManager, log),
+		postgres.NewLedgerRepository(txManager, log),
+	)
+	transactionService := service.NewTransactionService(repo, log, txManager)
+
+	userIDs := make([]int, 0, benchmarkUsers)
+	for i := range benchmarkUsers {
+		var id int
+		err = pgPool.QueryRow(ctx,
+			`INSERT INTO users (username, password_hash, balance) VALUES ($1, '', $2) RETURNING id`,
+			fmt.Sprintf("bench_user_%d", i), benchmarkBalance,
+		).Scan(&id)
+		require.NoError(b, err)
+		userIDs = append(userIDs, id)
+	}
+
+	b.Run("ReadModifyWriteSerializable", func(b *testing.B) {
+		runParallelTransfers(b, userIDs, func(ctx context.Context, senderID, receiverID int) error {
+			return legacyTransfer(ctx, txManager, senderID, receiverID, 1)
+		})
+	})
+
+	b.Run("ConditionalUpdateReadCommitted", func(b *testing.B) {
+		runParallelTransfers(b, userIDs, func(ctx context.Context, senderID, receiverID int) error {
+			_, err := transactionService.TransferCoins(ctx, senderID, models.TransferRecipient{ID: receiverID}, 1, models.TransferMemo{}, "")
+			return err
+		})
+	})
+}
+
+// runParallelTransfers гоняет переводы между случайными парами из небольшого набора пользователей,
+// чтобы транзакции постоянно конкурировали за одни и те же строки
+func runParallelTransfers(b *testing.B, userIDs []int, transfer transferFunc) {
+	var failures atomic.Int64
+
+	b.SetParallelism(benchmarkParallelism)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.Background()
+		for pb.Next() {
+			from := rand.IntN(len(userIDs))
+			to := (from + 1 + rand.IntN(len(userIDs)-1)) % len(userIDs)
+			if err := transfer(ctx, userIDs[from], userIDs[to]); err != nil {
+				failures.Add(1)
+			}
+		}
+	})
+	b.StopTimer()
+
+	b.ReportMetric(float64(failures.Load())/float64(b.N), "failures/op")
+}
+
+// legacyTransfer воспроизводит прежний перевод: балансы читаются, пересчитываются в Go и записываются обратно,
+// поэтому корректность держится только на SERIALIZABLE и повторах
+func legacyTransfer(ctx context.Context, txManager db.TxManager, senderID, receiverID, amount int) error {
+	const maxRetries = 3
+	var err error
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		err = txManager.WithTx(ctx, postgres.IsolationLevelSerializable, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
+			executor := txManager.GetExecutor(txCtx)
+
+			var senderBalance, receiverBalance int
+			if err := executor.QueryRow(txCtx, `SELECT balance FROM users WHERE id = $1`, senderID).Scan(&senderBalance); err != nil {
+				return err
+			}
+			if senderBalance < amount {
+				return fmt.Errorf("insufficient funds")
+			}
+			if err := executor.QueryRow(txCtx, `SELECT balance FROM users WHERE id = $1`, receiverID).Scan(&receiverBalance); err != nil {
+				return err
+			}
+
+			if _, err := executor.Exec(txCtx, `UPDATE users SET balance = $1 WHERE id = $2`, senderBalance-amount, senderID); err != nil {
+				return err
+			}
+			if _, err := executor.Exec(txCtx, `UPDATE users SET balance = $1 WHERE id = $2`, receiverBalance+amount, receiverID); err != nil {
+				return err
+			}
+
+			_, err := executor.Exec(txCtx,
+				`INSERT INTO transactions (sender_id, receiver_id, amount, created_at) VALUES ($1, $2, $3, $4)`,
+				senderID, receiverID, amount, time.Now(),
+			)
+			return err
+		})
+
+		if err == nil || !service.IsSerializationError(err) {
+			return err
+		}
+
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+	}
+
+	return err
+}
@@ -0,0 +1,73 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// TestConcurrentTransfersIntegration проверяет, что параллельные переводы не уводят баланс в минус
+// и не теряют списаний при READ COMMITTED
+func (s *TestSuite) TestConcurrentTransfersIntegration() {
+	const (
+		workers = 20
+		amount  = 100
+	)
+
+	admin := s.adminToken("concurrent_admin")
+	alice := s.registerUser("concurrent_alice")
+	bob := s.registerUser("concurrent_bob")
+
+	body, err := json.Marshal(dto.TransferRequest{ToUser: "concurrent_bob", Amount: amount})
+	s.Require().NoError(err)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		statuses = make(map[int]int)
+		failures []error
+	)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req, err := http.NewRequest("POST", s.server.URL+"/api/send-coin", bytes.NewReader(body))
+			if err != nil {
+				mu.Lock()
+				failures = append(failures, err)
+				mu.Unlock()
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+alice.Token)
+
+			resp, err := s.server.Client().Do(req)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures = append(failures, err)
+				return
+			}
+			resp.Body.Close()
+			statuses[resp.StatusCode]++
+		}()
+	}
+	wg.Wait()
+
+	s.Require().Empty(failures)
+	// У отправителя ровно 1000 монет, поэтому проходит ровно 10 переводов по 100, остальные отклоняются как нехватка средств
+	s.Equal(map[int]int{http.StatusOK: 10, http.StatusUnprocessableEntity: 10}, statuses)
+	s.Equal(0, s.getInfo(alice.Token).Balance)
+	s.Equal(2000, s.getInfo(bob.Token).Balance)
+
+	var report dto.LedgerReportResponse
+	s.Require().Equal(http.StatusOK, s.getHistory("/api/admin/ledger/verify", admin, nil, &report))
+	s.True(report.Consistent)
+	s.Empty(report.Mismatches)
+}
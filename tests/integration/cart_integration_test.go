@@ -0,0 +1,138 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"encoding/json"
+	"net/http"
+)
+
+func (s *TestSuite) addToCart(token, item string, quantity int) *http.Response {
+	body, err := json.Marshal(dto.CartItemRequest{Item: item, Quantity: quantity})
+	s.Require().NoError(err)
+	return s.authorizedPost("/api/cart/items", token, body)
+}
+
+func (s *TestSuite) getInfo(token string) dto.UserInfoResponse {
+	req, err := http.NewRequest("GET", s.server.URL+"/api/info", nil)
+	s.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var info dto.UserInfoResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&info))
+	return info
+}
+
+// TestBuyMerchIntegration_Quantity проверяет покупку нескольких единиц товара одним запросом
+func (s *TestSuite) TestBuyMerchIntegration_Quantity() {
+	buyer := s.registerUser("quantity_buyer")
+
+	resp := s.authorizedPost("/api/merch/buy/pen?quantity=5", buyer.Token, nil)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	info := s.getInfo(buyer.Token)
+	s.Require().Equal(950, info.Balance)
+	s.Require().Equal(50, info.Spent)
+	s.Require().Equal([]*dto.InventoryItemDTO{{Type: "pen", Quantity: 5}}, info.Inventory)
+}
+
+// TestCartIntegration_Checkout проверяет оформление корзины одной транзакцией
+func (s *TestSuite) TestCartIntegration_Checkout() {
+	buyer := s.registerUser("cart_buyer")
+
+	addResp := s.addToCart(buyer.Token, "pen", 2)
+	addResp.Body.Close()
+	s.Require().Equal(http.StatusOK, addResp.StatusCode)
+
+	addResp = s.addToCart(buyer.Token, "pen", 3)
+	addResp.Body.Close()
+	s.Require().Equal(http.StatusOK, addResp.StatusCode)
+
+	addResp = s.addToCart(buyer.Token, "cup", 1)
+	defer addResp.Body.Close()
+	s.Require().Equal(http.StatusOK, addResp.StatusCode)
+
+	var cart dto.CartResponse
+	s.Require().NoError(json.NewDecoder(addResp.Body).Decode(&cart))
+	s.Require().Len(cart.Items, 2)
+	s.Require().Equal(5, cart.Items[0].Quantity)
+	s.Require().Equal(70, cart.Total)
+
+	checkoutResp := s.authorizedPost("/api/cart/checkout", buyer.Token, nil)
+	defer checkoutResp.Body.Close()
+	s.Require().Equal(http.StatusOK, checkoutResp.StatusCode)
+
+	var checkout dto.CheckoutResponse
+	s.Require().NoError(json.NewDecoder(checkoutResp.Body).Decode(&checkout))
+	s.Require().Equal(70, checkout.Total)
+	s.Require().Len(checkout.Purchases, 2)
+
+	s.Require().Equal(930, s.getInfo(buyer.Token).Balance)
+
+	// Корзина пуста, повторное оформление ничего не покупает
+	emptyResp := s.authorizedPost("/api/cart/checkout", buyer.Token, nil)
+	emptyResp.Body.Close()
+	s.Require().Equal(http.StatusUnprocessableEntity, emptyResp.StatusCode)
+}
+
+// TestCartIntegration_CheckoutIsAllOrNothing проверяет, что при нехватке монет не покупается ни одна позиция
+func (s *TestSuite) TestCartIntegration_CheckoutIsAllOrNothing() {
+	buyer := s.registerUser("cart_poor_buyer")
+
+	addResp := s.addToCart(buyer.Token, "cup", 1)
+	addResp.Body.Close()
+	s.Require().Equal(http.StatusOK, addResp.StatusCode)
+
+	addResp = s.addToCart(buyer.Token, "pink-hoody", 2)
+	addResp.Body.Close()
+	s.Require().Equal(http.StatusOK, addResp.StatusCode)
+
+	checkoutResp := s.authorizedPost("/api/cart/checkout", buyer.Token, nil)
+	checkoutResp.Body.Close()
+	s.Require().NotEqual(http.StatusOK, checkoutResp.StatusCode)
+
+	info := s.getInfo(buyer.Token)
+	s.Require().Equal(1000, info.Balance)
+	s.Require().Empty(info.Inventory)
+
+	// Неудачное оформление не очищает корзину
+	req, err := http.NewRequest("GET", s.server.URL+"/api/cart", nil)
+	s.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer "+buyer.Token)
+	cartResp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer cartResp.Body.Close()
+
+	var cart dto.CartResponse
+	s.Require().NoError(json.NewDecoder(cartResp.Body).Decode(&cart))
+	s.Require().Len(cart.Items, 2)
+}
+
+func (s *TestSuite) TestCartIntegration_LineLimit() {
+	buyer := s.registerUser("cart_bulk_buyer")
+
+	addResp := s.addToCart(buyer.Token, "pen", 60)
+	addResp.Body.Close()
+	s.Require().Equal(http.StatusOK, addResp.StatusCode)
+
+	// Повторное добавление не выводит позицию за предел одной покупки
+	addResp = s.addToCart(buyer.Token, "pen", 60)
+	addResp.Body.Close()
+	s.Require().Equal(http.StatusBadRequest, addResp.StatusCode)
+
+	addResp = s.addToCart(buyer.Token, "pen", 40)
+	defer addResp.Body.Close()
+	s.Require().Equal(http.StatusOK, addResp.StatusCode)
+
+	var cart dto.CartResponse
+	s.Require().NoError(json.NewDecoder(addResp.Body).Decode(&cart))
+	s.Require().Len(cart.Items, 1)
+	s.Require().Equal(100, cart.Items[0].Quantity)
+}
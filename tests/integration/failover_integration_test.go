@@ -0,0 +1,55 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/config"
+	"avito-tech-merch/internal/metrics"
+	"avito-tech-merch/internal/storage/db/postgres"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"net"
+	"time"
+)
+
+// TestPrimaryFailover проверяет запуск, когда первый адрес кластера недоступен:
+// пул основной базы подключается к следующему адресу, который принимает запись
+func (s *TestSuite) TestPrimaryFailover() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	containerConfig := s.psqlContainer.Config
+	storage := config.StorageConfig{Postgres: config.PostgresConfig{
+		Hosts:              []string{"127.0.0.1:1", net.JoinHostPort(containerConfig.Host, containerConfig.MappedPort)},
+		Database:           containerConfig.Database,
+		Username:           containerConfig.User,
+		Password:           containerConfig.Password,
+		SSLMode:            "disable",
+		ConnectTimeout:     1,
+		ConnectionAttempts: 1,
+		Pool:               config.PoolConfig{MaxConnections: 2},
+	}}
+
+	primary, replicaPools, err := storage.ConnectionToPostgres(logger.Default())
+	s.Require().NoError(err)
+	defer primary.Close()
+	replicas := postgres.NewReplicaSet(replicaPools, time.Second, logger.Default())
+	defer replicas.Close()
+	s.Len(replicaPools, 2)
+
+	var one int
+	s.Require().NoError(primary.QueryRow(ctx, "SELECT 1").Scan(&one))
+
+	metrics.RecordDBPrimaryUp(false)
+	go postgres.NewPrimaryMonitor(primary, logger.Default()).Run(ctx, 100*time.Millisecond)
+	s.Eventually(func() bool {
+		return testutil.ToFloat64(metrics.DBPrimaryUp) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// Основная база не работает репликой, поэтому ни один пул реплик не получает чтения
+	go replicas.Run(ctx, 100*time.Millisecond)
+	txManager := postgres.NewReplicatedTxManager(primary, replicas, logger.Default())
+	time.Sleep(300 * time.Millisecond)
+	s.Equal(postgres.PrimaryPoolName, s.transactionPool(txManager, postgres.IsolationLevelRepeatableRead, postgres.AccessModeReadOnly))
+}
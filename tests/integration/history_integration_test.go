@@ -0,0 +1,106 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+func (s *TestSuite) getHistory(path, token string, query url.Values, result any) int {
+	req, err := http.NewRequest("GET", s.server.URL+path+"?"+query.Encode(), nil)
+	s.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(result))
+	}
+	return resp.StatusCode
+}
+
+func (s *TestSuite) sendCoins(token, toUser string, amount int) {
+	body, err := json.Marshal(dto.TransferRequest{ToUser: toUser, Amount: amount})
+	s.Require().NoError(err)
+	resp := s.authorizedPost("/api/send-coin", token, body)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+}
+
+// TestTransactionHistoryIntegration проверяет постраничную выдачу переводов и фильтры по направлению и собеседнику
+func (s *TestSuite) TestTransactionHistoryIntegration() {
+	alice := s.registerUser("history_alice")
+	bob := s.registerUser("history_bob")
+	carol := s.registerUser("history_carol")
+
+	for i := 1; i <= 5; i++ {
+		s.sendCoins(alice.Token, "history_bob", i)
+	}
+	s.sendCoins(carol.Token, "history_alice", 100)
+
+	// Обходим всю историю страницами по 2 записи
+	var amounts []int
+	query := url.Values{"limit": {"2"}}
+	for pages := 0; ; pages++ {
+		s.Require().Less(pages, 4)
+
+		var page dto.TransactionHistoryResponse
+		s.Require().Equal(http.StatusOK, s.getHistory("/api/history/transactions", alice.Token, query, &page))
+		for _, transfer := range page.Transactions {
+			amounts = append(amounts, transfer.Amount)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+	// От новых к старым, без пропусков и повторов
+	s.Require().Equal([]int{100, 5, 4, 3, 2, 1}, amounts)
+
+	var received dto.TransactionHistoryResponse
+	s.Require().Equal(http.StatusOK, s.getHistory("/api/history/transactions", alice.Token, url.Values{"direction": {"received"}}, &received))
+	s.Require().Len(received.Transactions, 1)
+	s.Require().Equal("history_carol", received.Transactions[0].Counterparty)
+	s.Require().Equal("received", received.Transactions[0].Direction)
+
+	var withBob dto.TransactionHistoryResponse
+	s.Require().Equal(http.StatusOK, s.getHistory("/api/history/transactions", alice.Token, url.Values{"counterparty": {"history_bob"}}, &withBob))
+	s.Require().Len(withBob.Transactions, 5)
+
+	var future dto.TransactionHistoryResponse
+	tomorrow := time.Now().Add(24 * time.Hour).Format(time.RFC3339)
+	s.Require().Equal(http.StatusOK, s.getHistory("/api/history/transactions", bob.Token, url.Values{"from": {tomorrow}}, &future))
+	s.Require().Empty(future.Transactions)
+
+	s.Require().Equal(http.StatusBadRequest, s.getHistory("/api/history/transactions", bob.Token, url.Values{"cursor": {"garbage!"}}, nil))
+}
+
+// TestPurchaseHistoryIntegration проверяет постраничную выдачу покупок
+func (s *TestSuite) TestPurchaseHistoryIntegration() {
+	buyer := s.registerUser("history_buyer")
+
+	var purchaseIDs []int
+	for _, item := range []string{"pen", "cup", "book"} {
+		purchaseIDs = append(purchaseIDs, s.buyMerch(buyer.Token, item))
+	}
+
+	var first dto.PurchaseHistoryResponse
+	s.Require().Equal(http.StatusOK, s.getHistory("/api/history/purchases", buyer.Token, url.Values{"limit": {"2"}}, &first))
+	s.Require().Len(first.Purchases, 2)
+	s.Require().Equal(purchaseIDs[2], first.Purchases[0].ID)
+	s.Require().Equal(purchaseIDs[1], first.Purchases[1].ID)
+	s.Require().NotEmpty(first.NextCursor)
+
+	var second dto.PurchaseHistoryResponse
+	query := url.Values{"limit": {"2"}, "cursor": {first.NextCursor}}
+	s.Require().Equal(http.StatusOK, s.getHistory("/api/history/purchases", buyer.Token, query, &second))
+	s.Require().Len(second.Purchases, 1)
+	s.Require().Equal(purchaseIDs[0], second.Purchases[0].ID)
+	s.Require().Empty(second.NextCursor)
+}
@@ -0,0 +1,91 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+func (s *TestSuite) postWithIdempotencyKey(path, token, key string, body []byte) *http.Response {
+	req, err := http.NewRequest("POST", s.server.URL+path, bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Idempotency-Key", key)
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	return resp
+}
+
+// TestSendCoinIdempotencyIntegration проверяет, что повтор перевода с тем же ключом не списывает монеты второй раз
+func (s *TestSuite) TestSendCoinIdempotencyIntegration() {
+	sender := s.registerUser("idem_sender")
+	receiver := s.registerUser("idem_receiver")
+	receiverID := s.getInfo(receiver.Token).UserID
+
+	body, err := json.Marshal(dto.TransferRequest{ReceiverID: receiverID, Amount: 100})
+	s.Require().NoError(err)
+
+	var transactionIDs []int
+	for i := 0; i < 2; i++ {
+		resp := s.postWithIdempotencyKey("/api/send-coin", sender.Token, "transfer-1", body)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		var result dto.TransferSuccessResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&result))
+		resp.Body.Close()
+		transactionIDs = append(transactionIDs, result.TransactionID)
+	}
+
+	s.Require().Equal(transactionIDs[0], transactionIDs[1])
+	s.Require().Equal(900, s.getInfo(sender.Token).Balance)
+	s.Require().Equal(1100, s.getInfo(receiver.Token).Balance)
+
+	// Тот же ключ с другой суммой отклоняется
+	otherBody, err := json.Marshal(dto.TransferRequest{ReceiverID: receiverID, Amount: 200})
+	s.Require().NoError(err)
+	resp := s.postWithIdempotencyKey("/api/send-coin", sender.Token, "transfer-1", otherBody)
+	resp.Body.Close()
+	s.Require().NotEqual(http.StatusOK, resp.StatusCode)
+	s.Require().Equal(900, s.getInfo(sender.Token).Balance)
+
+	// Ключи разных пользователей не пересекаются
+	back, err := json.Marshal(dto.TransferRequest{ReceiverID: s.getInfo(sender.Token).UserID, Amount: 100})
+	s.Require().NoError(err)
+	resp = s.postWithIdempotencyKey("/api/send-coin", receiver.Token, "transfer-1", back)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal(1000, s.getInfo(sender.Token).Balance)
+}
+
+// TestBuyMerchIdempotencyIntegration проверяет, что повтор покупки с тем же ключом возвращает исходную покупку
+func (s *TestSuite) TestBuyMerchIdempotencyIntegration() {
+	buyer := s.registerUser("idem_buyer")
+
+	var purchaseIDs []int
+	for i := 0; i < 2; i++ {
+		resp := s.postWithIdempotencyKey("/api/merch/buy/cup", buyer.Token, "buy-1", nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		var result dto.PurchaseSuccessResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&result))
+		resp.Body.Close()
+		purchaseIDs = append(purchaseIDs, result.PurchaseID)
+	}
+
+	s.Require().Equal(purchaseIDs[0], purchaseIDs[1])
+
+	info := s.getInfo(buyer.Token)
+	s.Require().Equal([]*dto.InventoryItemDTO{{Type: "cup", Quantity: 1}}, info.Inventory)
+	s.Require().Equal(980, info.Balance)
+
+	// Ключ покупки нельзя использовать для покупки другого товара
+	resp := s.postWithIdempotencyKey("/api/merch/buy/pen", buyer.Token, "buy-1", nil)
+	resp.Body.Close()
+	s.Require().NotEqual(http.StatusOK, resp.StatusCode)
+	s.Require().Len(s.getInfo(buyer.Token).Inventory, 1)
+}
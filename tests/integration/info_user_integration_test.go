@@ -3,9 +3,14 @@
 package integration
 
 import (
+	"avito-tech-merch/internal/models"
 	"avito-tech-merch/internal/models/dto"
+	"avito-tech-merch/internal/storage/db/postgres"
+	"avito-tech-merch/pkg/logger"
 	"bytes"
+	"context"
 	"encoding/json"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"net/http"
 )
 
@@ -20,11 +25,11 @@ func (s *TestSuite) TestGetInfo_Unauthorized_NoToken() {
 
 	s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
 
-	var errResp dto.ErrorJWTMissingToken
+	var errResp dto.Problem
 	err = json.NewDecoder(resp.Body).Decode(&errResp)
 	s.Require().NoError(err)
-	s.Require().Equal(401, errResp.Code)
-	s.Require().Equal("missing token", errResp.Message)
+	s.Require().Equal(401, errResp.Status)
+	s.Require().Equal("missing token", errResp.Detail)
 }
 
 // TestGetInfo_Unauthorized_InvalidToken проверяет, что если передан невалидный токен, возвращается 401 с сообщением "invalid token".
@@ -39,11 +44,11 @@ func (s *TestSuite) TestGetInfo_Unauthorized_InvalidToken() {
 
 	s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
 
-	var errResp dto.ErrorJWTInvalidToken
+	var errResp dto.Problem
 	err = json.NewDecoder(resp.Body).Decode(&errResp)
 	s.Require().NoError(err)
-	s.Require().Equal(401, errResp.Code)
-	s.Require().Equal("invalid token", errResp.Message)
+	s.Require().Equal(401, errResp.Status)
+	s.Require().Equal("invalid token", errResp.Detail)
 }
 
 // TestGetInfo_Success проверяет успешное получение информации о пользователе.
@@ -86,3 +91,73 @@ func (s *TestSuite) TestGetInfo_Success() {
 	s.Require().Equal(1, userInfo.UserID)
 	s.Require().Equal(1000, userInfo.Balance)
 }
+
+// TestGetInfo_InventoryAndCoinHistory проверяет агрегирование покупок по товарам и историю переводов с именами пользователей
+func (s *TestSuite) TestGetInfo_InventoryAndCoinHistory() {
+	alice := s.registerUser("inventory_alice")
+	bob := s.registerUser("inventory_bob")
+
+	s.buyMerch(alice.Token, "pen?quantity=2")
+	s.buyMerch(alice.Token, "pen")
+	s.buyMerch(alice.Token, "cup")
+
+	for _, transfer := range []struct {
+		token string
+		body  dto.TransferRequest
+	}{
+		{token: alice.Token, body: dto.TransferRequest{ToUser: "inventory_bob", Amount: 100}},
+		{token: bob.Token, body: dto.TransferRequest{ToUser: "inventory_alice", Amount: 40, Category: "thank_you"}},
+	} {
+		body, err := json.Marshal(transfer.body)
+		s.Require().NoError(err)
+		resp := s.authorizedPost("/api/send-coin", transfer.token, body)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+	}
+
+	info := s.getInfo(alice.Token)
+	s.Require().Equal([]*dto.InventoryItemDTO{
+		{Type: "cup", Quantity: 1},
+		{Type: "pen", Quantity: 3},
+	}, info.Inventory)
+	s.Require().Equal(50, info.Spent)
+	s.Require().Equal(1000-50-100+40, info.Balance)
+
+	s.Require().Len(info.CoinHistory.Sent, 1)
+	s.Require().Equal("inventory_bob", info.CoinHistory.Sent[0].ToUser)
+	s.Require().Equal(100, info.CoinHistory.Sent[0].Amount)
+
+	s.Require().Len(info.CoinHistory.Received, 1)
+	s.Require().Equal("inventory_bob", info.CoinHistory.Received[0].FromUser)
+	s.Require().Equal("thank_you", info.CoinHistory.Received[0].Category)
+
+	s.Require().Equal("inventory_alice", s.getInfo(bob.Token).CoinHistory.Received[0].FromUser)
+}
+
+// TestCoinHistoryLimit проверяет, что история в /api/info ограничена последними переводами в каждую сторону
+func (s *TestSuite) TestCoinHistoryLimit() {
+	alice := s.registerUser("limit_alice")
+	bob := s.registerUser("limit_bob")
+
+	for _, amount := range []int{10, 20, 30} {
+		s.sendCoins(alice.Token, "limit_bob", amount)
+	}
+	s.sendCoins(bob.Token, "limit_alice", 5)
+
+	pool, err := pgxpool.New(context.Background(), s.psqlContainer.GetDSN())
+	s.Require().NoError(err)
+	defer pool.Close()
+	repo := postgres.NewTransactionRepository(postgres.NewTxManager(pool, logger.Default()), logger.Default())
+
+	history, err := repo.GetCoinHistoryByUserID(context.Background(), s.getInfo(alice.Token).UserID, models.TransactionFilter{}, 2)
+	s.Require().NoError(err)
+
+	s.Require().Len(history.Sent, 2)
+	s.Require().Equal(30, history.Sent[0].Amount)
+	s.Require().Equal(20, history.Sent[1].Amount)
+	s.Require().Equal("limit_bob", history.Sent[0].Counterparty)
+
+	s.Require().Len(history.Received, 1)
+	s.Require().Equal(5, history.Received[0].Amount)
+	s.Require().Equal(models.DirectionReceived, history.Received[0].Direction)
+}
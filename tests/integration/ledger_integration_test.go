@@ -0,0 +1,77 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"database/sql"
+	"net/http"
+)
+
+// TestLedgerIntegration проверяет, что регистрация, переводы, покупки и возвраты сохраняют журнал согласованным
+func (s *TestSuite) TestLedgerIntegration() {
+	admin := s.adminToken("ledger_admin")
+	alice := s.registerUser("ledger_alice")
+	s.registerUser("ledger_bob")
+
+	s.sendCoins(alice.Token, "ledger_bob", 150)
+	s.buyMerch(alice.Token, "pen")
+	purchaseID := s.buyMerch(alice.Token, "cup")
+	resp := s.refundPurchase(alice.Token, purchaseID)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var report dto.LedgerReportResponse
+	s.Require().Equal(http.StatusOK, s.getHistory("/api/admin/ledger/verify", admin, nil, &report))
+	s.True(report.Consistent)
+	s.Equal(0, report.Total)
+	s.Equal(0, report.UnbalancedEntries)
+	s.Empty(report.Mismatches)
+	// Три сотрудника получили по 1000 монет; потраченные 10 монет остались на счёте магазина
+	s.Equal(3000, report.Issued)
+	s.Equal(1000-150-10, s.getInfo(alice.Token).Balance)
+
+	db, err := sql.Open("postgres", s.psqlContainer.GetDSN())
+	s.Require().NoError(err)
+	defer db.Close()
+
+	var storeBalance int
+	err = db.QueryRow(`SELECT SUM(delta) FROM ledger_postings WHERE account = 'system:merch_store'`).Scan(&storeBalance)
+	s.Require().NoError(err)
+	s.Equal(10, storeBalance)
+}
+
+// TestLedgerIntegration_AppendOnly проверяет, что журнал нельзя изменить задним числом
+func (s *TestSuite) TestLedgerIntegration_AppendOnly() {
+	s.registerUser("ledger_append_only")
+
+	db, err := sql.Open("postgres", s.psqlContainer.GetDSN())
+	s.Require().NoError(err)
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE ledger_postings SET delta = delta * 2`)
+	s.ErrorContains(err, "ledger is append-only")
+
+	_, err = db.Exec(`DELETE FROM ledger_entries`)
+	s.ErrorContains(err, "ledger is append-only")
+}
+
+// TestLedgerIntegration_DetectsDrift проверяет, что сверка находит баланс, изменённый в обход журнала
+func (s *TestSuite) TestLedgerIntegration_DetectsDrift() {
+	admin := s.adminToken("ledger_drift_admin")
+	s.registerUser("ledger_drift_user")
+
+	db, err := sql.Open("postgres", s.psqlContainer.GetDSN())
+	s.Require().NoError(err)
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE users SET balance = balance + 500 WHERE username = 'ledger_drift_user'`)
+	s.Require().NoError(err)
+
+	var report dto.LedgerReportResponse
+	s.Require().Equal(http.StatusOK, s.getHistory("/api/admin/ledger/verify", admin, nil, &report))
+	s.False(report.Consistent)
+	s.Require().Len(report.Mismatches, 1)
+	s.Equal(1500, report.Mismatches[0].Balance)
+	s.Equal(1000, report.Mismatches[0].LedgerBalance)
+}
@@ -0,0 +1,46 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/storage/db/postgres"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestDecrementMerchStockIntegration проверяет, что списание сверяет цену, архивацию и остаток с текущей строкой товара
+func (s *TestSuite) TestDecrementMerchStockIntegration() {
+	ctx := context.Background()
+	admin := s.adminToken("decrement_admin")
+	limited := s.createLimitedMerch(admin, "magnet", 5, 2)
+
+	pool, err := pgxpool.New(ctx, s.psqlContainer.GetDSN())
+	s.Require().NoError(err)
+	defer pool.Close()
+	repo := postgres.NewMerchRepository(postgres.NewTxManager(pool, logger.Default()), logger.Default())
+
+	// Цена, прочитанная до её смены, не проходит
+	_, err = repo.DecrementMerchStock(ctx, limited.ID, 4, 1)
+	s.Require().ErrorIs(err, pgx.ErrNoRows)
+
+	stock, err := repo.DecrementMerchStock(ctx, limited.ID, 5, 2)
+	s.Require().NoError(err)
+	s.Require().NotNil(stock)
+	s.Equal(0, *stock)
+
+	_, err = repo.DecrementMerchStock(ctx, limited.ID, 5, 1)
+	s.Require().ErrorIs(err, pgx.ErrNoRows)
+
+	// Товар без учёта остатка списывается без изменения остатка, пока не снят с продажи
+	unlimited, err := repo.GetMerchByName(ctx, "pen")
+	s.Require().NoError(err)
+	stock, err = repo.DecrementMerchStock(ctx, unlimited.ID, unlimited.Price, 3)
+	s.Require().NoError(err)
+	s.Nil(stock)
+
+	s.Require().NoError(repo.ArchiveMerch(ctx, limited.ID))
+	_, err = repo.DecrementMerchStock(ctx, limited.ID, 5, 0)
+	s.Require().ErrorIs(err, pgx.ErrNoRows)
+}
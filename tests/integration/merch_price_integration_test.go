@@ -0,0 +1,61 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// TestMerchPriceIntegration проверяет, что покупка хранит цену на момент покупки, а смена цены попадает в историю
+func (s *TestSuite) TestMerchPriceIntegration() {
+	admin := s.adminToken("price_admin")
+	buyer := s.registerUser("price_buyer")
+
+	body, err := json.Marshal(dto.CreateMerchRequest{Name: "mug", Price: 30})
+	s.Require().NoError(err)
+	createResp := s.authorizedPost("/api/admin/merch", admin, body)
+	defer createResp.Body.Close()
+	s.Require().Equal(http.StatusCreated, createResp.StatusCode)
+
+	var created dto.MerchDTO
+	s.Require().NoError(json.NewDecoder(createResp.Body).Decode(&created))
+
+	buyResp := s.authorizedPost("/api/merch/buy/mug?quantity=2", buyer.Token, nil)
+	buyResp.Body.Close()
+	s.Require().Equal(http.StatusOK, buyResp.StatusCode)
+
+	body, err = json.Marshal(dto.MerchRequest{Name: "mug", Price: 45})
+	s.Require().NoError(err)
+	req, err := http.NewRequest("PUT", s.server.URL+"/api/admin/merch/"+strconv.Itoa(created.ID), bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+admin)
+	updateResp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	updateResp.Body.Close()
+	s.Require().Equal(http.StatusOK, updateResp.StatusCode)
+
+	// Траты по-прежнему считаются по фактически списанной цене
+	info := s.getInfo(buyer.Token)
+	s.Require().Equal(60, info.Spent)
+	s.Require().Equal(940, info.Balance)
+
+	req, err = http.NewRequest("GET", s.server.URL+"/api/admin/merch/"+strconv.Itoa(created.ID)+"/prices", nil)
+	s.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer "+admin)
+	historyResp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer historyResp.Body.Close()
+	s.Require().Equal(http.StatusOK, historyResp.StatusCode)
+
+	var history []dto.MerchPriceDTO
+	s.Require().NoError(json.NewDecoder(historyResp.Body).Decode(&history))
+	s.Require().Len(history, 2)
+	s.Require().Equal(30, history[0].Price)
+	s.Require().Equal(45, history[1].Price)
+	s.Require().NotNil(history[1].ChangedBy)
+}
@@ -0,0 +1,121 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+func (s *TestSuite) createLimitedMerch(token, name string, price, stock int) dto.MerchDTO {
+	body, err := json.Marshal(dto.CreateMerchRequest{Name: name, Price: price, Stock: &stock})
+	s.Require().NoError(err)
+	resp := s.authorizedPost("/api/admin/merch", token, body)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+	var created dto.MerchDTO
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&created))
+	return created
+}
+
+func (s *TestSuite) findMerch(token, name string) dto.MerchDTO {
+	for _, merch := range s.listMerch(token) {
+		if merch.Name == name {
+			return merch
+		}
+	}
+	s.FailNow("merch not found", name)
+	return dto.MerchDTO{}
+}
+
+// TestMerchStockIntegration проверяет, что товар с ограниченным остатком нельзя купить сверх остатка
+func (s *TestSuite) TestMerchStockIntegration() {
+	admin := s.adminToken("stock_admin")
+	employee := s.registerUser("stock_employee")
+
+	created := s.createLimitedMerch(admin, "badge", 10, 1)
+
+	buyResp := s.authorizedPost("/api/merch/buy/badge", employee.Token, nil)
+	buyResp.Body.Close()
+	s.Require().Equal(http.StatusOK, buyResp.StatusCode)
+
+	buyResp = s.authorizedPost("/api/merch/buy/badge", employee.Token, nil)
+	buyResp.Body.Close()
+	s.Require().Equal(http.StatusUnprocessableEntity, buyResp.StatusCode)
+	s.Require().Equal(0, *s.findMerch(employee.Token, "badge").Stock)
+
+	body, err := json.Marshal(dto.RestockRequest{Quantity: 2})
+	s.Require().NoError(err)
+	restockResp := s.authorizedPost("/api/admin/merch/"+strconv.Itoa(created.ID)+"/restock", admin, body)
+	defer restockResp.Body.Close()
+	s.Require().Equal(http.StatusOK, restockResp.StatusCode)
+
+	var restocked dto.MerchDTO
+	s.Require().NoError(json.NewDecoder(restockResp.Body).Decode(&restocked))
+	s.Require().Equal(2, *restocked.Stock)
+
+	buyResp = s.authorizedPost("/api/merch/buy/badge", employee.Token, nil)
+	buyResp.Body.Close()
+	s.Require().Equal(http.StatusOK, buyResp.StatusCode)
+	s.Require().Equal(1, *s.findMerch(employee.Token, "badge").Stock)
+}
+
+// TestMerchStockIntegration_ConcurrentPurchases проверяет, что параллельные покупки не уводят остаток в минус
+func (s *TestSuite) TestMerchStockIntegration_ConcurrentPurchases() {
+	admin := s.adminToken("stock_race_admin")
+	s.createLimitedMerch(admin, "limited-hoody", 10, 3)
+
+	const buyers = 6
+	tokens := make([]string, buyers)
+	for i := range tokens {
+		tokens[i] = s.registerUser("stock_race_buyer_" + strconv.Itoa(i)).Token
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for _, token := range tokens {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+			req, err := http.NewRequest("POST", s.server.URL+"/api/merch/buy/limited-hoody", nil)
+			if err != nil {
+				return
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := s.server.Client().Do(req)
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}(token)
+	}
+	wg.Wait()
+
+	s.Require().LessOrEqual(succeeded, 3)
+	s.Require().Equal(3-succeeded, *s.findMerch(admin, "limited-hoody").Stock)
+}
+
+// TestMerchStockIntegration_RestockUnlimited проверяет, что пополнение не ограничивает товар без учёта остатка
+func (s *TestSuite) TestMerchStockIntegration_RestockUnlimited() {
+	admin := s.adminToken("restock_admin")
+	pen := s.findMerch(admin, "pen")
+
+	body, err := json.Marshal(dto.RestockRequest{Quantity: 5})
+	s.Require().NoError(err)
+	restockResp := s.authorizedPost("/api/admin/merch/"+strconv.Itoa(pen.ID)+"/restock", admin, body)
+	restockResp.Body.Close()
+	s.Require().Equal(http.StatusUnprocessableEntity, restockResp.StatusCode)
+	s.Require().Nil(s.findMerch(admin, "pen").Stock)
+}
@@ -61,12 +61,12 @@ func (s *TestSuite) TestBuyMerchIntegration_InsufficientFunds() {
 	s.Require().NoError(err)
 	defer resp.Body.Close()
 
-	s.Require().Equal(http.StatusInternalServerError, resp.StatusCode)
+	s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
 
-	var errResp dto.ErrorResponse500
+	var errResp dto.Problem
 	err = json.NewDecoder(resp.Body).Decode(&errResp)
 	s.Require().NoError(err)
-	s.Require().Contains(errResp.Message, "insufficient funds")
+	s.Require().Equal("urn:merch-store:problem:insufficient_funds", errResp.Type)
 }
 
 func (s *TestSuite) TestBuyMerchIntegration_Success() {
@@ -149,11 +149,11 @@ func (s *TestSuite) TestBuyMerchIntegration_InvalidItem() {
 	s.Require().NoError(err)
 	defer resp.Body.Close()
 
-	// Ожидаем Internal Server Error, так как товар не найден
-	s.Require().Equal(http.StatusInternalServerError, resp.StatusCode)
+	// Ожидаем Not Found, так как товара нет в каталоге
+	s.Require().Equal(http.StatusNotFound, resp.StatusCode)
 
-	var errResp dto.ErrorResponse500
+	var errResp dto.Problem
 	err = json.NewDecoder(resp.Body).Decode(&errResp)
 	s.Require().NoError(err)
-	s.Require().Contains(errResp.Message, "failed to get merch")
+	s.Require().Equal("urn:merch-store:problem:merch_not_found", errResp.Type)
 }
@@ -0,0 +1,83 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/models/dto"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+func (s *TestSuite) buyMerch(token, item string) int {
+	resp := s.authorizedPost("/api/merch/buy/"+item, token, nil)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var result dto.PurchaseSuccessResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&result))
+	return result.PurchaseID
+}
+
+func (s *TestSuite) refundPurchase(token string, purchaseID int) *http.Response {
+	return s.authorizedPost("/api/purchases/"+strconv.Itoa(purchaseID)+"/refund", token, nil)
+}
+
+// TestPurchaseRefundIntegration проверяет самостоятельный возврат: монеты и остаток возвращаются, покупка остаётся в истории
+func (s *TestSuite) TestPurchaseRefundIntegration() {
+	admin := s.adminToken("refund_admin")
+	buyer := s.registerUser("refund_buyer")
+	stranger := s.registerUser("refund_stranger")
+
+	s.createLimitedMerch(admin, "refund-cap", 40, 5)
+
+	purchaseID := s.buyMerch(buyer.Token, "refund-cap?quantity=2")
+	s.Require().Equal([]*dto.InventoryItemDTO{{Type: "refund-cap", Quantity: 2}}, s.getInfo(buyer.Token).Inventory)
+	s.Require().Equal(3, *s.findMerch(buyer.Token, "refund-cap").Stock)
+
+	// Чужую покупку сотрудник вернуть не может
+	strangerResp := s.refundPurchase(stranger.Token, purchaseID)
+	strangerResp.Body.Close()
+	s.Require().Equal(http.StatusNotFound, strangerResp.StatusCode)
+
+	refundResp := s.refundPurchase(buyer.Token, purchaseID)
+	defer refundResp.Body.Close()
+	s.Require().Equal(http.StatusOK, refundResp.StatusCode)
+
+	var refunded dto.PurchaseDTO
+	s.Require().NoError(json.NewDecoder(refundResp.Body).Decode(&refunded))
+	s.Require().Equal(purchaseID, refunded.ID)
+	s.Require().NotNil(refunded.RefundedAt)
+	s.Require().Equal(models.PurchaseStatusCancelled, refunded.Status)
+
+	// Возвращённая покупка не входит ни в траты, ни в инвентарь
+	info := s.getInfo(buyer.Token)
+	s.Require().Equal(1000, info.Balance)
+	s.Require().Equal(0, info.Spent)
+	s.Require().Empty(info.Inventory)
+	s.Require().Len(info.Purchases, 1)
+	s.Require().Equal(models.PurchaseStatusCancelled, info.Purchases[0].Status)
+	s.Require().NotNil(info.Purchases[0].RefundedAt)
+	s.Require().Equal(5, *s.findMerch(buyer.Token, "refund-cap").Stock)
+
+	// Повторный возврат не проходит и не начисляет монеты второй раз
+	againResp := s.refundPurchase(buyer.Token, purchaseID)
+	againResp.Body.Close()
+	s.Require().Equal(http.StatusConflict, againResp.StatusCode)
+	s.Require().Equal(1000, s.getInfo(buyer.Token).Balance)
+}
+
+// TestAdminRefundIntegration проверяет, что администратор может вернуть покупку другого сотрудника
+func (s *TestSuite) TestAdminRefundIntegration() {
+	admin := s.adminToken("refund_admin2")
+	buyer := s.registerUser("refund_buyer2")
+
+	purchaseID := s.buyMerch(buyer.Token, "cup")
+
+	refundResp := s.refundPurchase(admin, purchaseID)
+	refundResp.Body.Close()
+	s.Require().Equal(http.StatusOK, refundResp.StatusCode)
+
+	s.Require().Equal(1000, s.getInfo(buyer.Token).Balance)
+}
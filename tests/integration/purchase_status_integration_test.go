@@ -0,0 +1,104 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models"
+	"avito-tech-merch/internal/models/dto"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+func (s *TestSuite) setPurchaseStatus(token string, purchaseID int, status string) *http.Response {
+	body, err := json.Marshal(dto.UpdatePurchaseStatusRequest{Status: status})
+	s.Require().NoError(err)
+
+	req, err := http.NewRequest("PUT", s.server.URL+"/api/admin/purchases/"+strconv.Itoa(purchaseID)+"/status", bytes.NewBuffer(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	return resp
+}
+
+// TestPurchaseStatusIntegration проверяет жизненный цикл выдачи: placed -> ready_for_pickup -> delivered с историей переходов
+func (s *TestSuite) TestPurchaseStatusIntegration() {
+	admin := s.adminToken("status_admin")
+	buyer := s.registerUser("status_buyer")
+
+	purchaseID := s.buyMerch(buyer.Token, "pen")
+
+	info := s.getInfo(buyer.Token)
+	s.Require().Len(info.Purchases, 1)
+	s.Require().Equal(purchaseID, info.Purchases[0].ID)
+	s.Require().Equal(models.PurchaseStatusPlaced, info.Purchases[0].Status)
+
+	// Выдать покупку, минуя ready_for_pickup, нельзя
+	skipResp := s.setPurchaseStatus(admin, purchaseID, models.PurchaseStatusDelivered)
+	skipResp.Body.Close()
+	s.Require().NotEqual(http.StatusOK, skipResp.StatusCode)
+
+	readyResp := s.setPurchaseStatus(admin, purchaseID, models.PurchaseStatusReadyForPickup)
+	readyResp.Body.Close()
+	s.Require().Equal(http.StatusOK, readyResp.StatusCode)
+
+	deliveredResp := s.setPurchaseStatus(admin, purchaseID, models.PurchaseStatusDelivered)
+	defer deliveredResp.Body.Close()
+	s.Require().Equal(http.StatusOK, deliveredResp.StatusCode)
+
+	var delivered dto.PurchaseDTO
+	s.Require().NoError(json.NewDecoder(deliveredResp.Body).Decode(&delivered))
+	s.Require().Equal(models.PurchaseStatusDelivered, delivered.Status)
+	s.Require().Equal(models.PurchaseStatusDelivered, s.getInfo(buyer.Token).Purchases[0].Status)
+
+	// Выданную покупку нельзя ни отменить, ни вернуть
+	cancelResp := s.setPurchaseStatus(admin, purchaseID, models.PurchaseStatusCancelled)
+	cancelResp.Body.Close()
+	s.Require().NotEqual(http.StatusOK, cancelResp.StatusCode)
+
+	refundResp := s.refundPurchase(buyer.Token, purchaseID)
+	refundResp.Body.Close()
+	s.Require().NotEqual(http.StatusOK, refundResp.StatusCode)
+
+	req, err := http.NewRequest("GET", s.server.URL+"/api/admin/purchases/"+strconv.Itoa(purchaseID)+"/history", nil)
+	s.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer "+admin)
+	historyResp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer historyResp.Body.Close()
+	s.Require().Equal(http.StatusOK, historyResp.StatusCode)
+
+	var history []dto.PurchaseStatusChangeDTO
+	s.Require().NoError(json.NewDecoder(historyResp.Body).Decode(&history))
+	s.Require().Len(history, 3)
+	s.Require().Equal(models.PurchaseStatusPlaced, history[0].Status)
+	s.Require().Equal(models.PurchaseStatusReadyForPickup, history[1].Status)
+	s.Require().Equal(models.PurchaseStatusDelivered, history[2].Status)
+}
+
+// TestPurchaseCancelIntegration проверяет, что отмена администратором возвращает монеты покупателю
+func (s *TestSuite) TestPurchaseCancelIntegration() {
+	admin := s.adminToken("cancel_admin")
+	buyer := s.registerUser("cancel_buyer")
+
+	purchaseID := s.buyMerch(buyer.Token, "book")
+
+	cancelResp := s.setPurchaseStatus(admin, purchaseID, models.PurchaseStatusCancelled)
+	defer cancelResp.Body.Close()
+	s.Require().Equal(http.StatusOK, cancelResp.StatusCode)
+
+	var cancelled dto.PurchaseDTO
+	s.Require().NoError(json.NewDecoder(cancelResp.Body).Decode(&cancelled))
+	s.Require().Equal(models.PurchaseStatusCancelled, cancelled.Status)
+	s.Require().NotNil(cancelled.RefundedAt)
+
+	info := s.getInfo(buyer.Token)
+	s.Require().Equal(1000, info.Balance)
+	s.Require().Empty(info.Inventory)
+	s.Require().Len(info.Purchases, 1)
+	s.Require().Equal(models.PurchaseStatusCancelled, info.Purchases[0].Status)
+}
@@ -0,0 +1,78 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/storage/db"
+	"avito-tech-merch/internal/storage/db/postgres"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+// TestReplicaRouting проверяет маршрутизацию чтений. Роль реплики играет второй пул к той же базе:
+// она не в режиме восстановления, поэтому отставание равно нулю
+func (s *TestSuite) TestReplicaRouting() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	primary, err := pgxpool.New(ctx, s.psqlContainer.GetDSN())
+	s.Require().NoError(err)
+	defer primary.Close()
+
+	replicaPool, err := pgxpool.New(ctx, s.psqlContainer.GetDSN())
+	s.Require().NoError(err)
+
+	replicaConfig := replicaPool.Config().ConnConfig
+
+	// Реплика на закрытом порту всегда исключена из чтений
+	unavailablePool, err := pgxpool.New(ctx, fmt.Sprintf("host=%s port=1 user=test dbname=test connect_timeout=1", replicaConfig.Host))
+	s.Require().NoError(err)
+
+	replicas := postgres.NewReplicaSet([]*pgxpool.Pool{replicaPool, unavailablePool}, time.Second, logger.Default())
+	defer replicas.Close()
+	go replicas.Run(ctx, 100*time.Millisecond)
+
+	txManager := postgres.NewReplicatedTxManager(primary, replicas, logger.Default())
+
+	s.Eventually(func() bool {
+		return txManager.GetReadExecutor(ctx) == db.Executor(replicaPool)
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// Чтение, результат которого кешируется, идёт в основную базу
+	s.Equal(db.Executor(primary), txManager.GetReadExecutor(db.WithPrimaryRead(ctx)))
+
+	replicaName := fmt.Sprintf("%s:%d", replicaConfig.Host, replicaConfig.Port)
+	s.Equal(replicaName, s.transactionPool(txManager, postgres.IsolationLevelRepeatableRead, postgres.AccessModeReadOnly))
+	s.Equal(postgres.PrimaryPoolName, s.transactionPool(txManager, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite))
+	// Serializable на реплике не поддерживается
+	s.Equal(postgres.PrimaryPoolName, s.transactionPool(txManager, postgres.IsolationLevelSerializable, postgres.AccessModeReadOnly))
+}
+
+// transactionPool открывает пустую транзакцию и возвращает пул из атрибутов её спана
+func (s *TestSuite) transactionPool(txManager db.TxManager, isoLevel pgx.TxIsoLevel, accessMode pgx.TxAccessMode) string {
+	s.spans.Reset()
+
+	err := txManager.WithTx(context.Background(), isoLevel, accessMode, func(ctx context.Context) error {
+		_, err := txManager.GetExecutor(ctx).Exec(ctx, "SELECT 1")
+		return err
+	})
+	s.Require().NoError(err)
+
+	for _, span := range s.spans.GetSpans() {
+		if span.Name != "postgres.WithTx" {
+			continue
+		}
+		for _, attr := range span.Attributes {
+			if attr.Key == attribute.Key("db.pool") {
+				return attr.Value.AsString()
+			}
+		}
+	}
+	s.FailNow("postgres.WithTx span has no db.pool attribute")
+	return ""
+}
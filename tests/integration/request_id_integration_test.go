@@ -0,0 +1,45 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"encoding/json"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+// TestRequestID_EchoedInResponse проверяет, что переданный клиентом X-Request-ID возвращается в заголовке и в теле ошибки
+func (s *TestSuite) TestRequestID_EchoedInResponse() {
+	req, err := http.NewRequest("GET", s.server.URL+"/api/info", nil)
+	s.Require().NoError(err)
+	req.Header.Set("X-Request-ID", "integration-req-1")
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+	s.Equal("integration-req-1", resp.Header.Get("X-Request-ID"))
+
+	var errResp dto.Problem
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+	s.Equal("integration-req-1", errResp.RequestID)
+}
+
+// TestRequestID_GeneratedForSuccessfulRequest проверяет, что идентификатор запроса выдаётся и для успешных ответов
+func (s *TestSuite) TestRequestID_GeneratedForSuccessfulRequest() {
+	authResp := s.registerUser("request_id_user")
+
+	req, err := http.NewRequest("GET", s.server.URL+"/api/info", nil)
+	s.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer "+authResp.Token)
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	_, err = uuid.Parse(resp.Header.Get("X-Request-ID"))
+	s.NoError(err)
+}
@@ -46,11 +46,13 @@ func (s *TestSuite) TestSendCoin_InvalidRequest() {
 
 	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
 
-	var errResp400 dto.ErrorResponse400
+	var errResp400 dto.Problem
 	err = json.NewDecoder(resp.Body).Decode(&errResp400)
 	s.Require().NoError(err)
-	s.Require().Equal(400, errResp400.Code)
-	s.Require().Equal("invalid request", errResp400.Message)
+	s.Require().Equal(400, errResp400.Status)
+	s.Require().Equal("urn:merch-store:problem:validation_failed", errResp400.Type)
+	s.Require().Equal("request validation failed", errResp400.Detail)
+	s.Require().Equal([]dto.FieldError{{Field: "amount", Message: "is required"}}, errResp400.Errors)
 }
 
 // TestSendCoin_SameSenderReceiver проверяет, что если отправитель равен получателю, возвращается ошибка
@@ -93,12 +95,13 @@ func (s *TestSuite) TestSendCoin_SameSenderReceiver() {
 	s.Require().NoError(err)
 	defer resp.Body.Close()
 
-	s.Require().Equal(http.StatusInternalServerError, resp.StatusCode)
+	s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
 
-	var errResp500 dto.ErrorResponse500
-	err = json.NewDecoder(resp.Body).Decode(&errResp500)
+	var errResp dto.Problem
+	err = json.NewDecoder(resp.Body).Decode(&errResp)
 	s.Require().NoError(err)
-	s.Require().Contains(errResp500.Message, "cannot transfer to yourself")
+	s.Require().Equal("urn:merch-store:problem:self_transfer", errResp.Type)
+	s.Require().Contains(errResp.Detail, "cannot transfer to yourself")
 }
 
 // TestSendCoin_InsufficientFunds проверяет, что если сумма перевода превышает баланс отправителя, возвращается ошибка.
@@ -141,12 +144,13 @@ func (s *TestSuite) TestSendCoin_InsufficientFunds() {
 	s.Require().NoError(err)
 	defer resp.Body.Close()
 
-	s.Require().Equal(http.StatusInternalServerError, resp.StatusCode)
+	s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
 
-	var errResp500 dto.ErrorResponse500
-	err = json.NewDecoder(resp.Body).Decode(&errResp500)
+	var errResp dto.Problem
+	err = json.NewDecoder(resp.Body).Decode(&errResp)
 	s.Require().NoError(err)
-	s.Require().Contains(errResp500.Message, "insufficient funds")
+	s.Require().Equal("urn:merch-store:problem:insufficient_funds", errResp.Type)
+	s.Require().Contains(errResp.Detail, "insufficient funds")
 }
 
 // TestSendCoin_Success проверяет успешный перевод монет между двумя пользователями
@@ -0,0 +1,47 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"encoding/json"
+	"net/http"
+)
+
+// TestSendCoinIntegration_ByUsername проверяет перевод монет по имени получателя
+func (s *TestSuite) TestSendCoinIntegration_ByUsername() {
+	sender := s.registerUser("username_sender")
+	receiver := s.registerUser("username_receiver")
+
+	body, err := json.Marshal(dto.TransferRequest{ToUser: "username_receiver", Amount: 150})
+	s.Require().NoError(err)
+
+	resp := s.authorizedPost("/api/send-coin", sender.Token, body)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var result dto.TransferSuccessResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&result))
+	s.Require().NotZero(result.TransactionID)
+
+	s.Require().Equal(850, s.getInfo(sender.Token).Balance)
+	s.Require().Equal(1150, s.getInfo(receiver.Token).Balance)
+}
+
+// TestSendCoinIntegration_UnknownUsername проверяет, что перевод неизвестному пользователю возвращает 400 и не списывает монеты
+func (s *TestSuite) TestSendCoinIntegration_UnknownUsername() {
+	sender := s.registerUser("unknown_receiver_sender")
+
+	body, err := json.Marshal(dto.TransferRequest{ToUser: "nobody_here", Amount: 150})
+	s.Require().NoError(err)
+
+	resp := s.authorizedPost("/api/send-coin", sender.Token, body)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+
+	var errResp dto.Problem
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResp))
+	s.Require().Equal("receiver not found: nobody_here", errResp.Detail)
+
+	s.Require().Equal(1000, s.getInfo(sender.Token).Balance)
+}
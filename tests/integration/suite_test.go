@@ -7,8 +7,10 @@ import (
 	"avito-tech-merch/internal/config"
 	controller "avito-tech-merch/internal/controller/http"
 	"avito-tech-merch/internal/service"
+	"avito-tech-merch/internal/storage/cache"
 	"avito-tech-merch/internal/storage/db"
 	"avito-tech-merch/internal/storage/db/postgres"
+	"avito-tech-merch/internal/tracing"
 	"avito-tech-merch/pkg/jwt"
 	"avito-tech-merch/pkg/logger"
 	"avito-tech-merch/tests/integration/testutil"
@@ -17,6 +19,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -26,6 +32,7 @@ type TestSuite struct {
 	suite.Suite
 	psqlContainer *testutil.PostgreSQLContainer
 	server        *httptest.Server
+	spans         *tracetest.InMemoryExporter
 }
 
 func (s *TestSuite) SetupSuite() {
@@ -51,39 +58,62 @@ func (s *TestSuite) SetupSuite() {
 	poolConfig.MaxConnLifetime = time.Duration(cfg.Storage.Postgres.Pool.MaxLifeTime)
 	poolConfig.MaxConnIdleTime = time.Duration(cfg.Storage.Postgres.Pool.MaxIdleTime)
 	poolConfig.HealthCheckPeriod = time.Duration(cfg.Storage.Postgres.Pool.HealthCheckPeriod)
+	poolConfig.ConnConfig.Tracer = tracing.NewPgxTracer(cfg.Storage.Postgres.Database)
+
+	// Спаны пишутся в память синхронно, чтобы тесты могли проверить их сразу после ответа
+	s.spans = tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(s.spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
 
 	pgPool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	s.Require().NoError(err)
 
 	log := logger.NewLogger(cfg.Env)
 	defer log.Sync()
+	logger.SetDefault(log)
 
 	txManager := postgres.NewTxManager(pgPool, log)
 
 	userRepo := postgres.NewUserRepository(txManager, log)
 	merchRepo := postgres.NewMerchRepository(txManager, log)
 	purchaseRepo := postgres.NewPurchaseRepository(txManager, log)
+	cartRepo := postgres.NewCartRepository(txManager, log)
 	transactionRepo := postgres.NewTransactionRepository(txManager, log)
+	refreshTokenRepo := postgres.NewRefreshTokenRepository(txManager, log)
+	tokenRevocationRepo := cache.NewRevocationCache(
+		postgres.NewTokenRevocationRepository(txManager, log),
+		time.Duration(cfg.JWT.RevocationCacheTTL)*time.Second,
+	)
+
+	idempotencyRepo := postgres.NewIdempotencyRepository(txManager, log)
+	ledgerRepo := postgres.NewLedgerRepository(txManager, log)
 
-	repo := db.NewRepository(userRepo, merchRepo, purchaseRepo, transactionRepo)
+	repo := db.NewRepository(userRepo, merchRepo, purchaseRepo, cartRepo, transactionRepo, refreshTokenRepo, tokenRevocationRepo, idempotencyRepo, ledgerRepo)
+
+	keyRing, err := cfg.JWT.KeyRing()
+	s.Require().NoError(err)
 
-	tokenService := jwt.NewTokenService()
+	tokenService := jwt.NewTokenService(keyRing)
 
 	authService := service.NewAuthService(repo, log, cfg.JWT, tokenService, txManager)
 	userService := service.NewUserService(repo, log, txManager)
-	merchService := service.NewMerchService(repo, log)
-	purchaseService := service.NewPurchaseService(repo, log, txManager)
+	merchService := service.NewMerchService(repo, log, txManager)
+	purchaseService := service.NewPurchaseService(repo, log, cfg.Purchase, txManager)
+	cartService := service.NewCartService(repo, log)
 	transactionService := service.NewTransactionService(repo, log, txManager)
+	adminService := service.NewAdminService(repo, log, txManager)
 
-	serv := service.NewService(authService, userService, merchService, purchaseService, transactionService)
+	serv := service.NewService(authService, userService, merchService, purchaseService, cartService, transactionService, adminService)
 
 	authController := controller.NewAuthController(serv)
 	userController := controller.NewUserController(serv)
 	merchController := controller.NewMerchController(serv)
 	purchaseController := controller.NewPurchaseController(serv)
+	cartController := controller.NewCartController(serv)
 	transactionController := controller.NewTransactionController(serv)
+	adminController := controller.NewAdminController(serv)
 
-	contr := controller.NewController(authController, userController, merchController, purchaseController, transactionController)
+	contr := controller.NewController(authController, userController, merchController, purchaseController, cartController, transactionController, adminController)
 
 	router := gin.Default()
 	app.SetupRoutes(router, contr, serv)
@@ -99,7 +129,7 @@ func (s *TestSuite) SetupTest() {
 
 	// Очищаем все таблицы и сбрасываем идентификаторы
 	_, err = db.Exec(`
-        TRUNCATE TABLE users, purchases, transactions RESTART IDENTITY CASCADE;
+        TRUNCATE TABLE users, purchases, cart_items, transactions, refresh_tokens, revoked_tokens, ledger_postings, ledger_entries RESTART IDENTITY CASCADE;
     `)
 	s.Require().NoError(err)
 }
@@ -0,0 +1,49 @@
+//go:build integration
+
+package integration
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestTracing_SendCoin проверяет, что перевод даёт один трейс от HTTP-обработчика до запросов pgx:
+// серверный спан, транзакция с уровнем изоляции и номером попытки, вызовы репозиториев и SQL-запросы
+func (s *TestSuite) TestTracing_SendCoin() {
+	sender := s.registerUser("tracing_sender")
+	s.registerUser("tracing_receiver")
+
+	s.spans.Reset()
+	s.sendCoins(sender.Token, "tracing_receiver", 10)
+
+	var server *tracetest.SpanStub
+	spans := s.spans.GetSpans()
+	for i := range spans {
+		if spans[i].Name == "POST /api/send-coin" {
+			server = &spans[i]
+		}
+	}
+	s.Require().NotNil(server)
+	s.Equal(trace.SpanKindServer, server.SpanKind)
+
+	byName := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		if span.SpanContext.TraceID() == server.SpanContext.TraceID() {
+			byName[span.Name] = span
+		}
+	}
+
+	s.Contains(byName, "UserRepository.LockBalances")
+	s.Contains(byName, "UserRepository.DebitBalance")
+	s.Contains(byName, "postgres pool.acquire")
+	s.Contains(byName, "postgres UPDATE")
+
+	tx, ok := byName["postgres.WithTx"]
+	s.Require().True(ok)
+	s.Contains(tx.Attributes, attribute.String("db.transaction.isolation_level", "read committed"))
+	s.Contains(tx.Attributes, attribute.Int("db.transaction.attempt", 1))
+
+	// Запросы внутри транзакции вложены в её спан
+	s.Equal(tx.SpanContext.SpanID(), byName["UserRepository.DebitBalance"].Parent.SpanID())
+}
@@ -0,0 +1,71 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/models/dto"
+	"encoding/json"
+	"net/http"
+)
+
+func (s *TestSuite) getInfoByCategory(token, category string) (int, dto.UserInfoResponse) {
+	req, err := http.NewRequest("GET", s.server.URL+"/api/info?category="+category, nil)
+	s.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.server.Client().Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	var info dto.UserInfoResponse
+	if resp.StatusCode == http.StatusOK {
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&info))
+	}
+	return resp.StatusCode, info
+}
+
+// TestTransferMemoIntegration проверяет, что сообщение и категория перевода видны в истории и по категории можно фильтровать
+func (s *TestSuite) TestTransferMemoIntegration() {
+	sender := s.registerUser("memo_sender")
+	receiver := s.registerUser("memo_receiver")
+
+	transfers := []dto.TransferRequest{
+		{ToUser: "memo_receiver", Amount: 10, Message: "  Спасибо за помощь с релизом  ", Category: "helped_me"},
+		{ToUser: "memo_receiver", Amount: 20, Message: "Отличный доклад", Category: "great_talk"},
+		{ToUser: "memo_receiver", Amount: 30},
+	}
+	for _, transfer := range transfers {
+		body, err := json.Marshal(transfer)
+		s.Require().NoError(err)
+		resp := s.authorizedPost("/api/send-coin", sender.Token, body)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	s.Require().Len(s.getInfo(receiver.Token).CoinHistory.Received, 3)
+
+	status, info := s.getInfoByCategory(receiver.Token, "helped_me")
+	s.Require().Equal(http.StatusOK, status)
+	s.Require().Len(info.CoinHistory.Received, 1)
+	s.Require().Equal("Спасибо за помощь с релизом", info.CoinHistory.Received[0].Message)
+	s.Require().Equal("helped_me", info.CoinHistory.Received[0].Category)
+	s.Require().Equal(10, info.CoinHistory.Received[0].Amount)
+
+	status, _ = s.getInfoByCategory(receiver.Token, "bribe")
+	s.Require().Equal(http.StatusBadRequest, status)
+}
+
+// TestTransferMemoIntegration_Invalid проверяет, что перевод с некорректным сообщением отклоняется без списания монет
+func (s *TestSuite) TestTransferMemoIntegration_Invalid() {
+	sender := s.registerUser("invalid_memo_sender")
+	s.registerUser("invalid_memo_receiver")
+
+	body, err := json.Marshal(dto.TransferRequest{ToUser: "invalid_memo_receiver", Amount: 10, Category: "bribe"})
+	s.Require().NoError(err)
+
+	resp := s.authorizedPost("/api/send-coin", sender.Token, body)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+
+	s.Require().Equal(1000, s.getInfo(sender.Token).Balance)
+}
@@ -0,0 +1,43 @@
+//go:build integration
+
+package integration
+
+import (
+	"avito-tech-merch/internal/storage/db/postgres"
+	"avito-tech-merch/pkg/logger"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestAfterCommit проверяет, что отложенные функции выполняются только после коммита
+func (s *TestSuite) TestAfterCommit() {
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, s.psqlContainer.GetDSN())
+	s.Require().NoError(err)
+	defer pool.Close()
+	txManager := postgres.NewTxManager(pool, logger.Default())
+
+	var calls []string
+	err = txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
+		txManager.AfterCommit(txCtx, func(context.Context) { calls = append(calls, "committed") })
+		calls = append(calls, "in transaction")
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Equal([]string{"in transaction", "committed"}, calls)
+
+	calls = nil
+	rollbackErr := errors.New("rollback")
+	err = txManager.WithTx(ctx, postgres.IsolationLevelReadCommitted, postgres.AccessModeReadWrite, func(txCtx context.Context) error {
+		txManager.AfterCommit(txCtx, func(context.Context) { calls = append(calls, "committed") })
+		return rollbackErr
+	})
+	s.Require().ErrorIs(err, rollbackErr)
+	s.Empty(calls)
+
+	// Вне транзакции функция выполняется сразу
+	txManager.AfterCommit(ctx, func(context.Context) { calls = append(calls, "immediately") })
+	s.Equal([]string{"immediately"}, calls)
+}